@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// ValidateAssertion validates an Assertion, and checks that it holds across
+// the supplied resource templates. The templates' PatchSets must already be
+// included in their patches.
+func ValidateAssertion(a v1beta1.Assertion, cts []v1beta1.ComposedTemplate) *field.Error {
+	switch a.Type {
+	case v1beta1.AssertionTypeUniqueFieldValue:
+		if a.GetFieldPath() == "" {
+			return field.Required(field.NewPath("fieldPath"), fmt.Sprintf("fieldPath is required by type %s", a.Type))
+		}
+	case v1beta1.AssertionTypeUniqueCompositeFieldPath:
+	default:
+		return field.NotSupported(field.NewPath("type"), a.Type, []string{string(v1beta1.AssertionTypeUniqueFieldValue), string(v1beta1.AssertionTypeUniqueCompositeFieldPath)})
+	}
+	if fp := a.GetFieldPath(); fp != "" {
+		if _, err := fieldpath.Parse(fp); err != nil {
+			return field.Invalid(field.NewPath("fieldPath"), fp, err.Error())
+		}
+	}
+
+	names := make(map[string]bool, len(cts))
+	for _, t := range cts {
+		names[t.Name] = true
+	}
+	seen := make(map[string]bool, len(a.Resources))
+	for i, name := range a.Resources {
+		if !names[name] {
+			return field.NotFound(field.NewPath("resources").Index(i), name)
+		}
+		if seen[name] {
+			return field.Duplicate(field.NewPath("resources").Index(i), name)
+		}
+		seen[name] = true
+	}
+
+	if a.Type == v1beta1.AssertionTypeUniqueCompositeFieldPath {
+		return assertUniqueCompositeFieldPaths(a, cts)
+	}
+	return assertUniqueFieldValues(a, cts)
+}
+
+// assertUniqueFieldValues returns an error if two resource templates set the
+// assertion's field path of their composed resources to the same value.
+func assertUniqueFieldValues(a v1beta1.Assertion, cts []v1beta1.ComposedTemplate) *field.Error {
+	fp := a.GetFieldPath()
+	set := map[string]string{}
+	for _, t := range cts {
+		if !a.AppliesTo(t.Name) {
+			continue
+		}
+		v, ok := templateFieldValue(t, fp)
+		if !ok {
+			continue
+		}
+		if other, ok := set[v]; ok {
+			return field.Invalid(field.NewPath("fieldPath"), fp, fmt.Sprintf("resource templates %q and %q set this field to the same value", other, t.Name))
+		}
+		set[v] = t.Name
+	}
+	return nil
+}
+
+// templateFieldValue returns a string that identifies the value the supplied
+// resource template sets at the supplied field path of its composed resource.
+// The value is the last patch that writes the field path, or the value at the
+// field path of the template's base if no patch writes it. It returns false if
+// the template doesn't set the field path.
+func templateFieldValue(t v1beta1.ComposedTemplate, path string) (string, bool) {
+	for i := len(t.Patches) - 1; i >= 0; i-- {
+		p := t.Patches[i]
+		switch p.GetType() { //nolint:exhaustive // Only these patch types write to a composed resource's fields.
+		case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite,
+			v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment,
+			v1beta1.PatchTypeCopyFromCompositeFieldPath:
+		default:
+			continue
+		}
+		if p.GetToFieldPath() != path {
+			continue
+		}
+		j, err := json.Marshal(p)
+		if err != nil {
+			return "", false
+		}
+		return "patch:" + string(j), true
+	}
+
+	if t.Base == nil || t.Base.Raw == nil {
+		return "", false
+	}
+	base := map[string]any{}
+	if err := json.Unmarshal(t.Base.Raw, &base); err != nil {
+		// Let whoever renders the base template report that it's invalid.
+		return "", false
+	}
+	v, err := fieldpath.Pave(base).GetValue(path)
+	if err != nil {
+		return "", false
+	}
+	j, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return "base:" + string(j), true
+}
+
+// assertUniqueCompositeFieldPaths returns an error if two resource templates
+// patch overlapping field paths of the composite resource, optionally limited
+// to those at or under the assertion's field path.
+func assertUniqueCompositeFieldPaths(a v1beta1.Assertion, cts []v1beta1.ComposedTemplate) *field.Error {
+	type write struct {
+		template string
+		path     string
+	}
+	writes := make([]write, 0)
+	for _, t := range cts {
+		if !a.AppliesTo(t.Name) {
+			continue
+		}
+		for _, p := range t.Patches {
+			switch p.GetType() { //nolint:exhaustive // Only these patch types write to the composite resource.
+			case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeConditionToComposite:
+			default:
+				continue
+			}
+			to := p.GetToFieldPath()
+			if fp := a.GetFieldPath(); fp != "" && !fieldPathsOverlap(to, fp) {
+				continue
+			}
+			for _, w := range writes {
+				if w.template != t.Name && fieldPathsOverlap(w.path, to) {
+					return field.Invalid(field.NewPath("type"), a.Type, fmt.Sprintf("resource templates %q and %q both patch composite resource field %s", w.template, t.Name, to))
+				}
+			}
+			writes = append(writes, write{template: t.Name, path: to})
+		}
+	}
+	return nil
+}
+
+// fieldPathsOverlap returns true if the supplied field paths are the same, or
+// one is a parent of the other. Field paths that can't be parsed only overlap
+// if they're identical.
+func fieldPathsOverlap(a, b string) bool {
+	sa, err := fieldpath.Parse(a)
+	if err != nil {
+		return a == b
+	}
+	sb, err := fieldpath.Parse(b)
+	if err != nil {
+		return a == b
+	}
+	for i := 0; i < len(sa) && i < len(sb); i++ {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+	return true
+}
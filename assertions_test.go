@@ -0,0 +1,211 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestValidateAssertion(t *testing.T) {
+	externalName := "metadata.annotations[crossplane.io/external-name]"
+	fromName := func(from string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To(from),
+				ToFieldPath:   ptr.To(externalName),
+			},
+		}
+	}
+	toXR := func(from, to string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type: v1beta1.PatchTypeToCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To(from),
+				ToFieldPath:   ptr.To(to),
+			},
+		}
+	}
+
+	type args struct {
+		a   v1beta1.Assertion
+		cts []v1beta1.ComposedTemplate
+	}
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"UnknownType": {
+			reason: "An assertion of an unknown type should be invalid",
+			args: args{
+				a: v1beta1.Assertion{Type: "Unique"},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeNotSupported,
+					Field: "type",
+				},
+			},
+		},
+		"MissingFieldPath": {
+			reason: "A UniqueFieldValue assertion without a field path should be invalid",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueFieldValue},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fieldPath",
+				},
+			},
+		},
+		"UnknownResource": {
+			reason: "An assertion naming a resource template that doesn't exist should be invalid",
+			args: args{
+				a: v1beta1.Assertion{
+					Type:      v1beta1.AssertionTypeUniqueFieldValue,
+					FieldPath: ptr.To(externalName),
+					Resources: []string{"cache"},
+				},
+				cts: []v1beta1.ComposedTemplate{{Name: "bucket"}},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeNotFound,
+					Field: "resources[0]",
+				},
+			},
+		},
+		"UniquePatchedValues": {
+			reason: "Templates that patch a field from different sources should satisfy a UniqueFieldValue assertion",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueFieldValue, FieldPath: ptr.To(externalName)},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{fromName("spec.bucketName")}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{fromName("spec.dashboardName")}},
+				},
+			},
+		},
+		"DuplicatePatchedValues": {
+			reason: "Templates that patch a field from the same source should violate a UniqueFieldValue assertion",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueFieldValue, FieldPath: ptr.To(externalName)},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{fromName("spec.name")}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{fromName("spec.name")}},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "fieldPath",
+				},
+			},
+		},
+		"DuplicatePatchedValuesOfOtherTemplates": {
+			reason: "A UniqueFieldValue assertion should only check the resource templates it names",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueFieldValue, FieldPath: ptr.To(externalName), Resources: []string{"bucket", "dashboard"}},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{fromName("spec.name")}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{fromName("spec.dashboardName")}},
+					{Name: "alarm", Patches: []v1beta1.ComposedPatch{fromName("spec.name")}},
+				},
+			},
+		},
+		"DuplicateBaseValues": {
+			reason: "Templates whose bases set a field to the same value should violate a UniqueFieldValue assertion",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueFieldValue, FieldPath: ptr.To("spec.forProvider.name")},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Base: &runtime.RawExtension{Raw: []byte(`{"spec":{"forProvider":{"name":"cool"}}}`)}},
+					{Name: "dashboard", Base: &runtime.RawExtension{Raw: []byte(`{"spec":{"forProvider":{"name":"cool"}}}`)}},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "fieldPath",
+				},
+			},
+		},
+		"PatchOverridesDuplicateBaseValue": {
+			reason: "A patch that writes a field should take precedence over the base's value for it",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueFieldValue, FieldPath: ptr.To(externalName)},
+				cts: []v1beta1.ComposedTemplate{
+					{
+						Name:    "bucket",
+						Base:    &runtime.RawExtension{Raw: []byte(`{"metadata":{"annotations":{"crossplane.io/external-name":"cool"}}}`)},
+						Patches: []v1beta1.ComposedPatch{fromName("spec.bucketName")},
+					},
+					{Name: "dashboard", Base: &runtime.RawExtension{Raw: []byte(`{"metadata":{"annotations":{"crossplane.io/external-name":"cool"}}}`)}},
+				},
+			},
+		},
+		"UniqueCompositeFieldPaths": {
+			reason: "Templates that patch different fields of the XR should satisfy a UniqueCompositeFieldPath assertion",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueCompositeFieldPath},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{toXR("status.atProvider.arn", "status.bucketArn")}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{toXR("status.atProvider.arn", "status.dashboardArn")}},
+				},
+			},
+		},
+		"SameTemplatePatchesCompositeFieldPathTwice": {
+			reason: "A template may patch the same field of the XR more than once",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueCompositeFieldPath},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{toXR("status.atProvider.arn", "status.arn"), toXR("status.atProvider.id", "status.arn")}},
+				},
+			},
+		},
+		"OverlappingCompositeFieldPaths": {
+			reason: "Templates that patch a field of the XR and its parent should violate a UniqueCompositeFieldPath assertion",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueCompositeFieldPath},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{toXR("status.atProvider", "status.bucket")}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{toXR("status.atProvider.arn", "status.bucket.arn")}},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"DuplicateCompositeFieldPathsOutsideFieldPath": {
+			reason: "A UniqueCompositeFieldPath assertion with a field path should only check fields at or under it",
+			args: args{
+				a: v1beta1.Assertion{Type: v1beta1.AssertionTypeUniqueCompositeFieldPath, FieldPath: ptr.To("status")},
+				cts: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{toXR("metadata.labels[region]", "metadata.labels[region]")}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{toXR("metadata.labels[region]", "metadata.labels[region]")}},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateAssertion(tc.args.a, tc.args.cts)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateAssertion(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
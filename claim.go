@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+// ClaimField is a virtual top-level field of the observed composite resource.
+// Patches from the composite resource can read the apiVersion, kind, name, and
+// namespace of the claim the composite resource is bound to from it, e.g. using
+// fromFieldPath: claim.namespace.
+const ClaimField = "claim"
+
+// Labels Crossplane adds to a composite resource that's bound to a claim.
+const (
+	LabelClaimName      = "crossplane.io/claim-name"
+	LabelClaimNamespace = "crossplane.io/claim-namespace"
+)
+
+// SetClaimField sets the virtual claim field of the supplied observed composite
+// resource. The claim is read from the composite resource's claim reference,
+// falling back to the labels Crossplane adds to claimed composite resources.
+// The field isn't set if the composite resource isn't bound to a claim.
+func SetClaimField(xr *composite.Unstructured) {
+	c := map[string]any{}
+	if ref := xr.GetClaimReference(); ref != nil {
+		for k, v := range map[string]string{
+			"apiVersion": ref.APIVersion,
+			"kind":       ref.Kind,
+			"name":       ref.Name,
+			"namespace":  ref.Namespace,
+		} {
+			if v != "" {
+				c[k] = v
+			}
+		}
+	}
+
+	l := xr.GetLabels()
+	if _, ok := c["name"]; !ok && l[LabelClaimName] != "" {
+		c["name"] = l[LabelClaimName]
+	}
+	if _, ok := c["namespace"]; !ok && l[LabelClaimNamespace] != "" {
+		c["namespace"] = l[LabelClaimNamespace]
+	}
+
+	if len(c) == 0 {
+		return
+	}
+	xr.Object[ClaimField] = c
+}
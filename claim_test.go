@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+func TestSetClaimField(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		xr     *composite.Unstructured
+		want   map[string]any
+	}{
+		"NoClaim": {
+			reason: "We shouldn't set the claim field if the XR isn't bound to a claim.",
+			xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+				"apiVersion": "example.org/v1",
+				"kind": "XR"
+			}`)}},
+			want: nil,
+		},
+		"ClaimReference": {
+			reason: "We should set the claim field from the XR's claim reference.",
+			xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+				"apiVersion": "example.org/v1",
+				"kind": "XR",
+				"spec": {
+					"claimRef": {
+						"apiVersion": "example.org/v1",
+						"kind": "Claim",
+						"name": "cool-claim",
+						"namespace": "cool-namespace"
+					}
+				}
+			}`)}},
+			want: map[string]any{
+				"apiVersion": "example.org/v1",
+				"kind":       "Claim",
+				"name":       "cool-claim",
+				"namespace":  "cool-namespace",
+			},
+		},
+		"ClaimLabels": {
+			reason: "We should fall back to setting the claim field from the XR's claim labels.",
+			xr: &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+				"apiVersion": "example.org/v1",
+				"kind": "XR",
+				"metadata": {
+					"labels": {
+						"crossplane.io/claim-name": "cool-claim",
+						"crossplane.io/claim-namespace": "cool-namespace"
+					}
+				}
+			}`)}},
+			want: map[string]any{
+				"name":      "cool-claim",
+				"namespace": "cool-namespace",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			SetClaimField(tc.xr)
+			got, _ := tc.xr.Object[ClaimField].(map[string]any)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSetClaimField(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
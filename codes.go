@@ -0,0 +1,132 @@
+package main
+
+import (
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+	"github.com/crossplane/function-sdk-go/response"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+// An ErrorCode categorizes the error that caused a result. Every result the
+// Function returns starts with its error code in square brackets, e.g.
+// "[PT001] cannot render patches...", so that alerting and automation can
+// categorize failures without matching the rest of the message. Error codes
+// are stable; a code's meaning never changes and codes are never reused.
+type ErrorCode string
+
+// Error codes.
+const (
+	// ErrorCodeMissingRequiredField indicates a patch's required source
+	// field doesn't exist.
+	ErrorCodeMissingRequiredField ErrorCode = "PT001"
+
+	// ErrorCodeInvalidInput indicates the Function's input is invalid.
+	ErrorCodeInvalidInput ErrorCode = "PT002"
+
+	// ErrorCodeInvalidRequest indicates the Function couldn't decode the
+	// observed or desired state sent by Crossplane.
+	ErrorCodeInvalidRequest ErrorCode = "PT003"
+
+	// ErrorCodeInvalidEnvironment indicates the Function couldn't load the
+	// Composition environment, or read a map from it.
+	ErrorCodeInvalidEnvironment ErrorCode = "PT004"
+
+	// ErrorCodeInvalidTemplate indicates a resource template couldn't be
+	// rendered, for example because its base is invalid.
+	ErrorCodeInvalidTemplate ErrorCode = "PT005"
+
+	// ErrorCodePatchFailed indicates a patch couldn't be applied.
+	ErrorCodePatchFailed ErrorCode = "PT010"
+
+	// ErrorCodePatchSkipped indicates patches were skipped because an
+	// optional source field doesn't exist.
+	ErrorCodePatchSkipped ErrorCode = "PT011"
+
+	// ErrorCodeTransformTypeMismatch indicates a transform's input isn't of a
+	// type the transform supports.
+	ErrorCodeTransformTypeMismatch ErrorCode = "PT014"
+
+	// ErrorCodeConnectionDetailsFailed indicates connection details couldn't
+	// be extracted from a composed resource.
+	ErrorCodeConnectionDetailsFailed ErrorCode = "PT020"
+
+	// ErrorCodeReadinessCheckFailed indicates the readiness of a composed
+	// resource couldn't be determined.
+	ErrorCodeReadinessCheckFailed ErrorCode = "PT021"
+
+	// ErrorCodeResourceNotReady indicates composed resources that aren't
+	// critical aren't ready. They don't gate the composite resource's
+	// readiness.
+	ErrorCodeResourceNotReady ErrorCode = "PT022"
+
+	// ErrorCodeDeadlineExceeded indicates the Function ran out of time before
+	// it processed every resource template.
+	ErrorCodeDeadlineExceeded ErrorCode = "PT030"
+
+	// ErrorCodeUnknownFeatureGate indicates the input enables a feature gate
+	// the Function doesn't know.
+	ErrorCodeUnknownFeatureGate ErrorCode = "PT040"
+
+	// ErrorCodeInternal indicates the Function couldn't build its response.
+	ErrorCodeInternal ErrorCode = "PT099"
+)
+
+// A codedError is an error with an error code.
+type codedError struct {
+	code ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// WithCode returns an error that categorizes the supplied error using the
+// supplied error code. Its message is the message of the supplied error. It
+// returns nil if the supplied error is nil.
+func WithCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// Code returns the error code of the supplied error. This is the innermost,
+// most specific, code in the error's chain. An error with no code is missing
+// a required field if it was caused by a field path not being found, and has
+// the supplied fallback code otherwise.
+func Code(err error, fallback ErrorCode) ErrorCode {
+	code := fallback
+	found := false
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch ce := e.(type) { //nolint:errorlint // We're walking the chain.
+		case *codedError:
+			code, found = ce.code, true
+		case *transforms.TypeMismatchError:
+			code, found = ErrorCodeTransformTypeMismatch, true
+		}
+	}
+	if !found && fieldpath.IsNotFound(err) {
+		return ErrorCodeMissingRequiredField
+	}
+	return code
+}
+
+// fatal adds a fatal result to the supplied response. The result's message
+// starts with the error's code, or the fallback code if it has none.
+func fatal(rsp *fnv1beta1.RunFunctionResponse, fallback ErrorCode, err error) {
+	response.Fatal(rsp, errors.Errorf("[%s] %s", Code(err, fallback), err))
+}
+
+// warning adds a warning result to the supplied response. The result's
+// message starts with the error's code, or the fallback code if it has none.
+func warning(rsp *fnv1beta1.RunFunctionResponse, fallback ErrorCode, err error) {
+	response.Warning(rsp, errors.Errorf("[%s] %s", Code(err, fallback), err))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+func TestCode(t *testing.T) {
+	_, mismatch := transforms.ResolveMath(&v1beta1.MathTransform{Type: v1beta1.MathTransformTypeMultiply, Multiply: ptr.To[int64](2)}, "ola")
+
+	type args struct {
+		err      error
+		fallback ErrorCode
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   ErrorCode
+	}{
+		"NoCode": {
+			reason: "An error without a code should have the fallback code.",
+			args: args{
+				err:      errors.New("boom"),
+				fallback: ErrorCodePatchFailed,
+			},
+			want: ErrorCodePatchFailed,
+		},
+		"WrappedCode": {
+			reason: "An error should have the code of an error in its chain.",
+			args: args{
+				err:      errors.Wrap(mismatch, "cannot render patches"),
+				fallback: ErrorCodePatchFailed,
+			},
+			want: ErrorCodeTransformTypeMismatch,
+		},
+		"InnermostCode": {
+			reason: "An error should have the innermost, most specific, code in its chain.",
+			args: args{
+				err:      WithCode(ErrorCodeReadinessCheckFailed, errors.Wrap(mismatch, "cannot check readiness")),
+				fallback: ErrorCodePatchFailed,
+			},
+			want: ErrorCodeTransformTypeMismatch,
+		},
+		"FieldNotFound": {
+			reason: "An error caused by a field path not being found should be missing a required field.",
+			args: args{
+				err: func() error {
+					_, err := fieldpath.Pave(map[string]any{}).GetValue("spec.size")
+					return errors.Wrap(err, "cannot apply patch")
+				}(),
+				fallback: ErrorCodePatchFailed,
+			},
+			want: ErrorCodeMissingRequiredField,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Code(tc.args.err, tc.args.fallback)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nCode(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
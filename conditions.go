@@ -0,0 +1,105 @@
+package main
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+)
+
+// Error strings
+const (
+	errFmtConditionStatus    = "cannot get status of condition %q"
+	errFmtConditionMessage   = "cannot get message of condition %q"
+	errFmtInvalidStatusValue = "status must be a boolean, or one of True, False, or Unknown; got %v"
+)
+
+// RenderCompositeConditions sets the supplied conditions on the desired
+// composite resource. A condition's status and message may be read from the
+// observed composite resource or the environment. A condition keeps its last
+// transition time if the observed composite resource already has it with the
+// same status.
+func RenderCompositeConditions(env runtime.Object, oxr, dxr *composite.Unstructured, cs []v1beta1.CompositeCondition, now time.Time) error {
+	for i := range cs {
+		c := &cs[i]
+
+		var src runtime.Object = oxr
+		if c.GetSource() == v1beta1.ConditionSourceEnvironment {
+			src = env
+		}
+		p, err := fieldpaths.Pave(src)
+		if err != nil {
+			return errors.Wrapf(err, errFmtConditionStatus, c.Type)
+		}
+
+		status := c.GetStatus()
+		if c.StatusFromFieldPath != nil {
+			status, err = conditionStatus(p, *c.StatusFromFieldPath)
+			if err != nil {
+				return errors.Wrapf(err, errFmtConditionStatus, c.Type)
+			}
+		}
+
+		msg := ""
+		if c.Message != nil {
+			msg = *c.Message
+		}
+		if c.MessageFromFieldPath != nil {
+			s, err := p.GetString(*c.MessageFromFieldPath)
+			if err != nil && !fieldpath.IsNotFound(err) {
+				return errors.Wrapf(err, errFmtConditionMessage, c.Type)
+			}
+			if err == nil {
+				msg = s
+			}
+		}
+
+		cond := xpv1.Condition{
+			Type:               c.Type,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(now),
+			Reason:             c.Reason,
+			Message:            msg,
+		}
+		if o := oxr.GetCondition(c.Type); o.Status == status {
+			cond.LastTransitionTime = o.LastTransitionTime
+		}
+		dxr.SetConditions(cond)
+	}
+	return nil
+}
+
+// conditionStatus returns the condition status at the supplied field path. The
+// status is Unknown if the field doesn't exist.
+func conditionStatus(p *fieldpath.Paved, path string) (corev1.ConditionStatus, error) {
+	v, err := p.GetValue(path)
+	if fieldpath.IsNotFound(err) {
+		return corev1.ConditionUnknown, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	switch s := v.(type) {
+	case bool:
+		if s {
+			return corev1.ConditionTrue, nil
+		}
+		return corev1.ConditionFalse, nil
+	case string:
+		switch cs := corev1.ConditionStatus(s); cs {
+		case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+			return cs, nil
+		}
+	}
+	return "", errors.Errorf(errFmtInvalidStatusValue, v)
+}
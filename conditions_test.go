@@ -0,0 +1,173 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestRenderCompositeConditions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	then := metav1.NewTime(now.Add(-time.Hour))
+
+	type args struct {
+		env *unstructured.Unstructured
+		oxr *composite.Unstructured
+		cs  []v1beta1.CompositeCondition
+	}
+	type want struct {
+		conditions []xpv1.Condition
+		err        error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Constant": {
+			reason: "A condition without field paths should use its constant status and message.",
+			args: args{
+				oxr: composite.New(),
+				cs: []v1beta1.CompositeCondition{{
+					Type:    "DatabaseReady",
+					Reason:  "Available",
+					Message: ptr.To("The database is ready"),
+				}},
+			},
+			want: want{
+				conditions: []xpv1.Condition{{
+					Type:               "DatabaseReady",
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(now),
+					Reason:             "Available",
+					Message:            "The database is ready",
+				}},
+			},
+		},
+		"FromComposite": {
+			reason: "A condition should read its status and message from the observed XR, and keep its last transition time if its status is unchanged.",
+			args: args{
+				oxr: func() *composite.Unstructured {
+					xr := composite.New()
+					xr.Object["status"] = map[string]any{"db": map[string]any{"ready": true, "message": "cool"}}
+					xr.SetConditions(xpv1.Condition{Type: "DatabaseReady", Status: corev1.ConditionTrue, LastTransitionTime: then, Reason: "Available"})
+					return xr
+				}(),
+				cs: []v1beta1.CompositeCondition{{
+					Type:                 "DatabaseReady",
+					StatusFromFieldPath:  ptr.To("status.db.ready"),
+					Reason:               "Available",
+					MessageFromFieldPath: ptr.To("status.db.message"),
+				}},
+			},
+			want: want{
+				conditions: []xpv1.Condition{{
+					Type:               "DatabaseReady",
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: then,
+					Reason:             "Available",
+					Message:            "cool",
+				}},
+			},
+		},
+		"FromEnvironment": {
+			reason: "A condition should read its status from the environment, and fall back to its constant message.",
+			args: args{
+				env: &unstructured.Unstructured{Object: map[string]any{"maintenance": "False"}},
+				oxr: composite.New(),
+				cs: []v1beta1.CompositeCondition{{
+					Type:                 "Maintenance",
+					StatusFromFieldPath:  ptr.To("maintenance"),
+					Reason:               "Scheduled",
+					Message:              ptr.To("No maintenance scheduled"),
+					MessageFromFieldPath: ptr.To("maintenanceMessage"),
+					Source:               ptr.To(v1beta1.ConditionSourceEnvironment),
+				}},
+			},
+			want: want{
+				conditions: []xpv1.Condition{{
+					Type:               "Maintenance",
+					Status:             corev1.ConditionFalse,
+					LastTransitionTime: metav1.NewTime(now),
+					Reason:             "Scheduled",
+					Message:            "No maintenance scheduled",
+				}},
+			},
+		},
+		"StatusNotFound": {
+			reason: "A condition's status should be Unknown if its field doesn't exist.",
+			args: args{
+				oxr: composite.New(),
+				cs: []v1beta1.CompositeCondition{{
+					Type:                "DatabaseReady",
+					StatusFromFieldPath: ptr.To("status.db.ready"),
+					Reason:              "Creating",
+				}},
+			},
+			want: want{
+				conditions: []xpv1.Condition{{
+					Type:               "DatabaseReady",
+					Status:             corev1.ConditionUnknown,
+					LastTransitionTime: metav1.NewTime(now),
+					Reason:             "Creating",
+				}},
+			},
+		},
+		"InvalidStatus": {
+			reason: "We should return an error if a condition's status field isn't a valid status.",
+			args: args{
+				oxr: func() *composite.Unstructured {
+					xr := composite.New()
+					xr.Object["status"] = map[string]any{"db": map[string]any{"ready": "yes"}}
+					return xr
+				}(),
+				cs: []v1beta1.CompositeCondition{{
+					Type:                "DatabaseReady",
+					StatusFromFieldPath: ptr.To("status.db.ready"),
+					Reason:              "Available",
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtInvalidStatusValue, "yes"), errFmtConditionStatus, "DatabaseReady"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := tc.args.env
+			if env == nil {
+				env = &unstructured.Unstructured{Object: map[string]any{}}
+			}
+			dxr := composite.New()
+			err := RenderCompositeConditions(env, tc.args.oxr, dxr, tc.args.cs, now)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nRenderCompositeConditions(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+			var got []xpv1.Condition
+			for _, c := range tc.want.conditions {
+				got = append(got, dxr.GetCondition(c.Type))
+			}
+			if diff := cmp.Diff(tc.want.conditions, got); diff != "" {
+				t.Errorf("%s\nRenderCompositeConditions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
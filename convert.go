@@ -0,0 +1,671 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alecthomas/kong"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Error strings
+const (
+	errUnmarshalComposition = "cannot unmarshal Composition"
+	errMarshalComposition   = "cannot marshal Composition"
+	errConvertComposition   = "cannot convert Composition"
+	errConvertedInput       = "converted Function input is invalid"
+	errInvalidInput         = "invalid Function input"
+	errPropagate            = "propagating labels and annotations cannot be represented using native P&T"
+	errProviderConfigRef    = "injecting a ProviderConfig cannot be represented using native P&T"
+	errReadiness            = "readiness policies cannot be represented using native P&T"
+	errNoResources          = "Function input has no resource templates, which cannot be represented using native P&T"
+	errComposite            = "patching the XR or setting its conditions cannot be represented using native P&T"
+	errConnectionDetails    = "filtering or renaming the XR's connection details cannot be represented using native P&T"
+	errData                 = "input data cannot be represented using native P&T"
+	errEnvironmentDefaults  = "environment defaults cannot be represented using native P&T"
+
+	errFmtCompositionMode   = "Composition must use mode %q, not %q"
+	errFmtNativeField       = "cannot read native P&T field %q"
+	errFmtPipelineField     = "cannot read pipeline field %q"
+	errFmtNoFunctionStep    = "Composition has no pipeline step that uses Function %q"
+	errFmtMultipleSteps     = "Composition has more than one pipeline step that uses Function %q"
+	errFmtOtherFunctionStep = "pipeline step %q uses Function %q, which cannot be represented using native P&T"
+	errFmtNoBase            = "resource template %q has no base, which cannot be represented using native P&T"
+	errFmtPatchType         = "resource template %q uses a patch of type %q, which cannot be represented using native P&T"
+	errFmtDeleteCondition   = "resource template %q has a delete condition, which cannot be represented using native P&T"
+	errFmtNotCritical       = "resource template %q is not critical, which cannot be represented using native P&T"
+	errFmtNamespace         = "resource template %q has a namespace, which cannot be represented using native P&T"
+	errFmtFromFieldPaths    = "resource template %q connection detail %q has fallback field paths, which cannot be represented using native P&T"
+	errFmtConditionalPatch  = "resource template %q includes PatchSet %q conditionally, which cannot be represented using native P&T"
+	errFmtPatchSetPatchType = "PatchSet %q uses a patch of type %q, which cannot be represented using native P&T"
+	errFmtForceReady        = "resource template %q forces readiness, which cannot be represented using native P&T"
+	errFmtPatchSetPatch     = "PatchSet %q patch %d uses %s, which cannot be represented using native P&T"
+	errFmtEnvironmentPatch  = "environment patch %d uses %s, which cannot be represented using native P&T"
+	errFmtResourcePatch     = "resource template %q patch %d uses %s, which cannot be represented using native P&T"
+	errFmtUnknownMode       = "unknown Composition mode %q"
+)
+
+// Composition modes.
+const (
+	compositionModeResources = "Resources"
+	compositionModePipeline  = "Pipeline"
+)
+
+// Native environment patches are named from the perspective of the XR, while
+// ours are named from the perspective of the environment.
+var nativeEnvironmentPatchTypes = map[string]v1beta1.PatchType{
+	"": v1beta1.PatchTypeToEnvironmentFieldPath,
+	string(v1beta1.PatchTypeFromCompositeFieldPath): v1beta1.PatchTypeToEnvironmentFieldPath,
+	string(v1beta1.PatchTypeToCompositeFieldPath):   v1beta1.PatchTypeFromEnvironmentFieldPath,
+	string(v1beta1.PatchTypeCombineFromComposite):   v1beta1.PatchTypeCombineToEnvironment,
+	string(v1beta1.PatchTypeCombineToComposite):     v1beta1.PatchTypeCombineFromEnvironment,
+}
+
+// ConvertCmd converts a Composition that uses native P&T to use this Function,
+// or vice versa.
+type ConvertCmd struct {
+	Composition kong.FileContentFlag `arg:"" help:"A YAML file containing a Composition. Compositions that use native patch and transform ('Resources' mode) are converted to use this Function, and vice versa."`
+
+	FunctionName string `help:"Name of the Function package that the converted Composition should reference." default:"function-patch-and-transform"`
+	StepName     string `help:"Name of the pipeline step that the converted Composition should use." default:"patch-and-transform"`
+}
+
+// Run the convert command.
+func (c *ConvertCmd) Run(k *kong.Context) error {
+	in := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(c.Composition, &in.Object); err != nil {
+		return errors.Wrap(err, errUnmarshalComposition)
+	}
+
+	var out *unstructured.Unstructured
+	var warnings []string
+	var err error
+
+	mode, _, _ := unstructured.NestedString(in.Object, "spec", "mode")
+	switch mode {
+	case "", compositionModeResources:
+		out, warnings, err = ConvertCompositionToPipeline(in, c.FunctionName, c.StepName)
+	case compositionModePipeline:
+		out, warnings, err = ConvertCompositionToNative(in, c.FunctionName)
+	default:
+		err = errors.Errorf(errFmtUnknownMode, mode)
+	}
+	if err != nil {
+		return errors.Wrap(err, errConvertComposition)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(k.Stderr, "warning: %s\n", w)
+	}
+
+	y, err := yaml.Marshal(out.Object)
+	if err != nil {
+		return errors.Wrap(err, errMarshalComposition)
+	}
+	_, err = fmt.Fprintf(k.Stdout, "---\n%s", y)
+	return err
+}
+
+// ConvertCompositionToPipeline converts the supplied Composition, which must
+// use native patch and transform, into one that uses a single pipeline step to
+// call this Function. It returns warnings about anything that couldn't be
+// converted exactly.
+func ConvertCompositionToPipeline(in *unstructured.Unstructured, fn, step string) (*unstructured.Unstructured, []string, error) { //nolint:gocyclo // Mostly just reading fields.
+	mode, _, _ := unstructured.NestedString(in.Object, "spec", "mode")
+	if mode != "" && mode != compositionModeResources {
+		return nil, nil, errors.Errorf(errFmtCompositionMode, compositionModeResources, mode)
+	}
+
+	out := in.DeepCopy()
+	warnings := make([]string, 0)
+
+	input := map[string]any{
+		"apiVersion": "pt.fn.crossplane.io/v1beta1",
+		"kind":       "Resources",
+	}
+
+	pss, _, err := unstructured.NestedSlice(in.Object, "spec", "patchSets")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, errFmtNativeField, "spec.patchSets")
+	}
+	for i := range pss {
+		ps, ok := pss[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		warnings = append(warnings, convertNativePatches(ps["patches"], fmt.Sprintf("spec.patchSets[%d]", i))...)
+	}
+	if len(pss) > 0 {
+		input["patchSets"] = pss
+	}
+
+	eps, _, err := unstructured.NestedSlice(in.Object, "spec", "environment", "patches")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, errFmtNativeField, "spec.environment.patches")
+	}
+	for i := range eps {
+		p, ok := eps[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		t, _ := p["type"].(string)
+		if nt, ok := nativeEnvironmentPatchTypes[t]; ok {
+			p["type"] = string(nt)
+		}
+	}
+	warnings = append(warnings, convertNativePatches(eps, "spec.environment")...)
+	if len(eps) > 0 {
+		input["environment"] = map[string]any{"patches": eps}
+	}
+
+	rs, _, err := unstructured.NestedSlice(in.Object, "spec", "resources")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, errFmtNativeField, "spec.resources")
+	}
+	for i := range rs {
+		r, ok := rs[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("spec.resources[%d]", i)
+
+		// Our resource templates must be named.
+		if n, _ := r["name"].(string); n == "" {
+			r["name"] = fmt.Sprintf("resource-%d", i)
+			warnings = append(warnings, fmt.Sprintf("%s: generated name %q for anonymous resource template", path, r["name"]))
+		}
+
+		warnings = append(warnings, convertNativePatches(r["patches"], path)...)
+		convertNativeConnectionDetails(r["connectionDetails"])
+	}
+	input["resources"] = rs
+
+	// Make sure what we produced is something we'd accept as input.
+	j, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errConvertedInput)
+	}
+	ri := &v1beta1.Resources{}
+	if err := json.Unmarshal(j, ri); err != nil {
+		return nil, nil, errors.Wrap(err, errConvertedInput)
+	}
+	if err := ValidateResources(ri); err != nil {
+		return nil, nil, errors.Wrap(err, errConvertedInput)
+	}
+
+	unstructured.RemoveNestedField(out.Object, "spec", "resources")
+	unstructured.RemoveNestedField(out.Object, "spec", "patchSets")
+	unstructured.RemoveNestedField(out.Object, "spec", "environment", "patches")
+	if env, ok, _ := unstructured.NestedMap(out.Object, "spec", "environment"); ok && len(env) == 0 {
+		unstructured.RemoveNestedField(out.Object, "spec", "environment")
+	}
+
+	pipeline := []any{
+		map[string]any{
+			"step":        step,
+			"functionRef": map[string]any{"name": fn},
+			"input":       input,
+		},
+	}
+	if err := unstructured.SetNestedField(out.Object, compositionModePipeline, "spec", "mode"); err != nil {
+		return nil, nil, errors.Wrap(err, errConvertComposition)
+	}
+	if err := unstructured.SetNestedSlice(out.Object, pipeline, "spec", "pipeline"); err != nil {
+		return nil, nil, errors.Wrap(err, errConvertComposition)
+	}
+
+	return out, warnings, nil
+}
+
+// ConvertCompositionToNative converts the supplied Composition, which must use
+// a single pipeline step to call this Function, into one that uses native
+// patch and transform. It returns warnings about anything that couldn't be
+// converted exactly.
+func ConvertCompositionToNative(in *unstructured.Unstructured, fn string) (*unstructured.Unstructured, []string, error) { //nolint:gocyclo // Mostly just reading fields.
+	mode, _, _ := unstructured.NestedString(in.Object, "spec", "mode")
+	if mode != compositionModePipeline {
+		return nil, nil, errors.Errorf(errFmtCompositionMode, compositionModePipeline, mode)
+	}
+
+	steps, _, err := unstructured.NestedSlice(in.Object, "spec", "pipeline")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, errFmtPipelineField, "spec.pipeline")
+	}
+
+	var input map[string]any
+	for i := range steps {
+		s, ok := steps[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(s, "step")
+		ref, _, _ := unstructured.NestedString(s, "functionRef", "name")
+
+		// Native P&T can't do anything other Functions could be doing.
+		if ref != fn {
+			return nil, nil, errors.Errorf(errFmtOtherFunctionStep, name, ref)
+		}
+		if input != nil {
+			return nil, nil, errors.Errorf(errFmtMultipleSteps, fn)
+		}
+		input, _, err = unstructured.NestedMap(s, "input")
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, errFmtPipelineField, "spec.pipeline[].input")
+		}
+	}
+	if input == nil {
+		return nil, nil, errors.Errorf(errFmtNoFunctionStep, fn)
+	}
+
+	j, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errInvalidInput)
+	}
+	ri := &v1beta1.Resources{}
+	if err := json.Unmarshal(j, ri); err != nil {
+		return nil, nil, errors.Wrap(err, errInvalidInput)
+	}
+	if err := ValidateResources(ri); err != nil {
+		return nil, nil, errors.Wrap(err, errInvalidInput)
+	}
+
+	if ri.Propagate != nil {
+		return nil, nil, errors.New(errPropagate)
+	}
+	if ri.ProviderConfigRef != nil {
+		return nil, nil, errors.New(errProviderConfigRef)
+	}
+	if ri.Readiness.GetType() != v1beta1.ReadinessPolicyTypeAll {
+		return nil, nil, errors.New(errReadiness)
+	}
+
+	if len(ri.Resources) == 0 {
+		return nil, nil, errors.New(errNoResources)
+	}
+	if ri.Composite != nil && (len(ri.Composite.Patches) > 0 || len(ri.Composite.Conditions) > 0) {
+		return nil, nil, errors.New(errComposite)
+	}
+	if ri.ConnectionDetails != nil {
+		return nil, nil, errors.New(errConnectionDetails)
+	}
+	if len(ri.Data) > 0 {
+		return nil, nil, errors.New(errData)
+	}
+
+	for _, ps := range ri.PatchSets {
+		for i, p := range ps.Patches {
+			if p.GetType() == v1beta1.PatchTypeConditionToComposite {
+				return nil, nil, errors.Errorf(errFmtPatchSetPatchType, ps.Name, p.GetType())
+			}
+			if f := unsupportedPatchFeature(p.Patch); f != "" {
+				return nil, nil, errors.Errorf(errFmtPatchSetPatch, ps.Name, i, f)
+			}
+		}
+	}
+
+	if ri.Environment != nil {
+		if ri.Environment.Defaults != nil {
+			return nil, nil, errors.New(errEnvironmentDefaults)
+		}
+		for i, p := range ri.Environment.Patches {
+			if f := unsupportedPatchFeature(p.Patch); f != "" {
+				return nil, nil, errors.Errorf(errFmtEnvironmentPatch, i, f)
+			}
+		}
+	}
+
+	// Native P&T can't patch resources produced by another Function.
+	for _, t := range ri.Resources {
+		if t.Base == nil {
+			return nil, nil, errors.Errorf(errFmtNoBase, t.Name)
+		}
+		if t.Delete != nil {
+			return nil, nil, errors.Errorf(errFmtDeleteCondition, t.Name)
+		}
+		if !t.IsCritical() {
+			return nil, nil, errors.Errorf(errFmtNotCritical, t.Name)
+		}
+		if t.Namespace != nil {
+			return nil, nil, errors.Errorf(errFmtNamespace, t.Name)
+		}
+		if t.ForceReady != nil {
+			return nil, nil, errors.Errorf(errFmtForceReady, t.Name)
+		}
+		for _, cd := range t.ConnectionDetails {
+			if len(cd.FromFieldPaths) > 0 {
+				return nil, nil, errors.Errorf(errFmtFromFieldPaths, t.Name, cd.Name)
+			}
+		}
+		for i, p := range t.Patches {
+			switch p.Type { //nolint:exhaustive // Only connection detail, copy, and condition patches are unsupported.
+			case v1beta1.PatchTypeToConnectionDetail, v1beta1.PatchTypeCombineToConnectionDetail, v1beta1.PatchTypeCopyFromCompositeFieldPath, v1beta1.PatchTypeConditionToComposite:
+				return nil, nil, errors.Errorf(errFmtPatchType, t.Name, p.Type)
+			}
+			if p.When != nil {
+				return nil, nil, errors.Errorf(errFmtConditionalPatch, t.Name, p.GetPatchSetName())
+			}
+			if f := unsupportedPatchFeature(p.Patch); f != "" {
+				return nil, nil, errors.Errorf(errFmtResourcePatch, t.Name, i, f)
+			}
+		}
+	}
+
+	out := in.DeepCopy()
+	warnings := make([]string, 0)
+
+	// These fields only affect what the Function reports, not what it
+	// composes, so it's safe to drop them.
+	for _, f := range []string{"featureGates", "assertions", "warnSkippedPatchesAfter", "reportChangedFields", "recordFieldProvenance"} {
+		if _, ok := input[f]; ok {
+			warnings = append(warnings, fmt.Sprintf("removed unsupported input field %s", f))
+		}
+	}
+
+	unstructured.RemoveNestedField(out.Object, "spec", "pipeline")
+	if err := unstructured.SetNestedField(out.Object, compositionModeResources, "spec", "mode"); err != nil {
+		return nil, nil, errors.Wrap(err, errConvertComposition)
+	}
+
+	pss, _ := input["patchSets"].([]any)
+	for i := range pss {
+		ps, ok := pss[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		warnings = append(warnings, convertPatchesToNative(ps["patches"], fmt.Sprintf("spec.patchSets[%d]", i))...)
+	}
+	if len(pss) > 0 {
+		if err := unstructured.SetNestedSlice(out.Object, pss, "spec", "patchSets"); err != nil {
+			return nil, nil, errors.Wrap(err, errConvertComposition)
+		}
+	}
+
+	eps, _, _ := unstructured.NestedSlice(input, "environment", "patches")
+	for i := range eps {
+		p, ok := eps[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		for nt, t := range nativeEnvironmentPatchTypes {
+			if nt != "" && p["type"] == string(t) {
+				p["type"] = nt
+				break
+			}
+		}
+	}
+	warnings = append(warnings, convertPatchesToNative(eps, "spec.environment")...)
+	if len(eps) > 0 {
+		if err := unstructured.SetNestedSlice(out.Object, eps, "spec", "environment", "patches"); err != nil {
+			return nil, nil, errors.Wrap(err, errConvertComposition)
+		}
+	}
+
+	rs, _ := input["resources"].([]any)
+	for i := range rs {
+		r, ok := rs[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("spec.resources[%d]", i)
+
+		// Native P&T treats every resource as critical, and only supports
+		// the readiness policy that ignores weights.
+		delete(r, "critical")
+		if _, ok := r["readinessWeight"]; ok {
+			delete(r, "readinessWeight")
+			warnings = append(warnings, fmt.Sprintf("%s: removed unsupported readinessWeight", path))
+		}
+
+		warnings = append(warnings, convertPatchesToNative(r["patches"], path)...)
+	}
+	if err := unstructured.SetNestedSlice(out.Object, rs, "spec", "resources"); err != nil {
+		return nil, nil, errors.Wrap(err, errConvertComposition)
+	}
+
+	return out, warnings, nil
+}
+
+// convertNativePatches converts the supplied native patches in place. It
+// returns warnings about anything it couldn't convert.
+func convertNativePatches(v any, path string) []string {
+	ps, _ := v.([]any)
+	warnings := make([]string, 0)
+	for i := range ps {
+		p, ok := ps[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("%s.patches[%d]", path, i)
+
+		// Server-side apply makes merge options redundant. See the README.
+		if pol, ok := p["policy"].(map[string]any); ok {
+			if _, ok := pol["mergeOptions"]; ok {
+				delete(pol, "mergeOptions")
+				warnings = append(warnings, fmt.Sprintf("%s: removed unsupported policy.mergeOptions", path))
+			}
+			if len(pol) == 0 {
+				delete(p, "policy")
+			}
+		}
+
+		ts, _ := p["transforms"].([]any)
+		for j := range ts {
+			t, ok := ts[j].(map[string]any)
+			if !ok {
+				continue
+			}
+			// Native P&T defaults the type of math and string transforms. We
+			// require it to be set explicitly.
+			if m, ok := t["math"].(map[string]any); ok && m["type"] == nil {
+				m["type"] = string(v1beta1.MathTransformTypeMultiply)
+			}
+			if s, ok := t["string"].(map[string]any); ok && s["type"] == nil {
+				s["type"] = string(v1beta1.StringTransformTypeFormat)
+			}
+		}
+	}
+	return warnings
+}
+
+// convertPatchesToNative converts the supplied patches to native patches in
+// place, removing fields that only affect how the Function reports errors. It
+// returns warnings about anything it removed.
+func convertPatchesToNative(v any, path string) []string {
+	ps, _ := v.([]any)
+	warnings := make([]string, 0)
+	for i := range ps {
+		p, ok := ps[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("%s.patches[%d]", path, i)
+
+		// The default stage and toFieldPath policy behave like native P&T.
+		delete(p, "stage")
+		if pol, ok := p["policy"].(map[string]any); ok {
+			delete(pol, "toFieldPath")
+			if len(pol) == 0 {
+				delete(p, "policy")
+			}
+		}
+
+		for _, f := range []string{"transformTimeout", "sensitive"} {
+			if _, ok := p[f]; ok {
+				delete(p, f)
+				warnings = append(warnings, fmt.Sprintf("%s: removed unsupported %s", path, f))
+			}
+		}
+
+		ts, _ := p["transforms"].([]any)
+		for j := range ts {
+			t, ok := ts[j].(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, ok := t["sensitive"]; ok {
+				delete(t, "sensitive")
+				warnings = append(warnings, fmt.Sprintf("%s.transforms[%d]: removed unsupported sensitive", path, j))
+			}
+		}
+	}
+	return warnings
+}
+
+// unsupportedPatchFeature returns a description of the first feature of the
+// supplied patch that changes what it produces and that native P&T doesn't
+// support. It returns an empty string if native P&T supports the patch.
+func unsupportedPatchFeature(p v1beta1.Patch) string { //nolint:gocyclo // Just a long list of checks.
+	if p.GetStage() != v1beta1.PatchStageDefault {
+		return fmt.Sprintf("stage %s", p.GetStage())
+	}
+
+	pp := p.GetPolicy()
+	switch pol := pp.GetFromFieldPathPolicy(); {
+	case pol != v1beta1.FromFieldPathPolicyOptional && pol != v1beta1.FromFieldPathPolicyRequired:
+		return fmt.Sprintf("fromFieldPath policy %s", pol)
+	case pp != nil && pp.FromFieldPathDefault != nil:
+		return "policy fromFieldPathDefault"
+	case pp.GetToFieldPathPolicy() != v1beta1.ToFieldPathPolicyCreate:
+		return fmt.Sprintf("toFieldPath policy %s", pp.GetToFieldPathPolicy())
+	case pp.GetMergeKey() != "":
+		return "policy mergeKey"
+	case pp.GetSkipUnchanged():
+		return "policy skipUnchanged"
+	case pp.GetCreateOnly():
+		return "policy createOnly"
+	case pp.GetFreeze():
+		return "policy freeze"
+	case pp.GetCoerceToExistingType():
+		return "policy coerceToExistingType"
+	case pp.GetMemoize():
+		return "policy memoize"
+	}
+
+	for i, t := range p.GetTransforms() {
+		if f := unsupportedTransformFeature(t); f != "" {
+			return fmt.Sprintf("%s in transform %d", f, i)
+		}
+	}
+	return ""
+}
+
+// unsupportedTransformFeature returns a description of the first feature of
+// the supplied transform that native P&T doesn't support. It returns an empty
+// string if native P&T supports the transform.
+func unsupportedTransformFeature(t v1beta1.Transform) string { //nolint:gocyclo // Just a long list of checks.
+	switch t.Type { //nolint:exhaustive // Only these transform types are native.
+	case v1beta1.TransformTypeMap, v1beta1.TransformTypeMatch, v1beta1.TransformTypeMath, v1beta1.TransformTypeString, v1beta1.TransformTypeConvert:
+	default:
+		return fmt.Sprintf("transform type %s", t.Type)
+	}
+
+	switch {
+	case t.Name != nil || t.FromName != nil:
+		return "named transform values"
+	case t.MapFromEnvironment != nil:
+		return "mapFromEnvironment"
+	case t.MapFromData != nil:
+		return "mapFromData"
+	case t.GetMapIgnoreCase():
+		return "mapIgnoreCase"
+	case t.GetMapInvert():
+		return "mapInvert"
+	}
+
+	if m := t.Math; m != nil {
+		switch m.Type {
+		case "", v1beta1.MathTransformTypeMultiply, v1beta1.MathTransformTypeClampMin, v1beta1.MathTransformTypeClampMax:
+		default:
+			return fmt.Sprintf("math type %s", m.Type)
+		}
+		switch {
+		case m.MultiplyFloat != nil:
+			return "math multiplyFloat"
+		case m.Precision != nil:
+			return "math precision"
+		case m.Rounding != nil:
+			return "math rounding"
+		}
+	}
+
+	if m := t.Match; m != nil {
+		if m.FallbackFrom != nil {
+			return "match fallbackFrom"
+		}
+		for _, p := range m.Patterns {
+			if p.Type != v1beta1.MatchTransformPatternTypeLiteral && p.Type != v1beta1.MatchTransformPatternTypeRegexp {
+				return fmt.Sprintf("match pattern type %s", p.Type)
+			}
+		}
+	}
+
+	if s := t.String; s != nil {
+		switch s.Type { //nolint:exhaustive // Only these string transform types are native.
+		case "", v1beta1.StringTransformTypeFormat, v1beta1.StringTransformTypeConvert, v1beta1.StringTransformTypeTrimPrefix, v1beta1.StringTransformTypeTrimSuffix, v1beta1.StringTransformTypeRegexp:
+		default:
+			return fmt.Sprintf("string type %s", s.Type)
+		}
+		if s.Convert != nil {
+			switch *s.Convert { //nolint:exhaustive // Only these string conversions are native.
+			case v1beta1.StringConversionTypeToUpper, v1beta1.StringConversionTypeToLower, v1beta1.StringConversionTypeToJSON,
+				v1beta1.StringConversionTypeToBase64, v1beta1.StringConversionTypeFromBase64,
+				v1beta1.StringConversionTypeToSHA1, v1beta1.StringConversionTypeToSHA256, v1beta1.StringConversionTypeToSHA512,
+				v1beta1.StringConversionTypeToAdler32:
+			default:
+				return fmt.Sprintf("string conversion %s", *s.Convert)
+			}
+		}
+	}
+
+	if c := t.Convert; c != nil {
+		switch {
+		case c.Base != nil:
+			return "convert base"
+		case c.Width != nil:
+			return "convert width"
+		case c.FloatFormat != nil:
+			return "convert floatFormat"
+		case c.Precision != nil:
+			return "convert precision"
+		case c.LossyPolicy != nil:
+			return "convert lossyPolicy"
+		}
+	}
+
+	return ""
+}
+
+// convertNativeConnectionDetails converts the supplied native connection
+// details in place.
+func convertNativeConnectionDetails(v any) {
+	cds, _ := v.([]any)
+	for i := range cds {
+		cd, ok := cds[i].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		// Native P&T infers the type of a connection detail from which of its
+		// fields are set. We require it to be set explicitly.
+		if cd["type"] == nil {
+			switch {
+			case cd["value"] != nil:
+				cd["type"] = string(v1beta1.ConnectionDetailTypeFromValue)
+			case cd["fromConnectionSecretKey"] != nil:
+				cd["type"] = string(v1beta1.ConnectionDetailTypeFromConnectionSecretKey)
+			case cd["fromFieldPath"] != nil:
+				cd["type"] = string(v1beta1.ConnectionDetailTypeFromFieldPath)
+			}
+		}
+
+		// Native P&T defaults the name of a connection detail to the key it
+		// was read from. We require it to be set explicitly.
+		if n, _ := cd["name"].(string); n == "" && cd["fromConnectionSecretKey"] != nil {
+			cd["name"] = cd["fromConnectionSecretKey"]
+		}
+	}
+}
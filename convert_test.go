@@ -0,0 +1,975 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestConvertCompositionToPipeline(t *testing.T) {
+	fromYAML := func(y string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(y), &u.Object); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	type args struct {
+		in   *unstructured.Unstructured
+		fn   string
+		step string
+	}
+	type want struct {
+		out      *unstructured.Unstructured
+		warnings []string
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"PipelineMode": {
+			reason: "We should return an error if the Composition doesn't use native P&T.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+`),
+			},
+			want: want{
+				err: errors.Errorf(errFmtCompositionMode, compositionModeResources, compositionModePipeline),
+			},
+		},
+		"Success": {
+			reason: "We should convert native P&T to a single pipeline step, filling in fields the Function requires.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: example
+spec:
+  compositeTypeRef:
+    apiVersion: example.org/v1
+    kind: XR
+  environment:
+    patches:
+    - fromFieldPath: spec.region
+      toFieldPath: region
+  patchSets:
+  - name: common
+    patches:
+    - fromFieldPath: metadata.labels
+      policy:
+        mergeOptions:
+          keepMapValues: true
+  resources:
+  - base:
+      apiVersion: example.org/v1
+      kind: CD
+    patches:
+    - type: PatchSet
+      patchSetName: common
+    - fromFieldPath: spec.size
+      toFieldPath: spec.forProvider.size
+      transforms:
+      - type: math
+        math:
+          multiply: 2
+      - type: string
+        string:
+          fmt: "%d"
+    connectionDetails:
+    - fromConnectionSecretKey: password
+`),
+				fn:   "function-patch-and-transform",
+				step: "patch-and-transform",
+			},
+			want: want{
+				out: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: example
+spec:
+  compositeTypeRef:
+    apiVersion: example.org/v1
+    kind: XR
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      environment:
+        patches:
+        - type: ToEnvironmentFieldPath
+          fromFieldPath: spec.region
+          toFieldPath: region
+      patchSets:
+      - name: common
+        patches:
+        - fromFieldPath: metadata.labels
+      resources:
+      - name: resource-0
+        base:
+          apiVersion: example.org/v1
+          kind: CD
+        patches:
+        - type: PatchSet
+          patchSetName: common
+        - fromFieldPath: spec.size
+          toFieldPath: spec.forProvider.size
+          transforms:
+          - type: math
+            math:
+              type: Multiply
+              multiply: 2
+          - type: string
+            string:
+              type: Format
+              fmt: "%d"
+        connectionDetails:
+        - name: password
+          type: FromConnectionSecretKey
+          fromConnectionSecretKey: password
+`),
+				warnings: []string{
+					"spec.patchSets[0].patches[0]: removed unsupported policy.mergeOptions",
+					`spec.resources[0]: generated name "resource-0" for anonymous resource template`,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, warnings, err := ConvertCompositionToPipeline(tc.args.in, tc.args.fn, tc.args.step)
+
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nConvertCompositionToPipeline(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.warnings, warnings); diff != "" {
+				t.Errorf("%s\nConvertCompositionToPipeline(...): -want warnings, +got warnings:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nConvertCompositionToPipeline(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConvertCompositionToNative(t *testing.T) {
+	fromYAML := func(y string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(y), &u.Object); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	// fromInput returns a Composition with a single pipeline step that calls
+	// this Function with the supplied input.
+	fromInput := func(y string) *unstructured.Unstructured {
+		input := map[string]any{}
+		if err := yaml.Unmarshal([]byte(y), &input); err != nil {
+			t.Fatal(err)
+		}
+		u := fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+`)
+		step := map[string]any{
+			"step":        "patch-and-transform",
+			"functionRef": map[string]any{"name": "function-patch-and-transform"},
+			"input":       input,
+		}
+		if err := unstructured.SetNestedSlice(u.Object, []any{step}, "spec", "pipeline"); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	type args struct {
+		in *unstructured.Unstructured
+		fn string
+	}
+	type want struct {
+		out      *unstructured.Unstructured
+		warnings []string
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ResourcesMode": {
+			reason: "We should return an error if the Composition doesn't use a pipeline.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Resources
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtCompositionMode, compositionModePipeline, compositionModeResources),
+			},
+		},
+		"OtherFunction": {
+			reason: "We should return an error if the pipeline calls another Function.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: templates
+    functionRef:
+      name: function-go-templating
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtOtherFunctionStep, "templates", "function-go-templating"),
+			},
+		},
+		"NoBase": {
+			reason: "We should return an error if a resource template has no base.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      resources:
+      - name: cool-resource
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtNoBase, "cool-resource"),
+			},
+		},
+		"ConnectionDetailPatch": {
+			reason: "We should return an error if a resource template patches to connection details.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      resources:
+      - name: cool-resource
+        base:
+          apiVersion: example.org/v1
+          kind: CD
+        patches:
+        - type: ToConnectionDetail
+          fromFieldPath: status.atProvider.endpoint
+          connectionDetailName: host
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtPatchType, "cool-resource", v1beta1.PatchTypeToConnectionDetail),
+			},
+		},
+		"DeleteCondition": {
+			reason: "We should return an error if a resource template has a delete condition.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      resources:
+      - name: cool-resource
+        base:
+          apiVersion: example.org/v1
+          kind: CD
+        delete:
+          fromFieldPath: spec.disableCoolResource
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtDeleteCondition, "cool-resource"),
+			},
+		},
+		"ReadinessPolicy": {
+			reason: "We should return an error if the input has a readiness policy.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      readiness:
+        type: Quorum
+        quorum: 1
+      resources:
+      - name: cool-resource
+        base:
+          apiVersion: example.org/v1
+          kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errReadiness),
+			},
+		},
+		"FromFieldPaths": {
+			reason: "We should return an error if a connection detail has fallback field paths.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      resources:
+      - name: cool-resource
+        base:
+          apiVersion: example.org/v1
+          kind: CD
+        connectionDetails:
+        - name: endpoint
+          type: FromFieldPath
+          fromFieldPaths:
+          - status.atProvider.endpoint
+          - status.atProvider.address
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtFromFieldPaths, "cool-resource", "endpoint"),
+			},
+		},
+		"CompositeOnly": {
+			reason: "We should return an error if the input has no resource templates, even if it patches the XR.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+composite:
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: status.size
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errNoResources),
+			},
+		},
+		"CompositePatches": {
+			reason: "We should return an error if the input patches the XR.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+composite:
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: status.size
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errComposite),
+			},
+		},
+		"CompositeConditions": {
+			reason: "We should return an error if the input sets conditions on the XR.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+composite:
+  conditions:
+  - type: DatabaseReady
+    status: "True"
+    reason: Available
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errComposite),
+			},
+		},
+		"ConnectionDetailsPolicy": {
+			reason: "We should return an error if the input filters the XR's connection details.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+connectionDetails:
+  exclude:
+  - password
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errConnectionDetails),
+			},
+		},
+		"Data": {
+			reason: "We should return an error if the input has data.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+data:
+  regions:
+    us: us-east-1
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errData),
+			},
+		},
+		"EnvironmentDefaults": {
+			reason: "We should return an error if the input has environment defaults.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+environment:
+  defaults:
+    region: us-east-1
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.New(errEnvironmentDefaults),
+			},
+		},
+		"ForceReady": {
+			reason: "We should return an error if a resource template forces readiness.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  forceReady:
+    value: true
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtForceReady, "cool-resource"),
+			},
+		},
+		"PatchStage": {
+			reason: "We should return an error if a patch runs in a non-default stage.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    stage: PreBase
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "stage PreBase"),
+			},
+		},
+		"PatchSetPatchStage": {
+			reason: "We should return an error if a patch in a PatchSet runs in a non-default stage.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+patchSets:
+- name: common
+  patches:
+  - fromFieldPath: metadata.labels
+    toFieldPath: metadata.labels
+    stage: PostReadiness
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtPatchSetPatch, "common", 0, "stage PostReadiness"),
+			},
+		},
+		"CreateOnlyPolicy": {
+			reason: "We should return an error if a patch uses the createOnly policy.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    policy:
+      createOnly: true
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "policy createOnly"),
+			},
+		},
+		"FreezePolicy": {
+			reason: "We should return an error if a patch uses the freeze policy.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    policy:
+      freeze: true
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "policy freeze"),
+			},
+		},
+		"MemoizePolicy": {
+			reason: "We should return an error if a patch uses the memoize policy.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    policy:
+      memoize: true
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "policy memoize"),
+			},
+		},
+		"SkipUnchangedPolicy": {
+			reason: "We should return an error if a patch uses the skipUnchanged policy.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    policy:
+      skipUnchanged: true
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "policy skipUnchanged"),
+			},
+		},
+		"MergeKeyPolicy": {
+			reason: "We should return an error if a patch uses the mergeKey policy.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    policy:
+      mergeKey: name
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "policy mergeKey"),
+			},
+		},
+		"CoerceToExistingTypePolicy": {
+			reason: "We should return an error if a patch uses the coerceToExistingType policy.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    policy:
+      coerceToExistingType: true
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "policy coerceToExistingType"),
+			},
+		},
+		"BoolTransform": {
+			reason: "We should return an error if a patch uses a transform type native P&T doesn't have.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    transforms:
+    - type: bool
+      bool:
+        type: Not
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "transform type bool in transform 0"),
+			},
+		},
+		"StringTransformType": {
+			reason: "We should return an error if a patch uses a string transform type native P&T doesn't have.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    transforms:
+    - type: string
+      string:
+        type: EnsurePrefix
+        ensure: size-
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtResourcePatch, "cool-resource", 0, "string type EnsurePrefix in transform 0"),
+			},
+		},
+		"EnvironmentPatchTransform": {
+			reason: "We should return an error if an environment patch uses a transform native P&T doesn't have.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+environment:
+  patches:
+  - type: FromEnvironmentFieldPath
+    fromFieldPath: size
+    toFieldPath: status.size
+    transforms:
+    - type: parse
+      parse:
+        type: Int
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				err: errors.Errorf(errFmtEnvironmentPatch, 0, "transform type parse in transform 0"),
+			},
+		},
+		"TransformTimeout": {
+			reason: "We should remove a patch's transform timeout and warn about it.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+    transformTimeout: 5s
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				out: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Resources
+  resources:
+  - name: cool-resource
+    base:
+      apiVersion: example.org/v1
+      kind: CD
+    patches:
+    - fromFieldPath: spec.size
+      toFieldPath: spec.forProvider.size
+`),
+				warnings: []string{"spec.resources[0].patches[0]: removed unsupported transformTimeout"},
+			},
+		},
+		"Assertions": {
+			reason: "We should remove assertions and warn about them.",
+			args: args{
+				in: fromInput(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+assertions:
+- type: UniqueFieldValue
+  fieldPath: metadata.annotations[crossplane.io/external-name]
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - fromFieldPath: spec.size
+    toFieldPath: spec.forProvider.size
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				out: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+spec:
+  mode: Resources
+  resources:
+  - name: cool-resource
+    base:
+      apiVersion: example.org/v1
+      kind: CD
+    patches:
+    - fromFieldPath: spec.size
+      toFieldPath: spec.forProvider.size
+`),
+				warnings: []string{"removed unsupported input field assertions"},
+			},
+		},
+		"Success": {
+			reason: "We should convert a single pipeline step to native P&T.",
+			args: args{
+				in: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: example
+spec:
+  compositeTypeRef:
+    apiVersion: example.org/v1
+    kind: XR
+  mode: Pipeline
+  pipeline:
+  - step: patch-and-transform
+    functionRef:
+      name: function-patch-and-transform
+    input:
+      apiVersion: pt.fn.crossplane.io/v1beta1
+      kind: Resources
+      environment:
+        patches:
+        - type: FromEnvironmentFieldPath
+          fromFieldPath: region
+          toFieldPath: status.region
+      patchSets:
+      - name: common
+        patches:
+        - fromFieldPath: metadata.labels
+      resources:
+      - name: cool-resource
+        base:
+          apiVersion: example.org/v1
+          kind: CD
+        patches:
+        - type: PatchSet
+          patchSetName: common
+`),
+				fn: "function-patch-and-transform",
+			},
+			want: want{
+				out: fromYAML(`
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: example
+spec:
+  compositeTypeRef:
+    apiVersion: example.org/v1
+    kind: XR
+  mode: Resources
+  environment:
+    patches:
+    - type: ToCompositeFieldPath
+      fromFieldPath: region
+      toFieldPath: status.region
+  patchSets:
+  - name: common
+    patches:
+    - fromFieldPath: metadata.labels
+  resources:
+  - name: cool-resource
+    base:
+      apiVersion: example.org/v1
+      kind: CD
+    patches:
+    - type: PatchSet
+      patchSetName: common
+`),
+				warnings: []string{},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, warnings, err := ConvertCompositionToNative(tc.args.in, tc.args.fn)
+
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nConvertCompositionToNative(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.warnings, warnings); diff != "" {
+				t.Errorf("%s\nConvertCompositionToNative(...): -want warnings, +got warnings:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nConvertCompositionToNative(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
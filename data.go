@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+// DataField is a virtual top-level field of the observed composite resource.
+// Patches from the composite resource can read the Input's data documents from
+// it, e.g. using fromFieldPath: data.regions.
+const DataField = "data"
+
+// Error strings
+const (
+	errFmtDecodeData = "cannot decode data document %q"
+)
+
+// maxCachedDocuments is the maximum number of decoded data documents we'll
+// cache. Documents come from Compositions, so we expect relatively few distinct
+// documents. The limit just guards against unbounded growth.
+const maxCachedDocuments = 256
+
+// documents caches decoded data documents across RunFunction calls. A
+// Composition's data documents are the same each time it's rendered.
+var documents = newDocumentCache(maxCachedDocuments)
+
+// A documentCache caches decoded JSON documents by the hash of their content.
+type documentCache struct {
+	mu    sync.RWMutex
+	cache map[[sha256.Size]byte]any
+	size  int
+}
+
+func newDocumentCache(size int) *documentCache {
+	return &documentCache{cache: make(map[[sha256.Size]byte]any), size: size}
+}
+
+// Decode returns the decoded form of the supplied JSON document, decoding it
+// only if a document with the same content isn't already cached. Callers get
+// their own copy of the document, which they may modify. Documents that fail
+// to decode aren't cached.
+func (c *documentCache) Decode(raw []byte) (any, error) {
+	k := sha256.Sum256(raw)
+	c.mu.RLock()
+	v, ok := c.cache[k]
+	c.mu.RUnlock()
+	if ok {
+		return runtime.DeepCopyJSONValue(v), nil
+	}
+
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= c.size {
+		// Start over rather than tracking which documents were least
+		// recently used.
+		c.cache = make(map[[sha256.Size]byte]any)
+	}
+	c.cache[k] = v
+	return runtime.DeepCopyJSONValue(v), nil
+}
+
+// DecodeData decodes the supplied data documents. Documents are only decoded
+// the first time they're seen; after that they're copied from a cache.
+func DecodeData(in map[string]extv1.JSON) (map[string]any, error) {
+	data := make(map[string]any, len(in))
+	for name, doc := range in {
+		v, err := documents.Decode(doc.Raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtDecodeData, name)
+		}
+		data[name] = v
+	}
+	return data, nil
+}
+
+// SetDataField sets the virtual data field of the supplied observed composite
+// resource. The field isn't set if there are no data documents.
+func SetDataField(xr *composite.Unstructured, data map[string]any) {
+	if len(data) == 0 {
+		return
+	}
+	xr.Object[DataField] = data
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+func TestSetDataField(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		data   map[string]extv1.JSON
+		want   map[string]any
+	}{
+		"NoData": {
+			reason: "We shouldn't set the data field if there are no data documents.",
+			want:   nil,
+		},
+		"Data": {
+			reason: "We should set the data field to the decoded data documents.",
+			data: map[string]extv1.JSON{
+				"regions": {Raw: []byte(`{"us":"us-east-1"}`)},
+				"tiers":   {Raw: []byte(`["gold","silver"]`)},
+			},
+			want: map[string]any{
+				"regions": map[string]any{"us": "us-east-1"},
+				"tiers":   []any{"gold", "silver"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+				"apiVersion": "example.org/v1",
+				"kind": "XR"
+			}`)}}
+			data, err := DecodeData(tc.data)
+			if err != nil {
+				t.Fatalf("DecodeData(...): %v", err)
+			}
+			SetDataField(xr, data)
+			got, _ := xr.Object[DataField].(map[string]any)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSetDataField(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDocumentCacheDecode(t *testing.T) {
+	c := newDocumentCache(1)
+	raw := []byte(`{"regions":["us-east-1"]}`)
+
+	got, err := c.Decode(raw)
+	if err != nil {
+		t.Fatalf("Decode(...): %v", err)
+	}
+
+	// Modifying a decoded document mustn't modify the cached document.
+	got.(map[string]any)["regions"] = "modified"
+
+	got, err = c.Decode(raw)
+	if err != nil {
+		t.Fatalf("Decode(...): %v", err)
+	}
+	want := map[string]any{"regions": []any{"us-east-1"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Decode(...): -want, +got:\n%s", diff)
+	}
+
+	// Documents that don't fit in the cache should still be decoded.
+	got, err = c.Decode([]byte(`"us-west-2"`))
+	if err != nil {
+		t.Fatalf("Decode(...): %v", err)
+	}
+	if diff := cmp.Diff("us-west-2", got); diff != "" {
+		t.Errorf("Decode(...): -want, +got:\n%s", diff)
+	}
+
+	if _, err := c.Decode([]byte(`{`)); err == nil {
+		t.Errorf("Decode(...): want error decoding invalid JSON, got nil")
+	}
+}
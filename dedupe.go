@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+)
+
+// A WarningDeduplicator removes warning results that were already returned for
+// the same composite resource recently. Crossplane emits an event for each
+// warning result each time it reconciles a composite resource, so without
+// deduplication a persistent warning spams the XR and its claim with the same
+// event every reconcile.
+//
+// Warnings are remembered in memory, so each replica of the Function
+// deduplicates the warnings it returned, and a restarted Function returns each
+// warning again.
+type WarningDeduplicator struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewWarningDeduplicator returns a WarningDeduplicator that removes a warning
+// result if it was returned for the same composite resource within the
+// supplied window.
+func NewWarningDeduplicator(window time.Duration) *WarningDeduplicator {
+	return &WarningDeduplicator{window: window, now: time.Now, seen: map[string]time.Time{}}
+}
+
+// Deduplicate removes any warning result from the supplied response that was
+// returned for the composite resource with the supplied key within the
+// deduplication window. Results of other severities are never removed.
+func (d *WarningDeduplicator) Deduplicate(key string, rsp *fnv1beta1.RunFunctionResponse) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	// Forget warnings returned before the window, so we don't grow forever.
+	for h, t := range d.seen {
+		if now.Sub(t) >= d.window {
+			delete(d.seen, h)
+		}
+	}
+
+	results := make([]*fnv1beta1.Result, 0, len(rsp.GetResults()))
+	for _, r := range rsp.GetResults() {
+		if r.GetSeverity() != fnv1beta1.Severity_SEVERITY_WARNING {
+			results = append(results, r)
+			continue
+		}
+		sum := sha256.Sum256([]byte(key + "\x00" + r.GetMessage()))
+		h := hex.EncodeToString(sum[:])
+		if _, ok := d.seen[h]; ok {
+			continue
+		}
+		d.seen[h] = now
+		results = append(results, r)
+	}
+	rsp.Results = results
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+)
+
+func TestWarningDeduplicator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	warning := &fnv1beta1.Result{Severity: fnv1beta1.Severity_SEVERITY_WARNING, Message: "[PT011] patches were skipped"}
+	fatal := &fnv1beta1.Result{Severity: fnv1beta1.Severity_SEVERITY_FATAL, Message: "[PT002] invalid Function input"}
+
+	type call struct {
+		after time.Duration
+		key   string
+		in    []*fnv1beta1.Result
+		want  []*fnv1beta1.Result
+	}
+
+	cases := map[string]struct {
+		reason string
+		calls  []call
+	}{
+		"FirstWarning": {
+			reason: "A warning we haven't returned before should be returned.",
+			calls: []call{
+				{key: "xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{warning}},
+			},
+		},
+		"RepeatedWarning": {
+			reason: "A warning we returned for the same XR within the window should be removed.",
+			calls: []call{
+				{key: "xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{warning}},
+				{after: time.Minute, key: "xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{}},
+			},
+		},
+		"DifferentXR": {
+			reason: "A warning we returned for a different XR should be returned.",
+			calls: []call{
+				{key: "xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{warning}},
+				{after: time.Minute, key: "other-xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{warning}},
+			},
+		},
+		"WindowPassed": {
+			reason: "A warning we returned before the window should be returned again.",
+			calls: []call{
+				{key: "xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{warning}},
+				{after: 10 * time.Minute, key: "xr", in: []*fnv1beta1.Result{warning}, want: []*fnv1beta1.Result{warning}},
+			},
+		},
+		"RepeatedFatal": {
+			reason: "Results that aren't warnings should never be removed.",
+			calls: []call{
+				{key: "xr", in: []*fnv1beta1.Result{fatal}, want: []*fnv1beta1.Result{fatal}},
+				{after: time.Minute, key: "xr", in: []*fnv1beta1.Result{fatal}, want: []*fnv1beta1.Result{fatal}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d := NewWarningDeduplicator(5 * time.Minute)
+			for i, c := range tc.calls {
+				d.now = func() time.Time { return start.Add(c.after) }
+				rsp := &fnv1beta1.RunFunctionResponse{Results: c.in}
+				d.Deduplicate(c.key, rsp)
+				if diff := cmp.Diff(c.want, rsp.GetResults(), protocmp.Transform()); diff != "" {
+					t.Errorf("%s\ncall %d: Deduplicate(...): -want, +got:\n%s", tc.reason, i, diff)
+				}
+			}
+		})
+	}
+}
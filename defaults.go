@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+// Error strings
+const (
+	errReadDefaults    = "cannot read defaults file"
+	errParseDefaults   = "cannot parse defaults"
+	errInvalidDefaults = "invalid defaults"
+)
+
+// Defaults configure every input the Function runs. An operator sets them for
+// a whole control plane, for example from a file mounted into the Function's
+// pod, to enforce organization-wide policy. Defaults never override what an
+// input specifies.
+type Defaults struct {
+	// PatchSets are added to every input that doesn't define a PatchSet of
+	// the same name.
+	PatchSets []v1beta1.PatchSet `json:"patchSets,omitempty"`
+
+	// IncludePatchSets names PatchSets that are included at the start of
+	// every resource template's patches, unless the template already includes
+	// them. Each must be one of the defaults' PatchSets. The template's own
+	// patches are applied after them, so they take precedence. An input may
+	// not define a PatchSet with one of these names, so it can't replace what
+	// they include.
+	IncludePatchSets []string `json:"includePatchSets,omitempty"`
+
+	// FromFieldPathPolicy is the fromFieldPath policy of patches that read
+	// from a field path but don't specify one, e.g. Required to fail when a
+	// source field doesn't exist instead of skipping the patch.
+	FromFieldPathPolicy *v1beta1.FromFieldPathPolicy `json:"fromFieldPathPolicy,omitempty"`
+
+	// DeniedTransformTypes can't be used by any patch. An input that uses
+	// one is invalid.
+	DeniedTransformTypes []v1beta1.TransformType `json:"deniedTransformTypes,omitempty"`
+}
+
+// ParseDefaults parses and validates YAML or JSON encoded defaults. Unknown
+// fields are an error, so that a typo doesn't silently disable a policy.
+func ParseDefaults(b []byte) (*Defaults, error) {
+	d := &Defaults{}
+	if err := yaml.UnmarshalStrict(b, d); err != nil {
+		return nil, errors.Wrap(err, errParseDefaults)
+	}
+	if err := ValidateDefaults(d); err != nil {
+		return nil, errors.Wrap(err, errInvalidDefaults)
+	}
+	return d, nil
+}
+
+// ValidateDefaults validates Defaults.
+func ValidateDefaults(d *Defaults) *field.Error {
+	names := make(map[string]bool, len(d.PatchSets))
+	for i, ps := range d.PatchSets {
+		if err := ValidatePatchSet(ps); err != nil {
+			return WrapFieldError(err, field.NewPath("patchSets").Index(i))
+		}
+		if names[ps.Name] {
+			return field.Duplicate(field.NewPath("patchSets").Index(i).Child("name"), ps.Name)
+		}
+		names[ps.Name] = true
+	}
+	for i, name := range d.IncludePatchSets {
+		if !names[name] {
+			return field.NotFound(field.NewPath("includePatchSets").Index(i), name)
+		}
+	}
+	if p := d.FromFieldPathPolicy; p != nil {
+		switch *p {
+		case v1beta1.FromFieldPathPolicyOptional,
+			v1beta1.FromFieldPathPolicyRequired,
+			v1beta1.FromFieldPathPolicyOptionalNonEmpty,
+			v1beta1.FromFieldPathPolicyRequiredNonEmpty:
+		default:
+			return field.Invalid(field.NewPath("fromFieldPathPolicy"), *p, "unknown fromFieldPath policy")
+		}
+	}
+	for i, t := range d.DeniedTransformTypes {
+		if _, ok := transforms.Default.Get(t); !ok {
+			return field.Invalid(field.NewPath("deniedTransformTypes").Index(i), t, "unknown transform type")
+		}
+	}
+	return nil
+}
+
+// Apply the defaults to the supplied input. Applying nil defaults does
+// nothing.
+func (d *Defaults) Apply(r *v1beta1.Resources) {
+	if d == nil {
+		return
+	}
+
+	defined := make(map[string]bool, len(r.PatchSets))
+	for _, ps := range r.PatchSets {
+		defined[ps.Name] = true
+	}
+	for _, ps := range d.PatchSets {
+		if !defined[ps.Name] {
+			r.PatchSets = append(r.PatchSets, *ps.DeepCopy())
+		}
+	}
+
+	for i := range r.Resources {
+		r.Resources[i].Patches = includePatchSets(d.IncludePatchSets, r.Resources[i].Patches)
+	}
+
+	if d.FromFieldPathPolicy == nil {
+		return
+	}
+	eachPatch(r, func(_ *field.Path, p *v1beta1.Patch) {
+		if p.FromFieldPath == nil && p.Combine == nil {
+			// This patch doesn't read from a field path.
+			return
+		}
+		if p.Policy == nil {
+			p.Policy = &v1beta1.PatchPolicy{}
+		}
+		if p.Policy.FromFieldPath == nil {
+			p.Policy.FromFieldPath = ptr.To(*d.FromFieldPathPolicy)
+		}
+	})
+}
+
+// Check returns an error if the supplied input violates the defaults' policy,
+// for example by using a denied transform type, or by defining a PatchSet that
+// the defaults include in every resource template. Check the input before the
+// defaults are applied to it. Checking nil defaults always succeeds.
+func (d *Defaults) Check(r *v1beta1.Resources) *field.Error {
+	if d == nil {
+		return nil
+	}
+
+	for i, ps := range r.PatchSets {
+		for _, name := range d.IncludePatchSets {
+			if ps.Name == name {
+				return field.Forbidden(field.NewPath("patchSets").Index(i).Child("name"), fmt.Sprintf("PatchSet %s is defined by the Function's defaults", name))
+			}
+		}
+	}
+
+	if len(d.DeniedTransformTypes) == 0 {
+		return nil
+	}
+	denied := make(map[v1beta1.TransformType]bool, len(d.DeniedTransformTypes))
+	for _, t := range d.DeniedTransformTypes {
+		denied[t] = true
+	}
+
+	var err *field.Error
+	eachPatch(r, func(path *field.Path, p *v1beta1.Patch) {
+		for i, t := range p.Transforms {
+			if err == nil && denied[t.Type] {
+				err = field.Forbidden(path.Child("transforms").Index(i).Child("type"), fmt.Sprintf("transform type %s is denied by the Function's defaults", t.Type))
+			}
+		}
+	})
+	return err
+}
+
+// includePatchSets returns the supplied patches, prefixed with patches that
+// include each of the named PatchSets they don't already include.
+func includePatchSets(names []string, ps []v1beta1.ComposedPatch) []v1beta1.ComposedPatch {
+	if len(names) == 0 {
+		return ps
+	}
+	included := map[string]bool{}
+	for _, p := range ps {
+		if p.GetType() == v1beta1.PatchTypePatchSet {
+			included[p.GetPatchSetName()] = true
+		}
+	}
+	out := make([]v1beta1.ComposedPatch, 0, len(names)+len(ps))
+	for _, name := range names {
+		if !included[name] {
+			out = append(out, v1beta1.ComposedPatch{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To(name)})
+		}
+	}
+	return append(out, ps...)
+}
+
+// eachPatch calls the supplied function with every patch of the supplied
+// input, and the path to the patch.
+func eachPatch(r *v1beta1.Resources, fn func(path *field.Path, p *v1beta1.Patch)) {
+	for i := range r.PatchSets {
+		for j := range r.PatchSets[i].Patches {
+			fn(field.NewPath("patchSets").Index(i).Child("patches").Index(j), &r.PatchSets[i].Patches[j].Patch)
+		}
+	}
+	if r.Environment != nil {
+		for i := range r.Environment.Patches {
+			fn(field.NewPath("environment", "patches").Index(i), &r.Environment.Patches[i].Patch)
+		}
+	}
+	if r.Composite != nil {
+		for i := range r.Composite.Patches {
+			fn(field.NewPath("composite", "patches").Index(i), &r.Composite.Patches[i].Patch)
+		}
+	}
+	for i := range r.Resources {
+		for j := range r.Resources[i].Patches {
+			fn(field.NewPath("resources").Index(i).Child("patches").Index(j), &r.Resources[i].Patches[j].Patch)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	tags := v1beta1.PatchSet{
+		Name: "tags",
+		Patches: []v1beta1.PatchSetPatch{{
+			Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{FromFieldPath: ptr.To("metadata.labels[team]"), ToFieldPath: ptr.To("spec.forProvider.tags[team]")},
+		}},
+	}
+
+	cases := map[string]struct {
+		reason string
+		d      *Defaults
+		want   *field.Error
+	}{
+		"Valid": {
+			reason: "Defaults that include a PatchSet they define should be valid",
+			d: &Defaults{
+				PatchSets:            []v1beta1.PatchSet{tags},
+				IncludePatchSets:     []string{"tags"},
+				FromFieldPathPolicy:  ptr.To(v1beta1.FromFieldPathPolicyRequired),
+				DeniedTransformTypes: []v1beta1.TransformType{v1beta1.TransformTypeWebhook},
+			},
+		},
+		"UndefinedIncludedPatchSet": {
+			reason: "Defaults that include a PatchSet they don't define should be invalid",
+			d:      &Defaults{IncludePatchSets: []string{"tags"}},
+			want: &field.Error{
+				Type:  field.ErrorTypeNotFound,
+				Field: "includePatchSets[0]",
+			},
+		},
+		"DuplicatePatchSet": {
+			reason: "Defaults that define two PatchSets with the same name should be invalid",
+			d:      &Defaults{PatchSets: []v1beta1.PatchSet{tags, tags}},
+			want: &field.Error{
+				Type:  field.ErrorTypeDuplicate,
+				Field: "patchSets[1].name",
+			},
+		},
+		"UnknownFromFieldPathPolicy": {
+			reason: "Defaults with an unknown fromFieldPath policy should be invalid",
+			d:      &Defaults{FromFieldPathPolicy: ptr.To(v1beta1.FromFieldPathPolicy("Sometimes"))},
+			want: &field.Error{
+				Type:  field.ErrorTypeInvalid,
+				Field: "fromFieldPathPolicy",
+			},
+		},
+		"UnknownTransformType": {
+			reason: "Defaults that deny an unknown transform type should be invalid",
+			d:      &Defaults{DeniedTransformTypes: []v1beta1.TransformType{"cel"}},
+			want: &field.Error{
+				Type:  field.ErrorTypeInvalid,
+				Field: "deniedTransformTypes[0]",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateDefaults(tc.d)
+			if diff := cmp.Diff(tc.want, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateDefaults(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDefaultsApply(t *testing.T) {
+	patchSet := func(name, from string) v1beta1.PatchSet {
+		return v1beta1.PatchSet{
+			Name: name,
+			Patches: []v1beta1.PatchSetPatch{{
+				Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{FromFieldPath: ptr.To(from)},
+			}},
+		}
+	}
+	include := func(name string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To(name)}
+	}
+	from := func(path string, pp *v1beta1.PatchPolicy) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{FromFieldPath: ptr.To(path), Policy: pp},
+		}
+	}
+
+	cases := map[string]struct {
+		reason string
+		d      *Defaults
+		r      *v1beta1.Resources
+		want   *v1beta1.Resources
+	}{
+		"NilDefaults": {
+			reason: "Applying nil defaults should leave the input unchanged.",
+			r: &v1beta1.Resources{
+				Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{from("spec.region", nil)}}},
+			},
+			want: &v1beta1.Resources{
+				Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{from("spec.region", nil)}}},
+			},
+		},
+		"PatchSets": {
+			reason: "We should add default PatchSets the input doesn't define, and include them in every template that doesn't already include them.",
+			d: &Defaults{
+				PatchSets:        []v1beta1.PatchSet{patchSet("tags", "metadata.labels"), patchSet("region", "spec.region")},
+				IncludePatchSets: []string{"tags"},
+			},
+			r: &v1beta1.Resources{
+				PatchSets: []v1beta1.PatchSet{patchSet("region", "spec.location")},
+				Resources: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{from("spec.name", nil)}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{from("spec.name", nil), include("tags")}},
+				},
+			},
+			want: &v1beta1.Resources{
+				PatchSets: []v1beta1.PatchSet{patchSet("region", "spec.location"), patchSet("tags", "metadata.labels")},
+				Resources: []v1beta1.ComposedTemplate{
+					{Name: "bucket", Patches: []v1beta1.ComposedPatch{include("tags"), from("spec.name", nil)}},
+					{Name: "dashboard", Patches: []v1beta1.ComposedPatch{from("spec.name", nil), include("tags")}},
+				},
+			},
+		},
+		"FromFieldPathPolicy": {
+			reason: "We should set the fromFieldPath policy of patches that read from a field path and don't specify one.",
+			d:      &Defaults{FromFieldPathPolicy: ptr.To(v1beta1.FromFieldPathPolicyRequired)},
+			r: &v1beta1.Resources{
+				Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{
+					from("spec.name", nil),
+					from("spec.region", &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyOptional)}),
+					from("spec.size", &v1beta1.PatchPolicy{SkipUnchanged: ptr.To(true)}),
+					{Type: v1beta1.PatchTypeToCompositeFieldPath, Patch: v1beta1.Patch{ToFieldPath: ptr.To("status.bucket")}},
+				}}},
+			},
+			want: &v1beta1.Resources{
+				Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{
+					from("spec.name", &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)}),
+					from("spec.region", &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyOptional)}),
+					from("spec.size", &v1beta1.PatchPolicy{SkipUnchanged: ptr.To(true), FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)}),
+					{Type: v1beta1.PatchTypeToCompositeFieldPath, Patch: v1beta1.Patch{ToFieldPath: ptr.To("status.bucket")}},
+				}}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tc.d.Apply(tc.r)
+			if diff := cmp.Diff(tc.want, tc.r); diff != "" {
+				t.Errorf("%s\nApply(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDefaultsCheck(t *testing.T) {
+	webhook := v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To("spec.name"),
+			Transforms:    []v1beta1.Transform{{Type: v1beta1.TransformTypeWebhook}},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		d      *Defaults
+		r      *v1beta1.Resources
+		want   *field.Error
+	}{
+		"NilDefaults": {
+			reason: "Nil defaults shouldn't deny any transforms.",
+			r:      &v1beta1.Resources{Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{webhook}}}},
+		},
+		"Allowed": {
+			reason: "An input that doesn't use a denied transform type should be allowed.",
+			d:      &Defaults{DeniedTransformTypes: []v1beta1.TransformType{v1beta1.TransformTypeMath}},
+			r:      &v1beta1.Resources{Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{webhook}}}},
+		},
+		"RedefinesIncludedPatchSet": {
+			reason: "An input that defines a PatchSet the defaults include in every template should be forbidden.",
+			d: &Defaults{
+				PatchSets:        []v1beta1.PatchSet{{Name: "tags"}},
+				IncludePatchSets: []string{"tags"},
+			},
+			r: &v1beta1.Resources{PatchSets: []v1beta1.PatchSet{{Name: "region"}, {Name: "tags"}}},
+			want: &field.Error{
+				Type:  field.ErrorTypeForbidden,
+				Field: "patchSets[1].name",
+			},
+		},
+		"RedefinesDefaultPatchSet": {
+			reason: "An input may define a PatchSet the defaults define but don't include in every template.",
+			d: &Defaults{
+				PatchSets: []v1beta1.PatchSet{{Name: "tags"}},
+			},
+			r: &v1beta1.Resources{PatchSets: []v1beta1.PatchSet{{Name: "tags"}}},
+		},
+		"Denied": {
+			reason: "An input that uses a denied transform type should be forbidden.",
+			d:      &Defaults{DeniedTransformTypes: []v1beta1.TransformType{v1beta1.TransformTypeWebhook}},
+			r:      &v1beta1.Resources{Resources: []v1beta1.ComposedTemplate{{Name: "bucket", Patches: []v1beta1.ComposedPatch{webhook}}}},
+			want: &field.Error{
+				Type:  field.ErrorTypeForbidden,
+				Field: "resources[0].patches[0].transforms[0].type",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.d.Check(tc.r)
+			if diff := cmp.Diff(tc.want, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nCheck(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+)
+
+// A FieldDiff is a field of a composed resource whose desired value differs
+// from its observed value.
+type FieldDiff struct {
+	// Path to the field, e.g. spec.forProvider.region.
+	Path string
+
+	// Observed value of the field. Nil if the field wasn't observed.
+	Observed any
+
+	// Desired value of the field.
+	Desired any
+}
+
+// String returns a human-readable representation of the diff.
+func (d FieldDiff) String() string {
+	if d.Observed == nil {
+		return fmt.Sprintf("+ %s: %s", d.Path, diffValue(d.Desired))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", d.Path, diffValue(d.Observed), diffValue(d.Desired))
+}
+
+func diffValue(v any) string {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(j)
+}
+
+// DiffComposed returns the fields of the supplied desired composed resource
+// whose values differ from the supplied observed composed resource. Only fields
+// that are specified by the desired resource are compared; fields that were
+// only observed (e.g. status, or fields defaulted by the API server) never
+// cause churn, because Crossplane uses server-side apply. Diffs are returned in
+// order of their paths.
+func DiffComposed(observed, desired map[string]any) []FieldDiff {
+	diffs := diffFields("", observed, desired)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// ChangedFieldPaths returns the paths of the fields of the supplied desired
+// composed resource whose values differ from the supplied observed composed
+// resource, in order. It omits values, which may be sensitive.
+func ChangedFieldPaths(observed, desired map[string]any) []string {
+	diffs := DiffComposed(observed, desired)
+	paths := make([]string, len(diffs))
+	for i, d := range diffs {
+		paths[i] = d.Path
+	}
+	return paths
+}
+
+func diffFields(path string, observed, desired any) []FieldDiff {
+	switch d := desired.(type) {
+	case map[string]any:
+		o, _ := observed.(map[string]any)
+		diffs := make([]FieldDiff, 0)
+		for k, dv := range d {
+			var ov any
+			if o != nil {
+				ov = o[k]
+			}
+			diffs = append(diffs, diffFields(fieldpaths.AppendKey(path, k), ov, dv)...)
+		}
+		return diffs
+	case []any:
+		// We only diff arrays element by element if their lengths match.
+		// Otherwise the whole array is considered to have changed.
+		if o, ok := observed.([]any); ok && len(o) == len(d) {
+			diffs := make([]FieldDiff, 0)
+			for i := range d {
+				diffs = append(diffs, diffFields(fmt.Sprintf("%s[%d]", path, i), o[i], d[i])...)
+			}
+			return diffs
+		}
+	}
+
+	if reflect.DeepEqual(observed, desired) {
+		return nil
+	}
+	return []FieldDiff{{Path: path, Observed: observed, Desired: desired}}
+}
+
+// WriteDiff writes a field-level diff between the supplied observed composed
+// resources and the desired composed resources in the supplied response to the
+// supplied writer. Each observed composed resource must be annotated with the
+// name of the resource template that produced it. Composed resources are
+// written in order of their names.
+func WriteDiff(w io.Writer, observed []*unstructured.Unstructured, rsp *fnv1beta1.RunFunctionResponse) error {
+	ocds := make(map[string]map[string]any, len(observed))
+	for _, cd := range observed {
+		ocds[cd.GetAnnotations()[AnnotationKeyCompositionResourceName]] = cd.Object
+	}
+
+	names := make([]string, 0, len(rsp.GetDesired().GetResources()))
+	for name := range rsp.GetDesired().GetResources() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ocd, ok := ocds[name]
+		if !ok {
+			if _, err := fmt.Fprintf(w, "+++ %s (not observed)\n", name); err != nil {
+				return err
+			}
+			continue
+		}
+		diffs := DiffComposed(ocd, rsp.GetDesired().GetResources()[name].GetResource().AsMap())
+		if len(diffs) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "--- %s\n", name); err != nil {
+			return err
+		}
+		for _, d := range diffs {
+			if _, err := fmt.Fprintln(w, d.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+func TestDiffComposed(t *testing.T) {
+	type args struct {
+		observed map[string]any
+		desired  map[string]any
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []FieldDiff
+	}{
+		"NoDiff": {
+			reason: "Fields that are only observed should not be considered a diff.",
+			args: args{
+				observed: map[string]any{
+					"spec":   map[string]any{"region": "us-east-2"},
+					"status": map[string]any{"id": "cool-id"},
+				},
+				desired: map[string]any{
+					"spec": map[string]any{"region": "us-east-2"},
+				},
+			},
+			want: []FieldDiff{},
+		},
+		"ChangedAndAddedFields": {
+			reason: "We should return changed and added fields in order of their paths.",
+			args: args{
+				observed: map[string]any{
+					"metadata": map[string]any{"labels": map[string]any{"example.org/a": "a"}},
+					"spec":     map[string]any{"region": "us-east-1", "tags": []any{"a", "b"}},
+				},
+				desired: map[string]any{
+					"metadata": map[string]any{"labels": map[string]any{"example.org/a": "b"}},
+					"spec":     map[string]any{"region": "us-east-2", "size": float64(2), "tags": []any{"a", "c"}},
+				},
+			},
+			want: []FieldDiff{
+				{Path: "metadata.labels[example.org/a]", Observed: "a", Desired: "b"},
+				{Path: "spec.region", Observed: "us-east-1", Desired: "us-east-2"},
+				{Path: "spec.size", Desired: float64(2)},
+				{Path: "spec.tags[1]", Observed: "b", Desired: "c"},
+			},
+		},
+		"ArrayLengthChanged": {
+			reason: "We should consider the whole array changed if its length changed.",
+			args: args{
+				observed: map[string]any{"spec": map[string]any{"tags": []any{"a"}}},
+				desired:  map[string]any{"spec": map[string]any{"tags": []any{"a", "b"}}},
+			},
+			want: []FieldDiff{
+				{Path: "spec.tags", Observed: []any{"a"}, Desired: []any{"a", "b"}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := DiffComposed(tc.args.observed, tc.args.desired)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nDiffComposed(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	observed := []*unstructured.Unstructured{{Object: map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "CD",
+		"metadata": map[string]any{
+			"annotations": map[string]any{AnnotationKeyCompositionResourceName: "a"},
+		},
+		"spec": map[string]any{"watchers": "5"},
+	}}}
+
+	rsp := &fnv1beta1.RunFunctionResponse{
+		Desired: &fnv1beta1.State{
+			Resources: map[string]*fnv1beta1.Resource{
+				"b": {Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+				"a": {Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10"}}`)},
+			},
+		},
+	}
+
+	want := `--- a
+~ spec.watchers: "5" -> "10"
++++ b (not observed)
+`
+
+	b := &bytes.Buffer{}
+	if err := WriteDiff(b, observed, rsp); err != nil {
+		t.Fatalf("WriteDiff(...): %v", err)
+	}
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteDiff(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Error strings
+const (
+	errFmtReadCompositions = "cannot read Compositions from %s"
+	errFmtExtract          = "cannot extract PatchSets from Composition %q"
+)
+
+// ExtractPatchSetsCmd factors patches that are repeated across resource
+// templates into PatchSets.
+type ExtractPatchSetsCmd struct {
+	Compositions []string `arg:"" type:"existingfile" help:"YAML files containing Compositions that call this Function in a pipeline step."`
+
+	FunctionName string `help:"Name of the Function package that the Compositions reference." default:"function-patch-and-transform"`
+	MinPatches   int    `help:"Minimum number of consecutive patches to factor into a PatchSet." default:"2"`
+}
+
+// Run the extract-patch-sets command.
+func (c *ExtractPatchSetsCmd) Run(k *kong.Context) error {
+	for _, file := range c.Compositions {
+		data, err := os.ReadFile(file) //nolint:gosec // Reading user-supplied files is the point.
+		if err != nil {
+			return errors.Wrapf(err, errFmtReadCompositions, file)
+		}
+		comps, err := ParseYAMLDocuments(data)
+		if err != nil {
+			return errors.Wrapf(err, errFmtReadCompositions, file)
+		}
+		for _, comp := range comps {
+			extracted, err := ExtractCompositionPatchSets(comp, c.FunctionName, c.MinPatches)
+			if err != nil {
+				return errors.Wrapf(err, errFmtExtract, comp.GetName())
+			}
+			for _, ps := range extracted {
+				fmt.Fprintf(k.Stderr, "%s: extracted PatchSet %q with %d patches\n", comp.GetName(), ps.Name, len(ps.Patches))
+			}
+			y, err := yaml.Marshal(comp.Object)
+			if err != nil {
+				return errors.Wrap(err, errMarshalComposition)
+			}
+			if _, err := fmt.Fprintf(k.Stdout, "---\n%s", y); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExtractCompositionPatchSets extracts PatchSets from the input of each
+// pipeline step of the supplied Composition that calls the supplied Function.
+// The Composition is updated in place. It returns the extracted PatchSets.
+func ExtractCompositionPatchSets(comp *unstructured.Unstructured, fn string, minPatches int) ([]v1beta1.PatchSet, error) {
+	mode, _, _ := unstructured.NestedString(comp.Object, "spec", "mode")
+	if mode != compositionModePipeline {
+		return nil, errors.Errorf(errFmtCompositionMode, compositionModePipeline, mode)
+	}
+
+	steps, _, err := unstructured.NestedSlice(comp.Object, "spec", "pipeline")
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtPipelineField, "spec.pipeline")
+	}
+
+	found := false
+	extracted := make([]v1beta1.PatchSet, 0)
+	for i := range steps {
+		s, ok := steps[i].(map[string]any)
+		if !ok {
+			continue
+		}
+		if ref, _, _ := unstructured.NestedString(s, "functionRef", "name"); ref != fn {
+			continue
+		}
+		found = true
+
+		in, _, err := unstructured.NestedMap(s, "input")
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtPipelineField, "spec.pipeline[].input")
+		}
+		j, err := json.Marshal(in)
+		if err != nil {
+			return nil, errors.Wrap(err, errInvalidInput)
+		}
+		r := &v1beta1.Resources{}
+		if err := json.Unmarshal(j, r); err != nil {
+			return nil, errors.Wrap(err, errInvalidInput)
+		}
+
+		e := ExtractPatchSets(r, minPatches)
+		if len(e) == 0 {
+			continue
+		}
+		extracted = append(extracted, e...)
+
+		// Only replace the fields we changed, so the rest of the input is
+		// left exactly as it was written.
+		j, err = json.Marshal(&v1beta1.Resources{PatchSets: r.PatchSets, Resources: r.Resources})
+		if err != nil {
+			return nil, errors.Wrap(err, errInvalidInput)
+		}
+		out := map[string]any{}
+		if err := json.Unmarshal(j, &out); err != nil {
+			return nil, errors.Wrap(err, errInvalidInput)
+		}
+		in["patchSets"] = out["patchSets"]
+		in["resources"] = out["resources"]
+		s["input"] = in
+	}
+	if !found {
+		return nil, errors.Errorf(errFmtNoFunctionStep, fn)
+	}
+
+	return extracted, errors.Wrapf(unstructured.SetNestedSlice(comp.Object, steps, "spec", "pipeline"), errFmtPipelineField, "spec.pipeline")
+}
+
+// ExtractPatchSets factors sequences of at least the supplied number of
+// consecutive patches that are repeated across the supplied input's resource
+// templates into PatchSets. Each repeated sequence is replaced by a patch that
+// includes the new PatchSet, so the input behaves exactly as it did before.
+// A sequence is only extracted if doing so reduces the total number of
+// patches. The input is updated in place. It returns the extracted PatchSets.
+func ExtractPatchSets(r *v1beta1.Resources, minPatches int) []v1beta1.PatchSet {
+	minPatches = max(minPatches, 1)
+
+	names := map[string]bool{}
+	for _, ps := range r.PatchSets {
+		names[ps.Name] = true
+	}
+
+	extracted := make([]v1beta1.PatchSet, 0)
+	for {
+		seq := mostRepeatedPatches(r.Resources, minPatches)
+		if seq == nil {
+			return extracted
+		}
+
+		name := ""
+		for i := len(r.PatchSets); name == "" || names[name]; i++ {
+			name = "patchset-" + strconv.Itoa(i)
+		}
+		names[name] = true
+
+		for i := range r.Resources {
+			r.Resources[i].Patches = replacePatches(r.Resources[i].Patches, seq, name)
+		}
+		ps := v1beta1.PatchSet{Name: name, Patches: make([]v1beta1.PatchSetPatch, len(seq))}
+		for i, p := range seq {
+			ps.Patches[i], _ = patchSetPatch(p)
+		}
+		r.PatchSets = append(r.PatchSets, ps)
+		extracted = append(extracted, ps)
+	}
+}
+
+type patchSequence struct {
+	template, start, length int
+	uses                    int
+}
+
+// saved returns the number of patches that extracting the sequence saves.
+// Each use of the sequence becomes one patch, and the PatchSet adds back one
+// copy of the sequence.
+func (s patchSequence) saved() int {
+	return s.uses*s.length - s.uses - s.length
+}
+
+// better returns true if s should be extracted instead of o. Ties are broken
+// deterministically, preferring longer sequences that appear earlier.
+func (s patchSequence) better(o patchSequence) bool {
+	switch {
+	case s.saved() != o.saved():
+		return s.saved() > o.saved()
+	case s.length != o.length:
+		return s.length > o.length
+	case s.template != o.template:
+		return s.template < o.template
+	default:
+		return s.start < o.start
+	}
+}
+
+// mostRepeatedPatches returns the sequence of at least the supplied number of
+// consecutive patches that would save the most patches if it were extracted
+// into a PatchSet. It returns nil if no sequence would save any patches.
+// Patches that can't be part of a PatchSet, for example because they include
+// another PatchSet, are never part of a sequence.
+func mostRepeatedPatches(cts []v1beta1.ComposedTemplate, minPatches int) []v1beta1.ComposedPatch {
+	ids := patchIDs(cts)
+
+	seqs := map[string]*patchSequence{}
+	ends := map[string]int{}
+	for t := range cts {
+		for i := range ids[t] {
+			key := &strings.Builder{}
+			for j := i; j < len(ids[t]) && ids[t][j] >= 0; j++ {
+				key.WriteString(strconv.Itoa(ids[t][j]))
+				key.WriteByte(',')
+				if j+1-i < minPatches {
+					continue
+				}
+				// Key the end of the last use by template so overlapping
+				// uses within a template aren't counted twice.
+				k := key.String()
+				ek := strconv.Itoa(t) + "/" + k
+				s, ok := seqs[k]
+				if !ok {
+					s = &patchSequence{template: t, start: i, length: j + 1 - i}
+					seqs[k] = s
+				}
+				if end, ok := ends[ek]; ok && i < end {
+					continue
+				}
+				s.uses++
+				ends[ek] = j + 1
+			}
+		}
+	}
+
+	var best *patchSequence
+	for _, s := range seqs {
+		if s.saved() <= 0 {
+			continue
+		}
+		if best == nil || s.better(*best) {
+			best = s
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	seq := make([]v1beta1.ComposedPatch, best.length)
+	copy(seq, cts[best.template].Patches[best.start:best.start+best.length])
+	return seq
+}
+
+// patchIDs returns an ID for each patch of each supplied resource template.
+// Identical patches have the same ID. Patches that can't be part of a PatchSet
+// have ID -1.
+func patchIDs(cts []v1beta1.ComposedTemplate) [][]int {
+	known := map[string]int{}
+	ids := make([][]int, len(cts))
+	for t := range cts {
+		ids[t] = make([]int, len(cts[t].Patches))
+		for i, p := range cts[t].Patches {
+			ids[t][i] = patchID(known, p)
+		}
+	}
+	return ids
+}
+
+func patchID(known map[string]int, p v1beta1.ComposedPatch) int {
+	if _, ok := patchSetPatch(p); !ok {
+		return -1
+	}
+	j, err := json.Marshal(p)
+	if err != nil {
+		return -1
+	}
+	id, ok := known[string(j)]
+	if !ok {
+		id = len(known)
+		known[string(j)] = id
+	}
+	return id
+}
+
+// replacePatches replaces each non-overlapping use of the supplied sequence of
+// patches with a patch that includes the named PatchSet.
+func replacePatches(ps, seq []v1beta1.ComposedPatch, name string) []v1beta1.ComposedPatch {
+	known := map[string]int{}
+	want := make([]int, len(seq))
+	for i, p := range seq {
+		want[i] = patchID(known, p)
+	}
+
+	out := make([]v1beta1.ComposedPatch, 0, len(ps))
+	for i := 0; i < len(ps); {
+		if i+len(seq) <= len(ps) && matchPatches(known, ps[i:i+len(seq)], want) {
+			out = append(out, v1beta1.ComposedPatch{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To(name)})
+			i += len(seq)
+			continue
+		}
+		out = append(out, ps[i])
+		i++
+	}
+	return out
+}
+
+func matchPatches(known map[string]int, ps []v1beta1.ComposedPatch, want []int) bool {
+	for i, p := range ps {
+		// Look up existing IDs without adding new ones, so that patches
+		// that aren't part of the sequence never match.
+		j, err := json.Marshal(p)
+		if _, ok := patchSetPatch(p); err != nil || !ok {
+			return false
+		}
+		id, ok := known[string(j)]
+		if !ok || id != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// patchSetPatch returns the supplied patch as a patch in a PatchSet. It returns
+// false if the patch can't be part of a PatchSet, either because of its type or
+// because it sets fields a patch in a PatchSet doesn't have.
+func patchSetPatch(p v1beta1.ComposedPatch) (v1beta1.PatchSetPatch, bool) {
+	switch p.GetType() { //nolint:exhaustive // Only these patch types may be part of a PatchSet.
+	case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeToCompositeFieldPath,
+		v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCombineToComposite,
+		v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeToEnvironmentFieldPath,
+		v1beta1.PatchTypeCombineFromEnvironment, v1beta1.PatchTypeCombineToEnvironment,
+		v1beta1.PatchTypeConditionToComposite:
+	default:
+		return v1beta1.PatchSetPatch{}, false
+	}
+	if p.PatchSetName != nil || p.ConnectionDetailName != nil || p.Copy != nil || p.When != nil {
+		return v1beta1.PatchSetPatch{}, false
+	}
+	return v1beta1.PatchSetPatch{Type: p.Type, Condition: p.Condition, Patch: p.Patch}, true
+}
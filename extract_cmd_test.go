@@ -0,0 +1,168 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestExtractPatchSets(t *testing.T) {
+	patch := func(from string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{FromFieldPath: ptr.To(from), ToFieldPath: ptr.To(from)},
+		}
+	}
+	setPatch := func(from string) v1beta1.PatchSetPatch {
+		return v1beta1.PatchSetPatch{
+			Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{FromFieldPath: ptr.To(from), ToFieldPath: ptr.To(from)},
+		}
+	}
+	secret := func(name string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type:                 v1beta1.PatchTypeToConnectionDetail,
+			ConnectionDetailName: ptr.To(name),
+			Patch:                v1beta1.Patch{FromFieldPath: ptr.To("status.atProvider.url")},
+		}
+	}
+	include := func(name string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To(name)}
+	}
+
+	type args struct {
+		r          *v1beta1.Resources
+		minPatches int
+	}
+	type want struct {
+		r         *v1beta1.Resources
+		extracted []v1beta1.PatchSet
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"RepeatedSequence": {
+			reason: "We should factor a sequence of patches repeated across resource templates into a PatchSet.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{patch("spec.region"), patch("spec.size"), patch("spec.tier")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{patch("spec.name"), patch("spec.region"), patch("spec.size"), patch("spec.tier")}},
+						{Name: "c", Patches: []v1beta1.ComposedPatch{patch("spec.region"), patch("spec.size"), patch("spec.tier"), patch("spec.zone")}},
+					},
+				},
+				minPatches: 2,
+			},
+			want: want{
+				r: &v1beta1.Resources{
+					PatchSets: []v1beta1.PatchSet{
+						{Name: "patchset-0", Patches: []v1beta1.PatchSetPatch{setPatch("spec.region"), setPatch("spec.size"), setPatch("spec.tier")}},
+					},
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{include("patchset-0")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{patch("spec.name"), include("patchset-0")}},
+						{Name: "c", Patches: []v1beta1.ComposedPatch{include("patchset-0"), patch("spec.zone")}},
+					},
+				},
+				extracted: []v1beta1.PatchSet{
+					{Name: "patchset-0", Patches: []v1beta1.PatchSetPatch{setPatch("spec.region"), setPatch("spec.size"), setPatch("spec.tier")}},
+				},
+			},
+		},
+		"NoPatchesSaved": {
+			reason: "We shouldn't extract a PatchSet if doing so wouldn't reduce the number of patches.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{patch("spec.region"), patch("spec.size")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{patch("spec.region"), patch("spec.size")}},
+					},
+				},
+				minPatches: 2,
+			},
+			want: want{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{patch("spec.region"), patch("spec.size")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{patch("spec.region"), patch("spec.size")}},
+					},
+				},
+				extracted: []v1beta1.PatchSet{},
+			},
+		},
+		"ExistingPatchSet": {
+			reason: "We shouldn't include existing PatchSets in a sequence, or reuse an existing PatchSet's name.",
+			args: args{
+				r: &v1beta1.Resources{
+					PatchSets: []v1beta1.PatchSet{
+						{Name: "patchset-1", Patches: []v1beta1.PatchSetPatch{setPatch("spec.name")}},
+					},
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{include("patchset-1"), patch("spec.region"), patch("spec.size")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{include("patchset-1"), patch("spec.region"), patch("spec.size")}},
+						{Name: "c", Patches: []v1beta1.ComposedPatch{include("patchset-1"), patch("spec.region"), patch("spec.size")}},
+					},
+				},
+				minPatches: 2,
+			},
+			want: want{
+				r: &v1beta1.Resources{
+					PatchSets: []v1beta1.PatchSet{
+						{Name: "patchset-1", Patches: []v1beta1.PatchSetPatch{setPatch("spec.name")}},
+						{Name: "patchset-2", Patches: []v1beta1.PatchSetPatch{setPatch("spec.region"), setPatch("spec.size")}},
+					},
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{include("patchset-1"), include("patchset-2")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{include("patchset-1"), include("patchset-2")}},
+						{Name: "c", Patches: []v1beta1.ComposedPatch{include("patchset-1"), include("patchset-2")}},
+					},
+				},
+				extracted: []v1beta1.PatchSet{
+					{Name: "patchset-2", Patches: []v1beta1.PatchSetPatch{setPatch("spec.region"), setPatch("spec.size")}},
+				},
+			},
+		},
+		"UnsupportedPatches": {
+			reason: "We shouldn't include patches that can't be part of a PatchSet in a sequence.",
+			args: args{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{patch("spec.region"), secret("url")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{patch("spec.region"), secret("url")}},
+						{Name: "c", Patches: []v1beta1.ComposedPatch{patch("spec.region"), secret("url")}},
+					},
+				},
+				minPatches: 2,
+			},
+			want: want{
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{
+						{Name: "a", Patches: []v1beta1.ComposedPatch{patch("spec.region"), secret("url")}},
+						{Name: "b", Patches: []v1beta1.ComposedPatch{patch("spec.region"), secret("url")}},
+						{Name: "c", Patches: []v1beta1.ComposedPatch{patch("spec.region"), secret("url")}},
+					},
+				},
+				extracted: []v1beta1.PatchSet{},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			extracted := ExtractPatchSets(tc.args.r, tc.args.minPatches)
+			if diff := cmp.Diff(tc.want.extracted, extracted, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nExtractPatchSets(...): -want extracted, +got extracted:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.r, tc.args.r, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s\nExtractPatchSets(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// knownFeatureGates are the feature gates this release of the function
+// understands. Add a feature gate here when shipping an experimental behavior,
+// and check it using Resources.FeatureEnabled.
+var knownFeatureGates = map[v1beta1.FeatureGate]bool{
+	v1beta1.FeatureGateWebhookTransforms: true,
+}
+
+// UnknownFeatureGates returns the supplied feature gates that this release of
+// the function doesn't understand.
+func UnknownFeatureGates(gs []v1beta1.FeatureGate) []v1beta1.FeatureGate {
+	var unknown []v1beta1.FeatureGate
+	for _, g := range gs {
+		if !knownFeatureGates[g] {
+			unknown = append(unknown, g)
+		}
+	}
+	return unknown
+}
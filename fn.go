@@ -2,11 +2,21 @@ package main
 
 import (
 	"context"
-
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/structpb"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 
@@ -15,16 +25,43 @@ import (
 	"github.com/crossplane/function-sdk-go/request"
 	"github.com/crossplane/function-sdk-go/resource"
 	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
 	"github.com/crossplane/function-sdk-go/response"
 
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/connection"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/patch"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/readiness"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
 )
 
+// EnvironmentGroupVersionKind is the GroupVersionKind of the Composition
+// environment Crossplane passes to Functions.
+var EnvironmentGroupVersionKind = schema.GroupVersionKind{Group: "internal.crossplane.io", Version: "v1alpha1", Kind: "Environment"}
+
 // Function performs patch-and-transform style Composition.
 type Function struct {
 	fnv1beta1.UnimplementedFunctionRunnerServiceServer
 
 	log logging.Logger
+
+	// debugPatches enables logging of each patch that is applied.
+	debugPatches bool
+
+	// maxConcurrency is the maximum number of resource templates to process
+	// concurrently. Values less than 1 are treated as 1.
+	maxConcurrency int
+
+	// dedupe removes recently returned warning results, if it's not nil.
+	dedupe *WarningDeduplicator
+
+	// metrics counts the capabilities used by each input, if it's not nil.
+	metrics *Metrics
+
+	// defaults are applied to, and enforced on, every input if they're not
+	// nil.
+	defaults *Defaults
 }
 
 // RunFunction runs the Function.
@@ -37,36 +74,117 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 	// TODO(negz): We can probably use a longer TTL if all resources are ready.
 	rsp := response.To(req, response.DefaultTTL)
 
+	tracer := otel.Tracer(TracerName)
+	ctx, span := tracer.Start(ctx, "RunFunction", trace.WithAttributes(attribute.String("tag", req.GetMeta().GetTag())))
+
+	// Identifies the XR whose warnings we deduplicate, once we know it.
+	xrKey := ""
+
+	defer func() {
+		if f.dedupe != nil && xrKey != "" {
+			f.dedupe.Deduplicate(xrKey, rsp)
+		}
+		for _, r := range rsp.GetResults() {
+			if r.GetSeverity() == fnv1beta1.Severity_SEVERITY_FATAL {
+				span.SetStatus(codes.Error, r.GetMessage())
+			}
+		}
+		span.End()
+	}()
+
 	input := &v1beta1.Resources{}
 	if err := request.GetInput(req, input); err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot get Function input"))
+		fatal(rsp, ErrorCodeInvalidInput, errors.Wrap(err, "cannot get Function input"))
 		return rsp, nil
 	}
 
+	// Check the input against the operator's defaults before we apply them,
+	// so we can tell what the input itself defines.
+	if err := f.defaults.Check(input); err != nil {
+		fatal(rsp, ErrorCodeInvalidInput, errors.Wrap(err, "Function input violates the Function's defaults"))
+		return rsp, nil
+	}
+
+	// Merge the operator's defaults under the input before we validate it, so
+	// that e.g. patches may include a PatchSet only the defaults define.
+	f.defaults.Apply(input)
+
+	// Users may write field paths in several forms, e.g. spec.items.0 or
+	// spec.items[0]. Validate and apply their canonical form.
+	fieldpaths.NormalizeResources(input)
+
 	// Our input is an opaque object nested in a Composition, so unfortunately
 	// it won't handle validation for us.
 	if err := ValidateResources(input); err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "invalid Function input"))
+		fatal(rsp, ErrorCodeInvalidInput, errors.Wrap(err, "invalid Function input"))
 		return rsp, nil
 	}
 
+	if f.metrics != nil {
+		f.metrics.Observe(input)
+	}
+
+	for _, g := range UnknownFeatureGates(input.FeatureGates) {
+		warning(rsp, ErrorCodeUnknownFeatureGate, errors.Errorf("ignoring unknown feature gate %q", g))
+	}
+
 	// The composite resource that actually exists.
 	oxr, err := request.GetObservedCompositeResource(req)
 	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot get observed composite resource"))
+		fatal(rsp, ErrorCodeInvalidRequest, errors.Wrap(err, "cannot get observed composite resource"))
 		return rsp, nil
 	}
 
+	xrKey = string(oxr.Resource.GetUID())
+	if xrKey == "" {
+		xrKey = oxr.Resource.GetAPIVersion() + "/" + oxr.Resource.GetKind() + "/" + oxr.Resource.GetName()
+	}
+
+	// Identify the XR and the Composition that's composing it in every log
+	// line and span, so operators can tell which Composition is responsible
+	// for errors or latency. Crossplane doesn't tell us the name of the
+	// pipeline step we're running in, so we use the name of our input.
+	composition := ""
+	if ref := oxr.Resource.GetCompositionReference(); ref != nil {
+		composition = ref.Name
+	}
 	log = log.WithValues(
 		"xr-version", oxr.Resource.GetAPIVersion(),
 		"xr-kind", oxr.Resource.GetKind(),
 		"xr-name", oxr.Resource.GetName(),
+		"composition-name", composition,
+		"step-name", input.GetName(),
+	)
+	ctx = WithSpanAttributes(ctx,
+		attribute.String("xr-version", oxr.Resource.GetAPIVersion()),
+		attribute.String("xr-kind", oxr.Resource.GetKind()),
+		attribute.String("xr-name", oxr.Resource.GetName()),
+		attribute.String("composition-name", composition),
+		attribute.String("step-name", input.GetName()),
 	)
+	span.SetAttributes(SpanAttributes(ctx)...)
+
+	// Let patches read the XR's claim from a virtual field. We only ever read
+	// from the observed XR, so this field is never written back to the XR.
+	SetClaimField(oxr.Resource)
+
+	// Let patches read the current time from a virtual field. It's read once
+	// so that every patch, and every composite condition, sees the same time.
+	now := Now(ctx)
+	SetNowField(oxr.Resource, now)
+
+	// Let patches read the Input's data documents from a virtual field too.
+	data, err := DecodeData(input.Data)
+	if err != nil {
+		fatal(rsp, ErrorCodeInvalidInput, errors.Wrap(err, "cannot decode data documents"))
+		return rsp, nil
+	}
+	SetDataField(oxr.Resource, data)
 
 	// The composite resource desired by previous functions in the pipeline.
 	dxr, err := request.GetDesiredCompositeResource(req)
 	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot get desired composite resource"))
+		fatal(rsp, ErrorCodeInvalidRequest, errors.Wrap(err, "cannot get desired composite resource"))
 		return rsp, nil
 	}
 
@@ -85,40 +203,135 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 	// The composed resources that actually exist.
 	observed, err := request.GetObservedComposedResources(req)
 	if err != nil {
-		response.Fatal(rsp, errors.Wrapf(err, "cannot get observed composed resources from %T", req))
+		fatal(rsp, ErrorCodeInvalidRequest, errors.Wrapf(err, "cannot get observed composed resources from %T", req))
 		return rsp, nil
 	}
 
 	// The composed resources desired by any previous Functions in the pipeline.
 	desired, err := request.GetDesiredComposedResources(req)
 	if err != nil {
-		response.Fatal(rsp, errors.Wrapf(err, "cannot get desired composed resources from %T", req))
-		return rsp, nil
-	}
-
-	cts, err := ComposedTemplates(input.PatchSets, input.Resources)
-	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot resolve PatchSets"))
+		fatal(rsp, ErrorCodeInvalidRequest, errors.Wrapf(err, "cannot get desired composed resources from %T", req))
 		return rsp, nil
 	}
 
 	// The Composition environment. This could be set by Crossplane, and/or by a
 	// previous Function in the pipeline.
+	//
+	// TODO(negz): Let the input ask Crossplane for extra resources, like a
+	// ConfigMap or EnvironmentConfig, whose names, label values, or namespace
+	// are read from fields of the XR. That would let the environment depend on
+	// the claim, e.g. to load a per-team config object. Like the other TODOs
+	// about extra resources, it requires requirements, which the version of
+	// the Function SDK (and RunFunctionRequest) we use doesn't support yet. The
+	// selectors could be resolved using the same field path and transform
+	// logic as FromCompositeFieldPath patches.
 	env := &unstructured.Unstructured{}
-	if v, ok := request.GetContextKey(req, fncontext.KeyEnvironment); ok {
-		if err := resource.AsObject(v.GetStructValue(), env); err != nil {
-			response.Fatal(rsp, errors.Wrapf(err, "cannot get Composition environment from %T context key %q", req, fncontext.KeyEnvironment))
+	ctxenv, ok := request.GetContextKey(req, fncontext.KeyEnvironment)
+	switch {
+	case ok:
+		if err := resource.AsObject(ctxenv.GetStructValue(), env); err != nil {
+			fatal(rsp, ErrorCodeInvalidEnvironment, errors.Wrapf(err, "cannot get Composition environment from %T context key %q", req, fncontext.KeyEnvironment))
 			return rsp, nil
 		}
 		log.Debug("Loaded Composition environment from Function context", "context-key", fncontext.KeyEnvironment)
+	case input.Environment != nil && input.Environment.Defaults != nil:
+		if err := json.Unmarshal(input.Environment.Defaults.Raw, &env.Object); err != nil {
+			fatal(rsp, ErrorCodeInvalidEnvironment, errors.Wrap(err, "cannot unmarshal default Composition environment"))
+			return rsp, nil
+		}
+		if env.GetObjectKind().GroupVersionKind().Empty() {
+			env.SetGroupVersionKind(EnvironmentGroupVersionKind)
+		}
+		log.Debug("Loaded default Composition environment from Function input", "context-key", fncontext.KeyEnvironment)
 	}
 
+	// Many map transforms typically read their pairs from the same field of
+	// the environment or a data document. Read each field's pairs only once.
+	maps := transforms.NewMapLookups()
+
 	if input.Environment != nil {
+		for i := range input.Environment.Patches {
+			if err := maps.ResolveDataMaps(data, input.Environment.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidInput, errors.Wrapf(err, "cannot resolve map transforms of environment patch %d", i))
+				return rsp, nil
+			}
+			if err := maps.ResolveEnvironmentMaps(env, input.Environment.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidEnvironment, errors.Wrapf(err, "cannot resolve map transforms of environment patch %d", i))
+				return rsp, nil
+			}
+		}
+
 		// Run all patches that are from the (observed) XR to the environment or from the environment to the (desired) XR.
-		if err := RenderEnvironmentPatches(env, oxr.Resource, dxr.Resource, input.Environment.Patches); err != nil {
-			response.Fatal(rsp, errors.Wrapf(err, "cannot render ToEnvironment patches from the composite resource"))
+		_, espan := tracer.Start(ctx, "RenderEnvironmentPatches", trace.WithAttributes(SpanAttributes(ctx)...))
+		err := RenderEnvironmentPatches(ctx, env, oxr.Resource, dxr.Resource, input.Environment.Patches, f.patchLogger(log))
+		espan.End()
+		if err != nil {
+			fatal(rsp, ErrorCodePatchFailed, errors.Wrapf(err, "cannot render ToEnvironment patches from the composite resource"))
 			return rsp, nil
 		}
+
+		// Patches to the environment may have changed the fields we read.
+		maps.ForgetEnvironment()
+	}
+
+	// PatchSets may be included only when the XR or the environment has a
+	// particular value, so we resolve them once the environment is rendered.
+	//
+	// TODO(negz): Support PatchSets from a shared library stored in a separate
+	// object, e.g. a ConfigMap, so many Compositions can use the same standard
+	// patches. This requires the Function to ask Crossplane for extra
+	// resources using requirements, which the version of the Function SDK (and
+	// RunFunctionRequest) we use doesn't support yet.
+	_, pspan := tracer.Start(ctx, "ResolvePatchSets", trace.WithAttributes(SpanAttributes(ctx)...), trace.WithAttributes(attribute.Int("patch-sets", len(input.PatchSets))))
+	selected, err := patch.SelectPatchSets(input.Resources, oxr.Resource, env)
+	if err != nil {
+		pspan.End()
+		fatal(rsp, ErrorCodeInvalidInput, errors.Wrap(err, "cannot select PatchSets"))
+		return rsp, nil
+	}
+	cts, err := patch.ComposedTemplates(input.PatchSets, selected)
+	pspan.End()
+	if err != nil {
+		fatal(rsp, ErrorCodeInvalidInput, errors.Wrap(err, "cannot resolve PatchSets"))
+		return rsp, nil
+	}
+
+	// Map transforms may read their pairs from the environment or the Input's
+	// data documents, and match transforms may read their fallback value from
+	// the XR or the environment. We read them after rendering environment
+	// patches, and before processing templates concurrently.
+	for _, t := range cts {
+		for i := range t.Patches {
+			if err := maps.ResolveDataMaps(data, t.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidInput, errors.Wrapf(err, "cannot resolve map transforms of resource template %q patch %d", t.Name, i))
+				return rsp, nil
+			}
+			if err := maps.ResolveEnvironmentMaps(env, t.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidEnvironment, errors.Wrapf(err, "cannot resolve map transforms of resource template %q patch %d", t.Name, i))
+				return rsp, nil
+			}
+			if err := transforms.ResolveMatchFallbacks(&oxr.Resource.Unstructured, env, t.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidInput, errors.Wrapf(err, "cannot resolve match transforms of resource template %q patch %d", t.Name, i))
+				return rsp, nil
+			}
+		}
+	}
+
+	if input.Composite != nil {
+		for i := range input.Composite.Patches {
+			if err := maps.ResolveDataMaps(data, input.Composite.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidInput, errors.Wrapf(err, "cannot resolve map transforms of composite patch %d", i))
+				return rsp, nil
+			}
+			if err := maps.ResolveEnvironmentMaps(env, input.Composite.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidEnvironment, errors.Wrapf(err, "cannot resolve map transforms of composite patch %d", i))
+				return rsp, nil
+			}
+			if err := transforms.ResolveMatchFallbacks(&oxr.Resource.Unstructured, env, input.Composite.Patches[i].Transforms); err != nil {
+				fatal(rsp, ErrorCodeInvalidInput, errors.Wrapf(err, "cannot resolve match transforms of composite patch %d", i))
+				return rsp, nil
+			}
+		}
 	}
 
 	// Increment this if you emit a warning result.
@@ -128,96 +341,149 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 	// composed resource.
 	existing := 0
 
-	for _, t := range cts {
-		log := log.WithValues("resource-template-name", t.Name)
-		log.Debug("Processing resource template")
-
-		dcd := &resource.DesiredComposed{Resource: composed.New()}
-
-		// If we have a base template, render it into our desired resource. If a
-		// previous Function produced a desired resource with this name we'll
-		// overwrite it. If we don't have a base template we'll try to patch to
-		// and from a desired resource produced by a previous Function in the
-		// pipeline.
-		switch t.Base {
-		case nil:
-			cd, ok := desired[resource.Name(t.Name)]
-			if !ok {
-				response.Fatal(rsp, errors.Errorf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", t.Name))
-				return rsp, nil
-			}
-			// We want to return this resource unmutated if rendering fails.
-			dcd.Resource = cd.Resource.DeepCopy()
-		default:
-			if err := RenderFromJSON(dcd.Resource, t.Base.Raw); err != nil {
-				response.Fatal(rsp, errors.Wrapf(err, "cannot parse base template of composed resource %q", t.Name))
-				return rsp, nil
+	// Resource templates may be processed concurrently, so each template
+	// patches its own copy of the desired XR. We merge the copies back into the
+	// desired XR in template order, as if they'd been processed sequentially.
+	base := dxr.Resource.DeepCopy()
+	results := f.processTemplates(ctx, log, cts, input, oxr, base, env, observed, desired)
+
+	// Increment this for each resource template that was skipped because we
+	// ran out of time.
+	skipped := 0
+
+	for i, t := range cts {
+		r := results[i]
+		if r.skipped {
+			// Crossplane would delete an existing composed resource if we
+			// omitted it from our desired state, so we carry it forward.
+			if ocd, ok := observed[resource.Name(t.Name)]; ok {
+				dcd := ObservedAsDesired(ocd)
+
+				// The resource is unchanged, so it's as ready as it was.
+				// Keep the readiness a previous Function determined, and
+				// mark it ready if it passes its readiness checks.
+				if prev, ok := desired[resource.Name(t.Name)]; ok {
+					dcd.Ready = prev.Ready
+				}
+				if ready, err := readiness.IsReady(ctx, ocd.Resource, t.ReadinessChecks...); err == nil && ready {
+					dcd.Ready = resource.ReadyTrue
+				}
+				desired[resource.Name(t.Name)] = dcd
 			}
+			skipped++
+			continue
 		}
-
-		ocd, ok := observed[resource.Name(t.Name)]
-		if ok {
+		for _, err := range r.warnings {
+			warning(rsp, ErrorCodePatchFailed, err)
+			warnings++
+		}
+		if r.fatal != nil {
+			fatal(rsp, ErrorCodeInvalidTemplate, r.fatal)
+			return rsp, nil
+		}
+		if r.remove {
+			// Remove any desired resource produced by a previous Function.
+			// The response starts as a copy of the request's desired state,
+			// and setting desired resources only adds to it, so we must
+			// remove the resource from the response too.
+			delete(desired, resource.Name(t.Name))
+			delete(rsp.GetDesired().GetResources(), t.Name)
+			continue
+		}
+		if r.existing {
 			existing++
-			log.Debug("Resource template corresponds to existing composed resource", "metadata-name", ocd.Resource.GetName())
-
-			// If this template corresponds to an existing observed resource we
-			// want to keep them associated. We copy only the namespace and
-			// name, not the entire observed state, because we're trying to
-			// produce only a partial 'overlay' of desired state.
-			dcd.Resource.SetNamespace(ocd.Resource.GetNamespace())
-			dcd.Resource.SetName(ocd.Resource.GetName())
-
-			conn, err := ExtractConnectionDetails(ocd.Resource, managed.ConnectionDetails(ocd.ConnectionDetails), t.ConnectionDetails...)
-			if err != nil {
-				response.Warning(rsp, errors.Wrapf(err, "cannot extract composite resource connection details from composed resource %q", t.Name))
-				log.Info("Cannot extract composite resource connection details from composed resource", "warning", err)
-				warnings++
+		}
+		for k, v := range r.conn {
+			dxr.ConnectionDetails[k] = v
+		}
+		if r.dxr != nil {
+			for _, d := range DiffComposed(base.Object, r.dxr.Object) {
+				if err := dxr.Resource.SetValue(d.Path, d.Desired); err != nil {
+					fatal(rsp, ErrorCodePatchFailed, errors.Wrapf(err, "cannot merge patches from composed resource %q into desired composite resource", t.Name))
+					return rsp, nil
+				}
 			}
-			for k, v := range conn {
-				dxr.ConnectionDetails[k] = v
+		}
+		if r.store {
+			// Add or replace our desired resource.
+			desired[resource.Name(t.Name)] = r.dcd
+		}
+		if ocd, ok := observed[resource.Name(t.Name)]; ok && r.store && input.ReportChangedFields {
+			if paths := ChangedFieldPaths(ocd.Resource.Object, r.dcd.Resource.Object); len(paths) > 0 {
+				response.Normal(rsp, fmt.Sprintf("composed resource %q differs from its observed state at %s", t.Name, strings.Join(paths, ", ")))
 			}
+		}
+	}
+
+	// Non-critical resources don't count toward the readiness policy unless
+	// they're actually ready, so we mark them ready after applying it.
+	nr := NonCriticalNotReady(cts, desired)
+	if ApplyReadinessPolicy(input.Readiness, cts, desired) {
+		log.Debug("Readiness policy is satisfied; marked all composed resources ready", "readiness-policy", input.Readiness.GetType())
+	}
+	for _, name := range nr {
+		desired[resource.Name(name)].Ready = resource.ReadyTrue
+	}
+	if len(nr) > 0 {
+		warning(rsp, ErrorCodeResourceNotReady, errors.Errorf("non-critical composed resources are not ready: %s", strings.Join(nr, ", ")))
+		warnings++
+	}
 
-			ready, err := IsReady(ctx, ocd.Resource, t.ReadinessChecks...)
-			if err != nil {
-				response.Warning(rsp, errors.Wrapf(err, "cannot check readiness of composed resource %q", t.Name))
-				log.Info("Cannot check readiness of composed resource", "warning", err)
+	// Patches with a missing optional source field are skipped silently. Tell
+	// the user about them if they asked, once the XR has existed long enough
+	// that its fields should have been populated.
+	if d := input.WarnSkippedPatchesAfter; d != nil {
+		created := oxr.Resource.GetCreationTimestamp()
+		if !created.IsZero() && now.Sub(created.Time) >= d.Duration {
+			if s := SkippedPatches(cts, input.Composite, oxr.Resource, env, observed); len(s) > 0 {
+				warning(rsp, ErrorCodePatchSkipped, errors.Errorf("patches were skipped because an optional source field doesn't exist: %s", strings.Join(s, ", ")))
 				warnings++
 			}
-			if ready {
-				dcd.Ready = resource.ReadyTrue
-			}
-
-			log.Debug("Found corresponding observed resource",
-				"ready", ready,
-				"name", ocd.Resource.GetName())
 		}
+	}
 
-		errs, store := RenderComposedPatches(ocd.Resource, dcd.Resource, oxr.Resource, dxr.Resource, env, t.Patches)
-		for _, err := range errs {
-			response.Warning(rsp, errors.Wrapf(err, "cannot render patches for composed resource %q", t.Name))
-			log.Info("Cannot render patches for composed resource", "warning", err)
-			warnings++
-		}
+	if skipped > 0 {
+		warning(rsp, ErrorCodeDeadlineExceeded, errors.Errorf("ran out of time after processing %d of %d resource templates; skipped templates' existing composed resources were left unchanged", len(cts)-skipped, len(cts)))
+		warnings++
+	}
 
-		if store {
-			// Add or replace our desired resource.
-			desired[resource.Name(t.Name)] = dcd
+	// Patches and conditions applied directly to the XR run last, so they can
+	// shape the XR after all resource templates have been processed.
+	if input.Composite != nil {
+		_, cspan := tracer.Start(ctx, "RenderCompositePatches", trace.WithAttributes(SpanAttributes(ctx)...))
+		err := RenderCompositePatches(ctx, env, oxr.Resource, dxr.Resource, input.Composite.Patches, f.patchLogger(log))
+		cspan.End()
+		if err != nil {
+			fatal(rsp, ErrorCodePatchFailed, errors.Wrap(err, "cannot render patches to the composite resource"))
+			return rsp, nil
+		}
+		if err := RenderCompositeConditions(env, oxr.Resource, dxr.Resource, input.Composite.Conditions, now); err != nil {
+			fatal(rsp, ErrorCodePatchFailed, errors.Wrap(err, "cannot render conditions of the composite resource"))
+			return rsp, nil
 		}
 	}
 
+	// Only publish the connection details the Input allows.
+	dxr.ConnectionDetails = resource.ConnectionDetails(connection.FilterDetails(managed.ConnectionDetails(dxr.ConnectionDetails), input.ConnectionDetails))
+
 	if err := response.SetDesiredCompositeResource(rsp, dxr); err != nil {
-		response.Fatal(rsp, errors.Wrapf(err, "cannot set desired composite resource in %T", rsp))
+		fatal(rsp, ErrorCodeInternal, errors.Wrapf(err, "cannot set desired composite resource in %T", rsp))
 		return rsp, nil
 	}
 
+	// Note that we must return every desired composed resource, even those
+	// that are identical to their observed state. Crossplane garbage collects
+	// any composed resource that's missing from the desired state returned by
+	// the final Function in the pipeline, so omitting a resource would delete
+	// it rather than skip a no-op apply.
 	if err := response.SetDesiredComposedResources(rsp, desired); err != nil {
-		response.Fatal(rsp, errors.Wrapf(err, "cannot set desired composed resources in %T", rsp))
+		fatal(rsp, ErrorCodeInternal, errors.Wrapf(err, "cannot set desired composed resources in %T", rsp))
 		return rsp, nil
 	}
 
 	v, err := resource.AsStruct(env)
 	if err != nil {
-		response.Fatal(rsp, errors.Wrap(err, "cannot convert Composition environment to protobuf Struct well-known type"))
+		fatal(rsp, ErrorCodeInternal, errors.Wrap(err, "cannot convert Composition environment to protobuf Struct well-known type"))
 		return rsp, nil
 	}
 	response.SetContextKey(rsp, fncontext.KeyEnvironment, structpb.NewStructValue(v))
@@ -229,3 +495,398 @@ func (f *Function) RunFunction(ctx context.Context, req *fnv1beta1.RunFunctionRe
 
 	return rsp, nil
 }
+
+// patchLogger returns the supplied logger if patch debugging is enabled, and
+// nil otherwise.
+func (f *Function) patchLogger(log logging.Logger) logging.Logger {
+	if !f.debugPatches {
+		return nil
+	}
+	return log
+}
+
+// A templateResult is the result of processing a resource template.
+type templateResult struct {
+	// The desired composed resource, and whether it should be stored.
+	dcd   *resource.DesiredComposed
+	store bool
+
+	// Whether the desired composed resource should be removed.
+	remove bool
+
+	// Whether the template corresponds to an observed composed resource.
+	existing bool
+
+	// Connection details extracted from the observed composed resource.
+	conn managed.ConnectionDetails
+
+	// A copy of the desired XR with any of the template's patches applied. Nil
+	// if the template has no patches to the XR.
+	dxr *composite.Unstructured
+
+	// Warnings encountered processing the template, and a fatal error that
+	// prevented it from being processed.
+	warnings []error
+	fatal    error
+
+	// Whether the template was skipped because the Function ran out of time.
+	skipped bool
+}
+
+// processTemplates processes the supplied resource templates, returning a
+// result for each. Templates are processed concurrently, up to the Function's
+// maximum concurrency, unless any template patches the environment. Patches
+// from one template to the environment may be read by another template's
+// patches, so these templates must be processed in order.
+func (f *Function) processTemplates(ctx context.Context, log logging.Logger, cts []v1beta1.ComposedTemplate, in *v1beta1.Resources, oxr *resource.Composite, dxr *composite.Unstructured, env *unstructured.Unstructured, observed map[resource.Name]resource.ObservedComposed, desired map[resource.Name]*resource.DesiredComposed) []templateResult {
+	limit := f.maxConcurrency
+	if limit < 1 || patchesEnvironment(cts) {
+		limit = 1
+	}
+
+	results := make([]templateResult, len(cts))
+	sem := make(chan struct{}, limit)
+	wg := &sync.WaitGroup{}
+	for i := range cts {
+		sem <- struct{}{}
+
+		// Stop starting templates once the request's deadline has passed.
+		// Crossplane will kill us soon, and it's better to return the
+		// templates we've processed than nothing at all.
+		if ctx.Err() != nil {
+			<-sem
+			results[i] = templateResult{skipped: true}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = f.processTemplate(ctx, log, cts[i], in, oxr, dxr, env, observed, desired)
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// processTemplate processes the supplied resource template. It doesn't mutate
+// the supplied desired XR or desired composed resources. It mutates the
+// supplied environment only if the template has patches to the environment.
+func (f *Function) processTemplate(ctx context.Context, log logging.Logger, t v1beta1.ComposedTemplate, in *v1beta1.Resources, oxr *resource.Composite, dxr *composite.Unstructured, env *unstructured.Unstructured, observed map[resource.Name]resource.ObservedComposed, desired map[resource.Name]*resource.DesiredComposed) templateResult {
+	log = log.WithValues("resource-template-name", t.Name)
+	log.Debug("Processing resource template")
+
+	ctx, span := otel.Tracer(TracerName).Start(ctx, "ComposedTemplate", trace.WithAttributes(SpanAttributes(ctx)...), trace.WithAttributes(attribute.String("resource-template-name", t.Name)))
+	defer span.End()
+
+	r := templateResult{dcd: &resource.DesiredComposed{Resource: composed.New()}}
+
+	remove, err := ShouldDelete(oxr.Resource, t.Delete)
+	if err != nil {
+		r.fatal = errors.Wrapf(err, "cannot determine whether to delete composed resource %q", t.Name)
+		return r
+	}
+	if remove {
+		log.Debug("Removing composed resource from desired state", "from-field-path", t.Delete.FromFieldPath)
+		r.remove = true
+		return r
+	}
+
+	ocd, ok := observed[resource.Name(t.Name)]
+
+	// Only templates that patch the XR need their own copy of it.
+	xr := dxr
+	if patchesComposite(t) {
+		r.dxr = dxr.DeepCopy()
+		xr = r.dxr
+	}
+
+	// Provenance is only recorded if the input asks for it. Later patches to
+	// a field replace the provenance recorded by earlier ones.
+	var prov FieldProvenance
+	if in.RecordFieldProvenance {
+		prov = FieldProvenance{}
+	}
+
+	// PreBase patches are applied to an empty resource. The base template is
+	// then rendered over it, so fields set by the base template take
+	// precedence over fields set by PreBase patches.
+	pre := composed.New()
+	errs, store := RenderComposedPatches(ctx, ocd.Resource, pre, oxr.Resource, xr, env, r.conn, t.Patches, v1beta1.PatchStagePreBase, f.patchLogger(log), prov)
+
+	// If we have a base template, render it into our desired resource. If a
+	// previous Function produced a desired resource with this name we'll
+	// overwrite it. If we don't have a base template we'll try to patch to
+	// and from a desired resource produced by a previous Function in the
+	// pipeline.
+	//
+	// TODO(negz): Support templates without a base that only observe an
+	// existing resource, and patch from it to the XR. This requires the
+	// Function to ask Crossplane for extra resources using requirements, which
+	// the version of the Function SDK (and RunFunctionRequest) we use doesn't
+	// support yet.
+	//
+	// TODO(negz): Support alternative API versions for a base template, using
+	// the first whose CRD exists in the cluster. This would smooth provider
+	// upgrades that move kinds between API groups or versions. It also
+	// requires extra resources, to discover CRDs.
+	switch t.Base {
+	case nil:
+		cd, ok := desired[resource.Name(t.Name)]
+		if !ok {
+			r.fatal = errors.Errorf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", t.Name)
+			return r
+		}
+		// We want to return this resource unmutated if rendering fails.
+		r.dcd.Resource = cd.Resource.DeepCopy()
+
+		// Preserve the readiness the previous Function determined. Our own
+		// readiness checks may still mark the resource ready below.
+		r.dcd.Ready = cd.Ready
+	default:
+		if err := RenderFromJSON(r.dcd.Resource, t.Base.Raw); err != nil {
+			r.fatal = errors.Wrapf(err, "cannot parse base template of composed resource %q", t.Name)
+			return r
+		}
+	}
+	r.dcd.Resource.Object = overlay(pre.Object, r.dcd.Resource.Object)
+
+	// Set the namespace before we apply any patches, so that patches can
+	// override it. An existing resource keeps its namespace; see below.
+	if err := RenderNamespace(oxr.Resource, r.dcd.Resource, t.Namespace); err != nil {
+		r.warnings = append(r.warnings, errors.Wrapf(err, "cannot determine namespace of composed resource %q", t.Name))
+		log.Info("Cannot determine namespace of composed resource", "warning", err)
+	}
+
+	// Copy labels and annotations from the XR before we apply any patches, so
+	// that patches can override them.
+	RenderPropagatedMetadata(oxr.Resource, r.dcd.Resource, in.Propagate)
+
+	// Likewise, patches can override the injected ProviderConfig.
+	if pc := in.ProviderConfigRef; pc != nil && pc.AppliesTo(t.Name) {
+		if err := RenderProviderConfigRef(oxr.Resource, env, r.dcd.Resource, pc); err != nil {
+			r.warnings = append(r.warnings, errors.Wrapf(err, "cannot inject ProviderConfig into composed resource %q", t.Name))
+			log.Info("Cannot inject ProviderConfig into composed resource", "warning", err)
+		}
+	}
+
+	if ok {
+		r.existing = true
+		log.Debug("Resource template corresponds to existing composed resource", "metadata-name", ocd.Resource.GetName())
+
+		// If this template corresponds to an existing observed resource we
+		// want to keep them associated. We copy only the namespace and
+		// name, not the entire observed state, because we're trying to
+		// produce only a partial 'overlay' of desired state.
+		r.dcd.Resource.SetNamespace(ocd.Resource.GetNamespace())
+		r.dcd.Resource.SetName(ocd.Resource.GetName())
+
+		conn, err := connection.ExtractDetails(ocd.Resource, managed.ConnectionDetails(ocd.ConnectionDetails), t.ConnectionDetails...)
+		if err != nil {
+			r.warnings = append(r.warnings, WithCode(ErrorCodeConnectionDetailsFailed, errors.Wrapf(err, "cannot extract composite resource connection details from composed resource %q", t.Name)))
+			log.Info("Cannot extract composite resource connection details from composed resource", "warning", err)
+			conn = managed.ConnectionDetails{}
+		}
+		r.conn = conn
+
+		ready, err := readiness.IsReady(ctx, ocd.Resource, t.ReadinessChecks...)
+		if err != nil {
+			r.warnings = append(r.warnings, WithCode(ErrorCodeReadinessCheckFailed, errors.Wrapf(err, "cannot check readiness of composed resource %q", t.Name)))
+			log.Info("Cannot check readiness of composed resource", "warning", err)
+		}
+		if ready {
+			r.dcd.Ready = resource.ReadyTrue
+		}
+
+		log.Debug("Found corresponding observed resource",
+			"ready", ready,
+			"name", ocd.Resource.GetName())
+	}
+
+	force, err := ShouldForceReady(oxr.Resource, t.ForceReady)
+	if err != nil {
+		r.warnings = append(r.warnings, WithCode(ErrorCodeReadinessCheckFailed, errors.Wrapf(err, "cannot determine whether to force composed resource %q ready", t.Name)))
+		log.Info("Cannot determine whether to force composed resource ready", "warning", err)
+	}
+	if force {
+		log.Debug("Forcing composed resource ready, regardless of its readiness checks")
+		r.dcd.Ready = resource.ReadyTrue
+	}
+
+	derrs, dstore := RenderComposedPatches(ctx, ocd.Resource, r.dcd.Resource, oxr.Resource, xr, env, r.conn, t.Patches, v1beta1.PatchStageDefault, f.patchLogger(log), prov)
+	errs = append(errs, derrs...)
+	store = store && dstore
+
+	// PostReadiness patches are applied only once the composed resource
+	// exists and is ready.
+	if ok && r.dcd.Ready == resource.ReadyTrue {
+		perrs, pstore := RenderComposedPatches(ctx, ocd.Resource, r.dcd.Resource, oxr.Resource, xr, env, r.conn, t.Patches, v1beta1.PatchStagePostReadiness, f.patchLogger(log), prov)
+		errs = append(errs, perrs...)
+		store = store && pstore
+	}
+
+	for _, err := range errs {
+		r.warnings = append(r.warnings, errors.Wrapf(err, "cannot render patches for composed resource %q", t.Name))
+		log.Info("Cannot render patches for composed resource", "warning", err)
+		span.RecordError(err)
+	}
+	r.store = store
+
+	if err := prov.Annotate(r.dcd.Resource); err != nil {
+		r.warnings = append(r.warnings, errors.Wrapf(err, "cannot record field provenance for composed resource %q", t.Name))
+		log.Info("Cannot record field provenance for composed resource", "warning", err)
+	}
+
+	return r
+}
+
+// ObservedAsDesired returns a desired composed resource that leaves the
+// supplied observed composed resource unchanged. It omits the observed
+// resource's status, and metadata set by the API server. It doesn't determine
+// whether the resource is ready.
+func ObservedAsDesired(ocd resource.ObservedComposed) *resource.DesiredComposed {
+	o := ocd.Resource.DeepCopy()
+	dcd := &resource.DesiredComposed{Resource: composed.New()}
+	for _, k := range []string{"apiVersion", "kind", "spec"} {
+		if v, ok := o.Object[k]; ok {
+			dcd.Resource.Object[k] = v
+		}
+	}
+	dcd.Resource.SetNamespace(o.GetNamespace())
+	dcd.Resource.SetName(o.GetName())
+	dcd.Resource.SetGenerateName(o.GetGenerateName())
+	dcd.Resource.SetLabels(o.GetLabels())
+	dcd.Resource.SetAnnotations(o.GetAnnotations())
+	return dcd
+}
+
+// ShouldDelete returns true if the supplied delete condition's field of the
+// supplied composite resource is true. It returns false if the condition is
+// nil or the field doesn't exist.
+func ShouldDelete(xr *composite.Unstructured, d *v1beta1.DeleteCondition) (bool, error) {
+	if d == nil {
+		return false, nil
+	}
+	return compositeBool(xr, d.FromFieldPath)
+}
+
+// ApplyReadinessPolicy marks ready the desired composed resources produced by
+// the supplied resource templates if the supplied readiness policy is
+// satisfied. Crossplane considers the composite resource ready when all of its
+// composed resources are ready, so this stops resources the policy doesn't
+// require from blocking the composite resource's readiness. It returns true if
+// it marked resources ready.
+func ApplyReadinessPolicy(p *v1beta1.ReadinessPolicy, cts []v1beta1.ComposedTemplate, desired map[resource.Name]*resource.DesiredComposed) bool {
+	isReady := func(name string) bool {
+		dcd, ok := desired[resource.Name(name)]
+		return ok && dcd.Ready == resource.ReadyTrue
+	}
+
+	switch p.GetType() {
+	case v1beta1.ReadinessPolicyTypeAll:
+		return false
+	case v1beta1.ReadinessPolicyTypeResources:
+		for _, name := range p.Resources {
+			if !isReady(name) {
+				return false
+			}
+		}
+	case v1beta1.ReadinessPolicyTypeQuorum:
+		ready := 0
+		for _, t := range cts {
+			if isReady(t.Name) {
+				ready += t.GetReadinessWeight()
+			}
+		}
+		if p.Quorum == nil || ready < *p.Quorum {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for _, t := range cts {
+		if dcd, ok := desired[resource.Name(t.Name)]; ok {
+			dcd.Ready = resource.ReadyTrue
+		}
+	}
+	return true
+}
+
+// NonCriticalNotReady returns the names of the desired composed resources
+// produced by the supplied resource templates that aren't critical, and aren't
+// ready.
+func NonCriticalNotReady(cts []v1beta1.ComposedTemplate, desired map[resource.Name]*resource.DesiredComposed) []string {
+	var names []string
+	for _, t := range cts {
+		if t.IsCritical() {
+			continue
+		}
+		if dcd, ok := desired[resource.Name(t.Name)]; ok && dcd.Ready != resource.ReadyTrue {
+			names = append(names, t.Name)
+		}
+	}
+	return names
+}
+
+// ShouldForceReady returns true if the supplied composed resource should be
+// marked ready regardless of its readiness checks. It returns false if the
+// supplied override is nil or its field doesn't exist.
+func ShouldForceReady(xr *composite.Unstructured, f *v1beta1.ForceReady) (bool, error) {
+	switch {
+	case f == nil:
+		return false, nil
+	case f.FromFieldPath != nil:
+		return compositeBool(xr, *f.FromFieldPath)
+	case f.Value != nil:
+		return *f.Value, nil
+	}
+	return false, nil
+}
+
+// compositeBool returns the boolean field of the supplied composite resource
+// at the supplied path. It returns false if the field doesn't exist.
+func compositeBool(xr *composite.Unstructured, path string) (bool, error) {
+	v, err := xr.GetValue(path)
+	if fieldpath.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.Errorf("field %q of the composite resource must be a boolean, not %T", path, v)
+	}
+	return b, nil
+}
+
+// patchesComposite returns true if the supplied template has patches to the
+// composite resource.
+func patchesComposite(t v1beta1.ComposedTemplate) bool {
+	for _, p := range t.Patches {
+		switch p.Type { //nolint:exhaustive // We only care about patches to the XR.
+		case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeConditionToComposite:
+			return true
+		}
+	}
+	return false
+}
+
+// patchesEnvironment returns true if any of the supplied templates have
+// patches to the environment.
+func patchesEnvironment(cts []v1beta1.ComposedTemplate) bool {
+	for _, t := range cts {
+		for _, p := range t.Patches {
+			switch p.Type { //nolint:exhaustive // We only care about patches to the environment.
+			case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment:
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -4,15 +4,16 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/structpb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/ptr"
 
 	"github.com/crossplane/crossplane-runtime/pkg/logging"
@@ -28,7 +29,6 @@ import (
 func TestRunFunction(t *testing.T) {
 
 	type args struct {
-		ctx context.Context
 		req *fnv1beta1.RunFunctionRequest
 	}
 	type want struct {
@@ -52,7 +52,42 @@ func TestRunFunction(t *testing.T) {
 					Results: []*fnv1beta1.Result{
 						{
 							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
-							Message:  "invalid Function input: resources: Required value: resources is required",
+							Message:  "[PT002] invalid Function input: resources: Required value: resources is required",
+						},
+					},
+				},
+			},
+		},
+		"WebhookTransformWithoutFeatureGate": {
+			reason: "The Function should return a fatal result if the input uses a webhook transform without enabling its feature gate",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Composite: &v1beta1.Composite{
+							Patches: []v1beta1.CompositePatch{
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.name"),
+										ToFieldPath:   ptr.To[string]("status.name"),
+										Transforms: []v1beta1.Transform{{
+											Type:    v1beta1.TransformTypeWebhook,
+											Webhook: &v1beta1.WebhookTransform{URL: "https://naming.example.org/resolve"},
+										}},
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
+							Message:  "[PT002] invalid Function input: composite.patches[0].transforms[0].type: Forbidden: webhook transforms require the WebhookTransforms feature gate",
 						},
 					},
 				},
@@ -332,7 +367,7 @@ func TestRunFunction(t *testing.T) {
 					Results: []*fnv1beta1.Result{
 						{
 							Severity: fnv1beta1.Severity_SEVERITY_FATAL,
-							Message:  fmt.Sprintf("composed resource %q has no base template, and was not produced by a previous Function in the pipeline", "cool-resource"),
+							Message:  fmt.Sprintf("[PT005] composed resource %q has no base template, and was not produced by a previous Function in the pipeline", "cool-resource"),
 						},
 					},
 				},
@@ -384,6 +419,58 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"DeleteDesiredResource": {
+			reason: "A resource template whose delete condition is true should remove its desired resource, including one produced by a previous Function.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:   "cool-resource",
+								Base:   &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Delete: &v1beta1.DeleteCondition{FromFieldPath: "spec.disableCoolResource"},
+							},
+							{
+								Name:   "other-resource",
+								Base:   &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Delete: &v1beta1.DeleteCondition{FromFieldPath: "spec.disableOtherResource"},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"disableCoolResource":true}}`),
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":42}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"other-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
 		"FailedPatchNotSaved": {
 			reason: "If we fail to patch a desired resource produced by a previous Function in the pipeline we should return a warning result, and leave the original desired resource untouched.",
 			args: args{
@@ -459,7 +546,7 @@ func TestRunFunction(t *testing.T) {
 					Results: []*fnv1beta1.Result{
 						{
 							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
-							Message:  fmt.Sprintf("cannot render patches for composed resource %q: cannot apply the %q patch at index 1: spec.doesNotExist: no such field", "cool-resource", "FromCompositeFieldPath"),
+							Message:  fmt.Sprintf("[PT001] cannot render patches for composed resource %q: cannot apply the %q patch at index 1: spec.doesNotExist: no such field", "cool-resource", "FromCompositeFieldPath"),
 						},
 					},
 					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
@@ -512,6 +599,59 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"ReportChangedFields": {
+			reason: "We should return a normal result listing the fields of an observed composed resource that we changed if asked.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						ReportChangedFields: true,
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"region":"us-east-2","size":"large"}}`)},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42"},"spec":{"region":"us-east-1","size":"large"},"status":{"id":"cool-id"}}`),
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42"},"spec":{"region":"us-east-2","size":"large"}}`),
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_NORMAL,
+							Message:  `composed resource "cool-resource" differs from its observed state at spec.region`,
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
 		"ExtractCompositeConnectionDetails": {
 			reason: "We should extract any XR connection details specified by a composed template.",
 			args: args{
@@ -575,6 +715,131 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
+		"PatchOnlyTemplate": {
+			reason: "A template without a base should extract connection details from a resource produced by a previous Function, and preserve its readiness.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								ConnectionDetails: []v1beta1.ConnectionDetail{
+									{
+										Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+										Name:                    "very",
+										FromConnectionSecretKey: ptr.To[string]("very"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+								ConnectionDetails: map[string][]byte{
+									"very": []byte("secret"),
+								},
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":42}}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							ConnectionDetails: map[string][]byte{
+								"very": []byte("secret"),
+							},
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"spec":{"watchers":42}}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"PatchToCompositeConnectionDetails": {
+			reason: "We should patch values from an observed composed resource to XR connection details.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type:                 v1beta1.PatchTypeCombineToConnectionDetail,
+										ConnectionDetailName: ptr.To[string]("url"),
+										Patch: v1beta1.Patch{
+											Combine: &v1beta1.Combine{
+												Variables: []v1beta1.CombineVariable{
+													{FromFieldPath: "status.endpoint"},
+													{FromFieldPath: "status.database"},
+												},
+												Strategy: v1beta1.CombineStrategyString,
+												String:   &v1beta1.StringCombine{Format: "postgres://%s/%s"},
+											},
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"status":{"endpoint":"db.example.org","database":"cool"}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+							ConnectionDetails: map[string][]byte{
+								"url": []byte("postgres://db.example.org/cool"),
+							},
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
 		"PatchToComposite": {
 			reason: "A basic ToCompositeFieldPath patch should work.",
 			args: args{
@@ -645,38 +910,601 @@ func TestRunFunction(t *testing.T) {
 				},
 			},
 		},
-		"PatchToCompositeWithEnvironmentPatches": {
-			reason: "A basic ToCompositeFieldPath patch should work with environment.patches.",
+		"PatchToCompositeFromPatchSet": {
+			reason: "Patches to the XR should work when they're included from a PatchSet.",
 			args: args{
 				req: &fnv1beta1.RunFunctionRequest{
 					Input: resource.MustStructObject(&v1beta1.Resources{
+						PatchSets: []v1beta1.PatchSet{
+							{
+								Name: "surface-status",
+								Patches: []v1beta1.PatchSetPatch{
+									{
+										Type: v1beta1.PatchTypeToCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("status.atProvider.id"),
+											ToFieldPath:   ptr.To[string]("status.id"),
+										},
+									},
+									{
+										Type:      v1beta1.PatchTypeConditionToComposite,
+										Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+										Patch: v1beta1.Patch{
+											ToFieldPath: ptr.To[string]("status.error"),
+										},
+									},
+								},
+							},
+						},
 						Resources: []v1beta1.ComposedTemplate{
 							{
 								Name: "cool-resource",
 								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
-							}},
-						Environment: &v1beta1.Environment{
-							Patches: []v1beta1.EnvironmentPatch{
-								{
-									Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
-									Patch: v1beta1.Patch{
-										FromFieldPath: ptr.To[string]("data.widgets"),
-										ToFieldPath:   ptr.To[string]("spec.watchers"),
-										Transforms: []v1beta1.Transform{
-											{
-												Type: v1beta1.TransformTypeConvert,
-												Convert: &v1beta1.ConvertTransform{
-													ToType: v1beta1.TransformIOTypeInt64,
-												},
-											},
-											{
-												Type: v1beta1.TransformTypeMath,
-												Math: &v1beta1.MathTransform{
-													Type:     v1beta1.MathTransformTypeMultiply,
-													Multiply: ptr.To[int64](3),
-												}}}}}}}}),
-					Observed: &fnv1beta1.State{
-						Composite: &fnv1beta1.Resource{
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type:         v1beta1.PatchTypePatchSet,
+										PatchSetName: ptr.To[string]("surface-status"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"},"status":{"atProvider":{"id":"cool-id"},"conditions":[{"type":"Synced","status":"False","reason":"ReconcileError","message":"access denied"}]}}`),
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"id":"cool-id","error":"access denied"}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"namespace":"default","name":"cool-42"}}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"PatchStages": {
+			reason: "PreBase patches should be overridden by the base template, and PostReadiness patches should apply only to ready resources.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"region":"us-west-2"}}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.region"),
+											Stage:         ptr.To(v1beta1.PatchStagePreBase),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.size"),
+											Stage:         ptr.To(v1beta1.PatchStagePreBase),
+										},
+									},
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											Stage:         ptr.To(v1beta1.PatchStagePostReadiness),
+										},
+									},
+								},
+							},
+							{
+								Name: "uncool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Patches: []v1beta1.ComposedPatch{
+									{
+										Type: v1beta1.PatchTypeFromCompositeFieldPath,
+										Patch: v1beta1.Patch{
+											FromFieldPath: ptr.To[string]("spec.widgets"),
+											Stage:         ptr.To(v1beta1.PatchStagePostReadiness),
+										},
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"region":"eu-west-1","size":"large","widgets":10}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42"},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`),
+							},
+							"uncool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"uncool-42"}}`),
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"region":"eu-west-1","size":"large","widgets":10}}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"region":"eu-west-1","size":"large","widgets":10}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42"},"spec":{"region":"us-west-2","size":"large","widgets":10}}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+							"uncool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"uncool-42"}}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"UnknownFeatureGate": {
+			reason: "We should return a warning, but otherwise ignore, a feature gate we don't understand.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						FeatureGates: []v1beta1.FeatureGate{"FromTheFuture"},
+						Composite: &v1beta1.Composite{
+							Patches: []v1beta1.CompositePatch{
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.size"),
+										ToFieldPath:   ptr.To[string]("status.size"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"size":"large"}}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"size":"large"}}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  `[PT040] ignoring unknown feature gate "FromTheFuture"`,
+						},
+					},
+					Context: &structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							fncontext.KeyEnvironment: structpb.NewStructValue(&structpb.Struct{}),
+						},
+					},
+				},
+			},
+		},
+		"WarnSkippedPatches": {
+			reason: "We should return a warning listing the patches we skipped if the XR is older than the configured duration.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						WarnSkippedPatchesAfter: &metav1.Duration{Duration: time.Minute},
+						Composite: &v1beta1.Composite{
+							Patches: []v1beta1.CompositePatch{
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.sise"),
+										ToFieldPath:   ptr.To[string]("status.size"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","metadata":{"creationTimestamp":"2024-01-01T00:00:00Z"},"spec":{"size":"large"}}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "[PT011] patches were skipped because an optional source field doesn't exist: composite patch 0 (spec.sise)",
+						},
+					},
+					Context: &structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							fncontext.KeyEnvironment: structpb.NewStructValue(&structpb.Struct{}),
+						},
+					},
+				},
+			},
+		},
+		"CompositeOnly": {
+			reason: "An Input without resource templates should only patch the XR.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Composite: &v1beta1.Composite{
+							Patches: []v1beta1.CompositePatch{
+								{
+									Type: v1beta1.PatchTypeFromCompositeFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("spec.size"),
+										ToFieldPath:   ptr.To[string]("status.size"),
+									},
+								},
+								{
+									Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("region"),
+										ToFieldPath:   ptr.To[string]("status.region"),
+									},
+								},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"size":"large"}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42"}}`),
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							fncontext.KeyEnvironment: structpb.NewStructValue(&structpb.Struct{
+								Fields: map[string]*structpb.Value{
+									"apiVersion": structpb.NewStringValue("internal.crossplane.io/v1alpha1"),
+									"kind":       structpb.NewStringValue("Environment"),
+									"region":     structpb.NewStringValue("us-west-2"),
+								},
+							}),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"size":"large","region":"us-west-2"}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							fncontext.KeyEnvironment: structpb.NewStructValue(&structpb.Struct{
+								Fields: map[string]*structpb.Value{
+									"apiVersion": structpb.NewStringValue("internal.crossplane.io/v1alpha1"),
+									"kind":       structpb.NewStringValue("Environment"),
+									"region":     structpb.NewStringValue("us-west-2"),
+								},
+							}),
+						},
+					},
+				},
+			},
+		},
+		"ForceReady": {
+			reason: "Composed resources should be marked ready regardless of their readiness checks when forced.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name:       "cool-resource",
+								Base:       &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ForceReady: &v1beta1.ForceReady{Value: ptr.To(true)},
+							},
+							{
+								Name:       "broken-resource",
+								Base:       &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ForceReady: &v1beta1.ForceReady{Value: ptr.To(false), FromFieldPath: ptr.To[string]("spec.breakGlass")},
+							},
+							{
+								Name:       "uncool-resource",
+								Base:       &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								ForceReady: &v1beta1.ForceReady{FromFieldPath: ptr.To[string]("spec.missing")},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"breakGlass":true}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"broken-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"broken-42"},"status":{"conditions":[{"type":"Ready","status":"False"}]}}`),
+							},
+						},
+					},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"breakGlass":true}}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"breakGlass":true}}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+							"broken-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"broken-42"}}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+							"uncool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"ReadinessQuorum": {
+			reason: "All composed resources should be marked ready once the readiness policy's quorum is ready.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Readiness: &v1beta1.ReadinessPolicy{
+							Type:   v1beta1.ReadinessPolicyTypeQuorum,
+							Quorum: ptr.To(1),
+						},
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "database",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+							{
+								Name: "dashboard",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"database-42"},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"database-42"}}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+							"dashboard": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"ReadinessResourcesNotReady": {
+			reason: "Composed resources should not be marked ready if a resource the readiness policy requires isn't ready.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Readiness: &v1beta1.ReadinessPolicy{
+							Type:      v1beta1.ReadinessPolicyTypeResources,
+							Resources: []string{"dashboard"},
+						},
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "database",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+							{
+								Name: "dashboard",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"database-42"},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"database-42"}}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+							"dashboard": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"NonCriticalNotReady": {
+			reason: "Composed resources that aren't critical should be marked ready, with a warning if they're not actually ready.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "database",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							},
+							{
+								Name:     "dashboard",
+								Base:     &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+								Critical: ptr.To(false),
+							},
+						},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"database": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+							},
+							"dashboard": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`),
+								Ready:    fnv1beta1.Ready_READY_TRUE,
+							},
+						},
+					},
+					Results: []*fnv1beta1.Result{
+						{
+							Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+							Message:  "[PT022] non-critical composed resources are not ready: dashboard",
+						},
+					},
+					Context: &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(nil)}},
+				},
+			},
+		},
+		"PatchToCompositeWithEnvironmentPatches": {
+			reason: "A basic ToCompositeFieldPath patch should work with environment.patches.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Resources: []v1beta1.ComposedTemplate{
+							{
+								Name: "cool-resource",
+								Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							}},
+						Environment: &v1beta1.Environment{
+							Patches: []v1beta1.EnvironmentPatch{
+								{
+									Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
+									Patch: v1beta1.Patch{
+										FromFieldPath: ptr.To[string]("data.widgets"),
+										ToFieldPath:   ptr.To[string]("spec.watchers"),
+										Transforms: []v1beta1.Transform{
+											{
+												Type: v1beta1.TransformTypeConvert,
+												Convert: &v1beta1.ConvertTransform{
+													ToType: v1beta1.TransformIOTypeInt64,
+												},
+											},
+											{
+												Type: v1beta1.TransformTypeMath,
+												Math: &v1beta1.MathTransform{
+													Type:     v1beta1.MathTransformTypeMultiply,
+													Multiply: ptr.To[int64](3),
+												}}}}}}}}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
 							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{}}`),
 						},
 						Resources: map[string]*fnv1beta1.Resource{},
@@ -805,6 +1633,101 @@ func TestRunFunction(t *testing.T) {
 						"widgets": "10",
 					})}}},
 
+		"DefaultEnvironment": {
+			reason: "We should use the default environment if the Function context contains no environment.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Environment: &v1beta1.Environment{
+							Defaults: &runtime.RawExtension{Raw: []byte(`{"data":{"widgets":"5"}}`)},
+						},
+						Resources: []v1beta1.ComposedTemplate{{
+							Name: "cool-resource",
+							Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							Patches: []v1beta1.ComposedPatch{{
+								Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("data.widgets"),
+									ToFieldPath:   ptr.To[string]("spec.watchers"),
+								},
+							}},
+						}},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"5"}}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "5",
+					}),
+				},
+			},
+		},
+		"DefaultEnvironmentIgnored": {
+			reason: "We should ignore the default environment if the Function context contains an environment.",
+			args: args{
+				req: &fnv1beta1.RunFunctionRequest{
+					Input: resource.MustStructObject(&v1beta1.Resources{
+						Environment: &v1beta1.Environment{
+							Defaults: &runtime.RawExtension{Raw: []byte(`{"data":{"widgets":"5"}}`)},
+						},
+						Resources: []v1beta1.ComposedTemplate{{
+							Name: "cool-resource",
+							Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+							Patches: []v1beta1.ComposedPatch{{
+								Type: v1beta1.PatchTypeFromEnvironmentFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("data.widgets"),
+									ToFieldPath:   ptr.To[string]("spec.watchers"),
+								},
+							}},
+						}},
+					}),
+					Observed: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+			want: want{
+				rsp: &fnv1beta1.RunFunctionResponse{
+					Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+					Desired: &fnv1beta1.State{
+						Composite: &fnv1beta1.Resource{
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+						},
+						Resources: map[string]*fnv1beta1.Resource{
+							"cool-resource": {
+								Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"watchers":"10"}}`),
+							},
+						},
+					},
+					Context: contextWithEnvironment(map[string]interface{}{
+						"widgets": "10",
+					}),
+				},
+			},
+		},
 		"PatchComposedResourceFromEnvironmentShadowedNotSet": {
 			reason: "A basic FromEnvironmentPatch should work if defined at spec.resources[*].patches, even if a successive patch shadows it and its source is not set.",
 			args: args{
@@ -923,7 +1846,7 @@ func TestRunFunction(t *testing.T) {
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			f := &Function{log: logging.NewNopLogger()}
-			rsp, err := f.RunFunction(tc.args.ctx, tc.args.req)
+			rsp, err := f.RunFunction(context.Background(), tc.args.req)
 
 			if diff := cmp.Diff(tc.want.rsp, rsp, protocmp.Transform()); diff != "" {
 				t.Errorf("%s\nf.RunFunction(...): -want rsp, +got rsp:\n%s", tc.reason, diff)
@@ -949,10 +1872,156 @@ func contextWithEnvironment(data map[string]interface{}) *structpb.Struct {
 	u := unstructured.Unstructured{Object: map[string]interface{}{
 		"data": data,
 	}}
-	u.SetGroupVersionKind(schema.GroupVersionKind{Group: "internal.crossplane.io", Version: "v1alpha1", Kind: "Environment"})
+	u.SetGroupVersionKind(EnvironmentGroupVersionKind)
 	d, err := structpb.NewStruct(u.UnstructuredContent())
 	if err != nil {
 		panic(err)
 	}
 	return &structpb.Struct{Fields: map[string]*structpb.Value{fncontext.KeyEnvironment: structpb.NewStructValue(d)}}
 }
+
+func TestRunFunctionConcurrently(t *testing.T) {
+	in := &v1beta1.Resources{}
+	observed := map[string]*fnv1beta1.Resource{}
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("resource-%d", i)
+		in.Resources = append(in.Resources, v1beta1.ComposedTemplate{
+			Name: name,
+			Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+			Patches: []v1beta1.ComposedPatch{
+				{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.widgets"),
+						ToFieldPath:   ptr.To("spec.watchers"),
+					},
+				},
+				{
+					// Every template writes this field. The last one should win.
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("status.id"),
+						ToFieldPath:   ptr.To("status.last"),
+					},
+				},
+				{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("status.id"),
+						ToFieldPath:   ptr.To(fmt.Sprintf("status.ids[%s]", name)),
+					},
+				},
+			},
+		})
+		observed[name] = &fnv1beta1.Resource{
+			Resource: resource.MustStructJSON(fmt.Sprintf(`{"apiVersion":"example.org/v1","kind":"CD","status":{"id":"%s"}}`, name)),
+		}
+	}
+
+	req := &fnv1beta1.RunFunctionRequest{
+		Input: resource.MustStructObject(in),
+		Observed: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+			},
+			Resources: observed,
+		},
+	}
+
+	sequential, err := (&Function{log: logging.NewNopLogger(), maxConcurrency: 1}).RunFunction(context.Background(), req)
+	if err != nil {
+		t.Fatalf("f.RunFunction(...): %v", err)
+	}
+	if got := sequential.GetDesired().GetComposite().GetResource().AsMap()["status"].(map[string]any)["last"]; got != "resource-19" {
+		t.Errorf("f.RunFunction(...): want status.last resource-19, got %v", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		concurrent, err := (&Function{log: logging.NewNopLogger(), maxConcurrency: 8}).RunFunction(context.Background(), req)
+		if err != nil {
+			t.Fatalf("f.RunFunction(...): %v", err)
+		}
+		if diff := cmp.Diff(sequential, concurrent, protocmp.Transform()); diff != "" {
+			t.Errorf("f.RunFunction(...): -sequential, +concurrent:\n%s", diff)
+		}
+	}
+}
+
+func TestRunFunctionDeadlineExceeded(t *testing.T) {
+	in := &v1beta1.Resources{
+		Resources: []v1beta1.ComposedTemplate{
+			{
+				Name: "existing-resource",
+				Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD","spec":{"widgets":20}}`)},
+			},
+			{
+				Name: "new-resource",
+				Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+			},
+			{
+				Name: "namespaced-resource",
+				Base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"NamespacedCD"}`)},
+			},
+		},
+	}
+	req := &fnv1beta1.RunFunctionRequest{
+		Input: resource.MustStructObject(in),
+		Observed: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+			},
+			Resources: map[string]*fnv1beta1.Resource{
+				"existing-resource": {
+					Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42","resourceVersion":"7"},"spec":{"widgets":10},"status":{"ready":true}}`),
+				},
+				"namespaced-resource": {
+					Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"NamespacedCD","metadata":{"namespace":"cool-ns","name":"cool-43"},"status":{"conditions":[{"type":"Ready","status":"True"}]}}`),
+				},
+			},
+		},
+	}
+
+	// The request's deadline passed before we started processing templates.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Minute))
+	defer cancel()
+
+	want := &fnv1beta1.RunFunctionResponse{
+		Meta: &fnv1beta1.ResponseMeta{Ttl: durationpb.New(response.DefaultTTL)},
+		Desired: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+			},
+			Resources: map[string]*fnv1beta1.Resource{
+				"existing-resource": {
+					Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-42"},"spec":{"widgets":10}}`),
+				},
+				// Skipped resources keep their namespace, and are as ready
+				// as they were.
+				"namespaced-resource": {
+					Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"NamespacedCD","metadata":{"namespace":"cool-ns","name":"cool-43"}}`),
+					Ready:    fnv1beta1.Ready_READY_TRUE,
+				},
+			},
+		},
+		Results: []*fnv1beta1.Result{
+			{
+				Severity: fnv1beta1.Severity_SEVERITY_WARNING,
+				Message:  "[PT030] ran out of time after processing 0 of 3 resource templates; skipped templates' existing composed resources were left unchanged",
+			},
+		},
+		Context: &structpb.Struct{
+			Fields: map[string]*structpb.Value{
+				fncontext.KeyEnvironment: structpb.NewStructValue(&structpb.Struct{}),
+			},
+		},
+	}
+
+	f := &Function{log: logging.NewNopLogger()}
+	rsp, err := f.RunFunction(ctx, req)
+	if err != nil {
+		t.Fatalf("f.RunFunction(...): %v", err)
+	}
+	if diff := cmp.Diff(want, rsp, protocmp.Transform()); diff != "" {
+		t.Errorf("f.RunFunction(...): -want rsp, +got rsp:\n%s", diff)
+	}
+}
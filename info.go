@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+// InfoPath is the HTTP path at which the Function serves information about
+// its capabilities.
+const InfoPath = "/debug/info"
+
+// Version of the Function. Set it at build time using the linker, e.g.
+// -ldflags "-X main.Version=v0.2.0". The version is read from the binary's
+// build info if it isn't set.
+var Version = ""
+
+// Error strings
+const (
+	errInputVersions = "cannot determine input versions"
+)
+
+// Info describes the capabilities of the running Function, so operators can
+// check what a deployed Function supports before they author Compositions
+// that depend on it.
+type Info struct {
+	// Version of the Function.
+	Version string `json:"version"`
+
+	// TransformTypes the Function can resolve.
+	TransformTypes []string `json:"transformTypes"`
+
+	// InputVersions are the API versions of the Function's input that it
+	// accepts.
+	InputVersions []string `json:"inputVersions"`
+}
+
+// NewInfo returns information about the Function's capabilities, including
+// the transform types registered in the supplied registry.
+func NewInfo(r *transforms.Registry) (*Info, error) {
+	versions, err := InputVersions()
+	if err != nil {
+		return nil, errors.Wrap(err, errInputVersions)
+	}
+	types := r.Types()
+	i := &Info{
+		Version:        buildVersion(),
+		TransformTypes: make([]string, len(types)),
+		InputVersions:  versions,
+	}
+	for j, tt := range types {
+		i.TransformTypes[j] = string(tt)
+	}
+	return i, nil
+}
+
+// NewInfoHandler returns an HTTP handler that serves information about the
+// Function's capabilities as JSON. Transform types are read from the supplied
+// registry on each request, so types registered after the handler is created
+// are included.
+func NewInfoHandler(r *transforms.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		i, err := NewInfo(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(i)
+	})
+}
+
+// buildVersion returns the Function's version. It prefers the version set at
+// build time, then the module version or VCS revision recorded by the Go
+// toolchain.
+func buildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value
+		}
+	}
+	return "unknown"
+}
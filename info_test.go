@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+func TestInfoHandler(t *testing.T) {
+	Version = "v1.2.3"
+	t.Cleanup(func() { Version = "" })
+
+	r := transforms.NewRegistry(map[v1beta1.TransformType]transforms.Definition{
+		v1beta1.TransformTypeString: {},
+		v1beta1.TransformTypeMap:    {},
+	})
+
+	rec := httptest.NewRecorder()
+	NewInfoHandler(r).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InfoPath, nil))
+	if diff := cmp.Diff(http.StatusOK, rec.Code); diff != "" {
+		t.Fatalf("ServeHTTP(...): -want status, +got status:\n%s", diff)
+	}
+
+	got := &Info{}
+	if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+		t.Fatalf("json.Unmarshal(...): %v", err)
+	}
+	want := &Info{
+		Version:        "v1.2.3",
+		TransformTypes: []string{"map", "string"},
+		InputVersions:  []string{"pt.fn.crossplane.io/v1beta1"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ServeHTTP(...): -want, +got:\n%s", diff)
+	}
+
+	// Types registered after the handler is created should be reported.
+	r.Register(v1beta1.TransformTypeBool, transforms.Definition{})
+	rec = httptest.NewRecorder()
+	NewInfoHandler(r).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InfoPath, nil))
+	got = &Info{}
+	if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+		t.Fatalf("json.Unmarshal(...): %v", err)
+	}
+	if diff := cmp.Diff([]string{"bool", "map", "string"}, got.TransformTypes); diff != "" {
+		t.Errorf("ServeHTTP(...): -want transform types, +got transform types:\n%s", diff)
+	}
+}
@@ -6,6 +6,9 @@
 
 //go:generate go run -tags generate sigs.k8s.io/controller-tools/cmd/controller-gen paths=./v1beta1 object crd:crdVersions=v1 output:artifacts:config=../package/input
 
+// Derive a JSON Schema from the CRD generated above, for editors and linters.
+//go:generate sh -c "cd .. && go run . --print-schema > package/input/pt.fn.crossplane.io_resources.schema.json"
+
 package input
 
 import (
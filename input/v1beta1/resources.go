@@ -5,6 +5,7 @@
 package v1beta1
 
 import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,7 +33,153 @@ type Resources struct {
 	// +optional
 	Environment *Environment `json:"environment,omitempty"`
 
+	// FeatureGates opt this Composition into experimental behaviors of the
+	// function. Experimental behaviors may change or be removed in a future
+	// release. Unknown feature gates are ignored with a warning.
+	// +optional
+	FeatureGates []FeatureGate `json:"featureGates,omitempty"`
+
+	// Data is a set of named documents. Patches can read a document from the
+	// virtual data field of the composite resource, e.g. using fromFieldPath:
+	// data.regions. Map transforms can read their pairs from a document using
+	// mapFromData.
+	// +optional
+	Data map[string]extv1.JSON `json:"data,omitempty"`
+
+	// Propagate configures labels and annotations of the composite resource
+	// that are copied to every composed resource.
+	// +optional
+	Propagate *Propagate `json:"propagate,omitempty"`
+
+	// ProviderConfigRef configures the ProviderConfig that composed resources
+	// use, instead of patching spec.providerConfigRef.name per template.
+	// +optional
+	ProviderConfigRef *ProviderConfigRef `json:"providerConfigRef,omitempty"`
+
+	// ConnectionDetails configures which of the composite resource's
+	// connection details are published, and the keys they're published as.
+	// Use it to keep internal keys from composed resources out of claim
+	// connection secrets.
+	// +optional
+	ConnectionDetails *ConnectionDetailsPolicy `json:"connectionDetails,omitempty"`
+
+	// Composite configures patches and status conditions that are applied
+	// directly to the composite resource. Resources may be omitted if this is
+	// set, for example to shape the composite resource's status in the final
+	// step of a pipeline.
+	// +optional
+	Composite *Composite `json:"composite,omitempty"`
+
+	// Readiness configures when the composite resource is considered ready.
+	// By default it's ready when all of its composed resources are ready.
+	// +optional
+	Readiness *ReadinessPolicy `json:"readiness,omitempty"`
+
+	// Assertions that must hold across resource templates, for example that
+	// no two templates set the same external name. They're checked when the
+	// input is validated, to catch collisions between templates that were
+	// copied and pasted.
+	// +optional
+	Assertions []Assertion `json:"assertions,omitempty"`
+
+	// WarnSkippedPatchesAfter makes the function return a warning result that
+	// lists the patches it skipped because an optional source field doesn't
+	// exist, once the composite resource is older than this duration. Use it
+	// to catch patches that never take effect, e.g. because of a typo in
+	// their fromFieldPath.
+	// +optional
+	WarnSkippedPatchesAfter *metav1.Duration `json:"warnSkippedPatchesAfter,omitempty"`
+
+	// ReportChangedFields makes the function return a normal result for each
+	// observed composed resource whose desired state differs from its observed
+	// state, listing the paths of the fields that differ. Use it to find out
+	// why a composed resource is constantly updated.
+	// +optional
+	ReportChangedFields bool `json:"reportChangedFields,omitempty"`
+
+	// RecordFieldProvenance makes the function annotate each composed resource
+	// with the patch that last wrote each of its fields, by field path. Patches
+	// are identified by their index in the resource template's patches, after
+	// any PatchSets are included. Use it to find out which patch is
+	// responsible for an unexpected value.
+	// +optional
+	RecordFieldProvenance bool `json:"recordFieldProvenance,omitempty"`
+
 	// Resources is a list of resource templates that will be used when a
-	// composite resource is created.
-	Resources []ComposedTemplate `json:"resources"`
+	// composite resource is created. Required unless composite is set.
+	// +optional
+	Resources []ComposedTemplate `json:"resources,omitempty"`
+}
+
+// A FeatureGate enables an experimental behavior of the function.
+type FeatureGate string
+
+// Feature gates.
+const (
+	// FeatureGateWebhookTransforms enables webhook transforms.
+	FeatureGateWebhookTransforms FeatureGate = "WebhookTransforms"
+)
+
+// FeatureEnabled returns true if the supplied feature gate is enabled.
+func (r *Resources) FeatureEnabled(g FeatureGate) bool {
+	for _, fg := range r.FeatureGates {
+		if fg == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Propagate configures labels and annotations of the composite resource that
+// are copied to every composed resource. A label or annotation is only copied
+// if the composed resource doesn't already have it. Patches to the composed
+// resource may override copied labels and annotations.
+type Propagate struct {
+	// LabelPrefixes of composite resource labels to copy to every composed
+	// resource. A label is copied if its key starts with any of these
+	// prefixes.
+	// +optional
+	LabelPrefixes []string `json:"labelPrefixes,omitempty"`
+
+	// AnnotationPrefixes of composite resource annotations to copy to every
+	// composed resource. An annotation is copied if its key starts with any of
+	// these prefixes.
+	// +optional
+	AnnotationPrefixes []string `json:"annotationPrefixes,omitempty"`
+}
+
+// ProviderConfigRef configures the name of the ProviderConfig that composed
+// resources use. The name is written to spec.providerConfigRef.name of each
+// composed resource before its patches are applied, so patches may override
+// it. Nothing is written if the source field doesn't exist.
+type ProviderConfigRef struct {
+	// FromFieldPath is the path of a string field of the composite resource
+	// whose value is the name of the ProviderConfig. Exactly one of
+	// fromFieldPath and fromEnvironmentFieldPath must be set.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+
+	// FromEnvironmentFieldPath is the path of a string field of the
+	// Composition environment whose value is the name of the ProviderConfig.
+	// +optional
+	FromEnvironmentFieldPath *string `json:"fromEnvironmentFieldPath,omitempty"`
+
+	// Resources names the resource templates whose composed resources use the
+	// ProviderConfig. Defaults to all resource templates.
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+}
+
+// AppliesTo returns true if the ProviderConfig should be injected into the
+// composed resource produced by the named resource template.
+func (r *ProviderConfigRef) AppliesTo(name string) bool {
+	if len(r.Resources) == 0 {
+		return true
+	}
+	for _, n := range r.Resources {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
@@ -36,8 +36,9 @@ func (ps *PatchSet) GetComposedPatches() []ComposedPatch {
 	out := make([]ComposedPatch, len(ps.Patches))
 	for i, p := range ps.Patches {
 		out[i] = ComposedPatch{
-			Type:  p.GetType(),
-			Patch: p.Patch,
+			Type:      p.GetType(),
+			Condition: p.Condition,
+			Patch:     p.Patch,
 		}
 	}
 	return out
@@ -75,6 +76,96 @@ type ComposedTemplate struct {
 	// +optional
 	// +kubebuilder:default={{type:"MatchCondition",matchCondition:{type:"Ready",status:"True"}}}
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// Delete configures when to remove the composed resource from desired
+	// state, causing Crossplane to delete it. This includes a desired
+	// resource with the same name produced by a previous Function in the
+	// pipeline.
+	// +optional
+	Delete *DeleteCondition `json:"delete,omitempty"`
+
+	// ForceReady marks the composed resource ready regardless of its
+	// readiness checks. Use it to break glass, or for resources whose
+	// readiness signals are unreliable.
+	// +optional
+	ForceReady *ForceReady `json:"forceReady,omitempty"`
+
+	// Critical determines whether the composed resource gates the composite
+	// resource's readiness. A composed resource that isn't critical, like a
+	// dashboard or an alarm, is always marked ready. The function returns a
+	// warning result while it's not actually ready. Defaults to true.
+	// +optional
+	Critical *bool `json:"critical,omitempty"`
+
+	// ReadinessWeight is how many ready composed resources this resource
+	// counts as when the composite resource's readiness policy is Quorum.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ReadinessWeight *int `json:"readinessWeight,omitempty"`
+
+	// Namespace configures the namespace of a namespaced composed resource.
+	// Don't set it for cluster scoped composed resources.
+	// +optional
+	Namespace *ComposedNamespace `json:"namespace,omitempty"`
+}
+
+// IsCritical returns true if the composed resource gates the composite
+// resource's readiness.
+func (t *ComposedTemplate) IsCritical() bool {
+	if t.Critical == nil {
+		return true
+	}
+	return *t.Critical
+}
+
+// GetReadinessWeight returns how many ready composed resources this resource
+// counts as, defaulting to 1 if not specified.
+func (t *ComposedTemplate) GetReadinessWeight() int {
+	if t.ReadinessWeight == nil {
+		return 1
+	}
+	return *t.ReadinessWeight
+}
+
+// A DeleteCondition determines when to remove a composed resource from desired
+// state.
+type DeleteCondition struct {
+	// FromFieldPath is the path of a boolean field of the composite resource.
+	// The composed resource is removed from desired state when this field is
+	// true. It is kept if the field does not exist.
+	FromFieldPath string `json:"fromFieldPath"`
+}
+
+// ForceReady determines when to mark a composed resource ready regardless of
+// its readiness checks.
+type ForceReady struct {
+	// Value marks the composed resource ready when true. Ignored if
+	// fromFieldPath is set.
+	// +optional
+	Value *bool `json:"value,omitempty"`
+
+	// FromFieldPath is the path of a boolean field of the composite resource.
+	// The composed resource is marked ready when this field is true. Its
+	// readiness checks apply if the field does not exist.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+}
+
+// ComposedNamespace determines the namespace of a namespaced composed
+// resource. The namespace is set before patches are applied, so patches may
+// override it. An existing composed resource keeps its namespace.
+type ComposedNamespace struct {
+	// Value is the namespace. Used if fromFieldPath isn't set, or its field
+	// doesn't exist.
+	// +optional
+	Value *string `json:"value,omitempty"`
+
+	// FromFieldPath is the path of a string field of the composite resource
+	// whose value is the namespace. Use claim.namespace for the namespace of
+	// the claim the composite resource is bound to.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 }
 
 // ReadinessCheckType is used for readiness check types.
@@ -142,6 +233,74 @@ type MatchConditionReadinessCheck struct {
 	Status corev1.ConditionStatus `json:"status"`
 }
 
+// A ConditionSource is the object a composite condition reads its field paths
+// from.
+type ConditionSource string
+
+// Condition sources.
+const (
+	ConditionSourceComposite   ConditionSource = "Composite" // Default
+	ConditionSourceEnvironment ConditionSource = "Environment"
+)
+
+// A CompositeCondition is a status condition set on the composite resource.
+type CompositeCondition struct {
+	// Type of the condition, e.g. DatabaseReady. Crossplane owns the Ready
+	// and Synced conditions, so they can't be set.
+	Type xpv1.ConditionType `json:"type"`
+
+	// Status of the condition. Ignored if statusFromFieldPath is set.
+	// +optional
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	// +kubebuilder:default="True"
+	Status *corev1.ConditionStatus `json:"status,omitempty"`
+
+	// StatusFromFieldPath is the path of the field whose value is the
+	// condition's status. The field may be a boolean, or a string that is
+	// True, False, or Unknown. The status is Unknown if the field doesn't
+	// exist.
+	// +optional
+	StatusFromFieldPath *string `json:"statusFromFieldPath,omitempty"`
+
+	// Reason for the condition's status.
+	Reason xpv1.ConditionReason `json:"reason"`
+
+	// Message containing details about the condition's status. Ignored if
+	// messageFromFieldPath is set and the field exists.
+	// +optional
+	Message *string `json:"message,omitempty"`
+
+	// MessageFromFieldPath is the path of the string field whose value is the
+	// condition's message.
+	// +optional
+	MessageFromFieldPath *string `json:"messageFromFieldPath,omitempty"`
+
+	// Source of the statusFromFieldPath and messageFromFieldPath fields.
+	// Either the observed composite resource, or the environment.
+	// +optional
+	// +kubebuilder:validation:Enum=Composite;Environment
+	// +kubebuilder:default=Composite
+	Source *ConditionSource `json:"source,omitempty"`
+}
+
+// GetStatus returns the condition's status, or the default status if it isn't
+// set.
+func (c *CompositeCondition) GetStatus() corev1.ConditionStatus {
+	if c.Status == nil {
+		return corev1.ConditionTrue
+	}
+	return *c.Status
+}
+
+// GetSource returns the condition's source, or the default source if it isn't
+// set.
+func (c *CompositeCondition) GetSource() ConditionSource {
+	if c.Source == nil {
+		return ConditionSourceComposite
+	}
+	return *c.Source
+}
+
 // A ConnectionDetailType is a type of connection detail.
 type ConnectionDetailType string
 
@@ -187,9 +346,164 @@ type ConnectionDetail struct {
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromFieldPaths is an ordered list of fallback paths to fields on the
+	// composed resource. The value of the first field that exists is used.
+	// They're tried after FromFieldPath, if it's set. Use them when a provider
+	// moves a field between versions.
+	// +optional
+	FromFieldPaths []string `json:"fromFieldPaths,omitempty"`
+
 	// Value that will be propagated to the connection secret of the composite
 	// resource. May be set to inject a fixed, non-sensitive connection secret
 	// value, for example a well-known port.
 	// +optional
 	Value *string `json:"value,omitempty"`
 }
+
+// GetFromFieldPaths returns the field paths this ConnectionDetail reads from,
+// in the order they should be tried.
+func (cd *ConnectionDetail) GetFromFieldPaths() []string {
+	paths := make([]string, 0, len(cd.FromFieldPaths)+1)
+	if cd.FromFieldPath != nil {
+		paths = append(paths, *cd.FromFieldPath)
+	}
+	return append(paths, cd.FromFieldPaths...)
+}
+
+// ConnectionDetailsPolicy configures which of the composite resource's
+// connection details are published, and the keys they're published as. Keys
+// are included, excluded, and renamed using their names before renaming.
+type ConnectionDetailsPolicy struct {
+	// Include only these keys. All keys are included if this is empty.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude these keys, even if they're included.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Rename maps keys to the keys they're published as. A renamed key
+	// replaces any key with the same name that isn't renamed.
+	// +optional
+	Rename map[string]string `json:"rename,omitempty"`
+}
+
+// A ReadinessPolicyType determines when the composite resource is ready.
+type ReadinessPolicyType string
+
+// ReadinessPolicy types.
+const (
+	// ReadinessPolicyTypeAll considers the composite resource ready when all
+	// of its composed resources are ready.
+	ReadinessPolicyTypeAll ReadinessPolicyType = "All"
+
+	// ReadinessPolicyTypeResources considers the composite resource ready
+	// when the named composed resources are ready.
+	ReadinessPolicyTypeResources ReadinessPolicyType = "Resources"
+
+	// ReadinessPolicyTypeQuorum considers the composite resource ready when
+	// a minimum number of its composed resources are ready.
+	ReadinessPolicyTypeQuorum ReadinessPolicyType = "Quorum"
+)
+
+// A ReadinessPolicy configures when the composite resource is considered
+// ready. Crossplane considers a composite resource ready when all of its
+// composed resources are ready, so once the policy is satisfied the function
+// marks every composed resource it produces ready.
+type ReadinessPolicy struct {
+	// Type of readiness policy. All (the default) considers the composite
+	// resource ready when all of its composed resources are ready. Resources
+	// considers it ready when the named composed resources are ready. Quorum
+	// considers it ready when a minimum number of its composed resources are
+	// ready.
+	// +optional
+	// +kubebuilder:validation:Enum=All;Resources;Quorum
+	// +kubebuilder:default=All
+	Type ReadinessPolicyType `json:"type,omitempty"`
+
+	// Resources that must be ready for the composite resource to be ready,
+	// by resource template name. Required when type is Resources.
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+
+	// Quorum is the minimum number of composed resources that must be ready
+	// for the composite resource to be ready. Each ready composed resource
+	// counts as its resource template's readinessWeight. Required when type
+	// is Quorum.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Quorum *int `json:"quorum,omitempty"`
+}
+
+// GetType returns the type of this ReadinessPolicy, defaulting to All if not
+// specified.
+func (p *ReadinessPolicy) GetType() ReadinessPolicyType {
+	if p == nil || p.Type == "" {
+		return ReadinessPolicyTypeAll
+	}
+	return p.Type
+}
+
+// An AssertionType determines what an Assertion checks.
+type AssertionType string
+
+// Assertion types.
+const (
+	// AssertionTypeUniqueFieldValue asserts that no two resource templates
+	// set a field of their composed resources to the same value.
+	AssertionTypeUniqueFieldValue AssertionType = "UniqueFieldValue"
+
+	// AssertionTypeUniqueCompositeFieldPath asserts that no two resource
+	// templates patch the same field of the composite resource.
+	AssertionTypeUniqueCompositeFieldPath AssertionType = "UniqueCompositeFieldPath"
+)
+
+// An Assertion is checked across resource templates when the input is
+// validated. Assertions are checked statically, using each template's base
+// and patches after any PatchSets are included. A template's value for a
+// field is the last patch that writes to it, or its base value if no patch
+// does. Two templates have the same value if those patches or base values are
+// identical.
+type Assertion struct {
+	// Type of assertion. UniqueFieldValue asserts that no two resource
+	// templates set the field at fieldPath of their composed resources to the
+	// same value. UniqueCompositeFieldPath asserts that no two resource
+	// templates patch the same field of the composite resource.
+	// +kubebuilder:validation:Enum=UniqueFieldValue;UniqueCompositeFieldPath
+	Type AssertionType `json:"type"`
+
+	// FieldPath the assertion applies to. Required when type is
+	// UniqueFieldValue, e.g. metadata.annotations[crossplane.io/external-name].
+	// When type is UniqueCompositeFieldPath it limits the assertion to fields
+	// of the composite resource at or under this path, e.g. status.
+	// +optional
+	FieldPath *string `json:"fieldPath,omitempty"`
+
+	// Resources names the resource templates the assertion applies to.
+	// Defaults to all resource templates.
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+}
+
+// GetFieldPath returns the FieldPath of this Assertion, or an empty string if
+// it is nil.
+func (a *Assertion) GetFieldPath() string {
+	if a.FieldPath == nil {
+		return ""
+	}
+	return *a.FieldPath
+}
+
+// AppliesTo returns true if the assertion applies to the named resource
+// template.
+func (a *Assertion) AppliesTo(name string) bool {
+	if len(a.Resources) == 0 {
+		return true
+	}
+	for _, n := range a.Resources {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
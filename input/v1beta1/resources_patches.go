@@ -1,5 +1,13 @@
 package v1beta1
 
+import (
+	"time"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
 // A PatchType is a type of patch.
 type PatchType string
 
@@ -23,13 +31,75 @@ const (
 	PatchTypeCombineToEnvironment     PatchType = "CombineToEnvironment"
 )
 
+// Connection detail patch types. These patch from an observed composed resource
+// to the composite resource's connection details.
+const (
+	PatchTypeToConnectionDetail        PatchType = "ToConnectionDetail"
+	PatchTypeCombineToConnectionDetail PatchType = "CombineToConnectionDetail"
+)
+
+// Condition patch types. These patch from a status condition of an observed
+// composed resource to the composite resource.
+const (
+	PatchTypeConditionToComposite PatchType = "ConditionToComposite"
+)
+
+// A ConditionField is a field of a status condition.
+type ConditionField string
+
+// Condition fields.
+const (
+	ConditionFieldMessage ConditionField = "Message" // Default
+	ConditionFieldReason  ConditionField = "Reason"
+	ConditionFieldStatus  ConditionField = "Status"
+)
+
+// Subtree patch types. These copy an object from the composite resource to a
+// composed resource, optionally filtering its fields.
+const (
+	PatchTypeCopyFromCompositeFieldPath PatchType = "CopyFromCompositeFieldPath"
+)
+
+// A PatchStage determines when a patch is applied to a composed resource.
+type PatchStage string
+
+// Patch stages.
+const (
+	PatchStagePreBase       PatchStage = "PreBase"
+	PatchStageDefault       PatchStage = "Default" // Default
+	PatchStagePostReadiness PatchStage = "PostReadiness"
+)
+
 // A FromFieldPathPolicy determines how to patch from a field path.
 type FromFieldPathPolicy string
 
 // FromFieldPath patch policies.
 const (
-	FromFieldPathPolicyOptional FromFieldPathPolicy = "Optional"
-	FromFieldPathPolicyRequired FromFieldPathPolicy = "Required"
+	FromFieldPathPolicyOptional         FromFieldPathPolicy = "Optional"
+	FromFieldPathPolicyRequired         FromFieldPathPolicy = "Required"
+	FromFieldPathPolicyOptionalNonEmpty FromFieldPathPolicy = "OptionalNonEmpty"
+	FromFieldPathPolicyRequiredNonEmpty FromFieldPathPolicy = "RequiredNonEmpty"
+)
+
+// IsOptional returns true if a patch from a field path that doesn't exist
+// should be a no-op.
+func (p FromFieldPathPolicy) IsOptional() bool {
+	return p == FromFieldPathPolicyOptional || p == FromFieldPathPolicyOptionalNonEmpty
+}
+
+// TreatsEmptyAsMissing returns true if an empty value at a field path should
+// be treated as if the field path doesn't exist.
+func (p FromFieldPathPolicy) TreatsEmptyAsMissing() bool {
+	return p == FromFieldPathPolicyOptionalNonEmpty || p == FromFieldPathPolicyRequiredNonEmpty
+}
+
+// A ToFieldPathPolicy determines how to patch to a field path.
+type ToFieldPathPolicy string
+
+// ToFieldPath patch policies.
+const (
+	ToFieldPathPolicyCreate   ToFieldPathPolicy = "Create"
+	ToFieldPathPolicyRequired ToFieldPathPolicy = "Required"
 )
 
 // A PatchPolicy configures the specifics of patching behaviour.
@@ -37,10 +107,97 @@ type PatchPolicy struct {
 	// FromFieldPath specifies how to patch from a field path. The default is
 	// 'Optional', which means the patch will be a no-op if the specified
 	// fromFieldPath does not exist. Use 'Required' if the patch should fail if
-	// the specified path does not exist.
-	// +kubebuilder:validation:Enum=Optional;Required
+	// the specified path does not exist. 'OptionalNonEmpty' and
+	// 'RequiredNonEmpty' behave like 'Optional' and 'Required', but also treat
+	// an empty string, zero, null, or an empty array or object as if the path
+	// does not exist.
+	// +kubebuilder:validation:Enum=Optional;Required;OptionalNonEmpty;RequiredNonEmpty
 	// +optional
 	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+
+	// FromFieldPathDefault is the value to patch when the fromFieldPath does
+	// not exist, or is empty and the fromFieldPath policy treats empty values
+	// as missing. The value is transformed like any other. Only applies to
+	// patches with a fromFieldPath, not to combine patches.
+	// +optional
+	FromFieldPathDefault *extv1.JSON `json:"fromFieldPathDefault,omitempty"`
+
+	// ToFieldPath specifies how to patch to a field path. The default is
+	// 'Create', which means the patch will create any objects and arrays
+	// leading to the specified toFieldPath that don't exist. Use 'Required' if
+	// the patch should fail if the parent of the specified path does not exist.
+	// +kubebuilder:validation:Enum=Create;Required
+	// +optional
+	ToFieldPath *ToFieldPathPolicy `json:"toFieldPath,omitempty"`
+
+	// MergeKey is the name of a field that uniquely identifies each object in
+	// an array of objects. When set, patching an array of objects to a
+	// toFieldPath that's already an array merges each patched object into the
+	// existing object with the same value at this key, rather than replacing
+	// the array. Patched objects that don't match an existing object are
+	// appended. Existing objects that don't match a patched object are kept.
+	// +optional
+	MergeKey *string `json:"mergeKey,omitempty"`
+
+	// SkipUnchanged omits the patch's write to a composed resource when the
+	// observed composed resource already has the patched value at the
+	// toFieldPath. This avoids asserting desired state for fields that are
+	// already as desired, for example fields a controller defaults. Only
+	// applies to patches to composed resources.
+	// +optional
+	SkipUnchanged *bool `json:"skipUnchanged,omitempty"`
+
+	// CreateOnly applies the patch only when the composed resource doesn't
+	// exist yet. Once it exists the observed value at the toFieldPath is kept,
+	// so the field is never re-patched. This is useful for immutable fields,
+	// for example an availability zone or engine version. Only applies to
+	// patches to composed resources.
+	// +optional
+	CreateOnly *bool `json:"createOnly,omitempty"`
+
+	// Freeze applies the patch only until the observed composed resource has
+	// a value at the toFieldPath. From then on the observed value is kept, so
+	// the field is written once. Use it with values that change every time
+	// they're read, for example a timestamp patched from the composite
+	// resource's now field. Only applies to patches to composed resources.
+	// +optional
+	Freeze *bool `json:"freeze,omitempty"`
+
+	// CoerceToExistingType converts the patched value to the type of the
+	// value that already exists at the toFieldPath, for example the value set
+	// by the base template. Strings, numbers, and booleans are converted
+	// between each other, e.g. 42 to "42". The patch fails if the value can't
+	// be converted. The value is patched as is if no value exists at the
+	// toFieldPath.
+	// +optional
+	CoerceToExistingType *bool `json:"coerceToExistingType,omitempty"`
+
+	// Memoize skips the patch's transforms when the patch and its source
+	// values haven't changed since the composed resource was last patched,
+	// and keeps the observed value at the toFieldPath instead. Use it for
+	// patches with expensive transforms. Only memoize patches whose
+	// transforms always produce the same output for the same input. Only
+	// applies to patches to composed resources.
+	// +optional
+	Memoize *bool `json:"memoize,omitempty"`
+}
+
+// GetCoerceToExistingType returns true if the patched value should be
+// converted to the type of the value that already exists at the toFieldPath.
+func (pp *PatchPolicy) GetCoerceToExistingType() bool {
+	return pp != nil && pp.CoerceToExistingType != nil && *pp.CoerceToExistingType
+}
+
+// GetCreateOnly returns true if the patch should only be applied when the
+// composed resource doesn't exist yet.
+func (pp *PatchPolicy) GetCreateOnly() bool {
+	return pp != nil && pp.CreateOnly != nil && *pp.CreateOnly
+}
+
+// GetFreeze returns true if the patch should only be applied until the
+// observed composed resource has a value at the toFieldPath.
+func (pp *PatchPolicy) GetFreeze() bool {
+	return pp != nil && pp.Freeze != nil && *pp.Freeze
 }
 
 // GetFromFieldPathPolicy returns the FromFieldPathPolicy for this PatchPolicy, defaulting to FromFieldPathPolicyOptional if not specified.
@@ -51,6 +208,35 @@ func (pp *PatchPolicy) GetFromFieldPathPolicy() FromFieldPathPolicy {
 	return *pp.FromFieldPath
 }
 
+// GetMergeKey returns the MergeKey for this PatchPolicy, or an empty string if
+// not specified.
+func (pp *PatchPolicy) GetMergeKey() string {
+	if pp == nil || pp.MergeKey == nil {
+		return ""
+	}
+	return *pp.MergeKey
+}
+
+// GetSkipUnchanged returns true if the patch should be omitted when the
+// observed composed resource already has the patched value.
+func (pp *PatchPolicy) GetSkipUnchanged() bool {
+	return pp != nil && pp.SkipUnchanged != nil && *pp.SkipUnchanged
+}
+
+// GetMemoize returns true if the patch's transforms should be skipped when
+// the patch and its source values haven't changed.
+func (pp *PatchPolicy) GetMemoize() bool {
+	return pp != nil && pp.Memoize != nil && *pp.Memoize
+}
+
+// GetToFieldPathPolicy returns the ToFieldPathPolicy for this PatchPolicy, defaulting to ToFieldPathPolicyCreate if not specified.
+func (pp *PatchPolicy) GetToFieldPathPolicy() ToFieldPathPolicy {
+	if pp == nil || pp.ToFieldPath == nil {
+		return ToFieldPathPolicyCreate
+	}
+	return *pp.ToFieldPath
+}
+
 // Environment represents the Composition environment.
 type Environment struct {
 	// Patches is a list of environment patches that are executed before a
@@ -58,6 +244,13 @@ type Environment struct {
 	// and the Environment. Either from the Environment to the XR, or vice
 	// versa.
 	Patches []EnvironmentPatch `json:"patches,omitempty"`
+
+	// Defaults is the environment to use when the Function's context doesn't
+	// contain one, for example because EnvironmentConfigs aren't enabled. It
+	// is ignored if the context contains an environment.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Defaults *runtime.RawExtension `json:"defaults,omitempty"`
 }
 
 // EnvironmentPatch objects are applied between the composite resource and
@@ -83,6 +276,43 @@ func (ep *EnvironmentPatch) GetType() PatchType {
 	return ep.Type
 }
 
+// Composite configures patches and status conditions that are applied
+// directly to the composite resource. They're applied after all resource
+// templates have been processed.
+type Composite struct {
+	// Patches from the observed composite resource or the environment to the
+	// desired composite resource.
+	// +optional
+	Patches []CompositePatch `json:"patches,omitempty"`
+
+	// Conditions to set on the desired composite resource.
+	// +optional
+	Conditions []CompositeCondition `json:"conditions,omitempty"`
+}
+
+// CompositePatch objects are applied to the desired composite resource. The
+// default Type, FromCompositeFieldPath, copies a value from the observed
+// composite resource to the desired composite resource, applying any defined
+// transformers.
+type CompositePatch struct {
+	// Type sets the patching behaviour to be used. Each patch type may require
+	// its own fields to be set on the Patch object.
+	// +optional
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;CombineFromComposite;FromEnvironmentFieldPath;CombineFromEnvironment
+	// +kubebuilder:default=FromCompositeFieldPath
+	Type PatchType `json:"type,omitempty"`
+
+	Patch `json:",inline"`
+}
+
+// GetType returns the patch type. If the type is not set, it returns the default type.
+func (cp *CompositePatch) GetType() PatchType {
+	if cp.Type == "" {
+		return PatchTypeFromCompositeFieldPath
+	}
+	return cp.Type
+}
+
 // ComposedPatch objects are applied between composite and composed resources.
 // Their behaviour depends on the Type selected. The default Type,
 // FromCompositeFieldPath, copies a value from the composite resource to the
@@ -91,7 +321,7 @@ type ComposedPatch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its own fields to be set on the ComposedPatch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment;ToConnectionDetail;CombineToConnectionDetail;CopyFromCompositeFieldPath;ConditionToComposite
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
@@ -99,6 +329,28 @@ type ComposedPatch struct {
 	// +optional
 	PatchSetName *string `json:"patchSetName,omitempty"`
 
+	// When determines whether a patch of type PatchSet includes its PatchSet.
+	// The PatchSet is always included if this isn't set.
+	// +optional
+	When *PatchSetCondition `json:"when,omitempty"`
+
+	// ConnectionDetailName is the name of the composite resource connection
+	// detail to patch to. Required when type is ToConnectionDetail or
+	// CombineToConnectionDetail.
+	// +optional
+	ConnectionDetailName *string `json:"connectionDetailName,omitempty"`
+
+	// Condition selects the status condition of the observed composed
+	// resource to patch from. Required when type is ConditionToComposite.
+	// +optional
+	Condition *ConditionSelector `json:"condition,omitempty"`
+
+	// Copy selects the fields of the object at the fromFieldPath that are
+	// copied. Only applies when type is CopyFromCompositeFieldPath. The whole
+	// object is copied if it's not set.
+	// +optional
+	Copy *CopyFilter `json:"copy,omitempty"`
+
 	Patch `json:",inline"`
 }
 
@@ -119,19 +371,142 @@ func (p *ComposedPatch) GetPatchSetName() string {
 	return *p.PatchSetName
 }
 
+// GetConnectionDetailName returns the ConnectionDetailName for this
+// ComposedPatch, or an empty string if it is nil.
+func (p *ComposedPatch) GetConnectionDetailName() string {
+	if p.ConnectionDetailName == nil {
+		return ""
+	}
+	return *p.ConnectionDetailName
+}
+
+// GetCopy returns the Copy filter for this ComposedPatch, or nil if it is nil.
+func (p *ComposedPatch) GetCopy() *CopyFilter {
+	return p.Copy
+}
+
+// GetCondition returns the Condition selector for this ComposedPatch, or nil
+// if it is nil.
+func (p *ComposedPatch) GetCondition() *ConditionSelector {
+	return p.Condition
+}
+
+// A ConditionSelector selects a field of a status condition of a composed
+// resource, for example the message of its Synced condition.
+type ConditionSelector struct {
+	// Type of the condition, e.g. Synced or Ready.
+	Type string `json:"type"`
+
+	// Field of the condition to patch from. The default is Message.
+	// +kubebuilder:validation:Enum=Message;Reason;Status
+	// +kubebuilder:default=Message
+	// +optional
+	Field ConditionField `json:"field,omitempty"`
+}
+
+// GetField returns the field of the condition to patch from, defaulting to
+// ConditionFieldMessage if not specified.
+func (s *ConditionSelector) GetField() ConditionField {
+	if s.Field == "" {
+		return ConditionFieldMessage
+	}
+	return s.Field
+}
+
+// GetWhen returns the When condition for this ComposedPatch, or nil if it is
+// nil.
+func (p *ComposedPatch) GetWhen() *PatchSetCondition {
+	return p.When
+}
+
+// A PatchSetConditionSource is the object a PatchSetCondition reads from.
+type PatchSetConditionSource string
+
+// PatchSetCondition sources.
+const (
+	PatchSetConditionSourceComposite   PatchSetConditionSource = "Composite" // Default
+	PatchSetConditionSourceEnvironment PatchSetConditionSource = "Environment"
+)
+
+// A PatchSetCondition includes a PatchSet only when a field of the composite
+// resource or the environment has a particular value, for example when the
+// composite resource's environment label is prod.
+type PatchSetCondition struct {
+	// Source is the object whose field is compared. Either the observed
+	// Composite resource or the Environment.
+	// +kubebuilder:validation:Enum=Composite;Environment
+	// +kubebuilder:default=Composite
+	// +optional
+	Source PatchSetConditionSource `json:"source,omitempty"`
+
+	// FromFieldPath is the path of a string field of the source, e.g.
+	// metadata.labels.environment.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// Equals is the value the field must have for the PatchSet to be
+	// included. The PatchSet isn't included if the field doesn't exist.
+	Equals string `json:"equals"`
+}
+
+// GetSource returns the source of this PatchSetCondition, defaulting to
+// PatchSetConditionSourceComposite if not specified.
+func (c *PatchSetCondition) GetSource() PatchSetConditionSource {
+	if c.Source == "" {
+		return PatchSetConditionSourceComposite
+	}
+	return c.Source
+}
+
+// A CopyFilter selects the fields of an object that a CopyFromCompositeFieldPath
+// patch copies. Paths are relative to the patch's fromFieldPath, and may
+// contain wildcards.
+type CopyFilter struct {
+	// Include the fields at these paths, and no others. All fields are
+	// included if no paths are specified.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude the fields at these paths. Fields are excluded after the
+	// included fields are selected, so an excluded path may be nested under
+	// an included one.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// IsSensitive returns true if this ComposedPatch or any of its Transforms are
+// sensitive. Patches to connection details are always sensitive.
+func (p *ComposedPatch) IsSensitive() bool {
+	switch p.Type { //nolint:exhaustive // Only connection detail patches are always sensitive.
+	case PatchTypeToConnectionDetail, PatchTypeCombineToConnectionDetail:
+		return true
+	}
+	return p.Patch.IsSensitive()
+}
+
 // PatchSetPatch defines a set of Patches that can be referenced by name by
 // other patches of type PatchSet.
 type PatchSetPatch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its own fields to be set on the ComposedPatch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;ToEnvironmentFieldPath;CombineFromEnvironment;CombineToEnvironment;ConditionToComposite
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
+	// Condition selects the status condition of the observed composed
+	// resource to patch from. Required when type is ConditionToComposite.
+	// +optional
+	Condition *ConditionSelector `json:"condition,omitempty"`
+
 	Patch `json:",inline"`
 }
 
+// GetCondition returns the Condition selector for this PatchSetPatch, or nil
+// if it is nil.
+func (psp *PatchSetPatch) GetCondition() *ConditionSelector {
+	return psp.Condition
+}
+
 // GetType returns the patch type. If the type is not set, it returns the default type.
 func (psp *PatchSetPatch) GetType() PatchType {
 	if psp.Type == "" {
@@ -164,9 +539,43 @@ type Patch struct {
 	// +optional
 	Transforms []Transform `json:"transforms,omitempty"`
 
+	// TransformTimeout is the longest the patch's transforms may take to
+	// resolve, e.g. 5s. The patch fails once it's exceeded, so one slow
+	// transform, like a webhook, can't use up the time Crossplane allows the
+	// whole Function to run. Defaults to no timeout.
+	// +optional
+	TransformTimeout *metav1.Duration `json:"transformTimeout,omitempty"`
+
 	// Policy configures the specifics of patching behaviour.
 	// +optional
 	Policy *PatchPolicy `json:"policy,omitempty"`
+
+	// Sensitive indicates that the values this patch reads and writes are
+	// sensitive, for example because they're derived from connection details.
+	// Sensitive values are redacted from errors, results, and debug logs.
+	// +optional
+	Sensitive *bool `json:"sensitive,omitempty"`
+
+	// Stage at which the patch is applied. Only patches of composed resources
+	// support stages other than Default.
+	// `PreBase` patches are applied before the base template is rendered. The
+	// base template is merged over them, so it takes precedence. Only patches
+	// from the composite resource or environment may use this stage.
+	// `Default` patches are applied after the base template is rendered. This
+	// is the default.
+	// `PostReadiness` patches are applied after the readiness of the composed
+	// resource is evaluated, and only if it's ready.
+	// +optional
+	// +kubebuilder:validation:Enum=PreBase;Default;PostReadiness
+	Stage *PatchStage `json:"stage,omitempty"`
+}
+
+// GetStage returns the stage at which this Patch is applied.
+func (p *Patch) GetStage() PatchStage {
+	if p.Stage == nil {
+		return PatchStageDefault
+	}
+	return *p.Stage
 }
 
 // GetFromFieldPath returns the FromFieldPath for this Patch, or an empty string if it is nil.
@@ -201,6 +610,29 @@ func (p *Patch) GetPolicy() *PatchPolicy {
 	return p.Policy
 }
 
+// GetTransformTimeout returns the TransformTimeout for this Patch, or zero if
+// it is nil.
+func (p *Patch) GetTransformTimeout() time.Duration {
+	if p.TransformTimeout == nil {
+		return 0
+	}
+	return p.TransformTimeout.Duration
+}
+
+// IsSensitive returns true if this Patch or any of its Transforms are
+// sensitive.
+func (p *Patch) IsSensitive() bool {
+	if p.Sensitive != nil && *p.Sensitive {
+		return true
+	}
+	for i := range p.Transforms {
+		if p.Transforms[i].IsSensitive() {
+			return true
+		}
+	}
+	return false
+}
+
 // A CombineVariable defines the source of a value that is combined with
 // others to form and patch an output value. Currently, this only supports
 // retrieving values from a field path.
@@ -2,8 +2,10 @@ package v1beta1
 
 import (
 	"encoding/json"
+	"time"
 
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 )
@@ -15,20 +17,43 @@ type TransformType string
 const (
 	ErrFmtConvertFormatPairNotSupported = "conversion from %s to %s is not supported with format %s"
 
-	TransformTypeMap     TransformType = "map"
-	TransformTypeMatch   TransformType = "match"
-	TransformTypeMath    TransformType = "math"
-	TransformTypeString  TransformType = "string"
-	TransformTypeConvert TransformType = "convert"
+	TransformTypeMap      TransformType = "map"
+	TransformTypeMatch    TransformType = "match"
+	TransformTypeMath     TransformType = "math"
+	TransformTypeString   TransformType = "string"
+	TransformTypeConvert  TransformType = "convert"
+	TransformTypeBool     TransformType = "bool"
+	TransformTypeParse    TransformType = "parse"
+	TransformTypeChecksum TransformType = "checksum"
+	TransformTypeCombine  TransformType = "combine"
+	TransformTypeWebhook  TransformType = "webhook"
+	TransformTypeDig      TransformType = "dig"
 )
 
+// TransformNameInput is the name of the input of a chain of transforms.
+// Transforms can refer to it using fromName, or combine it with other named
+// outputs.
+const TransformNameInput = "input"
+
 // Transform is a unit of process whose input is transformed into an output with
 // the supplied configuration.
 type Transform struct {
 	// Type of the transform to be run.
-	// +kubebuilder:validation:Enum=map;match;math;string;convert
+	// +kubebuilder:validation:Enum=map;match;math;string;convert;bool;parse;checksum;combine;webhook;dig
 	Type TransformType `json:"type"`
 
+	// Name of the output of this transform. Later transforms in the chain can
+	// refer to it using fromName, or combine it with other named outputs. The
+	// original input of the chain is named "input".
+	// +optional
+	Name *string `json:"name,omitempty"`
+
+	// FromName is the name of an earlier output in the chain to use as the
+	// input of this transform, instead of the output of the previous
+	// transform.
+	// +optional
+	FromName *string `json:"fromName,omitempty"`
+
 	// Math is used to transform the input via mathematical operations such as
 	// multiplication.
 	// +optional
@@ -38,6 +63,32 @@ type Transform struct {
 	// +optional
 	Map *MapTransform `json:"map,omitempty"`
 
+	// MapFromEnvironment uses the input as a key in a map read from the
+	// Composition environment and returns the value. Transforms of type map
+	// may specify either map or mapFromEnvironment.
+	// +optional
+	MapFromEnvironment *MapFromEnvironmentTransform `json:"mapFromEnvironment,omitempty"`
+
+	// MapFromData uses the input as a key in a map read from one of the
+	// Input's data documents and returns the value. Transforms of type map may
+	// specify only one of map, mapFromEnvironment, or mapFromData.
+	// +optional
+	MapFromData *MapFromDataTransform `json:"mapFromData,omitempty"`
+
+	// MapIgnoreCase makes the lookups of map transforms case-insensitive, so
+	// an input of "US-West" matches a key of "us-west".
+	// Keys that exactly match the input are preferred. This is configured on
+	// the transform rather than the map because the map's keys are inlined.
+	// +optional
+	MapIgnoreCase *bool `json:"mapIgnoreCase,omitempty"`
+
+	// MapInvert makes map transforms look the input up among the map's values
+	// and return the matching key, so one map can be used by patches in both
+	// directions. The input must match exactly one value. MapIgnoreCase
+	// applies to string values.
+	// +optional
+	MapInvert *bool `json:"mapInvert,omitempty"`
+
 	// Match is a more complex version of Map that matches a list of patterns.
 	// +optional
 	Match *MatchTransform `json:"match,omitempty"`
@@ -50,6 +101,55 @@ type Transform struct {
 	// Convert is used to cast the input into the given output type.
 	// +optional
 	Convert *ConvertTransform `json:"convert,omitempty"`
+
+	// Bool is used to test the input, or to negate a boolean input. It always
+	// produces a boolean.
+	// +optional
+	Bool *BoolTransform `json:"bool,omitempty"`
+
+	// Parse is used to parse a string input into a number.
+	// +optional
+	Parse *ParseTransform `json:"parse,omitempty"`
+
+	// Checksum is used to produce a digest of an object or array input.
+	// +optional
+	Checksum *ChecksumTransform `json:"checksum,omitempty"`
+
+	// Combine is used to combine named outputs of earlier transforms in the
+	// chain.
+	// +optional
+	Combine *CombineTransform `json:"combine,omitempty"`
+
+	// Webhook is used to resolve the input by sending it to an HTTP endpoint.
+	// +optional
+	Webhook *WebhookTransform `json:"webhook,omitempty"`
+
+	// Dig is used to read a value nested within an object or array input.
+	// +optional
+	Dig *DigTransform `json:"dig,omitempty"`
+
+	// Sensitive indicates that the input and output of this transform are
+	// sensitive. Sensitive values are redacted from errors, results, and debug
+	// logs.
+	// +optional
+	Sensitive *bool `json:"sensitive,omitempty"`
+}
+
+// IsSensitive returns true if this Transform is sensitive.
+func (t *Transform) IsSensitive() bool {
+	return t.Sensitive != nil && *t.Sensitive
+}
+
+// GetMapIgnoreCase returns true if this Transform's map lookups are
+// case-insensitive.
+func (t *Transform) GetMapIgnoreCase() bool {
+	return t.MapIgnoreCase != nil && *t.MapIgnoreCase
+}
+
+// GetMapInvert returns true if this Transform's map lookups are inverted, i.e.
+// they look the input up among the map's values and return its key.
+func (t *Transform) GetMapInvert() bool {
+	return t.MapInvert != nil && *t.MapInvert
 }
 
 // GetFormat returns the format of the transform.
@@ -66,20 +166,264 @@ func (t *ConvertTransform) GetFormat() ConvertTransformFormat {
 func (t *Transform) GetOutputType() (*TransformIOType, error) {
 	var out TransformIOType
 	switch t.Type {
-	case TransformTypeMap, TransformTypeMatch:
+	case TransformTypeMap, TransformTypeMatch, TransformTypeWebhook, TransformTypeDig:
 		return nil, nil
 	case TransformTypeMath:
 		out = TransformIOTypeFloat64
-	case TransformTypeString:
+	case TransformTypeString, TransformTypeChecksum, TransformTypeCombine:
 		out = TransformIOTypeString
+		if t.String != nil && t.String.Type == StringTransformTypeLength {
+			out = TransformIOTypeInt64
+		}
 	case TransformTypeConvert:
 		out = t.Convert.ToType
+	case TransformTypeBool:
+		out = TransformIOTypeBool
+	case TransformTypeParse:
+		out = TransformIOTypeInt64
+		if t.Parse != nil && t.Parse.Type == ParseTransformTypeFloat {
+			out = TransformIOTypeFloat64
+		}
 	default:
 		return nil, errors.Errorf("unable to get output type, unknown transform type: %s", t.Type)
 	}
 	return &out, nil
 }
 
+// BoolTransformType produces a boolean.
+type BoolTransformType string
+
+// Accepted BoolTransformTypes.
+const (
+	BoolTransformTypeContains  BoolTransformType = "Contains"
+	BoolTransformTypeHasPrefix BoolTransformType = "HasPrefix"
+	BoolTransformTypeHasSuffix BoolTransformType = "HasSuffix"
+	BoolTransformTypeMatches   BoolTransformType = "Matches"
+	BoolTransformTypeNot       BoolTransformType = "Not"
+)
+
+// A BoolTransform returns a boolean given the supplied input.
+type BoolTransform struct {
+	// Type of the bool transform to be run.
+	// `Contains`, `HasPrefix`, and `HasSuffix` test whether the input string
+	// contains, starts with, or ends with the value.
+	// `Matches` tests whether the input string matches the value, which must
+	// be a regular expression.
+	// `Not` negates the input, which must be a boolean.
+	// +kubebuilder:validation:Enum=Contains;HasPrefix;HasSuffix;Matches;Not
+	Type BoolTransformType `json:"type"`
+
+	// Value to test the input against. Required by all types except `Not`.
+	// +optional
+	Value *string `json:"value,omitempty"`
+}
+
+// ParseTransformType is the type of number a parse transform produces.
+type ParseTransformType string
+
+// Accepted ParseTransformTypes.
+const (
+	ParseTransformTypeInt   ParseTransformType = "Int"
+	ParseTransformTypeFloat ParseTransformType = "Float"
+)
+
+// ParseErrorPolicy configures what happens when a parse transform can't parse
+// its input.
+type ParseErrorPolicy string
+
+// Accepted ParseErrorPolicies.
+const (
+	ParseErrorPolicyError ParseErrorPolicy = "Error"
+	ParseErrorPolicyZero  ParseErrorPolicy = "Zero"
+)
+
+// IsValid returns true if the error policy is valid.
+func (p ParseErrorPolicy) IsValid() bool {
+	switch p {
+	case ParseErrorPolicyError, ParseErrorPolicyZero:
+		return true
+	}
+	return false
+}
+
+// A ParseTransform parses a string input into a number, like Go's strconv
+// package.
+type ParseTransform struct {
+	// Type of the parse transform to be run.
+	// `Int` parses the input as an integer and produces an int64.
+	// `Float` parses the input as a floating point number and produces a
+	// float64.
+	// +kubebuilder:validation:Enum=Int;Float
+	Type ParseTransformType `json:"type"`
+
+	// Base of the integer to parse, between 2 and 36. If the base is 0 it's
+	// inferred from the input's prefix, i.e. 0b for base 2, 0 or 0o for base 8,
+	// and 0x for base 16. Only used by the `Int` type. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=36
+	// +optional
+	Base *int `json:"base,omitempty"`
+
+	// BitSize is the size of the number to parse. Inputs that don't fit are
+	// considered invalid. Must be 8, 16, 32, or 64 for the `Int` type and 32 or
+	// 64 for the `Float` type. Defaults to 64.
+	// +kubebuilder:validation:Enum=8;16;32;64
+	// +optional
+	BitSize *int `json:"bitSize,omitempty"`
+
+	// ErrorPolicy configures what happens when the input can't be parsed.
+	//
+	// * `Error` - returns an error. This is the default.
+	// * `Zero` - produces zero.
+	//
+	// +kubebuilder:validation:Enum=Error;Zero
+	// +optional
+	ErrorPolicy *ParseErrorPolicy `json:"errorPolicy,omitempty"`
+}
+
+// GetBase returns the base of the integer to parse.
+func (t *ParseTransform) GetBase() int {
+	if t.Base != nil {
+		return *t.Base
+	}
+	return 10
+}
+
+// GetBitSize returns the size of the number to parse.
+func (t *ParseTransform) GetBitSize() int {
+	if t.BitSize != nil {
+		return *t.BitSize
+	}
+	return 64
+}
+
+// GetErrorPolicy returns the error policy of the transform.
+func (t *ParseTransform) GetErrorPolicy() ParseErrorPolicy {
+	if t.ErrorPolicy != nil {
+		return *t.ErrorPolicy
+	}
+	return ParseErrorPolicyError
+}
+
+// ChecksumAlgorithm is the algorithm a checksum transform uses.
+type ChecksumAlgorithm string
+
+// Accepted ChecksumAlgorithms.
+const (
+	ChecksumAlgorithmSHA1   ChecksumAlgorithm = "Sha1"
+	ChecksumAlgorithmSHA256 ChecksumAlgorithm = "Sha256"
+	ChecksumAlgorithmSHA512 ChecksumAlgorithm = "Sha512"
+)
+
+// A ChecksumTransform produces the hex encoded digest of an object or array
+// input. The input is canonicalized before it's hashed, so objects with the same
+// keys and values always produce the same digest regardless of key order.
+type ChecksumTransform struct {
+	// Algorithm used to produce the digest. Defaults to `Sha256`.
+	// +kubebuilder:validation:Enum=Sha1;Sha256;Sha512
+	// +optional
+	Algorithm *ChecksumAlgorithm `json:"algorithm,omitempty"`
+}
+
+// GetAlgorithm returns the algorithm used to produce the digest.
+func (t *ChecksumTransform) GetAlgorithm() ChecksumAlgorithm {
+	if t.Algorithm != nil {
+		return *t.Algorithm
+	}
+	return ChecksumAlgorithmSHA256
+}
+
+// A DigTransform reads a value nested within an object or array input.
+type DigTransform struct {
+	// FieldPath of the value within the input, e.g. spec.rules[0].name. Use a
+	// path starting with an index, e.g. [0].name, if the input is an array.
+	FieldPath string `json:"fieldPath"`
+
+	// Default is produced if any segment of the field path doesn't exist. An
+	// error is returned if a segment doesn't exist and no default is
+	// specified.
+	// +optional
+	Default *extv1.JSON `json:"default,omitempty"`
+}
+
+// WebhookFailurePolicy specifies what happens when a webhook transform fails.
+type WebhookFailurePolicy string
+
+// Accepted WebhookFailurePolicies.
+const (
+	WebhookFailurePolicyFail   WebhookFailurePolicy = "Fail" // Default
+	WebhookFailurePolicyIgnore WebhookFailurePolicy = "Ignore"
+)
+
+// A WebhookTransform resolves the input by POSTing it to an HTTP endpoint, for
+// example an IPAM or naming service. The request body is a JSON object with an
+// input field containing the input and a context field containing the
+// context, if any. The endpoint must respond with a 2xx status and a JSON
+// object with an output field containing the output.
+type WebhookTransform struct {
+	// URL of the endpoint. Must be an http or https URL.
+	URL string `json:"url"`
+
+	// Context is additional data sent to the endpoint alongside the input.
+	// +optional
+	Context map[string]string `json:"context,omitempty"`
+
+	// Timeout of the request. Defaults to 10s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// CABundle is a PEM encoded bundle of CA certificates used to verify the
+	// endpoint's certificate. Defaults to the system's CA certificates.
+	// +optional
+	CABundle *string `json:"caBundle,omitempty"`
+
+	// InsecureSkipTLSVerify disables verification of the endpoint's
+	// certificate.
+	// +optional
+	InsecureSkipTLSVerify *bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// FailurePolicy specifies what happens when the request fails.
+	// `Fail` returns an error. This is the default.
+	// `Ignore` produces the input unchanged.
+	// +kubebuilder:validation:Enum=Fail;Ignore
+	// +optional
+	FailurePolicy *WebhookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// GetTimeout returns the timeout of the request.
+func (t *WebhookTransform) GetTimeout() time.Duration {
+	if t.Timeout != nil {
+		return t.Timeout.Duration
+	}
+	return 10 * time.Second
+}
+
+// GetFailurePolicy returns what happens when the request fails.
+func (t *WebhookTransform) GetFailurePolicy() WebhookFailurePolicy {
+	if t.FailurePolicy != nil {
+		return *t.FailurePolicy
+	}
+	return WebhookFailurePolicyFail
+}
+
+// A CombineTransform combines named outputs of earlier transforms in a chain.
+type CombineTransform struct {
+	// Names of the outputs to combine, in order. The original input of the
+	// chain is named "input".
+	// +kubebuilder:validation:MinItems=1
+	Names []string `json:"names"`
+
+	// Strategy defines the strategy to use to combine the named outputs.
+	// Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that the named outputs should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
 // MathTransformType conducts mathematical operations.
 type MathTransformType string
 
@@ -102,12 +446,56 @@ type MathTransform struct {
 	// Multiply the value.
 	// +optional
 	Multiply *int64 `json:"multiply,omitempty"`
+	// MultiplyFloat multiplies the value by a decimal number, e.g. "0.15".
+	// It's a string because CRDs can't reliably represent floating point
+	// numbers. A Multiply math transform must specify either multiply or
+	// multiplyFloat. The result is always a float64.
+	// +optional
+	MultiplyFloat *string `json:"multiplyFloat,omitempty"`
 	// ClampMin makes sure that the value is not smaller than the given value.
 	// +optional
 	ClampMin *int64 `json:"clampMin,omitempty"`
 	// ClampMax makes sure that the value is not bigger than the given value.
 	// +optional
 	ClampMax *int64 `json:"clampMax,omitempty"`
+
+	// Precision is the number of decimal places to round a float64 result
+	// to. Integer results aren't rounded. Results aren't rounded if no
+	// precision is specified. At most 17.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=17
+	Precision *int `json:"precision,omitempty"`
+
+	// Rounding specifies how to round a float64 result to the specified
+	// precision.
+	// `Round` rounds half away from zero.
+	// `Floor` rounds down.
+	// `Ceil` rounds up.
+	// `Truncate` rounds toward zero.
+	// +optional
+	// +kubebuilder:validation:Enum=Round;Floor;Ceil;Truncate
+	// +kubebuilder:default=Round
+	Rounding *MathRounding `json:"rounding,omitempty"`
+}
+
+// MathRounding specifies how a math transform rounds a float64 result.
+type MathRounding string
+
+// Accepted MathRoundings.
+const (
+	MathRoundingRound    MathRounding = "Round" // Default
+	MathRoundingFloor    MathRounding = "Floor"
+	MathRoundingCeil     MathRounding = "Ceil"
+	MathRoundingTruncate MathRounding = "Truncate"
+)
+
+// GetRounding returns how this MathTransform rounds a float64 result.
+func (m *MathTransform) GetRounding() MathRounding {
+	if m.Rounding == nil {
+		return MathRoundingRound
+	}
+	return *m.Rounding
 }
 
 // MapTransform returns a value for the input from the given map.
@@ -134,6 +522,26 @@ func (m *MapTransform) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.Pairs)
 }
 
+// A MapFromEnvironmentTransform returns a value for the input from a map read
+// from the Composition environment.
+type MapFromEnvironmentTransform struct {
+	// FieldPath of the map in the Composition environment. The map is read
+	// after any environment patches are applied.
+	FieldPath string `json:"fieldPath"`
+}
+
+// A MapFromDataTransform returns a value for the input from a map read from
+// one of the Input's data documents.
+type MapFromDataTransform struct {
+	// Name of the data document.
+	Name string `json:"name"`
+
+	// FieldPath of the map within the data document. The whole document is
+	// used as the map if no field path is specified.
+	// +optional
+	FieldPath *string `json:"fieldPath,omitempty"`
+}
+
 // MatchFallbackTo defines how a match operation will fallback.
 type MatchFallbackTo string
 
@@ -159,6 +567,45 @@ type MatchTransform struct {
 	// +kubebuilder:validation:Enum=Value;Input
 	// +kubebuilder:default=Value
 	FallbackTo MatchFallbackTo `json:"fallbackTo,omitempty"`
+
+	// FallbackFrom reads the fallback value from a field of the composite
+	// resource or the environment. If the field doesn't exist the transform
+	// falls back as specified by fallbackTo and fallbackValue.
+	// +optional
+	FallbackFrom *MatchFallbackFrom `json:"fallbackFrom,omitempty"`
+}
+
+// MatchFallbackSource is the object a match transform reads its fallback
+// value from.
+type MatchFallbackSource string
+
+// Valid MatchFallbackSources.
+const (
+	MatchFallbackSourceComposite   MatchFallbackSource = "Composite"
+	MatchFallbackSourceEnvironment MatchFallbackSource = "Environment"
+)
+
+// MatchFallbackFrom specifies a field a match transform reads its fallback
+// value from.
+type MatchFallbackFrom struct {
+	// Source is the object the field is read from. Either the observed
+	// Composite resource or the Environment.
+	// +kubebuilder:validation:Enum=Composite;Environment
+	// +kubebuilder:default=Composite
+	// +optional
+	Source MatchFallbackSource `json:"source,omitempty"`
+
+	// FieldPath of the fallback value, e.g. spec.parameters.instanceType.
+	FieldPath string `json:"fieldPath"`
+}
+
+// GetSource returns the source of the fallback value, defaulting to
+// MatchFallbackSourceComposite if not specified.
+func (f *MatchFallbackFrom) GetSource() MatchFallbackSource {
+	if f.Source == "" {
+		return MatchFallbackSourceComposite
+	}
+	return f.Source
 }
 
 // MatchTransformPatternType defines the type of a MatchTransformPattern.
@@ -168,6 +615,9 @@ type MatchTransformPatternType string
 const (
 	MatchTransformPatternTypeLiteral MatchTransformPatternType = "literal"
 	MatchTransformPatternTypeRegexp  MatchTransformPatternType = "regexp"
+	MatchTransformPatternTypeRange   MatchTransformPatternType = "range"
+	MatchTransformPatternTypeCIDR    MatchTransformPatternType = "cidr"
+	MatchTransformPatternTypeSemver  MatchTransformPatternType = "semver"
 )
 
 // MatchTransformPattern is a transform that returns the value that matches a
@@ -182,7 +632,16 @@ type MatchTransformPattern struct {
 	// which the input string is tested. Crossplane will throw an error if the
 	// key is not a valid regexp.
 	//
-	// +kubebuilder:validation:Enum=literal;regexp
+	// * `range` - the input number has to be within the bounds of the pattern's
+	// range.
+	//
+	// * `cidr` - the input IP address or CIDR has to be within the pattern's
+	// CIDR.
+	//
+	// * `semver` - the input version has to satisfy the pattern's semantic
+	// version constraint.
+	//
+	// +kubebuilder:validation:Enum=literal;regexp;range;cidr;semver
 	// +kubebuilder:default=literal
 	Type MatchTransformPatternType `json:"type"`
 
@@ -194,10 +653,35 @@ type MatchTransformPattern struct {
 	// Is required if `type` is `regexp`.
 	Regexp *string `json:"regexp,omitempty"`
 
+	// Range the input number must be within.
+	// Is required if `type` is `range`.
+	Range *MatchTransformRange `json:"range,omitempty"`
+
+	// CIDR the input IP address or CIDR must be within, e.g. 10.0.0.0/8.
+	// Is required if `type` is `cidr`.
+	CIDR *string `json:"cidr,omitempty"`
+
+	// Semver is a semantic version constraint the input version must satisfy,
+	// e.g. ">=14 <16". Constraints are separated by spaces or commas.
+	// Is required if `type` is `semver`.
+	Semver *string `json:"semver,omitempty"`
+
 	// The value that is used as result of the transform if the pattern matches.
 	Result extv1.JSON `json:"result"`
 }
 
+// A MatchTransformRange is an inclusive range of numbers. At least one bound
+// must be set.
+type MatchTransformRange struct {
+	// Gte is the inclusive lower bound of the range.
+	// +optional
+	Gte *int64 `json:"gte,omitempty"`
+
+	// Lte is the inclusive upper bound of the range.
+	// +optional
+	Lte *int64 `json:"lte,omitempty"`
+}
+
 // StringTransformType transforms a string.
 type StringTransformType string
 
@@ -208,6 +692,49 @@ const (
 	StringTransformTypeTrimPrefix StringTransformType = "TrimPrefix"
 	StringTransformTypeTrimSuffix StringTransformType = "TrimSuffix"
 	StringTransformTypeRegexp     StringTransformType = "Regexp"
+
+	StringTransformTypeEnsurePrefix StringTransformType = "EnsurePrefix"
+	StringTransformTypeEnsureSuffix StringTransformType = "EnsureSuffix"
+	StringTransformTypePadLeft      StringTransformType = "PadLeft"
+	StringTransformTypePadRight     StringTransformType = "PadRight"
+	StringTransformTypeSubstring    StringTransformType = "Substring"
+	StringTransformTypeTruncate     StringTransformType = "Truncate"
+	StringTransformTypeLength       StringTransformType = "Length"
+	StringTransformTypeJoin         StringTransformType = "Join"
+)
+
+// StringJoinQuote is how each element of a joined array is quoted.
+type StringJoinQuote string
+
+// Accepted StringJoinQuotes.
+const (
+	// StringJoinQuoteNone doesn't quote elements.
+	StringJoinQuoteNone StringJoinQuote = "None"
+
+	// StringJoinQuoteSingle wraps each element in single quotes. Single
+	// quotes within an element are escaped by doubling them, as in SQL.
+	StringJoinQuoteSingle StringJoinQuote = "Single"
+
+	// StringJoinQuoteDouble wraps each element in double quotes. Characters
+	// within an element are escaped as they would be in a JSON string.
+	StringJoinQuoteDouble StringJoinQuote = "Double"
+)
+
+// StringLengthUnit is a unit in which the length of a string is measured.
+type StringLengthUnit string
+
+// Accepted StringLengthUnits.
+const (
+	// StringLengthUnitRunes measures length in Unicode code points.
+	StringLengthUnitRunes StringLengthUnit = "Runes"
+
+	// StringLengthUnitGraphemes measures length in user-perceived
+	// characters, e.g. an emoji with a skin tone modifier, or a letter
+	// followed by combining accents, is one grapheme.
+	StringLengthUnitGraphemes StringLengthUnit = "Graphemes"
+
+	// StringLengthUnitBytes measures length in bytes of UTF-8.
+	StringLengthUnitBytes StringLengthUnit = "Bytes"
 )
 
 // StringConversionType converts a string.
@@ -224,6 +751,10 @@ const (
 	StringConversionTypeToSHA256   StringConversionType = "ToSha256"
 	StringConversionTypeToSHA512   StringConversionType = "ToSha512"
 	StringConversionTypeToAdler32  StringConversionType = "ToAdler32"
+
+	StringConversionTypeCollapseWhitespace StringConversionType = "CollapseWhitespace"
+	StringConversionTypeStripControl       StringConversionType = "StripControl"
+	StringConversionTypeCapitalizeWords    StringConversionType = "CapitalizeWords"
 )
 
 // A StringTransform returns a string given the supplied input.
@@ -231,7 +762,7 @@ type StringTransform struct {
 
 	// Type of the string transform to be run.
 	// +optional
-	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp;EnsurePrefix;EnsureSuffix;PadLeft;PadRight;Substring;Truncate;Length;Join
 	// +kubebuilder:default=Format
 	Type StringTransformType `json:"type,omitempty"`
 
@@ -246,17 +777,47 @@ type StringTransform struct {
 	// `ToJson` converts any input value into its raw JSON representation.
 	// `ToSha1`, `ToSha256` and `ToSha512` generate a hash value based on the input
 	// converted to JSON.
+	// `CollapseWhitespace` trims leading and trailing whitespace and replaces
+	// each run of internal whitespace with a single space.
+	// `StripControl` removes control characters, replacing whitespace control
+	// characters like tabs and newlines with a space.
+	// `CapitalizeWords` capitalizes the first letter of each word.
 	// +optional
-	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64;ToJson;ToSha1;ToSha256;ToSha512
+	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64;ToJson;ToSha1;ToSha256;ToSha512;CollapseWhitespace;StripControl;CapitalizeWords
 	Convert *StringConversionType `json:"convert,omitempty"`
 
 	// Trim the prefix or suffix from the input
 	// +optional
 	Trim *string `json:"trim,omitempty"`
 
+	// Ensure the input has the prefix or suffix, adding it only if the input
+	// doesn't already have it.
+	// +optional
+	Ensure *string `json:"ensure,omitempty"`
+
 	// Extract a match from the input using a regular expression.
 	// +optional
 	Regexp *StringTransformRegexp `json:"regexp,omitempty"`
+
+	// Pad the input to a minimum width.
+	// +optional
+	Pad *StringTransformPad `json:"pad,omitempty"`
+
+	// Extract a substring of the input.
+	// +optional
+	Substring *StringTransformSubstring `json:"substring,omitempty"`
+
+	// Truncate the input to a maximum length, without splitting a character.
+	// +optional
+	Truncate *StringTransformTruncate `json:"truncate,omitempty"`
+
+	// Length returns the length of the input as an integer.
+	// +optional
+	Length *StringTransformLength `json:"length,omitempty"`
+
+	// Join the elements of an array input into a string.
+	// +optional
+	Join *StringTransformJoin `json:"join,omitempty"`
 }
 
 // A StringTransformRegexp extracts a match from the input using a regular
@@ -271,6 +832,122 @@ type StringTransformRegexp struct {
 	Group *int `json:"group,omitempty"`
 }
 
+// A StringTransformJoin joins the elements of an array input into a string.
+// Each element is formatted, then quoted, then joined.
+type StringTransformJoin struct {
+	// Separator to place between elements, e.g. ",".
+	Separator string `json:"separator"`
+
+	// Format each element using a Go format string before it's quoted, e.g.
+	// "arn:aws:s3:::%s". See https://golang.org/pkg/fmt/ for details.
+	// Elements are formatted using "%v" by default.
+	// +optional
+	Format *string `json:"fmt,omitempty"`
+
+	// Quote each element. Defaults to None.
+	// +optional
+	// +kubebuilder:validation:Enum=None;Single;Double
+	Quote *StringJoinQuote `json:"quote,omitempty"`
+}
+
+// GetQuote returns the quote of this StringTransformJoin, defaulting to None
+// if not specified.
+func (j *StringTransformJoin) GetQuote() StringJoinQuote {
+	if j.Quote == nil {
+		return StringJoinQuoteNone
+	}
+	return *j.Quote
+}
+
+// A StringTransformPad pads the input to a minimum width.
+type StringTransformPad struct {
+	// Width to pad the input to, in characters. Inputs that are already at
+	// least this wide are not padded. At most 4096.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4096
+	Width int `json:"width"`
+
+	// Fill is the character to pad the input with. Defaults to a space.
+	// +optional
+	Fill *string `json:"fill,omitempty"`
+}
+
+// GetFill returns the fill character of this StringTransformPad, defaulting
+// to a space if not specified.
+func (p *StringTransformPad) GetFill() string {
+	if p.Fill == nil {
+		return " "
+	}
+	return *p.Fill
+}
+
+// A StringTransformSubstring extracts a substring of the input.
+type StringTransformSubstring struct {
+	// Start is the index of the first character of the substring. The
+	// substring is empty if the input is shorter than this.
+	// +kubebuilder:validation:Minimum=0
+	Start int `json:"start"`
+
+	// End is the index of the character after the last character of the
+	// substring. Defaults to the end of the input.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	End *int `json:"end,omitempty"`
+}
+
+// A StringTransformTruncate truncates the input to a maximum length. It never
+// splits a character, so the output is always valid UTF-8.
+type StringTransformTruncate struct {
+	// Length is the maximum length of the output, including any suffix and
+	// hash. Inputs that are no longer than this are not truncated.
+	// +kubebuilder:validation:Minimum=0
+	Length int `json:"length"`
+
+	// Unit in which length is measured. Defaults to Runes.
+	// +optional
+	// +kubebuilder:validation:Enum=Runes;Graphemes;Bytes
+	Unit *StringLengthUnit `json:"unit,omitempty"`
+
+	// Suffix is appended to the input if it's truncated, e.g. "...".
+	// +optional
+	Suffix *string `json:"suffix,omitempty"`
+
+	// HashLength is the number of hexadecimal characters of a SHA-256 hash of
+	// the whole input to append to the input if it's truncated, after any
+	// suffix. The hash keeps truncated outputs unique, e.g. when truncating
+	// names that differ only at the end.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	HashLength *int `json:"hashLength,omitempty"`
+}
+
+// GetUnit returns the unit of this StringTransformTruncate, defaulting to
+// Runes if not specified.
+func (t *StringTransformTruncate) GetUnit() StringLengthUnit {
+	if t.Unit == nil {
+		return StringLengthUnitRunes
+	}
+	return *t.Unit
+}
+
+// A StringTransformLength returns the length of the input.
+type StringTransformLength struct {
+	// Unit in which length is measured. Defaults to Runes.
+	// +optional
+	// +kubebuilder:validation:Enum=Runes;Graphemes;Bytes
+	Unit *StringLengthUnit `json:"unit,omitempty"`
+}
+
+// GetUnit returns the unit of this StringTransformLength, defaulting to Runes
+// if not specified.
+func (t *StringTransformLength) GetUnit() StringLengthUnit {
+	if t == nil || t.Unit == nil {
+		return StringLengthUnitRunes
+	}
+	return *t.Unit
+}
+
 // TransformIOType defines the type of a ConvertTransform.
 type TransformIOType string
 
@@ -325,12 +1002,123 @@ type ConvertTransform struct {
 	//
 	// * `quantity` - parses the input as a K8s [`resource.Quantity`](https://pkg.go.dev/k8s.io/apimachinery/pkg/api/resource#Quantity).
 	// Only used during `string -> float64` conversions.
-	// * `json` - parses the input as a JSON string.
-	// Only used during `string -> object` or `string -> list` conversions.
+	// * `json` - parses the input as a JSON string during `string -> object` or
+	// `string -> array` conversions, and encodes the input as a JSON string
+	// during `object -> string` or `array -> string` conversions.
 	//
 	// If this property is null, the default conversion is applied.
 	//
 	// +kubebuilder:validation:Enum=none;quantity;json
 	// +kubebuilder:validation:Default=none
 	Format *ConvertTransformFormat `json:"format,omitempty"`
+
+	// Base of integers parsed from or formatted as strings, between 2 and 36.
+	// Only used during `string -> int64` and `int64 -> string` conversions.
+	// Defaults to 10.
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=36
+	// +optional
+	Base *int `json:"base,omitempty"`
+
+	// Width is the minimum number of digits of an integer formatted as a
+	// string. Integers with fewer digits are padded with leading zeros. Only
+	// used during `int64 -> string` conversions. At most 4096.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4096
+	// +optional
+	Width *int `json:"width,omitempty"`
+
+	// FloatFormat is how to format a float as a string. Only used during
+	// `float64 -> string` conversions.
+	//
+	// * `decimal` - formats the float without an exponent, e.g. 123.45.
+	// * `scientific` - formats the float with an exponent, e.g. 1.2345e+02.
+	//
+	// Defaults to `decimal`.
+	// +kubebuilder:validation:Enum=decimal;scientific
+	// +optional
+	FloatFormat *ConvertFloatFormat `json:"floatFormat,omitempty"`
+
+	// Precision is the number of digits after the decimal point of a float
+	// formatted as a string. Only used during `float64 -> string`
+	// conversions. Defaults to the smallest number of digits necessary to
+	// represent the float exactly. At most 4096.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4096
+	// +optional
+	Precision *int `json:"precision,omitempty"`
+
+	// LossyPolicy configures what happens when a conversion would lose
+	// information, for example when converting 1.5 to an int64, or 2 to a
+	// bool.
+	//
+	// * `Truncate` - converts the input anyway. This is the default.
+	// * `Error` - returns an error.
+	//
+	// +kubebuilder:validation:Enum=Truncate;Error
+	// +optional
+	LossyPolicy *ConvertLossyPolicy `json:"lossyPolicy,omitempty"`
+}
+
+// GetBase returns the base of the transform, defaulting to 10.
+func (t *ConvertTransform) GetBase() int {
+	if t.Base == nil {
+		return 10
+	}
+	return *t.Base
+}
+
+// GetFloatFormat returns the float format of the transform, defaulting to
+// ConvertFloatFormatDecimal.
+func (t *ConvertTransform) GetFloatFormat() ConvertFloatFormat {
+	if t.FloatFormat == nil {
+		return ConvertFloatFormatDecimal
+	}
+	return *t.FloatFormat
+}
+
+// GetLossyPolicy returns the lossy policy of the transform, defaulting to
+// ConvertLossyPolicyTruncate.
+func (t *ConvertTransform) GetLossyPolicy() ConvertLossyPolicy {
+	if t.LossyPolicy == nil {
+		return ConvertLossyPolicyTruncate
+	}
+	return *t.LossyPolicy
+}
+
+// ConvertFloatFormat defines how to format a float as a string.
+type ConvertFloatFormat string
+
+// Possible ConvertFloatFormat values.
+const (
+	ConvertFloatFormatDecimal    ConvertFloatFormat = "decimal"
+	ConvertFloatFormatScientific ConvertFloatFormat = "scientific"
+)
+
+// IsValid returns true if the float format is valid.
+func (f ConvertFloatFormat) IsValid() bool {
+	switch f {
+	case ConvertFloatFormatDecimal, ConvertFloatFormatScientific:
+		return true
+	}
+	return false
+}
+
+// ConvertLossyPolicy defines what happens when a conversion would lose
+// information.
+type ConvertLossyPolicy string
+
+// Possible ConvertLossyPolicy values.
+const (
+	ConvertLossyPolicyTruncate ConvertLossyPolicy = "Truncate"
+	ConvertLossyPolicyError    ConvertLossyPolicy = "Error"
+)
+
+// IsValid returns true if the lossy policy is valid.
+func (p ConvertLossyPolicy) IsValid() bool {
+	switch p {
+	case ConvertLossyPolicyTruncate, ConvertLossyPolicyError:
+		return true
+	}
+	return false
 }
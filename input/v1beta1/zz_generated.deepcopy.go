@@ -5,10 +5,77 @@
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Assertion) DeepCopyInto(out *Assertion) {
+	*out = *in
+	if in.FieldPath != nil {
+		in, out := &in.FieldPath, &out.FieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Assertion.
+func (in *Assertion) DeepCopy() *Assertion {
+	if in == nil {
+		return nil
+	}
+	out := new(Assertion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BoolTransform) DeepCopyInto(out *BoolTransform) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BoolTransform.
+func (in *BoolTransform) DeepCopy() *BoolTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(BoolTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChecksumTransform) DeepCopyInto(out *ChecksumTransform) {
+	*out = *in
+	if in.Algorithm != nil {
+		in, out := &in.Algorithm, &out.Algorithm
+		*out = new(ChecksumAlgorithm)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChecksumTransform.
+func (in *ChecksumTransform) DeepCopy() *ChecksumTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(ChecksumTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Combine) DeepCopyInto(out *Combine) {
 	*out = *in
@@ -34,6 +101,31 @@ func (in *Combine) DeepCopy() *Combine {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CombineTransform) DeepCopyInto(out *CombineTransform) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(StringCombine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CombineTransform.
+func (in *CombineTransform) DeepCopy() *CombineTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(CombineTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CombineVariable) DeepCopyInto(out *CombineVariable) {
 	*out = *in
@@ -49,6 +141,31 @@ func (in *CombineVariable) DeepCopy() *CombineVariable {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComposedNamespace) DeepCopyInto(out *ComposedNamespace) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedNamespace.
+func (in *ComposedNamespace) DeepCopy() *ComposedNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(ComposedNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComposedPatch) DeepCopyInto(out *ComposedPatch) {
 	*out = *in
@@ -57,6 +174,26 @@ func (in *ComposedPatch) DeepCopyInto(out *ComposedPatch) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.When != nil {
+		in, out := &in.When, &out.When
+		*out = new(PatchSetCondition)
+		**out = **in
+	}
+	if in.ConnectionDetailName != nil {
+		in, out := &in.ConnectionDetailName, &out.ConnectionDetailName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(ConditionSelector)
+		**out = **in
+	}
+	if in.Copy != nil {
+		in, out := &in.Copy, &out.Copy
+		*out = new(CopyFilter)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Patch.DeepCopyInto(&out.Patch)
 }
 
@@ -99,6 +236,31 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Delete != nil {
+		in, out := &in.Delete, &out.Delete
+		*out = new(DeleteCondition)
+		**out = **in
+	}
+	if in.ForceReady != nil {
+		in, out := &in.ForceReady, &out.ForceReady
+		*out = new(ForceReady)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Critical != nil {
+		in, out := &in.Critical, &out.Critical
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReadinessWeight != nil {
+		in, out := &in.ReadinessWeight, &out.ReadinessWeight
+		*out = new(int)
+		**out = **in
+	}
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(ComposedNamespace)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplate.
@@ -111,6 +273,106 @@ func (in *ComposedTemplate) DeepCopy() *ComposedTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Composite) DeepCopyInto(out *Composite) {
+	*out = *in
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]CompositePatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]CompositeCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Composite.
+func (in *Composite) DeepCopy() *Composite {
+	if in == nil {
+		return nil
+	}
+	out := new(Composite)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositeCondition) DeepCopyInto(out *CompositeCondition) {
+	*out = *in
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(corev1.ConditionStatus)
+		**out = **in
+	}
+	if in.StatusFromFieldPath != nil {
+		in, out := &in.StatusFromFieldPath, &out.StatusFromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
+	if in.MessageFromFieldPath != nil {
+		in, out := &in.MessageFromFieldPath, &out.MessageFromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ConditionSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositeCondition.
+func (in *CompositeCondition) DeepCopy() *CompositeCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositeCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CompositePatch) DeepCopyInto(out *CompositePatch) {
+	*out = *in
+	in.Patch.DeepCopyInto(&out.Patch)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositePatch.
+func (in *CompositePatch) DeepCopy() *CompositePatch {
+	if in == nil {
+		return nil
+	}
+	out := new(CompositePatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConditionSelector) DeepCopyInto(out *ConditionSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionSelector.
+func (in *ConditionSelector) DeepCopy() *ConditionSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ConditionSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 	*out = *in
@@ -124,6 +386,11 @@ func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromFieldPaths != nil {
+		in, out := &in.FromFieldPaths, &out.FromFieldPaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Value != nil {
 		in, out := &in.Value, &out.Value
 		*out = new(string)
@@ -141,6 +408,38 @@ func (in *ConnectionDetail) DeepCopy() *ConnectionDetail {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailsPolicy) DeepCopyInto(out *ConnectionDetailsPolicy) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rename != nil {
+		in, out := &in.Rename, &out.Rename
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailsPolicy.
+func (in *ConnectionDetailsPolicy) DeepCopy() *ConnectionDetailsPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailsPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConvertTransform) DeepCopyInto(out *ConvertTransform) {
 	*out = *in
@@ -149,6 +448,31 @@ func (in *ConvertTransform) DeepCopyInto(out *ConvertTransform) {
 		*out = new(ConvertTransformFormat)
 		**out = **in
 	}
+	if in.Base != nil {
+		in, out := &in.Base, &out.Base
+		*out = new(int)
+		**out = **in
+	}
+	if in.Width != nil {
+		in, out := &in.Width, &out.Width
+		*out = new(int)
+		**out = **in
+	}
+	if in.FloatFormat != nil {
+		in, out := &in.FloatFormat, &out.FloatFormat
+		*out = new(ConvertFloatFormat)
+		**out = **in
+	}
+	if in.Precision != nil {
+		in, out := &in.Precision, &out.Precision
+		*out = new(int)
+		**out = **in
+	}
+	if in.LossyPolicy != nil {
+		in, out := &in.LossyPolicy, &out.LossyPolicy
+		*out = new(ConvertLossyPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConvertTransform.
@@ -161,6 +485,46 @@ func (in *ConvertTransform) DeepCopy() *ConvertTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CopyFilter) DeepCopyInto(out *CopyFilter) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CopyFilter.
+func (in *CopyFilter) DeepCopy() *CopyFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(CopyFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeleteCondition) DeepCopyInto(out *DeleteCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeleteCondition.
+func (in *DeleteCondition) DeepCopy() *DeleteCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(DeleteCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Environment) DeepCopyInto(out *Environment) {
 	*out = *in
@@ -171,6 +535,11 @@ func (in *Environment) DeepCopyInto(out *Environment) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Environment.
@@ -183,6 +552,26 @@ func (in *Environment) DeepCopy() *Environment {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DigTransform) DeepCopyInto(out *DigTransform) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DigTransform.
+func (in *DigTransform) DeepCopy() *DigTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(DigTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvironmentPatch) DeepCopyInto(out *EnvironmentPatch) {
 	*out = *in
@@ -199,6 +588,66 @@ func (in *EnvironmentPatch) DeepCopy() *EnvironmentPatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForceReady) DeepCopyInto(out *ForceReady) {
+	*out = *in
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForceReady.
+func (in *ForceReady) DeepCopy() *ForceReady {
+	if in == nil {
+		return nil
+	}
+	out := new(ForceReady)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapFromDataTransform) DeepCopyInto(out *MapFromDataTransform) {
+	*out = *in
+	if in.FieldPath != nil {
+		in, out := &in.FieldPath, &out.FieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapFromDataTransform.
+func (in *MapFromDataTransform) DeepCopy() *MapFromDataTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(MapFromDataTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MapFromEnvironmentTransform) DeepCopyInto(out *MapFromEnvironmentTransform) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapFromEnvironmentTransform.
+func (in *MapFromEnvironmentTransform) DeepCopy() *MapFromEnvironmentTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(MapFromEnvironmentTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MapTransform) DeepCopyInto(out *MapTransform) {
 	*out = *in
@@ -236,6 +685,21 @@ func (in *MatchConditionReadinessCheck) DeepCopy() *MatchConditionReadinessCheck
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchFallbackFrom) DeepCopyInto(out *MatchFallbackFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchFallbackFrom.
+func (in *MatchFallbackFrom) DeepCopy() *MatchFallbackFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchFallbackFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatchTransform) DeepCopyInto(out *MatchTransform) {
 	*out = *in
@@ -247,6 +711,11 @@ func (in *MatchTransform) DeepCopyInto(out *MatchTransform) {
 		}
 	}
 	in.FallbackValue.DeepCopyInto(&out.FallbackValue)
+	if in.FallbackFrom != nil {
+		in, out := &in.FallbackFrom, &out.FallbackFrom
+		*out = new(MatchFallbackFrom)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransform.
@@ -272,6 +741,21 @@ func (in *MatchTransformPattern) DeepCopyInto(out *MatchTransformPattern) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Range != nil {
+		in, out := &in.Range, &out.Range
+		*out = new(MatchTransformRange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CIDR != nil {
+		in, out := &in.CIDR, &out.CIDR
+		*out = new(string)
+		**out = **in
+	}
+	if in.Semver != nil {
+		in, out := &in.Semver, &out.Semver
+		*out = new(string)
+		**out = **in
+	}
 	in.Result.DeepCopyInto(&out.Result)
 }
 
@@ -285,6 +769,31 @@ func (in *MatchTransformPattern) DeepCopy() *MatchTransformPattern {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchTransformRange) DeepCopyInto(out *MatchTransformRange) {
+	*out = *in
+	if in.Gte != nil {
+		in, out := &in.Gte, &out.Gte
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Lte != nil {
+		in, out := &in.Lte, &out.Lte
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransformRange.
+func (in *MatchTransformRange) DeepCopy() *MatchTransformRange {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchTransformRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 	*out = *in
@@ -293,6 +802,11 @@ func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.MultiplyFloat != nil {
+		in, out := &in.MultiplyFloat, &out.MultiplyFloat
+		*out = new(string)
+		**out = **in
+	}
 	if in.ClampMin != nil {
 		in, out := &in.ClampMin, &out.ClampMin
 		*out = new(int64)
@@ -303,6 +817,16 @@ func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.Precision != nil {
+		in, out := &in.Precision, &out.Precision
+		*out = new(int)
+		**out = **in
+	}
+	if in.Rounding != nil {
+		in, out := &in.Rounding, &out.Rounding
+		*out = new(MathRounding)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MathTransform.
@@ -315,6 +839,36 @@ func (in *MathTransform) DeepCopy() *MathTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParseTransform) DeepCopyInto(out *ParseTransform) {
+	*out = *in
+	if in.Base != nil {
+		in, out := &in.Base, &out.Base
+		*out = new(int)
+		**out = **in
+	}
+	if in.BitSize != nil {
+		in, out := &in.BitSize, &out.BitSize
+		*out = new(int)
+		**out = **in
+	}
+	if in.ErrorPolicy != nil {
+		in, out := &in.ErrorPolicy, &out.ErrorPolicy
+		*out = new(ParseErrorPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParseTransform.
+func (in *ParseTransform) DeepCopy() *ParseTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(ParseTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Patch) DeepCopyInto(out *Patch) {
 	*out = *in
@@ -340,29 +894,84 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TransformTimeout != nil {
+		in, out := &in.TransformTimeout, &out.TransformTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	if in.Policy != nil {
 		in, out := &in.Policy, &out.Policy
 		*out = new(PatchPolicy)
 		(*in).DeepCopyInto(*out)
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Patch.
-func (in *Patch) DeepCopy() *Patch {
-	if in == nil {
-		return nil
+	if in.Sensitive != nil {
+		in, out := &in.Sensitive, &out.Sensitive
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Stage != nil {
+		in, out := &in.Stage, &out.Stage
+		*out = new(PatchStage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Patch.
+func (in *Patch) DeepCopy() *Patch {
+	if in == nil {
+		return nil
+	}
+	out := new(Patch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchPolicy) DeepCopyInto(out *PatchPolicy) {
+	*out = *in
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(FromFieldPathPolicy)
+		**out = **in
+	}
+	if in.FromFieldPathDefault != nil {
+		in, out := &in.FromFieldPathDefault, &out.FromFieldPathDefault
+		*out = new(v1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ToFieldPath != nil {
+		in, out := &in.ToFieldPath, &out.ToFieldPath
+		*out = new(ToFieldPathPolicy)
+		**out = **in
+	}
+	if in.MergeKey != nil {
+		in, out := &in.MergeKey, &out.MergeKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.SkipUnchanged != nil {
+		in, out := &in.SkipUnchanged, &out.SkipUnchanged
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CreateOnly != nil {
+		in, out := &in.CreateOnly, &out.CreateOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Freeze != nil {
+		in, out := &in.Freeze, &out.Freeze
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CoerceToExistingType != nil {
+		in, out := &in.CoerceToExistingType, &out.CoerceToExistingType
+		*out = new(bool)
+		**out = **in
 	}
-	out := new(Patch)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PatchPolicy) DeepCopyInto(out *PatchPolicy) {
-	*out = *in
-	if in.FromFieldPath != nil {
-		in, out := &in.FromFieldPath, &out.FromFieldPath
-		*out = new(FromFieldPathPolicy)
+	if in.Memoize != nil {
+		in, out := &in.Memoize, &out.Memoize
+		*out = new(bool)
 		**out = **in
 	}
 }
@@ -399,9 +1008,29 @@ func (in *PatchSet) DeepCopy() *PatchSet {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PatchSetCondition) DeepCopyInto(out *PatchSetCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchSetCondition.
+func (in *PatchSetCondition) DeepCopy() *PatchSetCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(PatchSetCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PatchSetPatch) DeepCopyInto(out *PatchSetPatch) {
 	*out = *in
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(ConditionSelector)
+		**out = **in
+	}
 	in.Patch.DeepCopyInto(&out.Patch)
 }
 
@@ -415,6 +1044,61 @@ func (in *PatchSetPatch) DeepCopy() *PatchSetPatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Propagate) DeepCopyInto(out *Propagate) {
+	*out = *in
+	if in.LabelPrefixes != nil {
+		in, out := &in.LabelPrefixes, &out.LabelPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnnotationPrefixes != nil {
+		in, out := &in.AnnotationPrefixes, &out.AnnotationPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Propagate.
+func (in *Propagate) DeepCopy() *Propagate {
+	if in == nil {
+		return nil
+	}
+	out := new(Propagate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProviderConfigRef) DeepCopyInto(out *ProviderConfigRef) {
+	*out = *in
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.FromEnvironmentFieldPath != nil {
+		in, out := &in.FromEnvironmentFieldPath, &out.FromEnvironmentFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProviderConfigRef.
+func (in *ProviderConfigRef) DeepCopy() *ProviderConfigRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ProviderConfigRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	*out = *in
@@ -450,6 +1134,31 @@ func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessPolicy) DeepCopyInto(out *ReadinessPolicy) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quorum != nil {
+		in, out := &in.Quorum, &out.Quorum
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessPolicy.
+func (in *ReadinessPolicy) DeepCopy() *ReadinessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resources) DeepCopyInto(out *Resources) {
 	*out = *in
@@ -467,6 +1176,55 @@ func (in *Resources) DeepCopyInto(out *Resources) {
 		*out = new(Environment)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make([]FeatureGate, len(*in))
+		copy(*out, *in)
+	}
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = make(map[string]v1.JSON, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Propagate != nil {
+		in, out := &in.Propagate, &out.Propagate
+		*out = new(Propagate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProviderConfigRef != nil {
+		in, out := &in.ProviderConfigRef, &out.ProviderConfigRef
+		*out = new(ProviderConfigRef)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionDetails != nil {
+		in, out := &in.ConnectionDetails, &out.ConnectionDetails
+		*out = new(ConnectionDetailsPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Composite != nil {
+		in, out := &in.Composite, &out.Composite
+		*out = new(Composite)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(ReadinessPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Assertions != nil {
+		in, out := &in.Assertions, &out.Assertions
+		*out = make([]Assertion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.WarnSkippedPatchesAfter != nil {
+		in, out := &in.WarnSkippedPatchesAfter, &out.WarnSkippedPatchesAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]ComposedTemplate, len(*in))
@@ -527,11 +1285,41 @@ func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Ensure != nil {
+		in, out := &in.Ensure, &out.Ensure
+		*out = new(string)
+		**out = **in
+	}
 	if in.Regexp != nil {
 		in, out := &in.Regexp, &out.Regexp
 		*out = new(StringTransformRegexp)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Pad != nil {
+		in, out := &in.Pad, &out.Pad
+		*out = new(StringTransformPad)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Substring != nil {
+		in, out := &in.Substring, &out.Substring
+		*out = new(StringTransformSubstring)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Truncate != nil {
+		in, out := &in.Truncate, &out.Truncate
+		*out = new(StringTransformTruncate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Length != nil {
+		in, out := &in.Length, &out.Length
+		*out = new(StringTransformLength)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Join != nil {
+		in, out := &in.Join, &out.Join
+		*out = new(StringTransformJoin)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransform.
@@ -544,6 +1332,26 @@ func (in *StringTransform) DeepCopy() *StringTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformPad) DeepCopyInto(out *StringTransformPad) {
+	*out = *in
+	if in.Fill != nil {
+		in, out := &in.Fill, &out.Fill
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformPad.
+func (in *StringTransformPad) DeepCopy() *StringTransformPad {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformPad)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringTransformRegexp) DeepCopyInto(out *StringTransformRegexp) {
 	*out = *in
@@ -564,9 +1372,39 @@ func (in *StringTransformRegexp) DeepCopy() *StringTransformRegexp {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformSubstring) DeepCopyInto(out *StringTransformSubstring) {
+	*out = *in
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformSubstring.
+func (in *StringTransformSubstring) DeepCopy() *StringTransformSubstring {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformSubstring)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transform) DeepCopyInto(out *Transform) {
 	*out = *in
+	if in.Name != nil {
+		in, out := &in.Name, &out.Name
+		*out = new(string)
+		**out = **in
+	}
+	if in.FromName != nil {
+		in, out := &in.FromName, &out.FromName
+		*out = new(string)
+		**out = **in
+	}
 	if in.Math != nil {
 		in, out := &in.Math, &out.Math
 		*out = new(MathTransform)
@@ -577,6 +1415,26 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(MapTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.MapFromEnvironment != nil {
+		in, out := &in.MapFromEnvironment, &out.MapFromEnvironment
+		*out = new(MapFromEnvironmentTransform)
+		**out = **in
+	}
+	if in.MapFromData != nil {
+		in, out := &in.MapFromData, &out.MapFromData
+		*out = new(MapFromDataTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MapIgnoreCase != nil {
+		in, out := &in.MapIgnoreCase, &out.MapIgnoreCase
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MapInvert != nil {
+		in, out := &in.MapInvert, &out.MapInvert
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Match != nil {
 		in, out := &in.Match, &out.Match
 		*out = new(MatchTransform)
@@ -592,6 +1450,41 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 		*out = new(ConvertTransform)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Bool != nil {
+		in, out := &in.Bool, &out.Bool
+		*out = new(BoolTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Parse != nil {
+		in, out := &in.Parse, &out.Parse
+		*out = new(ParseTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Checksum != nil {
+		in, out := &in.Checksum, &out.Checksum
+		*out = new(ChecksumTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(CombineTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dig != nil {
+		in, out := &in.Dig, &out.Dig
+		*out = new(DigTransform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sensitive != nil {
+		in, out := &in.Sensitive, &out.Sensitive
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transform.
@@ -618,3 +1511,120 @@ func (in *TypeReference) DeepCopy() *TypeReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTransform) DeepCopyInto(out *WebhookTransform) {
+	*out = *in
+	if in.Context != nil {
+		in, out := &in.Context, &out.Context
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(string)
+		**out = **in
+	}
+	if in.InsecureSkipTLSVerify != nil {
+		in, out := &in.InsecureSkipTLSVerify, &out.InsecureSkipTLSVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(WebhookFailurePolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTransform.
+func (in *WebhookTransform) DeepCopy() *WebhookTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformJoin) DeepCopyInto(out *StringTransformJoin) {
+	*out = *in
+	if in.Format != nil {
+		in, out := &in.Format, &out.Format
+		*out = new(string)
+		**out = **in
+	}
+	if in.Quote != nil {
+		in, out := &in.Quote, &out.Quote
+		*out = new(StringJoinQuote)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformJoin.
+func (in *StringTransformJoin) DeepCopy() *StringTransformJoin {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformJoin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformLength) DeepCopyInto(out *StringTransformLength) {
+	*out = *in
+	if in.Unit != nil {
+		in, out := &in.Unit, &out.Unit
+		*out = new(StringLengthUnit)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformLength.
+func (in *StringTransformLength) DeepCopy() *StringTransformLength {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformLength)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformTruncate) DeepCopyInto(out *StringTransformTruncate) {
+	*out = *in
+	if in.Unit != nil {
+		in, out := &in.Unit, &out.Unit
+		*out = new(StringLengthUnit)
+		**out = **in
+	}
+	if in.Suffix != nil {
+		in, out := &in.Suffix, &out.Suffix
+		*out = new(string)
+		**out = **in
+	}
+	if in.HashLength != nil {
+		in, out := &in.HashLength, &out.HashLength
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformTruncate.
+func (in *StringTransformTruncate) DeepCopy() *StringTransformTruncate {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformTruncate)
+	in.DeepCopyInto(out)
+	return out
+}
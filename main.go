@@ -1,33 +1,145 @@
-// Package main implements a Composition Function.
+// Package main implements a Composition Function. Its patch, transform,
+// readiness, and connection detail logic live in importable packages under pkg,
+// so other Functions and tools can reuse the same semantics.
 package main
 
 import (
+	"context"
+	"os"
+	"time"
+
 	"github.com/alecthomas/kong"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
 
 	"github.com/crossplane/function-sdk-go"
 )
 
 // CLI of this Function.
 type CLI struct {
-	Debug bool `short:"d" help:"Emit debug logs in addition to info logs."`
+	Serve            ServeCmd            `cmd:"" default:"withargs" help:"Serve the Function via gRPC. This is the default command."`
+	Convert          ConvertCmd          `cmd:"" help:"Convert a Composition that uses native patch and transform to use this Function."`
+	Render           RenderCmd           `cmd:"" help:"Render the Function's input locally, without a Crossplane control plane."`
+	Test             TestCmd             `cmd:"" help:"Render directories of test cases and compare the output to golden files."`
+	ExtractPatchSets ExtractPatchSetsCmd `cmd:"" help:"Factor patches that are repeated across resource templates into PatchSets."`
+}
+
+// ServeCmd serves this Function.
+type ServeCmd struct {
+	Debug        bool `short:"d" help:"Emit debug logs in addition to info logs."`
+	DebugPatches bool `help:"Log the values each patch reads, transforms, and writes. Implies --debug."`
 
 	Network     string `help:"Network on which to listen for gRPC connections." default:"tcp"`
 	Address     string `help:"Address at which to listen for gRPC connections." default:":9443"`
 	TLSCertsDir string `help:"Directory containing server certs (tls.key, tls.crt) and the CA used to verify client certificates (ca.crt)" env:"TLS_SERVER_CERTS_DIR"`
 	Insecure    bool   `help:"Run without mTLS credentials. If you supply this flag --tls-server-certs-dir will be ignored."`
+
+	HealthAddress  string `help:"Address at which to serve HTTP health checks at /healthz, and capability information at /debug/info, e.g. :8081. The endpoints are unauthenticated. Disabled by default."`
+	MetricsAddress string `help:"Address at which to serve Prometheus metrics at /metrics, e.g. :8080. Disabled by default."`
+	PreviewAddress string `help:"Address at which to serve previews of rendered resources, with a trace of each patch, at /preview. Intended for local development only; the endpoint is unauthenticated. Disabled by default."`
+
+	MaxMessageSize   int           `help:"Maximum size in MiB of a gRPC message the Function will send or receive." default:"4" env:"GRPC_MAX_MESSAGE_SIZE"`
+	KeepaliveTime    time.Duration `help:"How often to ping idle clients to check whether the connection is still alive. Zero uses the gRPC default of 2h." env:"GRPC_KEEPALIVE_TIME"`
+	KeepaliveTimeout time.Duration `help:"How long to wait for a keepalive ping to be acknowledged before closing the connection." default:"20s" env:"GRPC_KEEPALIVE_TIMEOUT"`
+
+	MaxConcurrency int `help:"Maximum number of resource templates to process concurrently." default:"4"`
+
+	DedupeWarningsFor time.Duration `help:"Return each warning result at most once within this duration for each composite resource. Zero disables deduplication." default:"0s"`
+
+	DefaultsFile string `help:"A YAML file of defaults that are merged under every input, e.g. mounted from a ConfigMap." env:"DEFAULTS_FILE" type:"existingfile" xor:"defaults"`
+	Defaults     string `help:"Defaults that are merged under every input, as inline YAML. Useful to set them from an environment variable." env:"DEFAULTS" xor:"defaults"`
+
+	PrintSchema bool `help:"Print a JSON Schema describing the Function's input, then exit."`
 }
 
 // Run this Function.
-func (c *CLI) Run() error {
-	log, err := function.NewLogger(c.Debug)
+func (c *ServeCmd) Run() error {
+	if c.PrintSchema {
+		s, err := InputSchema()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(s)
+		return err
+	}
+
+	log, err := function.NewLogger(c.Debug || c.DebugPatches)
+	if err != nil {
+		return err
+	}
+
+	stop, err := SetupTracing(context.Background())
 	if err != nil {
 		return err
 	}
+	defer stop(context.Background()) //nolint:errcheck // There's not much we can do if we can't flush spans.
+
+	var creds credentials.TransportCredentials
+	switch {
+	case c.Insecure:
+		creds = insecure.NewCredentials()
+	case c.TLSCertsDir != "":
+		creds, err = NewMTLSCredentials(c.TLSCertsDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	f := &Function{log: log, debugPatches: c.DebugPatches, maxConcurrency: c.MaxConcurrency}
+	if c.DedupeWarningsFor > 0 {
+		f.dedupe = NewWarningDeduplicator(c.DedupeWarningsFor)
+	}
+	if f.defaults, err = c.loadDefaults(); err != nil {
+		return err
+	}
 
-	return function.Serve(&Function{log: log},
-		function.Listen(c.Network, c.Address),
-		function.MTLSCertificates(c.TLSCertsDir),
-		function.Insecure(c.Insecure))
+	errs := make(chan error, 4)
+	if c.HealthAddress != "" {
+		go func() { errs <- ServeHealth(c.HealthAddress) }()
+	}
+	if c.MetricsAddress != "" {
+		f.metrics = NewMetrics()
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(f.metrics)
+		go func() { errs <- ServeMetrics(c.MetricsAddress, reg) }()
+	}
+	if c.PreviewAddress != "" {
+		go func() { errs <- ServePreview(c.PreviewAddress) }()
+	}
+	go func() {
+		errs <- Serve(f,
+			c.Network, c.Address, creds,
+			grpc.MaxRecvMsgSize(c.MaxMessageSize*1024*1024),
+			grpc.MaxSendMsgSize(c.MaxMessageSize*1024*1024),
+			grpc.KeepaliveParams(keepalive.ServerParameters{
+				Time:    c.KeepaliveTime,
+				Timeout: c.KeepaliveTimeout,
+			}))
+	}()
+
+	// Return as soon as either server fails.
+	return <-errs
+}
+
+// loadDefaults returns the defaults configured by the --defaults-file or
+// --defaults flags, or nil if neither is set.
+func (c *ServeCmd) loadDefaults() (*Defaults, error) {
+	switch {
+	case c.DefaultsFile != "":
+		b, err := os.ReadFile(c.DefaultsFile)
+		if err != nil {
+			return nil, errors.Wrap(err, errReadDefaults)
+		}
+		return ParseDefaults(b)
+	case c.Defaults != "":
+		return ParseDefaults([]byte(c.Defaults))
+	}
+	return nil, nil
 }
 
 func main() {
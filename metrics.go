@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// MetricsPath is the HTTP path at which the Function serves metrics.
+const MetricsPath = "/metrics"
+
+// Error strings
+const (
+	errServeMetrics = "cannot serve HTTP metrics"
+)
+
+// deprecatedFeatures are the deprecated features of the function's input, by
+// name. Add a feature here when deprecating it, so its usage is counted and
+// maintainers can tell when it's safe to remove.
+var deprecatedFeatures = map[string]func(r *v1beta1.Resources) bool{}
+
+// Metrics counts which of the Function's capabilities are used by the inputs
+// it's run with, so maintainers and platform teams can see what's actually in
+// use before deprecating or optimizing it.
+type Metrics struct {
+	transforms *prometheus.CounterVec
+	deprecated *prometheus.CounterVec
+}
+
+// NewMetrics returns Metrics with all counters at zero.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		transforms: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "function_patch_and_transform",
+			Name:      "transforms_total",
+			Help:      "Number of transforms in the Function's inputs, by transform type. Each transform is counted each time the Function runs.",
+		}, []string{"type"}),
+		deprecated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "function_patch_and_transform",
+			Name:      "deprecated_features_total",
+			Help:      "Number of times the Function ran with an input that uses a deprecated feature, by feature.",
+		}, []string{"feature"}),
+	}
+}
+
+// Describe sends the descriptors of the counters to the supplied channel.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.transforms.Describe(ch)
+	m.deprecated.Describe(ch)
+}
+
+// Collect sends the counters to the supplied channel.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.transforms.Collect(ch)
+	m.deprecated.Collect(ch)
+}
+
+// Observe counts the transforms and deprecated features used by the supplied
+// input. Transforms of patches in PatchSets are counted once per PatchSet, not
+// once per resource template that includes it.
+func (m *Metrics) Observe(r *v1beta1.Resources) {
+	count := func(ts []v1beta1.Transform) {
+		for _, t := range ts {
+			m.transforms.WithLabelValues(string(t.Type)).Inc()
+		}
+	}
+
+	for _, ps := range r.PatchSets {
+		for _, p := range ps.Patches {
+			count(p.Transforms)
+		}
+	}
+	if r.Environment != nil {
+		for _, p := range r.Environment.Patches {
+			count(p.Transforms)
+		}
+	}
+	if r.Composite != nil {
+		for _, p := range r.Composite.Patches {
+			count(p.Transforms)
+		}
+	}
+	for _, t := range r.Resources {
+		for _, p := range t.Patches {
+			count(p.Transforms)
+		}
+	}
+
+	for name, used := range deprecatedFeatures {
+		if used(r) {
+			m.deprecated.WithLabelValues(name).Inc()
+		}
+	}
+}
+
+// ServeMetrics serves Prometheus metrics gathered from the supplied gatherer
+// at the supplied address. Blocks until the server returns an error.
+func ServeMetrics(address string, g prometheus.Gatherer) error {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.HandlerFor(g, promhttp.HandlerOpts{}))
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return errors.Wrap(srv.ListenAndServe(), errServeMetrics)
+}
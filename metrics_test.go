@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestMetricsObserve(t *testing.T) {
+	deprecatedFeatures["CoolFeature"] = func(r *v1beta1.Resources) bool { return r.Composite != nil }
+	defer delete(deprecatedFeatures, "CoolFeature")
+
+	m := NewMetrics()
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+
+	m.Observe(&v1beta1.Resources{
+		PatchSets: []v1beta1.PatchSet{{
+			Name: "cool-patchset",
+			Patches: []v1beta1.PatchSetPatch{{
+				Patch: v1beta1.Patch{Transforms: []v1beta1.Transform{{Type: v1beta1.TransformTypeMap}}},
+			}},
+		}},
+		Composite: &v1beta1.Composite{
+			Patches: []v1beta1.CompositePatch{{
+				Patch: v1beta1.Patch{Transforms: []v1beta1.Transform{{Type: v1beta1.TransformTypeString}}},
+			}},
+		},
+		Resources: []v1beta1.ComposedTemplate{{
+			Name: "cool-resource",
+			Patches: []v1beta1.ComposedPatch{{
+				Patch: v1beta1.Patch{Transforms: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMap},
+					{Type: v1beta1.TransformTypeMath},
+				}},
+			}},
+		}},
+	})
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather(): %v", err)
+	}
+	got := map[string]float64{}
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			got[mf.GetName()+"/"+metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+		}
+	}
+
+	want := map[string]float64{
+		"function_patch_and_transform_transforms_total/map":                  2,
+		"function_patch_and_transform_transforms_total/math":                 1,
+		"function_patch_and_transform_transforms_total/string":               1,
+		"function_patch_and_transform_deprecated_features_total/CoolFeature": 1,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Observe(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+// NowField is a virtual top-level field of the observed composite resource.
+// Patches from the composite resource can read the time the Function started
+// running from it, e.g. using fromFieldPath: now.timestamp. Every patch reads
+// the same time, so it's stable within a RunFunction call.
+const NowField = "now"
+
+type nowKey struct{}
+
+// WithNow returns a context that makes the Function use the supplied time as
+// the current time, for example so that rendering the same input always
+// produces the same output.
+func WithNow(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, nowKey{}, t)
+}
+
+// Now returns the time carried by the supplied context, or the current time if
+// it doesn't carry one.
+func Now(ctx context.Context) time.Time {
+	if t, ok := ctx.Value(nowKey{}).(time.Time); ok {
+		return t
+	}
+	return time.Now()
+}
+
+// SetNowField sets the virtual now field of the supplied observed composite
+// resource to the supplied time. The field contains the time as an RFC 3339
+// timestamp, a date, and seconds since the Unix epoch, all in UTC.
+func SetNowField(xr *composite.Unstructured, t time.Time) {
+	t = t.UTC()
+	xr.Object[NowField] = map[string]any{
+		"timestamp": t.Format(time.RFC3339),
+		"date":      t.Format(time.DateOnly),
+		"unix":      t.Unix(),
+	}
+}
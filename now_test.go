@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+)
+
+func TestNow(t *testing.T) {
+	want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	if got := Now(WithNow(context.Background(), want)); !got.Equal(want) {
+		t.Errorf("Now(WithNow(...)): want %s, got %s", want, got)
+	}
+	if got := Now(context.Background()); got.IsZero() {
+		t.Errorf("Now(...): want the current time, got the zero time")
+	}
+}
+
+func TestSetNowField(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		t      time.Time
+		want   map[string]any
+	}{
+		"UTC": {
+			reason: "We should set the now field to the supplied time.",
+			t:      time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+			want: map[string]any{
+				"timestamp": "2024-01-02T15:04:05Z",
+				"date":      "2024-01-02",
+				"unix":      int64(1704207845),
+			},
+		},
+		"OtherTimeZone": {
+			reason: "We should convert the supplied time to UTC.",
+			t:      time.Date(2024, 1, 2, 23, 4, 5, 0, time.FixedZone("UTC+9", 9*60*60)),
+			want: map[string]any{
+				"timestamp": "2024-01-02T14:04:05Z",
+				"date":      "2024-01-02",
+				"unix":      int64(1704204245),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}}
+			SetNowField(xr, tc.t)
+			got, _ := xr.Object[NowField].(map[string]any)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSetNowField(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
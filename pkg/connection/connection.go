@@ -0,0 +1,154 @@
+// Package connection extracts, filters, and validates the connection details
+// function-patch-and-transform publishes.
+package connection
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+)
+
+// DetailsExtractor extracts the connection details of a resource.
+type DetailsExtractor interface {
+	// ExtractConnection of the supplied resource.
+	ExtractConnection(cd resource.Composed, conn managed.ConnectionDetails, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error)
+}
+
+// A DetailsExtractorFn is a function that satisfies
+// DetailsExtractor.
+type DetailsExtractorFn func(cd resource.Composed, conn managed.ConnectionDetails, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error)
+
+// ExtractConnection of the supplied resource.
+func (fn DetailsExtractorFn) ExtractConnection(cd resource.Composed, conn managed.ConnectionDetails, cfg ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
+	return fn(cd, conn, cfg...)
+}
+
+// ExtractDetails extracts XR connection details from the supplied
+// composed resource. If no ExtractConfigs are supplied no connection details
+// will be returned.
+func ExtractDetails(cd resource.Composed, data managed.ConnectionDetails, cfgs ...v1beta1.ConnectionDetail) (managed.ConnectionDetails, error) {
+	out := map[string][]byte{}
+	for _, cfg := range cfgs {
+		if err := ValidateDetail(cfg); err != nil {
+			return nil, errors.Wrap(err, "invalid")
+		}
+		switch cfg.Type {
+		case v1beta1.ConnectionDetailTypeFromValue:
+			out[cfg.Name] = []byte(*cfg.Value)
+		case v1beta1.ConnectionDetailTypeFromConnectionSecretKey:
+			if data[*cfg.FromConnectionSecretKey] == nil {
+				// We don't consider this an error because it's possible the
+				// key will still be written at some point in the future.
+				continue
+			}
+			out[cfg.Name] = data[*cfg.FromConnectionSecretKey]
+		case v1beta1.ConnectionDetailTypeFromFieldPath:
+			// Note we're checking that the error _is_ nil. If we hit an error
+			// we silently try the next path, and avoid including this
+			// connection secret if there isn't one. It's possible a path will
+			// start existing with a valid value in future.
+			for _, path := range cfg.GetFromFieldPaths() {
+				if b, err := fromFieldPath(cd, path); err == nil {
+					out[cfg.Name] = b
+					break
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// fromFieldPath tries to read the value from the supplied field path first as a
+// plain string. If this fails, it falls back to reading it as JSON.
+func fromFieldPath(from runtime.Object, path string) ([]byte, error) {
+	paved, err := fieldpaths.Pave(from)
+	if err != nil {
+		return nil, err
+	}
+
+	str, err := paved.GetString(path)
+	if err == nil {
+		return []byte(str), nil
+	}
+
+	in, err := paved.GetValue(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(in)
+}
+
+// FilterDetails returns the supplied connection details that should
+// be published per the supplied policy, renamed as configured. All connection
+// details are published if the policy is nil.
+func FilterDetails(conn managed.ConnectionDetails, p *v1beta1.ConnectionDetailsPolicy) managed.ConnectionDetails {
+	if p == nil {
+		return conn
+	}
+	include := make(map[string]bool, len(p.Include))
+	for _, k := range p.Include {
+		include[k] = true
+	}
+	exclude := make(map[string]bool, len(p.Exclude))
+	for _, k := range p.Exclude {
+		exclude[k] = true
+	}
+
+	out := managed.ConnectionDetails{}
+	renamed := managed.ConnectionDetails{}
+	for k, v := range conn {
+		if (len(include) > 0 && !include[k]) || exclude[k] {
+			continue
+		}
+		if to, ok := p.Rename[k]; ok {
+			renamed[to] = v
+			continue
+		}
+		out[k] = v
+	}
+	for k, v := range renamed {
+		out[k] = v
+	}
+	return out
+}
+
+// ValidateDetail checks if the connection detail is logically valid.
+func ValidateDetail(cd v1beta1.ConnectionDetail) *field.Error {
+	if cd.Type == "" {
+		return field.Required(field.NewPath("type"), "connection detail type is required")
+	}
+	if !cd.Type.IsValid() {
+		return field.Invalid(field.NewPath("type"), string(cd.Type), "unknown connection detail type")
+	}
+	if cd.Name == "" {
+		return field.Required(field.NewPath("name"), "name is required")
+	}
+	switch cd.Type {
+	case v1beta1.ConnectionDetailTypeFromValue:
+		if cd.Value == nil {
+			return field.Required(field.NewPath("value"), "value connection detail requires a value")
+		}
+	case v1beta1.ConnectionDetailTypeFromConnectionSecretKey:
+		if cd.FromConnectionSecretKey == nil {
+			return field.Required(field.NewPath("fromConnectionSecretKey"), "from connection secret key connection detail requires a key")
+		}
+	case v1beta1.ConnectionDetailTypeFromFieldPath:
+		if cd.FromFieldPath == nil && len(cd.FromFieldPaths) == 0 {
+			return field.Required(field.NewPath("fromFieldPath"), "from field path connection detail requires a field path")
+		}
+		for i, p := range cd.FromFieldPaths {
+			if p == "" {
+				return field.Required(field.NewPath("fromFieldPaths").Index(i), "field path must not be empty")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package connection
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestExtractDetails(t *testing.T) {
+	type args struct {
+		cd   resource.Composed
+		data managed.ConnectionDetails
+		cfg  []v1beta1.ConnectionDetail
+	}
+	type want struct {
+		conn managed.ConnectionDetails
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"MissingNameError": {
+			reason: "We should return an error if a connection detail is missing a name.",
+			args: args{
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						// A nameless connection detail.
+						Type: v1beta1.ConnectionDetailTypeFromValue,
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errors.New("name: Required value: name is required"), "invalid"),
+			},
+		},
+		"FetchConfigSuccess": {
+			reason: "Should extract only the selected set of secret keys",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:       "test",
+						Generation: 4,
+					},
+				},
+				data: managed.ConnectionDetails{
+					"foo": []byte("a"),
+					"bar": []byte("b"),
+				},
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "bar",
+						FromConnectionSecretKey: ptr.To[string]("bar"),
+					},
+					{
+						Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "none",
+						FromConnectionSecretKey: ptr.To[string]("none"),
+					},
+					{
+						Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+						Name:                    "convfoo",
+						FromConnectionSecretKey: ptr.To[string]("foo"),
+					},
+					{
+						Type:  v1beta1.ConnectionDetailTypeFromValue,
+						Name:  "fixed",
+						Value: ptr.To[string]("value"),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:          "name",
+						FromFieldPath: ptr.To[string]("objectMeta.name"),
+					},
+					{
+						Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:          "generation",
+						FromFieldPath: ptr.To[string]("objectMeta.generation"),
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"convfoo":    []byte("a"),
+					"bar":        []byte("b"),
+					"fixed":      []byte("value"),
+					"name":       []byte("test"),
+					"generation": []byte("4"),
+				},
+			},
+		},
+		"FallbackFieldPaths": {
+			reason: "Should extract the first of the supplied field paths that exists",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:       "test",
+						Generation: 4,
+					},
+				},
+				cfg: []v1beta1.ConnectionDetail{
+					{
+						Type:           v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:           "endpoint",
+						FromFieldPath:  ptr.To[string]("status.atProvider.endpoint"),
+						FromFieldPaths: []string{"status.atProvider.address", "objectMeta.name", "objectMeta.generation"},
+					},
+					{
+						Type:           v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:           "generation",
+						FromFieldPaths: []string{"status.atProvider.generation", "objectMeta.generation"},
+					},
+					{
+						Type:           v1beta1.ConnectionDetailTypeFromFieldPath,
+						Name:           "none",
+						FromFieldPaths: []string{"status.atProvider.address", "status.atProvider.endpoint"},
+					},
+				},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"endpoint":   []byte("test"),
+					"generation": []byte("4"),
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			conn, err := ExtractDetails(tc.args.cd, tc.args.data, tc.args.cfg...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExtractDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.conn, conn, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\nExtractDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFilterDetails(t *testing.T) {
+	conn := managed.ConnectionDetails{
+		"username": []byte("cool-user"),
+		"password": []byte("cool-pass"),
+		"endpoint": []byte("cool.example.org"),
+		"internal": []byte("secret"),
+	}
+
+	type args struct {
+		conn managed.ConnectionDetails
+		p    *v1beta1.ConnectionDetailsPolicy
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   managed.ConnectionDetails
+	}{
+		"NoPolicy": {
+			reason: "All connection details should be published if there's no policy.",
+			args: args{
+				conn: conn,
+			},
+			want: conn,
+		},
+		"Include": {
+			reason: "Only included connection details should be published.",
+			args: args{
+				conn: conn,
+				p:    &v1beta1.ConnectionDetailsPolicy{Include: []string{"username", "password"}},
+			},
+			want: managed.ConnectionDetails{
+				"username": []byte("cool-user"),
+				"password": []byte("cool-pass"),
+			},
+		},
+		"Exclude": {
+			reason: "Excluded connection details shouldn't be published, even if they're included.",
+			args: args{
+				conn: conn,
+				p: &v1beta1.ConnectionDetailsPolicy{
+					Include: []string{"username", "internal"},
+					Exclude: []string{"internal"},
+				},
+			},
+			want: managed.ConnectionDetails{
+				"username": []byte("cool-user"),
+			},
+		},
+		"Rename": {
+			reason: "Renamed connection details should be published using their new key, replacing any existing key.",
+			args: args{
+				conn: conn,
+				p: &v1beta1.ConnectionDetailsPolicy{
+					Exclude: []string{"internal"},
+					Rename:  map[string]string{"endpoint": "host", "password": "username"},
+				},
+			},
+			want: managed.ConnectionDetails{
+				"username": []byte("cool-pass"),
+				"host":     []byte("cool.example.org"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := FilterDetails(tc.args.conn, tc.args.p)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nFilterDetails(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateDetail(t *testing.T) {
+	type args struct {
+		cd v1beta1.ConnectionDetail
+	}
+	type want struct {
+		output *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"InvalidType": {
+			reason: "An invalid type should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{Type: v1beta1.ConnectionDetailType("wat")},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"EmptyName": {
+			reason: "An empty name should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeFromValue,
+					Name: "",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "name",
+				},
+			},
+		},
+		"InvalidValue": {
+			reason: "An invalid value should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeFromValue,
+					Name: "cool",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "value",
+				},
+			},
+		},
+		"InvalidFromConnectionSecretKey": {
+			reason: "An invalid from connection secret key should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+					Name: "cool",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromConnectionSecretKey",
+				},
+			},
+		},
+		"InvalidFromFieldPath": {
+			reason: "An invalid from field path should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type: v1beta1.ConnectionDetailTypeFromFieldPath,
+					Name: "cool",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"ValidValue": {
+			reason: "An valid value should not cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:  v1beta1.ConnectionDetailTypeFromValue,
+					Name:  "cool",
+					Value: ptr.To[string]("cooler"),
+				},
+			},
+			want: want{
+				output: nil,
+			},
+		},
+		"ValidFromConnectionSecretKey": {
+			reason: "An valid from connection secret key should not cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
+					Name:                    "cool",
+					FromConnectionSecretKey: ptr.To[string]("key"),
+				},
+			},
+			want: want{
+				output: nil,
+			},
+		},
+		"ValidFromFieldPath": {
+			reason: "An valid from field path should not cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
+					Name:          "cool",
+					FromFieldPath: ptr.To[string]("status.coolness"),
+				},
+			},
+			want: want{
+				output: nil,
+			},
+		},
+		"ValidFromFieldPaths": {
+			reason: "Fallback from field paths without a from field path should not cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:           v1beta1.ConnectionDetailTypeFromFieldPath,
+					Name:           "cool",
+					FromFieldPaths: []string{"status.coolness", "status.atProvider.coolness"},
+				},
+			},
+			want: want{
+				output: nil,
+			},
+		},
+		"EmptyFromFieldPaths": {
+			reason: "An empty fallback from field path should cause a validation error",
+			args: args{
+				cd: v1beta1.ConnectionDetail{
+					Type:           v1beta1.ConnectionDetailTypeFromFieldPath,
+					Name:           "cool",
+					FromFieldPaths: []string{"status.coolness", ""},
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPaths[1]",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ValidateDetail(tc.args.cd)
+			if diff := cmp.Diff(tc.want.output, got, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateDetail(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Package fieldpaths validates, normalizes, and expands the field paths of
+// function-patch-and-transform's patches.
+package fieldpaths
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Error strings
+const (
+	errFmtUnquotedMetadataKey = "%s splits a key containing a period into several fields; quote the key using brackets, e.g. %s"
+)
+
+// AppendKey appends the supplied object key to the supplied field
+// path. Keys that contain periods, slashes, or brackets are quoted using
+// bracket notation, so they're treated as a single key. Keys that are numbers
+// are quoted using quoted bracket notation, so they're not treated as array
+// indexes. For example appending
+// the label key crossplane.io/claim-name to metadata.labels returns
+// metadata.labels[crossplane.io/claim-name]. Field paths can't represent keys
+// that contain both a period and a right bracket, keys that are quoted, or the
+// key *, which is always a wildcard.
+func AppendKey(path, key string) string {
+	if strings.ContainsAny(key, "./[") {
+		return path + "[" + key + "]"
+	}
+	if isIndex(key) {
+		// Dot notation would make this an array index.
+		return path + "['" + key + "']"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ExpandWildcards expands the wildcards of the supplied field path against the
+// supplied object, like Paved.ExpandWildcards. Unlike Paved.ExpandWildcards the
+// expanded field paths are sorted, so they're in the same order each time even
+// when a wildcard expands the keys of an object.
+func ExpandWildcards(p *fieldpath.Paved, path string) ([]string, error) {
+	paths, err := p.ExpandWildcards(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// ValidateKeys returns an error if the supplied field path addresses
+// a label or annotation key that contains a period without quoting it, e.g.
+// metadata.annotations.crossplane.io/external-name. Such a field path is valid,
+// but it addresses a nested field that can't exist, because label and
+// annotation values are strings. The error suggests the quoted field path.
+func ValidateKeys(path string) error {
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		// Let whoever parses the field path report that it's invalid.
+		return nil //nolint:nilerr // See above.
+	}
+	for i := 0; i+3 < len(segments); i++ {
+		if !isField(segments[i], "metadata") || !(isField(segments[i+1], "labels") || isField(segments[i+1], "annotations")) {
+			continue
+		}
+		key := make([]string, 0, len(segments)-i-2)
+		for _, s := range segments[i+2:] {
+			key = append(key, fieldpath.Segments{s}.String())
+		}
+		return errors.Errorf(errFmtUnquotedMetadataKey, path, AppendKey(segments[:i+2].String(), strings.Join(key, ".")))
+	}
+	return nil
+}
+
+func isField(s fieldpath.Segment, name string) bool {
+	return s.Type == fieldpath.SegmentField && s.Field == name
+}
+
+// Normalize returns the canonical form of the supplied field path. It
+// accepts array indexes in dot notation, e.g. spec.items.0.name, as well as
+// bracket notation, e.g. spec.items[0].name. It accepts keys in quoted bracket
+// notation, e.g. metadata.labels['crossplane.io/claim-name']. The canonical
+// form uses bracket notation for array indexes and wildcards, and for keys that
+// can't be represented using dot notation. A key that is a number must use
+// quoted bracket notation, e.g. data['42'], to be treated as a key rather than
+// an array index. Field paths that can't be parsed are returned unchanged.
+func Normalize(path string) string {
+	if _, err := fieldpath.Parse(path); err != nil {
+		return path
+	}
+
+	out := ""
+	for rest := path; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+		case '[':
+			end := strings.IndexRune(rest, ']')
+			tok := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case isQuoted(tok):
+				out = AppendKey(out, tok[1:len(tok)-1])
+			case isIndex(tok), tok == "*":
+				out += "[" + tok + "]"
+			default:
+				out = AppendKey(out, tok)
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			tok := rest[:end]
+			rest = rest[end:]
+			switch {
+			case isQuoted(tok):
+				out = AppendKey(out, tok[1:len(tok)-1])
+			case isIndex(tok), tok == "*":
+				out += "[" + tok + "]"
+			default:
+				out = AppendKey(out, tok)
+			}
+		}
+	}
+	return out
+}
+
+// NormalizePatch replaces the field paths of the supplied patch with
+// their canonical form.
+func NormalizePatch(p *v1beta1.Patch) {
+	if p.FromFieldPath != nil {
+		*p.FromFieldPath = Normalize(*p.FromFieldPath)
+	}
+	if p.ToFieldPath != nil {
+		*p.ToFieldPath = Normalize(*p.ToFieldPath)
+	}
+	if p.Combine != nil {
+		for i := range p.Combine.Variables {
+			p.Combine.Variables[i].FromFieldPath = Normalize(p.Combine.Variables[i].FromFieldPath)
+		}
+	}
+}
+
+// NormalizeResources replaces the field paths of all the supplied input's
+// patches with their canonical form.
+func NormalizeResources(r *v1beta1.Resources) {
+	for i := range r.PatchSets {
+		for j := range r.PatchSets[i].Patches {
+			NormalizePatch(&r.PatchSets[i].Patches[j].Patch)
+		}
+	}
+	if r.Environment != nil {
+		for i := range r.Environment.Patches {
+			NormalizePatch(&r.Environment.Patches[i].Patch)
+		}
+	}
+	if r.Composite != nil {
+		for i := range r.Composite.Patches {
+			NormalizePatch(&r.Composite.Patches[i].Patch)
+		}
+	}
+	for i := range r.Resources {
+		for j := range r.Resources[i].Patches {
+			NormalizePatch(&r.Resources[i].Patches[j].Patch)
+		}
+	}
+}
+
+func isIndex(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 32)
+	return err == nil
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]
+}
+
+// Pave returns a paved view of the supplied object. The XR, environment,
+// and composed resources are all unstructured, so they're paved in place: the
+// view shares the object's content, and every patch to or from the object
+// shares it too. Nothing is converted or copied, no matter how many patches
+// read from or write to a large object. Callers must not mutate values they
+// read from the view; SetValue copies any value it writes.
+func Pave(o runtime.Object) (*fieldpath.Paved, error) {
+	if u, ok := o.(runtime.Unstructured); ok {
+		return fieldpath.Pave(u.UnstructuredContent()), nil
+	}
+	return fieldpath.PaveObject(o)
+}
@@ -0,0 +1,233 @@
+package fieldpaths
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestAppendKey(t *testing.T) {
+	type args struct {
+		path string
+		key  string
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   string
+	}{
+		"Simple": {
+			reason: "A simple key should be appended using dot notation.",
+			args:   args{path: "metadata.labels", key: "app"},
+			want:   "metadata.labels.app",
+		},
+		"EmptyPath": {
+			reason: "A simple key appended to an empty path should be the whole path.",
+			args:   args{key: "spec"},
+			want:   "spec",
+		},
+		"Period": {
+			reason: "A key containing periods and slashes should be quoted using brackets.",
+			args:   args{path: "metadata.labels", key: "crossplane.io/claim-name"},
+			want:   "metadata.labels[crossplane.io/claim-name]",
+		},
+		"Slash": {
+			reason: "A key containing slashes should be quoted using brackets.",
+			args:   args{path: "metadata.annotations", key: "example/owner"},
+			want:   "metadata.annotations[example/owner]",
+		},
+		"Number": {
+			reason: "A key that is a number should be quoted using quoted brackets, so it's not an array index.",
+			args:   args{path: "data", key: "42"},
+			want:   "data['42']",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := AppendKey(tc.args.path, tc.args.key)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nAppendKey(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			// The field path should address the key.
+			p := fieldpath.Pave(map[string]any{})
+			if err := p.SetValue(got, "cool"); err != nil {
+				t.Fatalf("%s\nSetValue(%q): %v", tc.reason, got, err)
+			}
+			s, err := p.GetString(AppendKey(tc.args.path, tc.args.key))
+			if err != nil || s != "cool" {
+				t.Errorf("%s\nGetString(%q): want cool, got %q, %v", tc.reason, got, s, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   string
+	}{
+		"Canonical": {
+			reason: "A canonical field path should be unchanged.",
+			path:   "spec.items[0].name",
+			want:   "spec.items[0].name",
+		},
+		"DotIndex": {
+			reason: "An array index in dot notation should use bracket notation.",
+			path:   "spec.items.0.name",
+			want:   "spec.items[0].name",
+		},
+		"DotWildcard": {
+			reason: "A wildcard in dot notation should use bracket notation.",
+			path:   "spec.items.*.name",
+			want:   "spec.items[*].name",
+		},
+		"QuotedKey": {
+			reason: "A key in quoted bracket notation should use bracket notation.",
+			path:   `metadata.labels['crossplane.io/claim-name']`,
+			want:   "metadata.labels[crossplane.io/claim-name]",
+		},
+		"DoubleQuotedSimpleKey": {
+			reason: "A simple key in quoted bracket notation should use dot notation.",
+			path:   `metadata.labels["app"]`,
+			want:   "metadata.labels.app",
+		},
+		"QuotedNumberKey": {
+			reason: "A key that is a number in quoted bracket notation should stay quoted, so it's not an array index.",
+			path:   `data["42"].name`,
+			want:   "data['42'].name",
+		},
+		"Invalid": {
+			reason: "A field path that can't be parsed should be unchanged.",
+			path:   "spec..name",
+			want:   "spec..name",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Normalize(tc.path)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nNormalize(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		path   string
+		want   error
+	}{
+		"QuotedKey": {
+			reason: "A field path that quotes a label key should be valid.",
+			path:   "metadata.labels[crossplane.io/claim-name]",
+		},
+		"SimpleKey": {
+			reason: "A field path that addresses a simple annotation key should be valid.",
+			path:   "spec.template.metadata.annotations.owner",
+		},
+		"NotMetadata": {
+			reason: "A field path that doesn't address a label or annotation shouldn't be validated.",
+			path:   "spec.forProvider.tags.example.io/owner",
+		},
+		"UnquotedKey": {
+			reason: "A field path that splits an annotation key should be invalid.",
+			path:   "metadata.annotations.crossplane.io/external-name",
+			want:   errors.Errorf(errFmtUnquotedMetadataKey, "metadata.annotations.crossplane.io/external-name", "metadata.annotations[crossplane.io/external-name]"),
+		},
+		"NestedUnquotedKey": {
+			reason: "A field path that splits a label key of a nested object's metadata should be invalid.",
+			path:   "spec.template.metadata.labels.app.kubernetes.io/name",
+			want:   errors.Errorf(errFmtUnquotedMetadataKey, "spec.template.metadata.labels.app.kubernetes.io/name", "spec.template.metadata.labels[app.kubernetes.io/name]"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateKeys(tc.path)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nValidateKeys(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExpandWildcards(t *testing.T) {
+	type args struct {
+		o    map[string]any
+		path string
+	}
+	type want struct {
+		paths []string
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ObjectKeys": {
+			reason: "Wildcards that expand the keys of an object should return sorted field paths.",
+			args: args{
+				o:    map[string]any{"zones": map[string]any{"c": 3, "a": 1, "d": 4, "b": 2}},
+				path: "zones[*]",
+			},
+			want: want{
+				paths: []string{"zones.a", "zones.b", "zones.c", "zones.d"},
+			},
+		},
+		"NoWildcards": {
+			reason: "A field path without wildcards should be returned as is.",
+			args: args{
+				o:    map[string]any{"spec": map[string]any{"region": "us-east-2"}},
+				path: "spec.region",
+			},
+			want: want{
+				paths: []string{"spec.region"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ExpandWildcards(fieldpath.Pave(tc.args.o), tc.args.path)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nExpandWildcards(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.paths, got); diff != "" {
+				t.Errorf("%s\nExpandWildcards(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func FuzzFieldPath(f *testing.F) {
+	f.Add("metadata.labels", "crossplane.io/claim-name", []byte(`{"metadata":{"labels":{"a":"b"}}}`))
+	f.Add("metadata.annotations.crossplane.io/external-name", "0", []byte(`{"metadata":{"annotations":{}}}`))
+	f.Add("spec.forProvider.items[*].name", "a.b]c", []byte(`{"spec":{"forProvider":{"items":[{"name":"a"},{"name":"b"}]}}}`))
+	f.Add("spec.tags[*]", "*", []byte(`{"spec":{"tags":{"b":"1","a":"2"}}}`))
+
+	f.Fuzz(func(_ *testing.T, path, key string, object []byte) {
+		o := map[string]any{}
+		if err := json.Unmarshal(object, &o); err != nil {
+			return
+		}
+
+		// We only care that we don't panic.
+		_ = ValidateKeys(path)
+		_ = ValidateKeys(AppendKey(path, key))
+		_, _ = ExpandWildcards(fieldpath.Pave(o), path)
+		_, _ = ExpandWildcards(fieldpath.Pave(o), AppendKey(path, key))
+	})
+}
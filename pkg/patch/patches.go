@@ -0,0 +1,977 @@
+// Package patch applies and validates function-patch-and-transform's patches.
+// Other functions can import it to patch resources exactly as
+// function-patch-and-transform does.
+package patch
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+const (
+	errPatchSetType             = "a patch in a PatchSet cannot be of type PatchSet"
+	errCombineRequiresVariables = "combine patch types require at least one variable"
+
+	errFmtRequiredField               = "%s is required by type %s"
+	errFmtPatch                       = "cannot apply the %q patch at index %d"
+	errFmtUndefinedPatchSet           = "cannot find PatchSet by name %s"
+	errFmtInvalidPatchType            = "patch type %s is unsupported"
+	errFmtExpandingArrayFieldPaths    = "cannot expand ToFieldPath %s"
+	errFmtToFieldPathParentNotFound   = "parent %s of ToFieldPath %s does not exist, and the ToFieldPath policy is Required"
+	errFmtMergeKeyNotArray            = "cannot merge %s by key %q: both the patched value and the existing value must be arrays of objects"
+	errFmtMergeKeyMissing             = "cannot merge element %d of the patched value: it must be an object with a value at merge key %q"
+	errFmtSensitivePatch              = "cannot apply sensitive patch to %s (error omitted because it may contain sensitive values)"
+	errFmtFromFieldPathEmpty          = "fromFieldPath %s is empty, and the FromFieldPath policy is RequiredNonEmpty"
+	errFmtInvalidFromFieldPathDefault = "cannot decode default value of fromFieldPath %s"
+	errFmtCoerce                      = "cannot convert patched value to the type of the existing value at %s"
+	errFmtCoerceType                  = "cannot convert %T to %T"
+	errFmtCopyNotObject               = "cannot copy fromFieldPath %s: patch type CopyFromCompositeFieldPath requires an object, not %T"
+	errFmtCopyInclude                 = "cannot include %s"
+	errFmtCopyExclude                 = "cannot exclude %s"
+	errFmtPatchSetCondition           = "cannot evaluate when condition of resource template %q patch %d"
+	errFmtConditionField              = "cannot get %s of condition %q"
+)
+
+// A Interface is a patch that can be applied between resources.
+type Interface interface {
+	GetType() v1beta1.PatchType
+	GetFromFieldPath() string
+	GetToFieldPath() string
+	GetCombine() *v1beta1.Combine
+	GetTransforms() []v1beta1.Transform
+	GetPolicy() *v1beta1.PatchPolicy
+	GetStage() v1beta1.PatchStage
+	GetTransformTimeout() time.Duration
+	IsSensitive() bool
+}
+
+// WithPatchSetName is an Interface that has a PatchSetName field.
+type WithPatchSetName interface {
+	Interface
+	GetPatchSetName() string
+}
+
+// WithConnectionDetailName is an Interface that has a
+// ConnectionDetailName field.
+type WithConnectionDetailName interface {
+	Interface
+	GetConnectionDetailName() string
+}
+
+// WithPatchSetCondition is an Interface that has a When field.
+type WithPatchSetCondition interface {
+	Interface
+	GetWhen() *v1beta1.PatchSetCondition
+}
+
+// WithCopyFilter is an Interface that has a Copy field.
+type WithCopyFilter interface {
+	Interface
+	GetCopy() *v1beta1.CopyFilter
+}
+
+// WithConditionSelector is an Interface that has a Condition field.
+type WithConditionSelector interface {
+	Interface
+	GetCondition() *v1beta1.ConditionSelector
+}
+
+// Apply executes a patching operation between the from and to resources.
+// Applies all patch types unless an 'only' filter is supplied.
+func Apply(ctx context.Context, p Interface, xr resource.Composite, cd resource.Composed, only ...v1beta1.PatchType) error {
+	return ApplyToObjects(ctx, p, xr, cd, only...)
+}
+
+// ApplyToObjects works like Apply but accepts any kind of runtime.Object. It
+// might be vulnerable to conversion panics (see
+// https://github.com/crossplane/crossplane/pull/3394 for details).
+func ApplyToObjects(ctx context.Context, p Interface, a, b runtime.Object, only ...v1beta1.PatchType) error {
+	if filterPatch(p, only...) {
+		return nil
+	}
+
+	err := applyToObjects(ctx, p, a, b)
+	if err != nil && p.IsSensitive() {
+		// Errors may include the values being patched, for example when a
+		// map transform can't find a key.
+		return errors.Errorf(errFmtSensitivePatch, p.GetToFieldPath())
+	}
+	return err
+}
+
+func applyToObjects(ctx context.Context, p Interface, a, b runtime.Object) error {
+	switch p.GetType() {
+	case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCopyFromCompositeFieldPath:
+		return ApplyFromFieldPathPatch(ctx, p, a, b)
+	case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeToEnvironmentFieldPath:
+		return ApplyFromFieldPathPatch(ctx, p, b, a)
+	case v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCombineFromEnvironment:
+		return ApplyCombineFromVariablesPatch(ctx, p, a, b)
+	case v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeCombineToEnvironment:
+		return ApplyCombineFromVariablesPatch(ctx, p, b, a)
+	case v1beta1.PatchTypeConditionToComposite:
+		cp, ok := p.(WithConditionSelector)
+		if !ok {
+			return errors.Errorf(errFmtInvalidPatchType, p.GetType())
+		}
+		return ApplyConditionPatch(ctx, cp, b, a)
+	case v1beta1.PatchTypePatchSet:
+		// Already resolved - nothing to do.
+	}
+	return errors.Errorf(errFmtInvalidPatchType, p.GetType())
+}
+
+// filterPatch returns true if patch should be filtered (not applied)
+func filterPatch(p Interface, only ...v1beta1.PatchType) bool {
+	// filter does not apply if not set
+	if len(only) == 0 {
+		return false
+	}
+
+	for _, patchType := range only {
+		if patchType == p.GetType() {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveTransforms applies a list of transforms to a patch value using the
+// default transform registry. See transforms.Registry.Evaluate.
+func ResolveTransforms(ctx context.Context, ts []v1beta1.Transform, input any) (any, error) {
+	return transforms.Default.Evaluate(ctx, ts, input)
+}
+
+// ResolvePatchTransforms applies the supplied patch's transforms to the
+// supplied value. It returns an error if the patch has a transform timeout and
+// its transforms don't resolve within it.
+func ResolvePatchTransforms(ctx context.Context, p Interface, input any) (any, error) {
+	if d := p.GetTransformTimeout(); d > 0 {
+		return transforms.Default.EvaluateWithin(ctx, p.GetTransforms(), input, d)
+	}
+	return ResolveTransforms(ctx, p.GetTransforms(), input)
+}
+
+// ApplyFromFieldPathPatch patches the "to" resource, using a source field
+// on the "from" resource. Values may be transformed if any are defined on
+// the patch.
+func ApplyFromFieldPathPatch(ctx context.Context, p Interface, from, to runtime.Object) error {
+	out, ok, err := resolveFromFieldPathPatch(ctx, p, from)
+	if err != nil || !ok {
+		return err
+	}
+
+	// ComposedPatch all expanded fields if the ToFieldPath contains wildcards
+	if strings.Contains(p.GetToFieldPath(), "[*]") {
+		return patchFieldValueToMultiple(p.GetToFieldPath(), out, to, p.GetPolicy())
+	}
+
+	return errors.Wrap(patchFieldValueToObject(p.GetToFieldPath(), out, to, p.GetPolicy()), "cannot patch to object")
+}
+
+// resolveFromFieldPathPatch returns the transformed value of the supplied
+// patch's source field on the "from" resource. It returns false if the source
+// field is optional and doesn't exist.
+func resolveFromFieldPathPatch(ctx context.Context, p Interface, from runtime.Object) (any, bool, error) {
+	if p.GetFromFieldPath() == "" {
+		return nil, false, errors.Errorf(errFmtRequiredField, "FromFieldPath", p.GetType())
+	}
+
+	paved, err := fieldpaths.Pave(from)
+	if err != nil {
+		return nil, false, err
+	}
+
+	in, ok, err := GetFromFieldPath(paved, p.GetFromFieldPath(), p.GetPolicy(), true)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	// Copy patches filter the object they copy before it's transformed.
+	if cp, ok := p.(WithCopyFilter); ok && p.GetType() == v1beta1.PatchTypeCopyFromCompositeFieldPath {
+		if in, err = FilterCopy(p.GetFromFieldPath(), in, cp.GetCopy()); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Apply transform pipeline
+	out, err := ResolvePatchTransforms(ctx, p, in)
+	return out, err == nil, err
+}
+
+// ApplyConditionPatch patches the "to" resource, using a field of a status
+// condition of the "from" resource. The patch's FromFieldPath policy applies to
+// the condition's field, so by default nothing is patched if the condition
+// doesn't exist or the field isn't set.
+func ApplyConditionPatch(ctx context.Context, p WithConditionSelector, from, to runtime.Object) error {
+	c := p.GetCondition()
+	if c == nil {
+		return errors.Errorf(errFmtRequiredField, "Condition", p.GetType())
+	}
+	if p.GetToFieldPath() == "" {
+		return errors.Errorf(errFmtRequiredField, "ToFieldPath", p.GetType())
+	}
+
+	paved, err := fieldpaths.Pave(from)
+	if err != nil {
+		return err
+	}
+
+	// Read the conditions through the paved object, rather than using
+	// unstructured.NestedSlice, which would deep copy all of them.
+	cond := map[string]any{}
+	conds, _ := paved.GetValue("status.conditions")
+	l, _ := conds.([]any)
+	for _, v := range l {
+		m, ok := v.(map[string]any)
+		if ok && m["type"] == c.Type {
+			cond = m
+			break
+		}
+	}
+
+	in, ok, err := GetFromFieldPath(fieldpath.Pave(cond), strings.ToLower(string(c.GetField())), p.GetPolicy(), true)
+	if err != nil || !ok {
+		return errors.Wrapf(err, errFmtConditionField, c.GetField(), c.Type)
+	}
+
+	out, err := ResolvePatchTransforms(ctx, p, in)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(patchFieldValueToObject(p.GetToFieldPath(), out, to, p.GetPolicy()), "cannot patch to object")
+}
+
+// FilterCopy returns the supplied object, read from the supplied field path,
+// with only the fields the supplied filter selects. Fields at included paths
+// are selected first, then fields at excluded paths are removed. Paths that
+// don't exist are ignored. The object is returned unfiltered if the filter is
+// nil, but it must be an object regardless.
+func FilterCopy(fromFieldPath string, in any, f *v1beta1.CopyFilter) (any, error) {
+	obj, ok := in.(map[string]any)
+	if !ok {
+		return nil, errors.Errorf(errFmtCopyNotObject, fromFieldPath, in)
+	}
+	if f == nil {
+		return obj, nil
+	}
+
+	// The object shares its fields with the source resource, so we filter a
+	// copy of it.
+	j, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	cp := map[string]any{}
+	if err := json.Unmarshal(j, &cp); err != nil {
+		return nil, err
+	}
+	src := fieldpath.Pave(cp)
+	out := src
+	if len(f.Include) > 0 {
+		out = fieldpath.Pave(map[string]any{})
+		for _, path := range f.Include {
+			paths, err := fieldpaths.ExpandWildcards(src, path)
+			if err != nil {
+				return nil, errors.Wrapf(err, errFmtCopyInclude, path)
+			}
+			for _, p := range paths {
+				v, err := src.GetValue(p)
+				if err != nil {
+					return nil, errors.Wrapf(err, errFmtCopyInclude, path)
+				}
+				if err := out.SetValue(p, v); err != nil {
+					return nil, errors.Wrapf(err, errFmtCopyInclude, path)
+				}
+			}
+		}
+	}
+	for _, path := range f.Exclude {
+		paths, err := fieldpaths.ExpandWildcards(out, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtCopyExclude, path)
+		}
+		for _, p := range paths {
+			if err := out.DeleteField(p); err != nil {
+				return nil, errors.Wrapf(err, errFmtCopyExclude, path)
+			}
+		}
+	}
+	return out.UnstructuredContent(), nil
+}
+
+// ApplyCombineFromVariablesPatch patches the "to" resource, taking a list of
+// input variables and combining them into a single output value.
+// The single output value may then be further transformed if they are defined
+// on the patch.
+func ApplyCombineFromVariablesPatch(ctx context.Context, p Interface, from, to runtime.Object) error {
+	// Destination field path is required since we can't default to multiple
+	// fields.
+	if p.GetCombine() != nil && p.GetToFieldPath() == "" {
+		return errors.Errorf(errFmtRequiredField, "ToFieldPath", p.GetType())
+	}
+
+	out, ok, err := resolveCombineFromVariablesPatch(ctx, p, from)
+	if err != nil || !ok {
+		return err
+	}
+
+	return errors.Wrap(patchFieldValueToObject(p.GetToFieldPath(), out, to, p.GetPolicy()), "cannot patch to object")
+}
+
+// resolveCombineFromVariablesPatch returns the combined and transformed value
+// of the supplied patch's input variables on the "from" resource. It returns
+// false if any variable is optional and doesn't exist.
+func resolveCombineFromVariablesPatch(ctx context.Context, p Interface, from runtime.Object) (any, bool, error) {
+	// Combine patch requires configuration
+	if p.GetCombine() == nil {
+		return nil, false, errors.Errorf(errFmtRequiredField, "Combine", p.GetType())
+	}
+
+	combine := p.GetCombine()
+	vl := len(combine.Variables)
+
+	if vl < 1 {
+		return nil, false, errors.New(errCombineRequiresVariables)
+	}
+
+	paved, err := fieldpaths.Pave(from)
+	if err != nil {
+		return nil, false, err
+	}
+
+	in := make([]any, vl)
+
+	// Get value of each variable
+	// NOTE: This currently assumes all variables define a 'fromFieldPath'
+	// value. If we add new variable types, this may not be the case and
+	// this code may be better served split out into a dedicated function.
+	for i, sp := range combine.Variables {
+		// If any source field is not found, we will not
+		// apply the patch. This is to avoid situations
+		// where a combine patch is expecting a fixed
+		// number of inputs (e.g. a string format
+		// expecting 3 fields '%s-%s-%s' but only
+		// receiving 2 values).
+		iv, ok, err := GetFromFieldPath(paved, sp.FromFieldPath, p.GetPolicy(), false)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		in[i] = iv
+	}
+
+	// Combine input values
+	cb, err := transforms.Combine(*p.GetCombine(), in)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Apply transform pipeline
+	out, err := ResolvePatchTransforms(ctx, p, cb)
+	return out, err == nil, err
+}
+
+// ApplyToConnectionDetails patches the supplied composite resource connection
+// details, using source field(s) on the "from" resource. Values may be
+// transformed if any transforms are defined on the patch. Values that aren't
+// strings are encoded as JSON.
+func ApplyToConnectionDetails(ctx context.Context, p WithConnectionDetailName, from runtime.Object, conn managed.ConnectionDetails) error {
+	if p.GetConnectionDetailName() == "" {
+		return errors.Errorf(errFmtRequiredField, "ConnectionDetailName", p.GetType())
+	}
+
+	var out any
+	var ok bool
+	var err error
+	switch p.GetType() { //nolint:exhaustive // Only connection detail patches are supported.
+	case v1beta1.PatchTypeToConnectionDetail:
+		out, ok, err = resolveFromFieldPathPatch(ctx, p, from)
+	case v1beta1.PatchTypeCombineToConnectionDetail:
+		out, ok, err = resolveCombineFromVariablesPatch(ctx, p, from)
+	default:
+		return errors.Errorf(errFmtInvalidPatchType, p.GetType())
+	}
+	if err != nil && p.IsSensitive() {
+		return errors.Errorf(errFmtSensitivePatch, p.GetConnectionDetailName())
+	}
+	if err != nil || !ok {
+		return err
+	}
+
+	if s, ok := out.(string); ok {
+		conn[p.GetConnectionDetailName()] = []byte(s)
+		return nil
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return errors.Errorf(errFmtSensitivePatch, p.GetConnectionDetailName())
+	}
+	conn[p.GetConnectionDetailName()] = b
+	return nil
+}
+
+// GetFromFieldPath returns the value of the supplied field path. It returns
+// false if the field path doesn't exist and the supplied policy indicates a
+// patch from it is optional. Empty values are treated as if the field path
+// doesn't exist if the policy says so. If useDefault is true the policy's
+// default value, if any, is returned instead of a missing value.
+func GetFromFieldPath(from *fieldpath.Paved, path string, pp *v1beta1.PatchPolicy, useDefault bool) (any, bool, error) {
+	v, err := from.GetValue(path)
+	if err != nil && !fieldpath.IsNotFound(err) {
+		return nil, false, err
+	}
+	missing := fieldpath.IsNotFound(err) || (pp.GetFromFieldPathPolicy().TreatsEmptyAsMissing() && IsEmpty(v))
+	if !missing {
+		return v, true, nil
+	}
+	if useDefault && pp != nil && pp.FromFieldPathDefault != nil {
+		var d any
+		if err := json.Unmarshal(pp.FromFieldPathDefault.Raw, &d); err != nil {
+			return nil, false, errors.Wrapf(err, errFmtInvalidFromFieldPathDefault, path)
+		}
+		return d, true, nil
+	}
+	if pp.GetFromFieldPathPolicy().IsOptional() {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return nil, false, errors.Errorf(errFmtFromFieldPathEmpty, path)
+}
+
+// IsEmpty returns true if the supplied value is null, an empty string, zero,
+// or an empty array or object.
+func IsEmpty(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case int64:
+		return t == 0
+	case float64:
+		return t == 0
+	case []any:
+		return len(t) == 0
+	case map[string]any:
+		return len(t) == 0
+	}
+	return false
+}
+
+// IsOptionalFieldPathNotFound returns true if the supplied error indicates a
+// field path was not found, and the supplied policy indicates a patch from that
+// field path was optional.
+func IsOptionalFieldPathNotFound(err error, p *v1beta1.PatchPolicy) bool {
+	return p.GetFromFieldPathPolicy().IsOptional() && fieldpath.IsNotFound(err)
+}
+
+// SelectPatchSets returns the supplied resource templates without any patches
+// that include a PatchSet only when a condition is met, and whose condition
+// isn't met by the supplied composite resource or environment. Patches that
+// always include their PatchSet are kept. The supplied templates aren't
+// modified.
+func SelectPatchSets(cts []v1beta1.ComposedTemplate, xr, env runtime.Object) ([]v1beta1.ComposedTemplate, error) {
+	out := make([]v1beta1.ComposedTemplate, len(cts))
+	for i := range cts {
+		out[i] = cts[i]
+		ps := make([]v1beta1.ComposedPatch, 0, len(cts[i].Patches))
+		for j := range cts[i].Patches {
+			p := cts[i].Patches[j]
+			if c := p.GetWhen(); p.Type == v1beta1.PatchTypePatchSet && c != nil {
+				from := xr
+				if c.GetSource() == v1beta1.PatchSetConditionSourceEnvironment {
+					from = env
+				}
+				met, err := patchSetConditionMet(c, from)
+				if err != nil {
+					return nil, errors.Wrapf(err, errFmtPatchSetCondition, cts[i].Name, j)
+				}
+				if !met {
+					continue
+				}
+			}
+			ps = append(ps, p)
+		}
+		out[i].Patches = ps
+	}
+	return out, nil
+}
+
+// patchSetConditionMet returns true if the supplied object's field at the
+// condition's fromFieldPath equals the condition's value. It returns false if
+// the field doesn't exist.
+func patchSetConditionMet(c *v1beta1.PatchSetCondition, from runtime.Object) (bool, error) {
+	paved, err := fieldpaths.Pave(from)
+	if err != nil {
+		return false, err
+	}
+	v, err := paved.GetString(c.FromFieldPath)
+	if fieldpath.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return v == c.Equals, nil
+}
+
+// ComposedTemplates returns the supplied composed resource templates with any
+// supplied patchsets dereferenced.
+func ComposedTemplates(pss []v1beta1.PatchSet, cts []v1beta1.ComposedTemplate) ([]v1beta1.ComposedTemplate, error) {
+	pn := make(map[string][]v1beta1.ComposedPatch)
+	for _, s := range pss {
+		for _, p := range s.Patches {
+			if p.Type == v1beta1.PatchTypePatchSet {
+				return nil, errors.New(errPatchSetType)
+			}
+		}
+		pn[s.Name] = s.GetComposedPatches()
+	}
+
+	ct := make([]v1beta1.ComposedTemplate, len(cts))
+	for i := range cts {
+		r := &cts[i]
+
+		// Size the dereferenced patches up front to avoid repeatedly growing
+		// (and thus copying) the slice as we append patch sets.
+		size := 0
+		for j := range r.Patches {
+			size++
+			if n := r.Patches[j].PatchSetName; r.Patches[j].Type == v1beta1.PatchTypePatchSet && n != nil {
+				size += len(pn[*n])
+			}
+		}
+
+		po := make([]v1beta1.ComposedPatch, 0, size)
+		for _, p := range r.Patches {
+			if p.Type != v1beta1.PatchTypePatchSet {
+				po = append(po, p)
+				continue
+			}
+			if p.PatchSetName == nil {
+				return nil, errors.Errorf(errFmtRequiredField, "PatchSetName", p.Type)
+			}
+			ps, ok := pn[*p.PatchSetName]
+			if !ok {
+				return nil, errors.Errorf(errFmtUndefinedPatchSet, *p.PatchSetName)
+			}
+			po = append(po, ps...)
+		}
+		ct[i] = *r
+		ct[i].Patches = po
+	}
+	return ct, nil
+}
+
+// patchFieldValueToObject applies the value to the "to" object at the given
+// path, returning any errors as they occur.
+func patchFieldValueToObject(fieldPath string, value any, to runtime.Object, pp *v1beta1.PatchPolicy) error {
+	paved, err := fieldpaths.Pave(to)
+	if err != nil {
+		return err
+	}
+
+	if err := setValue(paved, fieldPath, value, pp); err != nil {
+		return err
+	}
+
+	return fromPaved(paved, to)
+}
+
+// patchFieldValueToMultiple, given a path with wildcards in an array index,
+// expands the arrays paths in the "to" object and patches the value into each
+// of the resulting fields, returning any errors as they occur.
+func patchFieldValueToMultiple(fieldPath string, value any, to runtime.Object, pp *v1beta1.PatchPolicy) error {
+	paved, err := fieldpaths.Pave(to)
+	if err != nil {
+		return err
+	}
+
+	arrayFieldPaths, err := fieldpaths.ExpandWildcards(paved, fieldPath)
+	if err != nil {
+		return err
+	}
+
+	if len(arrayFieldPaths) == 0 {
+		return errors.Errorf(errFmtExpandingArrayFieldPaths, fieldPath)
+	}
+
+	for _, field := range arrayFieldPaths {
+		if err := setValue(paved, field, value, pp); err != nil {
+			return err
+		}
+	}
+
+	return fromPaved(paved, to)
+}
+
+// setValue sets the supplied value at the supplied field path, honoring the
+// supplied patch policy.
+func setValue(paved *fieldpath.Paved, fieldPath string, value any, pp *v1beta1.PatchPolicy) error {
+	if err := checkToFieldPathParent(paved, fieldPath, pp); err != nil {
+		return err
+	}
+
+	if key := pp.GetMergeKey(); key != "" {
+		merged, err := mergeArrayByKey(paved, fieldPath, value, key)
+		if err != nil {
+			return err
+		}
+		value = merged
+	}
+
+	if pp.GetCoerceToExistingType() {
+		coerced, err := coerceToExisting(paved, fieldPath, value)
+		if err != nil {
+			return err
+		}
+		value = coerced
+	}
+
+	return paved.SetValue(fieldPath, value)
+}
+
+// coerceToExisting converts the supplied value to the type of the value at the
+// supplied field path. The value is returned as is if there's no value at the
+// field path.
+//
+// TODO(negz): Use the composed resource's OpenAPI schema, rather than the
+// existing value, to determine the type. This requires the Function to ask
+// Crossplane for the composed resource's CRD using requirements, which the
+// version of the Function SDK (and RunFunctionRequest) we use doesn't support
+// yet.
+func coerceToExisting(paved *fieldpath.Paved, fieldPath string, value any) (any, error) {
+	existing, err := paved.GetValue(fieldPath)
+	if fieldpath.IsNotFound(err) || existing == nil || value == nil {
+		return value, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out, err := coerce(value, existing)
+	return out, errors.Wrapf(err, errFmtCoerce, fieldPath)
+}
+
+// coerce converts the supplied value to the type of the supplied value. Only
+// strings, numbers, and booleans are converted. Other values are returned as
+// is.
+func coerce(v, to any) (any, error) { //nolint:gocyclo // Just a switch.
+	if i, ok := v.(int); ok {
+		v = int64(i)
+	}
+	switch to.(type) {
+	case string:
+		switch t := v.(type) {
+		case string:
+			return t, nil
+		case bool:
+			return strconv.FormatBool(t), nil
+		case int64:
+			return strconv.FormatInt(t, 10), nil
+		case float64:
+			return strconv.FormatFloat(t, 'f', -1, 64), nil
+		}
+	case int64:
+		switch t := v.(type) {
+		case int64:
+			return t, nil
+		case float64:
+			if t == math.Trunc(t) {
+				return int64(t), nil
+			}
+		case string:
+			return strconv.ParseInt(t, 10, 64)
+		}
+	case float64:
+		switch t := v.(type) {
+		case float64:
+			return t, nil
+		case int64:
+			return float64(t), nil
+		case string:
+			return strconv.ParseFloat(t, 64)
+		}
+	case bool:
+		switch t := v.(type) {
+		case bool:
+			return t, nil
+		case string:
+			return strconv.ParseBool(t)
+		}
+	default:
+		return v, nil
+	}
+	return nil, errors.Errorf(errFmtCoerceType, v, to)
+}
+
+// mergeArrayByKey merges the supplied array of objects into the array of
+// objects at the supplied field path. Objects with the same value at the
+// supplied key are merged, and new objects are appended. The supplied value is
+// returned as is if there's no array at the field path yet.
+func mergeArrayByKey(paved *fieldpath.Paved, fieldPath string, value any, key string) (any, error) {
+	src, ok := value.([]any)
+	if !ok {
+		return nil, errors.Errorf(errFmtMergeKeyNotArray, fieldPath, key)
+	}
+
+	existing, err := paved.GetValue(fieldPath)
+	if fieldpath.IsNotFound(err) {
+		return value, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	dst, ok := existing.([]any)
+	if !ok {
+		return nil, errors.Errorf(errFmtMergeKeyNotArray, fieldPath, key)
+	}
+
+	out := make([]any, len(dst), len(dst)+len(src))
+	copy(out, dst)
+
+	for i := range src {
+		s, ok := src[i].(map[string]any)
+		if !ok || s[key] == nil {
+			return nil, errors.Errorf(errFmtMergeKeyMissing, i, key)
+		}
+
+		merged := false
+		for j := range out {
+			d, ok := out[j].(map[string]any)
+			if !ok || !reflect.DeepEqual(d[key], s[key]) {
+				continue
+			}
+			out[j] = mergeObjects(d, s)
+			merged = true
+			break
+		}
+		if !merged {
+			out = append(out, s)
+		}
+	}
+
+	return out, nil
+}
+
+// mergeObjects returns a new object containing the fields of dst, overridden
+// by the fields of src. Fields that are objects in both are merged
+// recursively.
+func mergeObjects(dst, src map[string]any) map[string]any {
+	out := make(map[string]any, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, v := range src {
+		d, dok := out[k].(map[string]any)
+		s, sok := v.(map[string]any)
+		if dok && sok {
+			out[k] = mergeObjects(d, s)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// checkToFieldPathParent returns an error if the supplied patch policy
+// requires the parent of the supplied field path to exist, and it doesn't.
+// This catches patches that would otherwise silently create a new subtree,
+// for example because the field path contains a typo.
+func checkToFieldPathParent(paved *fieldpath.Paved, fieldPath string, pp *v1beta1.PatchPolicy) error {
+	if pp.GetToFieldPathPolicy() != v1beta1.ToFieldPathPolicyRequired {
+		return nil
+	}
+
+	segments, err := fieldpath.Parse(fieldPath)
+	if err != nil {
+		return err
+	}
+
+	// Top level fields have no parent, other than the object itself.
+	if len(segments) < 2 {
+		return nil
+	}
+
+	parent := segments[:len(segments)-1].String()
+	if _, err := paved.GetValue(parent); err != nil {
+		if fieldpath.IsNotFound(err) {
+			return errors.Errorf(errFmtToFieldPathParentNotFound, parent, fieldPath)
+		}
+		return err
+	}
+	return nil
+}
+
+// A fieldWrite is a value to be written to a field path.
+type fieldWrite struct {
+	path      string
+	value     any
+	typ       v1beta1.PatchType
+	index     int
+	sensitive bool
+}
+
+// Batch accumulates the values that a resource's patches write to it, so
+// they can be written in one pass rather than once per patch. Only plain
+// writes are accumulated - patches whose policy depends on the value that's
+// already at their ToFieldPath must be applied after the writes are written.
+type Batch struct {
+	writes []fieldWrite
+}
+
+// Batchable returns true if the supplied patch's value can be accumulated and
+// written later, in order with the other accumulated values.
+func Batchable(p Interface) bool {
+	switch p.GetType() { //nolint:exhaustive // Only these patch types write plain values to a composed resource.
+	case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeFromEnvironmentFieldPath,
+		v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCombineFromEnvironment:
+	default:
+		return false
+	}
+	pp := p.GetPolicy()
+	return !strings.Contains(p.GetToFieldPath(), "[*]") &&
+		pp.GetMergeKey() == "" &&
+		!pp.GetCoerceToExistingType() &&
+		pp.GetToFieldPathPolicy() != v1beta1.ToFieldPathPolicyRequired &&
+		!pp.GetCreateOnly() && !pp.GetFreeze() && !pp.GetMemoize() && !pp.GetSkipUnchanged()
+}
+
+// Add the value of the supplied patch, which is at the supplied index, read
+// from the supplied object. Nothing is added if the patch's source field is
+// optional and doesn't exist.
+func (w *Batch) Add(ctx context.Context, p Interface, i int, from runtime.Object) error {
+	var out any
+	var ok bool
+	var err error
+	switch p.GetType() { //nolint:exhaustive // Only batchable patch types are added.
+	case v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCombineFromEnvironment:
+		if p.GetToFieldPath() == "" {
+			return errors.Errorf(errFmtRequiredField, "ToFieldPath", p.GetType())
+		}
+		out, ok, err = resolveCombineFromVariablesPatch(ctx, p, from)
+	default:
+		out, ok, err = resolveFromFieldPathPatch(ctx, p, from)
+	}
+	if err != nil && p.IsSensitive() {
+		return errors.Errorf(errFmtSensitivePatch, p.GetToFieldPath())
+	}
+	if err != nil || !ok {
+		return err
+	}
+	w.writes = append(w.writes, fieldWrite{path: p.GetToFieldPath(), value: out, typ: p.GetType(), index: i, sensitive: p.IsSensitive()})
+	return nil
+}
+
+// Write the accumulated values to the supplied object, in the order they were
+// added, then forget them. The object is paved once, and all values are
+// converted to valid JSON in one round trip rather than one per value. The
+// returned error identifies the patch whose value couldn't be written.
+func (w *Batch) Write(to runtime.Object) error {
+	if len(w.writes) == 0 {
+		return nil
+	}
+	writes := w.writes
+	w.writes = w.writes[:0]
+
+	paved, err := fieldpaths.Pave(to)
+	if err != nil {
+		return err
+	}
+
+	values := make([]any, len(writes))
+	for i := range writes {
+		values[i] = writes[i].value
+	}
+	valid, err := validJSONValues(values)
+	for i, wr := range writes {
+		// If we can't convert the values in one go let SetValue convert each,
+		// so the error is attributed to the patch that produced the value.
+		if err := setValidValue(paved, wr.path, wr.value, valid, i, err); err != nil {
+			err = errors.Wrap(err, "cannot patch to object")
+			if wr.sensitive {
+				err = errors.Errorf(errFmtSensitivePatch, wr.path)
+			}
+			return errors.Wrapf(err, errFmtPatch, wr.typ, wr.index)
+		}
+	}
+	return fromPaved(paved, to)
+}
+
+// setValidValue sets the value at the supplied index of the supplied valid
+// JSON values at the supplied field path. Field paths that only contain
+// fields are set directly, without converting the value again. Other field
+// paths, or any path if converting the values failed, are set using SetValue,
+// which converts the supplied original value.
+func setValidValue(paved *fieldpath.Paved, path string, original any, valid []any, i int, convertErr error) error {
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse path %q", path)
+	}
+	if convertErr != nil || len(segments) == 0 {
+		return paved.SetValue(path, original)
+	}
+	for _, s := range segments {
+		if s.Type != fieldpath.SegmentField {
+			return paved.SetValue(path, valid[i])
+		}
+	}
+
+	// This matches how SetValue creates missing objects, and the error it
+	// returns when a parent isn't an object.
+	obj := paved.UnstructuredContent()
+	for j, s := range segments[:len(segments)-1] {
+		v, ok := obj[s.Field]
+		if !ok {
+			v = map[string]any{}
+			obj[s.Field] = v
+		}
+		child, ok := v.(map[string]any)
+		if !ok {
+			return errors.Errorf("%s is not an object", segments[:j+1])
+		}
+		obj = child
+	}
+	obj[segments[len(segments)-1].Field] = valid[i]
+	return nil
+}
+
+// validJSONValues returns the supplied values after a round trip through JSON,
+// so they only contain the types that unmarshalling JSON produces.
+func validJSONValues(value []any) ([]any, error) {
+	j, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal value to JSON")
+	}
+	out := make([]any, 0, len(value))
+	return out, errors.Wrap(json.Unmarshal(j, &out), "cannot unmarshal value from JSON")
+}
+
+// fromPaved writes the supplied paved content back to the "to" object. Paving
+// an unstructured object doesn't copy it, so in that case we only need to set
+// its content. This avoids an expensive JSON round trip for each patch.
+func fromPaved(paved *fieldpath.Paved, to runtime.Object) error {
+	if u, ok := to.(runtime.Unstructured); ok {
+		u.SetUnstructuredContent(paved.UnstructuredContent())
+		return nil
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(paved.UnstructuredContent(), to)
+}
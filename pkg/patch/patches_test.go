@@ -0,0 +1,2266 @@
+package patch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+func TestPatchApply(t *testing.T) {
+	errNotFound := func(path string) error {
+		p := &fieldpath.Paved{}
+		_, err := p.GetValue(path)
+		return err
+	}
+
+	type args struct {
+		patch v1beta1.ComposedPatch
+		xr    *composite.Unstructured
+		cd    *composed.Unstructured
+		only  []v1beta1.PatchType
+	}
+	type want struct {
+		xr  *composite.Unstructured
+		cd  *composed.Unstructured
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"InvalidCompositeFieldPathPatch": {
+			reason: "Should return error when required fields not passed to applyFromFieldPathPatch",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					// This is missing fields.
+				},
+				xr: &composite.Unstructured{},
+				cd: &composed.Unstructured{},
+			},
+			want: want{
+				err: errors.Errorf(errFmtRequiredField, "FromFieldPath", v1beta1.PatchTypeFromCompositeFieldPath),
+			},
+		},
+		"Invalidv1.PatchType": {
+			reason: "Should return an error if an invalid patch type is specified",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: "invalid-patchtype",
+				},
+				xr: &composite.Unstructured{},
+				cd: &composed.Unstructured{},
+			},
+			want: want{
+				err: errors.Errorf(errFmtInvalidPatchType, "invalid-patchtype"),
+			},
+		},
+		"SensitivePatchError": {
+			reason: "Should omit errors returned by a sensitive patch, since they may contain sensitive values",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.password"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeMap,
+							Map: &v1beta1.MapTransform{
+								Pairs: map[string]extv1.JSON{"a": {Raw: []byte(`"b"`)}},
+							},
+						}},
+						Sensitive: ptr.To(true),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"password": "hunter2"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{},
+			},
+			want: want{
+				err: errors.Errorf(errFmtSensitivePatch, "spec.password"),
+			},
+		},
+		"ValidCompositeFieldPathPatch": {
+			reason: "Should correctly apply a CompositeFieldPathPatch with valid settings",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.labels"),
+						ToFieldPath:   ptr.To[string]("metadata.labels"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "cd"
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "cd",
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				err: nil,
+			},
+		},
+		"ValidCompositeFieldPathPatchWithWildcards": {
+			reason: "When passed a wildcarded path, adds a field to each element of an array",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.name"),
+						ToFieldPath:   ptr.To[string]("metadata.ownerReferences[*].name"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"ownerReferences": [
+								{
+									"name": ""
+								},
+								{
+									"name": ""
+								}
+							]
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"ownerReferences": [
+								{
+									"name": "test"
+								},
+								{
+									"name": "test"
+								}
+							]
+						}
+					}`)},
+				},
+			},
+		},
+		"InvalidCompositeFieldPathPatchWithWildcards": {
+			reason: "When passed a wildcarded path, throws an error if ToFieldPath cannot be expanded",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.name"),
+						ToFieldPath:   ptr.To[string]("metadata.ownerReferences[*].badField"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"ownerReferences": [
+								{
+									"name": "test"
+								},
+								{
+									"name": "test"
+								}
+							]
+						}
+					}`)},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtExpandingArrayFieldPaths, "metadata.ownerReferences[*].badField"),
+			},
+		},
+		"MissingOptionalFieldPath": {
+			reason: "A FromFieldPath patch should be a no-op when an optional fromFieldPath doesn't exist",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.labels"),
+						ToFieldPath:   ptr.To[string]("metadata.labels"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				err: nil,
+			},
+		},
+		"MissingRequiredFieldPath": {
+			reason: "A FromFieldPath patch should return an error when a required fromFieldPath doesn't exist",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("wat"),
+						Policy: &v1beta1.PatchPolicy{
+							FromFieldPath: func() *v1beta1.FromFieldPathPolicy {
+								s := v1beta1.FromFieldPathPolicyRequired
+								return &s
+							}(),
+						},
+						ToFieldPath: ptr.To[string]("wat"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				err: errNotFound("wat"),
+			},
+		},
+		"EmptyOptionalNonEmptyFieldPath": {
+			reason: "A FromFieldPath patch should be a no-op when an OptionalNonEmpty fromFieldPath is empty",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						Policy:        &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyOptionalNonEmpty)},
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": ""}}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+		},
+		"EmptyRequiredNonEmptyFieldPath": {
+			reason: "A FromFieldPath patch should return an error when a RequiredNonEmpty fromFieldPath is empty",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						Policy:        &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequiredNonEmpty)},
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": []}}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtFromFieldPathEmpty, "spec.size"),
+			},
+		},
+		"EmptyFieldPathDefault": {
+			reason: "A FromFieldPath patch should patch its default when an OptionalNonEmpty fromFieldPath is empty",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						Policy: &v1beta1.PatchPolicy{
+							FromFieldPath:        ptr.To(v1beta1.FromFieldPathPolicyOptionalNonEmpty),
+							FromFieldPathDefault: &extv1.JSON{Raw: []byte(`"small"`)},
+						},
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": 0}}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": "small"}}`)},
+				},
+			},
+		},
+		"MissingFieldPathDefault": {
+			reason: "A FromFieldPath patch should patch its default when its fromFieldPath doesn't exist",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						Policy:        &v1beta1.PatchPolicy{FromFieldPathDefault: &extv1.JSON{Raw: []byte(`"small"`)}},
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {}}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": "small"}}`)},
+				},
+			},
+		},
+		"PresentFieldPathDefault": {
+			reason: "A FromFieldPath patch should patch a non-empty value rather than its default",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						Policy: &v1beta1.PatchPolicy{
+							FromFieldPath:        ptr.To(v1beta1.FromFieldPathPolicyOptionalNonEmpty),
+							FromFieldPathDefault: &extv1.JSON{Raw: []byte(`"small"`)},
+						},
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": "large"}}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"size": "large"}}`)},
+				},
+			},
+		},
+		"MissingRequiredToFieldPathParent": {
+			reason: "A FromFieldPath patch should return an error when the parent of a required toFieldPath doesn't exist",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.name"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To[v1beta1.ToFieldPathPolicy](v1beta1.ToFieldPathPolicyRequired),
+						},
+						ToFieldPath: ptr.To[string]("spec.forPrivoder.name"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {}
+						}
+					}`)},
+				},
+				err: errors.Wrap(errors.Errorf(errFmtToFieldPathParentNotFound, "spec.forPrivoder", "spec.forPrivoder.name"), "cannot patch to object"),
+			},
+		},
+		"MergeArrayByKey": {
+			reason: "A FromFieldPath patch with a merge key should merge patched objects into existing objects with the same key",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.rules"),
+						Policy: &v1beta1.PatchPolicy{
+							MergeKey: ptr.To[string]("name"),
+						},
+						ToFieldPath: ptr.To[string]("spec.forProvider.rules"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"spec": {
+							"rules": [
+								{"name": "http", "port": "8080"},
+								{"name": "https", "port": "443"}
+							]
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {
+								"rules": [
+									{"name": "ssh", "port": "22"},
+									{"name": "http", "port": "80", "protocol": "tcp"}
+								]
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {
+								"rules": [
+									{"name": "ssh", "port": "22"},
+									{"name": "http", "port": "8080", "protocol": "tcp"},
+									{"name": "https", "port": "443"}
+								]
+							}
+						}
+					}`)},
+				},
+			},
+		},
+		"MergeArrayByKeyMissingKey": {
+			reason: "A FromFieldPath patch with a merge key should return an error if a patched object has no value at the merge key",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.rules"),
+						Policy: &v1beta1.PatchPolicy{
+							MergeKey: ptr.To[string]("name"),
+						},
+						ToFieldPath: ptr.To[string]("spec.forProvider.rules"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"spec": {
+							"rules": [
+								{"port": "8080"}
+							]
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {
+								"rules": [
+									{"name": "http", "port": "80"}
+								]
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errors.Errorf(errFmtMergeKeyMissing, 0, "name"), "cannot patch to object"),
+			},
+		},
+		"CoerceToExistingType": {
+			reason: "A FromFieldPath patch that coerces to the existing type should convert the patched value to the type of the existing value",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.port"),
+						Policy: &v1beta1.PatchPolicy{
+							CoerceToExistingType: ptr.To(true),
+						},
+						ToFieldPath: ptr.To[string]("spec.forProvider.port"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"spec": {
+							"port": 8080
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {
+								"port": "80"
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {
+								"port": "8080"
+							}
+						}
+					}`)},
+				},
+			},
+		},
+		"ExistingRequiredToFieldPathParent": {
+			reason: "A FromFieldPath patch should patch a required toFieldPath when its parent exists",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.name"),
+						Policy: &v1beta1.PatchPolicy{
+							ToFieldPath: ptr.To[v1beta1.ToFieldPathPolicy](v1beta1.ToFieldPathPolicyRequired),
+						},
+						ToFieldPath: ptr.To[string]("spec.forProvider.name"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"spec": {
+							"forProvider": {
+								"name": "test"
+							}
+						}
+					}`)},
+				},
+			},
+		},
+		"FilterExcludeCompositeFieldPathPatch": {
+			reason: "Should not apply the patch as the v1.PatchType is not present in filter.",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.labels"),
+						ToFieldPath:   ptr.To[string]("metadata.labels"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				cd:   &composed.Unstructured{},
+				only: []v1beta1.PatchType{v1beta1.PatchTypePatchSet},
+			},
+			want: want{
+				cd:  &composed.Unstructured{},
+				err: nil,
+			},
+		},
+		"FilterIncludeCompositeFieldPathPatch": {
+			reason: "Should apply the patch as the v1.PatchType is present in filter.",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.labels"),
+						ToFieldPath:   ptr.To[string]("metadata.labels"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed"
+					}`)},
+				},
+				only: []v1beta1.PatchType{v1beta1.PatchTypeFromCompositeFieldPath},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				err: nil,
+			},
+		},
+		"DefaultToFieldCompositeFieldPathPatch": {
+			reason: "Should correctly default the ToFieldPath value if not specified.",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.labels"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed"
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				err: nil,
+			},
+		},
+		"ValidToCompositeFieldPathPatch": {
+			reason: "Should correctly apply a ToCompositeFieldPath patch with valid settings",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.labels"),
+						ToFieldPath:   ptr.To[string]("metadata.labels"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR"
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				err: nil,
+			},
+		},
+		"ValidToCompositeFieldPathPatchWithWildcards": {
+			reason: "When passed a wildcarded path, adds a field to each element of an array",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("metadata.name"),
+						ToFieldPath:   ptr.To[string]("metadata.ownerReferences[*].name"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"ownerReferences": [
+								{
+									"name": ""
+								},
+								{
+									"name": ""
+								}
+							]
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"name": "test"
+						}
+					}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"ownerReferences": [
+								{
+									"name": "test"
+								},
+								{
+									"name": "test"
+								}
+							]
+						}
+					}`)},
+				},
+			},
+		},
+		"MissingCombineFromCompositeConfig": {
+			reason: "Should return an error if Combine config is not passed",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+						// Missing a Combine field
+						Combine: nil,
+					},
+				},
+				xr: &composite.Unstructured{},
+				cd: &composed.Unstructured{},
+			},
+			want: want{
+				xr:  &composite.Unstructured{},
+				cd:  &composed.Unstructured{},
+				err: errors.Errorf(errFmtRequiredField, "Combine", v1beta1.PatchTypeCombineFromComposite),
+			},
+		},
+		"MissingCombineStrategyFromCompositeConfig": {
+			reason: "Should return an error if Combine strategy config is not passed",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{FromFieldPath: "metadata.labels.source2"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							// Missing a String combine config.
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"source1": "a",
+								"source2": "b"
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				err: func() error {
+					_, err := transforms.Combine(v1beta1.Combine{Strategy: v1beta1.CombineStrategyString}, nil)
+					return err
+				}(),
+			},
+		},
+		"MissingCombineVariablesFromCompositeConfig": {
+			reason: "Should return an error if no variables have been passed",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							// This is empty.
+							Variables: []v1beta1.CombineVariable{},
+							Strategy:  v1beta1.CombineStrategyString,
+							String:    &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("objectMeta.labels.destination"),
+					},
+				},
+			},
+			want: want{
+				err: errors.New(errCombineRequiresVariables),
+			},
+		},
+		"NoOpOptionalInputFieldFromCompositeConfig": {
+			// Note: OptionalFieldPathNotFound is tested below, but we want to
+			// test that we abort the patch if _any_ of our source fields are
+			// not available.
+			reason: "Should return no error and not apply patch if an optional variable is missing",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{FromFieldPath: "metadata.labels.source2"},
+								{FromFieldPath: "metadata.labels.source3"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"source1": "foo",
+								"source3": "baz"
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				err: nil,
+			},
+		},
+		"ValidCombineFromComposite": {
+			reason: "Should correctly apply a CombineFromComposite patch with valid settings",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{FromFieldPath: "metadata.labels.source2"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "XR",
+						"metadata": {
+							"labels": {
+								"source1": "foo",
+								"source2": "bar"
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"labels": {
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"apiVersion": "test.crossplane.io/v1",
+						"kind": "Composed",
+						"metadata": {
+							"labels": {
+								"destination": "foo-bar",
+								"test": "blah"
+							}
+						}
+					}`)},
+				},
+				err: nil,
+			},
+		},
+		"ValidCombineToComposite": {
+			reason: "Should correctly apply a CombineToComposite patch with valid settings",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineToComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "metadata.labels.source1"},
+								{FromFieldPath: "metadata.labels.source2"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "%s-%s"},
+						},
+						ToFieldPath: ptr.To[string]("metadata.labels.destination"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"metadata": {
+								"labels": {
+									"test": "blah"
+								}
+							}
+						}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "Composed",
+							"metadata": {
+								"labels": {
+									"source1": "foo",
+									"source2": "bar"
+								}
+							}
+						}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+							"apiVersion": "test.crossplane.io/v1",
+							"kind": "XR",
+							"metadata": {
+								"labels": {
+									"destination": "foo-bar",
+									"test": "blah"
+								}
+							}
+						}`)},
+				},
+				err: nil,
+			},
+		},
+		"CopyFromCompositeFieldPathExclude": {
+			reason: "Should copy the whole object at the fromFieldPath except the excluded fields, without modifying the XR",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCopyFromCompositeFieldPath,
+					Copy: &v1beta1.CopyFilter{
+						Exclude: []string{"advanced.mtu", "advanced.jumboFrames"},
+					},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.parameters.network"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.network"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"parameters": {
+								"network": {
+									"cidr": "10.0.0.0/16",
+									"advanced": {
+										"mtu": "9001",
+										"jumboFrames": "true",
+										"dns": "enabled"
+									}
+								}
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"parameters": {
+								"network": {
+									"cidr": "10.0.0.0/16",
+									"advanced": {
+										"mtu": "9001",
+										"jumboFrames": "true",
+										"dns": "enabled"
+									}
+								}
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"forProvider": {
+								"network": {
+									"cidr": "10.0.0.0/16",
+									"advanced": {
+										"dns": "enabled"
+									}
+								}
+							}
+						}
+					}`)},
+				},
+			},
+		},
+		"CopyFromCompositeFieldPathInclude": {
+			reason: "Should copy only the included fields of the object at the fromFieldPath, less any excluded fields",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCopyFromCompositeFieldPath,
+					Copy: &v1beta1.CopyFilter{
+						Include: []string{"cidr", "advanced", "nonexistent"},
+						Exclude: []string{"advanced[*]"},
+					},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.parameters.network"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.network"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"parameters": {
+								"network": {
+									"cidr": "10.0.0.0/16",
+									"advanced": {
+										"mtu": "9001",
+										"jumboFrames": "true",
+										"dns": "enabled"
+									}
+								}
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"forProvider": {
+								"network": {
+									"cidr": "10.0.0.0/16",
+									"advanced": {}
+								}
+							}
+						}
+					}`)},
+				},
+			},
+		},
+		"CopyFromCompositeFieldPathNotObject": {
+			reason: "Should return an error if the value at the fromFieldPath isn't an object",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCopyFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.parameters.network.cidr"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"spec": {
+							"parameters": {
+								"network": {
+									"cidr": "10.0.0.0/16",
+									"advanced": {
+										"mtu": "9001",
+										"jumboFrames": "true",
+										"dns": "enabled"
+									}
+								}
+							}
+						}
+					}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCopyNotObject, "spec.parameters.network.cidr", "10.0.0.0/16"),
+			},
+		},
+		"ConditionToComposite": {
+			reason: "Should patch the message of the named condition of the composed resource to the XR",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeConditionToComposite,
+					Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("status.error"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"status": {
+							"conditions": [
+								{"type": "Ready", "status": "False", "reason": "Creating"},
+								{"type": "Synced", "status": "False", "reason": "ReconcileError", "message": "cannot create bucket: access denied"}
+							]
+						}
+					}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"status": {
+							"error": "cannot create bucket: access denied"
+						}
+					}`)},
+				},
+			},
+		},
+		"ConditionToCompositeReason": {
+			reason: "Should patch the selected field of the named condition of the composed resource to the XR",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeConditionToComposite,
+					Condition: &v1beta1.ConditionSelector{Type: "Ready", Field: v1beta1.ConditionFieldReason},
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("status.reason"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"status": {
+							"conditions": [
+								{"type": "Ready", "status": "False", "reason": "Creating"}
+							]
+						}
+					}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"status": {
+							"reason": "Creating"
+						}
+					}`)},
+				},
+			},
+		},
+		"ConditionToCompositeMissingCondition": {
+			reason: "Should not patch the XR if the composed resource doesn't have the named condition",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeConditionToComposite,
+					Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("status.error"),
+					},
+				},
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+				cd: &composed.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{
+						"status": {
+							"conditions": [
+								{"type": "Ready", "status": "True", "reason": "Available"}
+							]
+						}
+					}`)},
+				},
+			},
+			want: want{
+				xr: &composite.Unstructured{
+					Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ncp := tc.args.xr.DeepCopyObject().(resource.Composite)
+			err := Apply(context.Background(), &tc.args.patch, ncp, tc.args.cd, tc.args.only...)
+
+			if tc.want.xr != nil {
+				if diff := cmp.Diff(tc.want.xr, ncp); diff != "" {
+					t.Errorf("\n%s\nApply(cp): -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if tc.want.cd != nil {
+				if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+					t.Errorf("\n%s\nApply(cd): -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApply(err): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func MustObject(j string) map[string]any {
+	out := map[string]any{}
+	if err := json.Unmarshal([]byte(j), &out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func TestCoerce(t *testing.T) {
+	type args struct {
+		v  any
+		to any
+	}
+	type want struct {
+		out any
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"IntToString": {
+			reason: "An integer should be converted to a string",
+			args:   args{v: int64(42), to: "cool"},
+			want:   want{out: "42"},
+		},
+		"FloatToString": {
+			reason: "A float should be converted to a string without an exponent",
+			args:   args{v: 1.5, to: "cool"},
+			want:   want{out: "1.5"},
+		},
+		"BoolToString": {
+			reason: "A boolean should be converted to a string",
+			args:   args{v: true, to: "cool"},
+			want:   want{out: "true"},
+		},
+		"StringToInt": {
+			reason: "A string containing an integer should be converted to an integer",
+			args:   args{v: "42", to: int64(1)},
+			want:   want{out: int64(42)},
+		},
+		"WholeFloatToInt": {
+			reason: "A whole float should be converted to an integer",
+			args:   args{v: 42.0, to: int64(1)},
+			want:   want{out: int64(42)},
+		},
+		"FractionalFloatToInt": {
+			reason: "A fractional float can't be converted to an integer",
+			args:   args{v: 4.2, to: int64(1)},
+			want:   want{err: errors.Errorf(errFmtCoerceType, 4.2, int64(1))},
+		},
+		"IntToFloat": {
+			reason: "An integer should be converted to a float",
+			args:   args{v: int64(42), to: 1.0},
+			want:   want{out: 42.0},
+		},
+		"StringToBool": {
+			reason: "A string containing a boolean should be converted to a boolean",
+			args:   args{v: "true", to: false},
+			want:   want{out: true},
+		},
+		"IntToBool": {
+			reason: "An integer can't be converted to a boolean",
+			args:   args{v: int64(1), to: false},
+			want:   want{err: errors.Errorf(errFmtCoerceType, int64(1), false)},
+		},
+		"Object": {
+			reason: "A value should be returned as is if the existing value is an object",
+			args:   args{v: "cool", to: map[string]any{}},
+			want:   want{out: "cool"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := coerce(tc.args.v, tc.args.to)
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("\n%s\ncoerce(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ncoerce(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestOptionalFieldPathNotFound(t *testing.T) {
+	errBoom := errors.New("boom")
+	errNotFound := func() error {
+		p := &fieldpath.Paved{}
+		_, err := p.GetValue("boom")
+		return err
+	}
+	required := v1beta1.FromFieldPathPolicyRequired
+	optional := v1beta1.FromFieldPathPolicyOptional
+	type args struct {
+		err error
+		p   *v1beta1.PatchPolicy
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want bool
+	}{
+		"NotAnError": {
+			reason: "Should perform patch if no error finding field.",
+			args:   args{},
+			want:   false,
+		},
+		"NotFieldNotFoundError": {
+			reason: "Should return error if something other than field not found.",
+			args: args{
+				err: errBoom,
+			},
+			want: false,
+		},
+		"DefaultOptionalNoPolicy": {
+			reason: "Should return no-op if field not found and no patch policy specified.",
+			args: args{
+				err: errNotFound(),
+			},
+			want: true,
+		},
+		"DefaultOptionalNoPathPolicy": {
+			reason: "Should return no-op if field not found and empty patch policy specified.",
+			args: args{
+				p:   &v1beta1.PatchPolicy{},
+				err: errNotFound(),
+			},
+			want: true,
+		},
+		"OptionalNotFound": {
+			reason: "Should return no-op if field not found and optional patch policy explicitly specified.",
+			args: args{
+				p: &v1beta1.PatchPolicy{
+					FromFieldPath: &optional,
+				},
+				err: errNotFound(),
+			},
+			want: true,
+		},
+		"RequiredNotFound": {
+			reason: "Should return error if field not found and required patch policy explicitly specified.",
+			args: args{
+				p: &v1beta1.PatchPolicy{
+					FromFieldPath: &required,
+				},
+				err: errNotFound(),
+			},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := IsOptionalFieldPathNotFound(tc.args.err, tc.args.p)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("IsOptionalFieldPathNotFound(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectPatchSets(t *testing.T) {
+	type args struct {
+		cts []v1beta1.ComposedTemplate
+		xr  *composite.Unstructured
+		env *unstructured.Unstructured
+	}
+	type want struct {
+		cts []v1beta1.ComposedTemplate
+		err error
+	}
+
+	include := func(name string, c *v1beta1.PatchSetCondition) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{Type: v1beta1.PatchTypePatchSet, PatchSetName: ptr.To(name), When: c}
+	}
+	prod := &v1beta1.PatchSetCondition{FromFieldPath: "metadata.labels.environment", Equals: "prod"}
+	region := v1beta1.ComposedPatch{
+		Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.region")},
+	}
+	xr := func(env string) *composite.Unstructured {
+		return &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(fmt.Sprintf(`{
+			"metadata": {"labels": {"environment": %q}}
+		}`, env))}}
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ConditionMet": {
+			reason: "A PatchSet should be included when the XR meets its condition.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{include("prod-hardening", prod), region}}},
+				xr:  xr("prod"),
+				env: &unstructured.Unstructured{},
+			},
+			want: want{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{include("prod-hardening", prod), region}}},
+			},
+		},
+		"ConditionNotMet": {
+			reason: "A PatchSet shouldn't be included when the XR doesn't meet its condition.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{include("prod-hardening", prod), region}}},
+				xr:  xr("dev"),
+				env: &unstructured.Unstructured{},
+			},
+			want: want{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{region}}},
+			},
+		},
+		"FieldNotFound": {
+			reason: "A PatchSet shouldn't be included when the field its condition reads doesn't exist.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{include("prod-hardening", prod)}}},
+				xr:  &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				env: &unstructured.Unstructured{},
+			},
+			want: want{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{}}},
+			},
+		},
+		"Unconditional": {
+			reason: "A PatchSet without a condition should always be included.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{include("common", nil)}}},
+				xr:  xr("dev"),
+				env: &unstructured.Unstructured{},
+			},
+			want: want{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{include("common", nil)}}},
+			},
+		},
+		"EnvironmentSource": {
+			reason: "A PatchSet should be included when the environment meets its condition.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{
+					include("prod-hardening", &v1beta1.PatchSetCondition{Source: v1beta1.PatchSetConditionSourceEnvironment, FromFieldPath: "tier", Equals: "prod"}),
+				}}},
+				xr:  xr("dev"),
+				env: &unstructured.Unstructured{Object: MustObject(`{"tier": "prod"}`)},
+			},
+			want: want{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{
+					include("prod-hardening", &v1beta1.PatchSetCondition{Source: v1beta1.PatchSetConditionSourceEnvironment, FromFieldPath: "tier", Equals: "prod"}),
+				}}},
+			},
+		},
+		"NotAString": {
+			reason: "We should return an error if the field a condition reads isn't a string.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{Name: "cool", Patches: []v1beta1.ComposedPatch{
+					include("prod-hardening", &v1beta1.PatchSetCondition{FromFieldPath: "metadata.labels", Equals: "prod"}),
+				}}},
+				xr:  xr("prod"),
+				env: &unstructured.Unstructured{},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf("%s: not a string", "metadata.labels"), errFmtPatchSetCondition, "cool", 0),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := SelectPatchSets(tc.args.cts, tc.args.xr, tc.args.env)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nSelectPatchSets(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cts, got); diff != "" {
+				t.Errorf("\n%s\nSelectPatchSets(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestComposedTemplates(t *testing.T) {
+	asJSON := func(val interface{}) extv1.JSON {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := extv1.JSON{}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	type args struct {
+		pss []v1beta1.PatchSet
+		cts []v1beta1.ComposedTemplate
+	}
+
+	type want struct {
+		ct  []v1beta1.ComposedTemplate
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NoCompositionPatchSets": {
+			reason: "Patches defined on a composite resource should be applied correctly if no PatchSets are defined on the composition",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				ct: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"UndefinedPatchSet": {
+			reason: "Should return error and not modify the patches field when referring to an undefined PatchSet",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{
+					Patches: []v1beta1.ComposedPatch{
+						{
+							Type:         v1beta1.PatchTypePatchSet,
+							PatchSetName: ptr.To[string]("patch-set-1"),
+						},
+					},
+				}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtUndefinedPatchSet, "patch-set-1"),
+			},
+		},
+		"DefinedPatchSets": {
+			reason: "Should de-reference PatchSets defined on the Composition when referenced in a composed resource",
+			args: args{
+				// PatchSets, existing patches and references
+				// should output in the correct order.
+				pss: []v1beta1.PatchSet{
+					{
+						Name: "patch-set-1",
+						Patches: []v1beta1.PatchSetPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("spec.parameters.test"),
+								},
+							},
+						},
+					},
+					{
+						Name: "patch-set-2",
+						Patches: []v1beta1.PatchSetPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-1"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-2"),
+									Transforms: []v1beta1.Transform{{
+										Type: v1beta1.TransformTypeMap,
+										Map: &v1beta1.MapTransform{
+											Pairs: map[string]extv1.JSON{
+												"k-1": asJSON("v-1"),
+												"k-2": asJSON("v-2"),
+											},
+										},
+									}},
+								},
+							},
+						},
+					},
+				},
+				cts: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("patch-set-2"),
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("patch-set-1"),
+							},
+						},
+					},
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("patch-set-1"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: nil,
+				ct: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-1"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.annotations.patch-test-2"),
+									Transforms: []v1beta1.Transform{{
+										Type: v1beta1.TransformTypeMap,
+										Map: &v1beta1.MapTransform{
+											Pairs: map[string]extv1.JSON{
+												"k-1": asJSON("v-1"),
+												"k-2": asJSON("v-2"),
+											},
+										},
+									}},
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.name"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("spec.parameters.test"),
+								},
+							},
+						},
+					},
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("metadata.namespace"),
+								},
+							},
+							{
+								Type: v1beta1.PatchTypeFromCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("spec.parameters.test"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"PatchSetsToComposite": {
+			reason: "Should de-reference PatchSets that patch the XR, including their condition selectors",
+			args: args{
+				pss: []v1beta1.PatchSet{
+					{
+						Name: "surface-status",
+						Patches: []v1beta1.PatchSetPatch{
+							{
+								Type: v1beta1.PatchTypeToCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("status.atProvider.id"),
+									ToFieldPath:   ptr.To[string]("status.id"),
+								},
+							},
+							{
+								Type:      v1beta1.PatchTypeConditionToComposite,
+								Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+								Patch: v1beta1.Patch{
+									ToFieldPath: ptr.To[string]("status.error"),
+								},
+							},
+						},
+					},
+				},
+				cts: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type:         v1beta1.PatchTypePatchSet,
+								PatchSetName: ptr.To[string]("surface-status"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				ct: []v1beta1.ComposedTemplate{
+					{
+						Patches: []v1beta1.ComposedPatch{
+							{
+								Type: v1beta1.PatchTypeToCompositeFieldPath,
+								Patch: v1beta1.Patch{
+									FromFieldPath: ptr.To[string]("status.atProvider.id"),
+									ToFieldPath:   ptr.To[string]("status.id"),
+								},
+							},
+							{
+								Type:      v1beta1.PatchTypeConditionToComposite,
+								Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+								Patch: v1beta1.Patch{
+									ToFieldPath: ptr.To[string]("status.error"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ComposedTemplates(tc.args.pss, tc.args.cts)
+
+			if diff := cmp.Diff(tc.want.ct, got); diff != "" {
+				t.Errorf("\n%s\nrs.ComposedTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nrs.ComposedTemplates(...)): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func FuzzApplyToObjects(f *testing.F) {
+	f.Add([]byte(`{"type":"FromCompositeFieldPath","fromFieldPath":"spec.a","toFieldPath":"spec.b"}`), []byte(`{"spec":{"a":"a"}}`), []byte(`{}`))
+	f.Add([]byte(`{"type":"ToCompositeFieldPath","fromFieldPath":"spec.a[0].b","toFieldPath":"status.c[*]"}`), []byte(`{"status":{"c":[1]}}`), []byte(`{"spec":{"a":[{"b":1}]}}`))
+	f.Add([]byte(`{"type":"CombineFromComposite","combine":{"strategy":"string","variables":[{"fromFieldPath":"spec.a"}],"string":{"fmt":"%s"}},"toFieldPath":"metadata.labels[a.b/c]"}`), []byte(`{"spec":{"a":"a"}}`), []byte(`{}`))
+
+	f.Fuzz(func(_ *testing.T, patch, from, to []byte) {
+		p := &v1beta1.ComposedPatch{}
+		if err := json.Unmarshal(patch, p); err != nil {
+			return
+		}
+		for _, t := range p.Transforms {
+			// Webhook transforms would make real HTTP requests.
+			if t.Type == v1beta1.TransformTypeWebhook {
+				return
+			}
+		}
+		a := &unstructured.Unstructured{}
+		if err := json.Unmarshal(from, &a.Object); err != nil || a.Object == nil {
+			return
+		}
+		b := &unstructured.Unstructured{}
+		if err := json.Unmarshal(to, &b.Object); err != nil || b.Object == nil {
+			return
+		}
+
+		// We only care that we don't panic.
+		_ = ApplyToObjects(context.Background(), p, a, b)
+	})
+}
+
+func TestApplyToConnectionDetails(t *testing.T) {
+	cd := &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+		"status": {
+			"atProvider": {
+				"endpoint": "db.example.org",
+				"port": 5432
+			}
+		}
+	}`)}}
+
+	type args struct {
+		p    *v1beta1.ComposedPatch
+		from *composed.Unstructured
+	}
+	type want struct {
+		conn managed.ConnectionDetails
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"MissingConnectionDetailName": {
+			reason: "We should return an error if the patch doesn't name a connection detail.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:  v1beta1.PatchTypeToConnectionDetail,
+					Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.atProvider.endpoint")},
+				},
+				from: cd,
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+				err:  errors.Errorf(errFmtRequiredField, "ConnectionDetailName", v1beta1.PatchTypeToConnectionDetail),
+			},
+		},
+		"FromFieldPath": {
+			reason: "We should patch a string value to the named connection detail.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeToConnectionDetail,
+					ConnectionDetailName: ptr.To("host"),
+					Patch:                v1beta1.Patch{FromFieldPath: ptr.To("status.atProvider.endpoint")},
+				},
+				from: cd,
+			},
+			want: want{
+				conn: managed.ConnectionDetails{"host": []byte("db.example.org")},
+			},
+		},
+		"FromFieldPathNotString": {
+			reason: "We should encode a value that isn't a string as JSON.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeToConnectionDetail,
+					ConnectionDetailName: ptr.To("port"),
+					Patch:                v1beta1.Patch{FromFieldPath: ptr.To("status.atProvider.port")},
+				},
+				from: cd,
+			},
+			want: want{
+				conn: managed.ConnectionDetails{"port": []byte("5432")},
+			},
+		},
+		"OptionalFromFieldPathNotFound": {
+			reason: "We should not patch the connection detail if an optional field doesn't exist.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeToConnectionDetail,
+					ConnectionDetailName: ptr.To("user"),
+					Patch:                v1beta1.Patch{FromFieldPath: ptr.To("status.atProvider.user")},
+				},
+				from: cd,
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+			},
+		},
+		"Combine": {
+			reason: "We should combine values and patch them to the named connection detail.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeCombineToConnectionDetail,
+					ConnectionDetailName: ptr.To("url"),
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{FromFieldPath: "status.atProvider.endpoint"},
+								{FromFieldPath: "status.atProvider.port"},
+							},
+							Strategy: v1beta1.CombineStrategyString,
+							String:   &v1beta1.StringCombine{Format: "postgres://%s:%v"},
+						},
+					},
+				},
+				from: cd,
+			},
+			want: want{
+				conn: managed.ConnectionDetails{"url": []byte("postgres://db.example.org:5432")},
+			},
+		},
+		"ErrorOmitted": {
+			reason: "We should omit the details of any error, since connection details are sensitive.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeToConnectionDetail,
+					ConnectionDetailName: ptr.To("host"),
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("status.atProvider.endpoint"),
+						Transforms: []v1beta1.Transform{{
+							Type: v1beta1.TransformTypeMap,
+							Map:  &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"a": {Raw: []byte(`"b"`)}}},
+						}},
+					},
+				},
+				from: cd,
+			},
+			want: want{
+				conn: managed.ConnectionDetails{},
+				err:  errors.Errorf(errFmtSensitivePatch, "host"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			conn := managed.ConnectionDetails{}
+			err := ApplyToConnectionDetails(context.Background(), tc.args.p, tc.args.from, conn)
+			if diff := cmp.Diff(tc.want.conn, conn); diff != "" {
+				t.Errorf("%s\nApplyToConnectionDetails(...): -want conn, +got conn:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nApplyToConnectionDetails(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyToObjectsDoesNotShareValues(t *testing.T) {
+	xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+		"spec": {"parameters": {"tags": {"a": "b"}}}
+	}`)}}
+	cd := composed.New()
+
+	p := &v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To("spec.parameters.tags"),
+			ToFieldPath:   ptr.To("spec.forProvider.tags"),
+		},
+	}
+	if err := ApplyToObjects(context.Background(), p, xr, cd); err != nil {
+		t.Fatalf("ApplyToObjects(...): %v", err)
+	}
+
+	// Mutating the patched object must not mutate the object we patched from.
+	if err := fieldpath.Pave(cd.Object).SetValue("spec.forProvider.tags.a", "c"); err != nil {
+		t.Fatalf("SetValue(...): %v", err)
+	}
+	want := MustObject(`{"spec": {"parameters": {"tags": {"a": "b"}}}}`)
+	if diff := cmp.Diff(want, xr.Object); diff != "" {
+		t.Errorf("ApplyToObjects(...): -want, +got:\n%s", diff)
+	}
+}
+
+func BenchmarkApplyToObjects(b *testing.B) {
+	// A composite resource with a lot of fields, to simulate a big XR.
+	params := map[string]any{}
+	for i := 0; i < 100; i++ {
+		params[fmt.Sprintf("param%d", i)] = map[string]any{
+			"name":  fmt.Sprintf("value-%d", i),
+			"tags":  []any{"a", "b", "c"},
+			"count": int64(i),
+		}
+	}
+	xr := &composite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "XR",
+		"metadata":   map[string]any{"name": "cool-xr"},
+		"spec":       map[string]any{"parameters": params},
+	}}}
+
+	ps := make([]v1beta1.ComposedPatch, 0, len(params))
+	for i := 0; i < len(params); i++ {
+		ps = append(ps, v1beta1.ComposedPatch{
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To(fmt.Sprintf("spec.parameters.param%d.name", i)),
+				ToFieldPath:   ptr.To(fmt.Sprintf("spec.forProvider.param%d", i)),
+			},
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		cd := composed.New()
+		cd.SetAPIVersion("example.org/v1")
+		cd.SetKind("Composed")
+		for i := range ps {
+			if err := ApplyToObjects(context.Background(), &ps[i], xr, cd); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkComposedTemplates(b *testing.B) {
+	pss := make([]v1beta1.PatchSet, 0, 10)
+	for i := 0; i < 10; i++ {
+		ps := v1beta1.PatchSet{Name: fmt.Sprintf("patchset-%d", i)}
+		for j := 0; j < 10; j++ {
+			ps.Patches = append(ps.Patches, v1beta1.PatchSetPatch{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To(fmt.Sprintf("spec.parameters.param%d", j)),
+					ToFieldPath:   ptr.To(fmt.Sprintf("spec.forProvider.param%d", j)),
+				},
+			})
+		}
+		pss = append(pss, ps)
+	}
+
+	cts := make([]v1beta1.ComposedTemplate, 0, 50)
+	for i := 0; i < 50; i++ {
+		ct := v1beta1.ComposedTemplate{Name: fmt.Sprintf("resource-%d", i)}
+		for j := range pss {
+			ct.Patches = append(ct.Patches, v1beta1.ComposedPatch{
+				Type:         v1beta1.PatchTypePatchSet,
+				PatchSetName: ptr.To(pss[j].Name),
+			})
+		}
+		cts = append(cts, ct)
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := ComposedTemplates(pss, cts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+// wrapFieldError wraps the given field.Error adding the given field.Path as
+// root of the Field.
+func wrapFieldError(err *field.Error, path *field.Path) *field.Error {
+	if err == nil {
+		return nil
+	}
+	if path == nil {
+		return err
+	}
+	err.Field = path.Child(err.Field).String()
+	return err
+}
+
+// Validate validates a patch.
+func Validate(p Interface) *field.Error { //nolint: gocyclo // This is a long but simple/same-y switch.
+	switch p.GetType() {
+	case v1beta1.PatchTypeFromCompositeFieldPath,
+		v1beta1.PatchTypeToCompositeFieldPath,
+		v1beta1.PatchTypeFromEnvironmentFieldPath,
+		v1beta1.PatchTypeToEnvironmentFieldPath:
+		if p.GetFromFieldPath() == "" {
+			return field.Required(field.NewPath("fromFieldPath"), fmt.Sprintf("fromFieldPath must be set for patch type %s", p.GetType()))
+		}
+	case v1beta1.PatchTypePatchSet:
+		ps, ok := p.(WithPatchSetName)
+		if !ok {
+			return field.Invalid(field.NewPath("type"), p.GetType(), fmt.Sprintf("patch type %T does not support patch of type %s", p, p.GetType()))
+		}
+		if ps.GetPatchSetName() == "" {
+			return field.Required(field.NewPath("patchSetName"), fmt.Sprintf("patchSetName must be set for patch type %s", p.GetType()))
+		}
+		if pc, ok := p.(WithPatchSetCondition); ok {
+			if err := ValidatePatchSetCondition(pc.GetWhen()); err != nil {
+				return wrapFieldError(err, field.NewPath("when"))
+			}
+		}
+	case v1beta1.PatchTypeCombineFromComposite,
+		v1beta1.PatchTypeCombineToComposite,
+		v1beta1.PatchTypeCombineFromEnvironment,
+		v1beta1.PatchTypeCombineToEnvironment:
+		if p.GetCombine() == nil {
+			return field.Required(field.NewPath("combine"), fmt.Sprintf("combine must be set for patch type %s", p.GetType()))
+		}
+		if p.GetToFieldPath() == "" {
+			return field.Required(field.NewPath("toFieldPath"), fmt.Sprintf("toFieldPath must be set for patch type %s", p.GetType()))
+		}
+	case v1beta1.PatchTypeToConnectionDetail,
+		v1beta1.PatchTypeCombineToConnectionDetail:
+		cd, ok := p.(WithConnectionDetailName)
+		if !ok {
+			return field.Invalid(field.NewPath("type"), p.GetType(), fmt.Sprintf("patch type %T does not support patch of type %s", p, p.GetType()))
+		}
+		if cd.GetConnectionDetailName() == "" {
+			return field.Required(field.NewPath("connectionDetailName"), fmt.Sprintf("connectionDetailName must be set for patch type %s", p.GetType()))
+		}
+		if p.GetType() == v1beta1.PatchTypeToConnectionDetail && p.GetFromFieldPath() == "" {
+			return field.Required(field.NewPath("fromFieldPath"), fmt.Sprintf("fromFieldPath must be set for patch type %s", p.GetType()))
+		}
+		if p.GetType() == v1beta1.PatchTypeCombineToConnectionDetail && p.GetCombine() == nil {
+			return field.Required(field.NewPath("combine"), fmt.Sprintf("combine must be set for patch type %s", p.GetType()))
+		}
+	case v1beta1.PatchTypeCopyFromCompositeFieldPath:
+		cp, ok := p.(WithCopyFilter)
+		if !ok {
+			return field.Invalid(field.NewPath("type"), p.GetType(), fmt.Sprintf("patch type %T does not support patch of type %s", p, p.GetType()))
+		}
+		if p.GetFromFieldPath() == "" {
+			return field.Required(field.NewPath("fromFieldPath"), fmt.Sprintf("fromFieldPath must be set for patch type %s", p.GetType()))
+		}
+		if err := ValidateCopyFilter(cp.GetCopy()); err != nil {
+			return wrapFieldError(err, field.NewPath("copy"))
+		}
+	case v1beta1.PatchTypeConditionToComposite:
+		cp, ok := p.(WithConditionSelector)
+		if !ok {
+			return field.Invalid(field.NewPath("type"), p.GetType(), fmt.Sprintf("patch type %T does not support patch of type %s", p, p.GetType()))
+		}
+		if cp.GetCondition() == nil {
+			return field.Required(field.NewPath("condition"), fmt.Sprintf("condition must be set for patch type %s", p.GetType()))
+		}
+		if cp.GetCondition().Type == "" {
+			return field.Required(field.NewPath("condition", "type"), "cannot be empty")
+		}
+		switch f := cp.GetCondition().GetField(); f {
+		case v1beta1.ConditionFieldMessage, v1beta1.ConditionFieldReason, v1beta1.ConditionFieldStatus:
+		default:
+			return field.Invalid(field.NewPath("condition", "field"), f, "unknown condition field")
+		}
+		if p.GetToFieldPath() == "" {
+			return field.Required(field.NewPath("toFieldPath"), fmt.Sprintf("toFieldPath must be set for patch type %s", p.GetType()))
+		}
+	default:
+		// Should never happen
+		return field.Invalid(field.NewPath("type"), p.GetType(), "unknown patch type")
+	}
+	if pc, ok := p.(WithPatchSetCondition); ok && pc.GetWhen() != nil && p.GetType() != v1beta1.PatchTypePatchSet {
+		return field.Forbidden(field.NewPath("when"), fmt.Sprintf("when is only supported for patch type %s", v1beta1.PatchTypePatchSet))
+	}
+	if cp, ok := p.(WithCopyFilter); ok && cp.GetCopy() != nil && p.GetType() != v1beta1.PatchTypeCopyFromCompositeFieldPath {
+		return field.Forbidden(field.NewPath("copy"), fmt.Sprintf("copy is only supported for patch type %s", v1beta1.PatchTypeCopyFromCompositeFieldPath))
+	}
+	if cp, ok := p.(WithConditionSelector); ok && cp.GetCondition() != nil && p.GetType() != v1beta1.PatchTypeConditionToComposite {
+		return field.Forbidden(field.NewPath("condition"), fmt.Sprintf("condition is only supported for patch type %s", v1beta1.PatchTypeConditionToComposite))
+	}
+	if err := ValidateFieldPathKeys(p); err != nil {
+		return err
+	}
+	if err := ValidatePolicy(p); err != nil {
+		return wrapFieldError(err, field.NewPath("policy"))
+	}
+	if err := ValidateStage(p); err != nil {
+		return err
+	}
+	if d := p.GetTransformTimeout(); d < 0 {
+		return field.Invalid(field.NewPath("transformTimeout"), d.String(), "must not be negative")
+	}
+	// Follow the type of each transform's input through the chain, so that
+	// we can reject chains that could never succeed.
+	named := map[string]bool{v1beta1.TransformNameInput: true}
+	outputs := map[string]v1beta1.TransformIOType{v1beta1.TransformNameInput: patchInputType(p)}
+	in := outputs[v1beta1.TransformNameInput] // The type of the next transform's input, if known.
+	for i, t := range p.GetTransforms() {
+		if err := transforms.ValidateTransform(t); err != nil {
+			return wrapFieldError(err, field.NewPath("transforms").Index(i))
+		}
+		if err := transforms.ValidateTransformNames(t, named); err != nil {
+			return wrapFieldError(err, field.NewPath("transforms").Index(i))
+		}
+		switch {
+		case t.Type == v1beta1.TransformTypeCombine && t.Combine != nil:
+			in = v1beta1.TransformIOTypeArray
+		case t.FromName != nil:
+			in = outputs[*t.FromName]
+		}
+		if err := transforms.ValidateTransformInput(t, in); err != nil {
+			return wrapFieldError(err, field.NewPath("transforms").Index(i))
+		}
+		in = transforms.Default.Output(t)
+		if t.Name != nil {
+			named[*t.Name] = true
+			outputs[*t.Name] = in
+		}
+	}
+
+	return nil
+}
+
+// patchInputType returns the type of the value the supplied patch transforms,
+// or an empty type if it can't be known without running the patch. Combine
+// patches transform the string they combine their variables into.
+func patchInputType(p Interface) v1beta1.TransformIOType {
+	switch p.GetType() { //nolint:exhaustive // Other patch types read a field of any type.
+	case v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCombineToComposite,
+		v1beta1.PatchTypeCombineFromEnvironment, v1beta1.PatchTypeCombineToEnvironment,
+		v1beta1.PatchTypeCombineToConnectionDetail:
+		if c := p.GetCombine(); c != nil && c.Strategy == v1beta1.CombineStrategyString {
+			return v1beta1.TransformIOTypeString
+		}
+	}
+	return ""
+}
+
+// ValidatePatchSetCondition validates the condition under which a patch
+// includes a PatchSet.
+func ValidatePatchSetCondition(c *v1beta1.PatchSetCondition) *field.Error {
+	if c == nil {
+		return nil
+	}
+	switch c.GetSource() {
+	case v1beta1.PatchSetConditionSourceComposite, v1beta1.PatchSetConditionSourceEnvironment:
+	default:
+		return field.Invalid(field.NewPath("source"), c.Source, "unknown source")
+	}
+	if c.FromFieldPath == "" {
+		return field.Required(field.NewPath("fromFieldPath"), "fromFieldPath must be set")
+	}
+	if err := fieldpaths.ValidateKeys(c.FromFieldPath); err != nil {
+		return field.Invalid(field.NewPath("fromFieldPath"), c.FromFieldPath, err.Error())
+	}
+	return nil
+}
+
+// ValidateCopyFilter validates the paths of the fields a copy patch includes
+// and excludes.
+func ValidateCopyFilter(f *v1beta1.CopyFilter) *field.Error {
+	if f == nil {
+		return nil
+	}
+	for i, path := range f.Include {
+		if path == "" {
+			return field.Required(field.NewPath("include").Index(i), "included paths can't be empty")
+		}
+		if _, err := fieldpath.Parse(path); err != nil {
+			return field.Invalid(field.NewPath("include").Index(i), path, err.Error())
+		}
+	}
+	for i, path := range f.Exclude {
+		if path == "" {
+			return field.Required(field.NewPath("exclude").Index(i), "excluded paths can't be empty")
+		}
+		if _, err := fieldpath.Parse(path); err != nil {
+			return field.Invalid(field.NewPath("exclude").Index(i), path, err.Error())
+		}
+	}
+	return nil
+}
+
+// ValidateFieldPathKeys validates that a patch's field paths quote any
+// label or annotation keys that contain a period.
+func ValidateFieldPathKeys(p Interface) *field.Error {
+	if err := fieldpaths.ValidateKeys(p.GetFromFieldPath()); err != nil {
+		return field.Invalid(field.NewPath("fromFieldPath"), p.GetFromFieldPath(), err.Error())
+	}
+	if err := fieldpaths.ValidateKeys(p.GetToFieldPath()); err != nil {
+		return field.Invalid(field.NewPath("toFieldPath"), p.GetToFieldPath(), err.Error())
+	}
+	if c := p.GetCombine(); c != nil {
+		for i, v := range c.Variables {
+			if err := fieldpaths.ValidateKeys(v.FromFieldPath); err != nil {
+				return field.Invalid(field.NewPath("combine", "variables").Index(i).Child("fromFieldPath"), v.FromFieldPath, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePolicy validates a patch's policy.
+func ValidatePolicy(p Interface) *field.Error {
+	pp := p.GetPolicy()
+	if pp == nil {
+		return nil
+	}
+	switch pp.GetFromFieldPathPolicy() {
+	case v1beta1.FromFieldPathPolicyOptional,
+		v1beta1.FromFieldPathPolicyRequired,
+		v1beta1.FromFieldPathPolicyOptionalNonEmpty,
+		v1beta1.FromFieldPathPolicyRequiredNonEmpty:
+	default:
+		return field.Invalid(field.NewPath("fromFieldPath"), pp.GetFromFieldPathPolicy(), "unknown fromFieldPath policy")
+	}
+	if pp.FromFieldPathDefault == nil {
+		return nil
+	}
+	if p.GetCombine() != nil {
+		return field.Forbidden(field.NewPath("fromFieldPathDefault"), fmt.Sprintf("patch type %s does not support a fromFieldPath default", p.GetType()))
+	}
+	if !json.Valid(pp.FromFieldPathDefault.Raw) {
+		return field.Invalid(field.NewPath("fromFieldPathDefault"), string(pp.FromFieldPathDefault.Raw), "default must be valid JSON")
+	}
+	return nil
+}
+
+// ValidateStage validates that a patch's type may be applied at its
+// stage. Only patches to the composed resource may be applied before its base
+// template is rendered.
+func ValidateStage(p Interface) *field.Error {
+	switch p.GetStage() {
+	case v1beta1.PatchStageDefault:
+		return nil
+	case v1beta1.PatchStagePreBase:
+		switch p.GetType() { //nolint:exhaustive // Only patches to the composed resource are valid.
+		case v1beta1.PatchTypeFromCompositeFieldPath,
+			v1beta1.PatchTypeCombineFromComposite,
+			v1beta1.PatchTypeCopyFromCompositeFieldPath,
+			v1beta1.PatchTypeFromEnvironmentFieldPath,
+			v1beta1.PatchTypeCombineFromEnvironment:
+			return nil
+		}
+		return field.Invalid(field.NewPath("stage"), p.GetStage(), fmt.Sprintf("patch type %s cannot be applied at stage %s", p.GetType(), p.GetStage()))
+	case v1beta1.PatchStagePostReadiness:
+		if p.GetType() == v1beta1.PatchTypePatchSet {
+			return field.Invalid(field.NewPath("stage"), p.GetStage(), fmt.Sprintf("patch type %s cannot be applied at stage %s", p.GetType(), p.GetStage()))
+		}
+		return nil
+	}
+	return field.Invalid(field.NewPath("stage"), p.GetStage(), "unknown patch stage")
+}
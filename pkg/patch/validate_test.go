@@ -0,0 +1,631 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestValidate(t *testing.T) {
+	type args struct {
+		patch v1beta1.ComposedPatch
+	}
+
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidFromCompositeFieldPath": {
+			reason: "FromCompositeFieldPath patch with FromFieldPath set should be valid",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+					},
+				},
+			},
+		},
+		"InvalidUnquotedAnnotationKey": {
+			reason: "A patch whose toFieldPath splits an annotation key containing a period should be invalid",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.name"),
+						ToFieldPath:   ptr.To[string]("metadata.annotations.crossplane.io/external-name"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "toFieldPath",
+				},
+			},
+		},
+		"FromCompositeFieldPathWithInvalidTransforms": {
+			reason: "FromCompositeFieldPath with invalid transforms should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeMath,
+								Math: nil,
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "transforms[0].math",
+				},
+			},
+		},
+		"ValidToConnectionDetail": {
+			reason: "ToConnectionDetail patch with ConnectionDetailName and FromFieldPath set should be valid",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeToConnectionDetail,
+					ConnectionDetailName: ptr.To[string]("url"),
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("status.atProvider.endpoint"),
+					},
+				},
+			},
+		},
+		"InvalidToConnectionDetailMissingConnectionDetailName": {
+			reason: "Invalid ToConnectionDetail missing ConnectionDetailName should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToConnectionDetail,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("status.atProvider.endpoint"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "connectionDetailName",
+				},
+			},
+		},
+		"InvalidPatchSetConditionMissingFromFieldPath": {
+			reason: "A PatchSet patch whose when condition has no fromFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:         v1beta1.PatchTypePatchSet,
+					PatchSetName: ptr.To[string]("prod-hardening"),
+					When:         &v1beta1.PatchSetCondition{Equals: "prod"},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "when.fromFieldPath",
+				},
+			},
+		},
+		"InvalidPatchSetConditionOnOtherPatchType": {
+			reason: "A when condition on a patch that isn't of type PatchSet should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					When: &v1beta1.PatchSetCondition{FromFieldPath: "metadata.labels.environment", Equals: "prod"},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.region"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeForbidden,
+					Field: "when",
+				},
+			},
+		},
+		"ValidCopyFromCompositeFieldPath": {
+			reason: "CopyFromCompositeFieldPath patch with FromFieldPath and a copy filter set should be valid",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCopyFromCompositeFieldPath,
+					Copy: &v1beta1.CopyFilter{Exclude: []string{"advanced.mtu"}},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.parameters.network"),
+					},
+				},
+			},
+		},
+		"InvalidCopyFromCompositeFieldPathEmptyExclude": {
+			reason: "Invalid CopyFromCompositeFieldPath with an empty excluded path should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCopyFromCompositeFieldPath,
+					Copy: &v1beta1.CopyFilter{Exclude: []string{""}},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.parameters.network"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "copy.exclude[0]",
+				},
+			},
+		},
+		"InvalidCopyFilterOnOtherPatchType": {
+			reason: "A copy filter on a patch that isn't of type CopyFromCompositeFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Copy: &v1beta1.CopyFilter{Exclude: []string{"advanced.mtu"}},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.parameters.network"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeForbidden,
+					Field: "copy",
+				},
+			},
+		},
+		"ValidConditionToComposite": {
+			reason: "ConditionToComposite patch with a condition type and ToFieldPath set should be valid",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeConditionToComposite,
+					Condition: &v1beta1.ConditionSelector{Type: "Synced", Field: v1beta1.ConditionFieldReason},
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("status.reason"),
+					},
+				},
+			},
+		},
+		"InvalidConditionToCompositeMissingConditionType": {
+			reason: "Invalid ConditionToComposite missing a condition type should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeConditionToComposite,
+					Condition: &v1beta1.ConditionSelector{},
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("status.error"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "condition.type",
+				},
+			},
+		},
+		"InvalidConditionToCompositeMissingToFieldPath": {
+			reason: "Invalid ConditionToComposite missing ToFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeConditionToComposite,
+					Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "toFieldPath",
+				},
+			},
+		},
+		"InvalidConditionOnOtherPatchType": {
+			reason: "A condition on a patch that isn't of type ConditionToComposite should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:      v1beta1.PatchTypeToCompositeFieldPath,
+					Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("status.atProvider.id"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeForbidden,
+					Field: "condition",
+				},
+			},
+		},
+		"InvalidCombineToConnectionDetailMissingCombine": {
+			reason: "Invalid CombineToConnectionDetail missing Combine should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type:                 v1beta1.PatchTypeCombineToConnectionDetail,
+					ConnectionDetailName: ptr.To[string]("url"),
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "combine",
+				},
+			},
+		},
+		"InvalidFromCompositeFieldPathMissingFromFieldPath": {
+			reason: "Invalid FromCompositeFieldPath missing FromFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: nil,
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"InvalidFromCompositeFieldPathMissingToFieldPath": {
+			reason: "Invalid ToCompositeFieldPath missing ToFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						ToFieldPath: nil,
+					},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "fromFieldPath",
+				},
+			},
+		},
+		"Invalidv1beta1.PatchSetMissingv1beta1.PatchSetName": {
+			reason: "Invalid v1beta1.PatchSet missing v1beta1.PatchSetName should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypePatchSet,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "patchSetName",
+				},
+			},
+		},
+		"InvalidCombineMissingCombine": {
+			reason: "Invalid Combine missing Combine should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineToComposite,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "combine",
+				},
+			},
+		},
+		"InvalidCombineMissingToFieldPath": {
+			reason: "Invalid Combine missing ToFieldPath should return error",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineToComposite,
+					Patch: v1beta1.Patch{
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{
+								{
+									FromFieldPath: "spec.forProvider.foo",
+								},
+							},
+						},
+						ToFieldPath: nil,
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "toFieldPath",
+				},
+			},
+		},
+		"TransformFromUnknownName": {
+			reason: "A transform should only refer to the named outputs of earlier transforms",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type:     v1beta1.TransformTypeString,
+								FromName: ptr.To[string]("later"),
+								String: &v1beta1.StringTransform{
+									Type:   v1beta1.StringTransformTypeFormat,
+									Format: ptr.To[string]("%s"),
+								},
+							},
+							{
+								Type: v1beta1.TransformTypeString,
+								Name: ptr.To[string]("later"),
+								String: &v1beta1.StringTransform{
+									Type:   v1beta1.StringTransformTypeFormat,
+									Format: ptr.To[string]("%s"),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeNotFound,
+					Field: "transforms[0].fromName",
+				},
+			},
+		},
+		"TransformDuplicateName": {
+			reason: "A transform shouldn't reuse the name of the chain's input",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeString,
+								Name: ptr.To[string](v1beta1.TransformNameInput),
+								String: &v1beta1.StringTransform{
+									Type:   v1beta1.StringTransformTypeFormat,
+									Format: ptr.To[string]("%s"),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeDuplicate,
+					Field: "transforms[0].name",
+				},
+			},
+		},
+		"TransformFormatVerbMismatch": {
+			reason: "A string format transform's verbs should be able to format the output of the previous transform",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeConvert,
+								Convert: &v1beta1.ConvertTransform{
+									ToType: v1beta1.TransformIOTypeString,
+								},
+							},
+							{
+								Type: v1beta1.TransformTypeString,
+								String: &v1beta1.StringTransform{
+									Type:   v1beta1.StringTransformTypeFormat,
+									Format: ptr.To[string]("%05d"),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "transforms[1].string.fmt",
+				},
+			},
+		},
+		"TransformMathAfterStringMap": {
+			reason: "A math transform can never succeed after a map transform whose values are all strings",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.nodes"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeMap,
+								Map: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{
+									"small": {Raw: []byte(`"1"`)},
+									"large": {Raw: []byte(`"8"`)},
+								}},
+							},
+							{
+								Type: v1beta1.TransformTypeMath,
+								Math: &v1beta1.MathTransform{
+									Type:     v1beta1.MathTransformTypeMultiply,
+									Multiply: ptr.To[int64](2),
+								},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "transforms[1].type",
+				},
+			},
+		},
+		"TransformMathAfterNumberMap": {
+			reason: "A math transform can succeed after a map transform whose values are all numbers",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.size"),
+						ToFieldPath:   ptr.To[string]("spec.forProvider.nodes"),
+						Transforms: []v1beta1.Transform{
+							{
+								Type: v1beta1.TransformTypeMap,
+								Map: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{
+									"small": {Raw: []byte(`1`)},
+									"large": {Raw: []byte(`8`)},
+								}},
+							},
+							{
+								Type: v1beta1.TransformTypeMath,
+								Math: &v1beta1.MathTransform{
+									Type:     v1beta1.MathTransformTypeMultiply,
+									Multiply: ptr.To[int64](2),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"TransformFromNameTypeMismatch": {
+			reason: "A transform's input should be checked against the type of the named output it refers to",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{{FromFieldPath: "spec.foo"}},
+							Strategy:  v1beta1.CombineStrategyString,
+							String:    &v1beta1.StringCombine{Format: "%s"},
+						},
+						Transforms: []v1beta1.Transform{
+							{
+								Type:    v1beta1.TransformTypeConvert,
+								Convert: &v1beta1.ConvertTransform{ToType: v1beta1.TransformIOTypeInt64},
+							},
+							{
+								Type:     v1beta1.TransformTypeParse,
+								FromName: ptr.To[string](v1beta1.TransformNameInput),
+								Parse:    &v1beta1.ParseTransform{Type: v1beta1.ParseTransformTypeInt},
+							},
+							{
+								Type: v1beta1.TransformTypeBool,
+								Bool: &v1beta1.BoolTransform{Type: v1beta1.BoolTransformTypeNot},
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "transforms[2].type",
+				},
+			},
+		},
+		"CombineWithFromFieldPathDefault": {
+			reason: "A combine patch can't have a fromFieldPath default",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{{FromFieldPath: "spec.foo"}},
+							Strategy:  v1beta1.CombineStrategyString,
+							String:    &v1beta1.StringCombine{Format: "%s"},
+						},
+						Policy: &v1beta1.PatchPolicy{
+							FromFieldPathDefault: &extv1.JSON{Raw: []byte(`"foo"`)},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeForbidden,
+					Field: "policy.fromFieldPathDefault",
+				},
+			},
+		},
+		"ValidPreBaseStage": {
+			reason: "A FromCompositeFieldPath patch may be applied before the base template is rendered",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Stage:         ptr.To(v1beta1.PatchStagePreBase),
+					},
+				},
+			},
+		},
+		"InvalidPreBaseStage": {
+			reason: "A ToCompositeFieldPath patch can't be applied before the base template is rendered",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("status.atProvider.foo"),
+						Stage:         ptr.To(v1beta1.PatchStagePreBase),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "stage",
+				},
+			},
+		},
+		"UnknownStage": {
+			reason: "A patch with an unknown stage should be invalid",
+			args: args{
+				patch: v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
+						Stage:         ptr.To(v1beta1.PatchStage("Eventually")),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "stage",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(&tc.args.patch)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -1,14 +1,19 @@
-package main
+// Package readiness runs and validates function-patch-and-transform's
+// readiness checks.
+package readiness
 
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
 )
 
 // Error strings
@@ -19,16 +24,16 @@ const (
 	errFmtRunCheck = "cannot run readiness check at index %d"
 )
 
-// A ReadinessChecker checks whether a composed resource is ready or not.
-type ReadinessChecker interface {
+// A Checker checks whether a composed resource is ready or not.
+type Checker interface {
 	IsReady(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
 }
 
-// A ReadinessCheckerFn checks whether a composed resource is ready or not.
-type ReadinessCheckerFn func(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
+// A CheckerFn checks whether a composed resource is ready or not.
+type CheckerFn func(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error)
 
 // IsReady reports whether a composed resource is ready or not.
-func (fn ReadinessCheckerFn) IsReady(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error) {
+func (fn CheckerFn) IsReady(ctx context.Context, o ConditionedObject, rc ...v1beta1.ReadinessCheck) (ready bool, err error) {
 	return fn(ctx, o, rc...)
 }
 
@@ -46,7 +51,7 @@ func IsReady(_ context.Context, o ConditionedObject, rc ...v1beta1.ReadinessChec
 	}
 
 	for i := range rc {
-		ready, err := RunReadinessCheck(rc[i], o)
+		ready, err := RunCheck(rc[i], o)
 		if err != nil {
 			return false, errors.Wrapf(err, errFmtRunCheck, i)
 		}
@@ -57,13 +62,13 @@ func IsReady(_ context.Context, o ConditionedObject, rc ...v1beta1.ReadinessChec
 	return true, nil
 }
 
-// RunReadinessCheck runs the readiness check against the supplied object.
-func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, error) { //nolint:gocyclo // just a switch
-	if err := ValidateReadinessCheck(c); err != nil {
+// RunCheck runs the readiness check against the supplied object.
+func RunCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, error) { //nolint:gocyclo // just a switch
+	if err := ValidateCheck(c); err != nil {
 		return false, errors.Wrap(err, errInvalidCheck)
 	}
 
-	p, err := fieldpath.PaveObject(o)
+	p, err := fieldpaths.Pave(o)
 	if err != nil {
 		return false, errors.Wrap(err, errPaveObject)
 	}
@@ -107,3 +112,50 @@ func RunReadinessCheck(c v1beta1.ReadinessCheck, o ConditionedObject) (bool, err
 
 	return false, nil
 }
+
+// ValidateCheck checks if the readiness check is logically valid.
+func ValidateCheck(r v1beta1.ReadinessCheck) *field.Error { //nolint:gocyclo // This function is not that complex, just a switch
+	if !r.Type.IsValid() {
+		return field.Invalid(field.NewPath("type"), string(r.Type), "unknown readiness check type")
+	}
+	switch r.Type {
+	case v1beta1.ReadinessCheckTypeNone:
+		return nil
+	case v1beta1.ReadinessCheckTypeMatchString:
+		if r.MatchString == nil {
+			return field.Required(field.NewPath("matchString"), "cannot be nil for type MatchString")
+		}
+	case v1beta1.ReadinessCheckTypeMatchInteger:
+		if r.MatchInteger == nil {
+			return field.Required(field.NewPath("matchInteger"), "cannot be nil for type MatchInteger")
+		}
+	case v1beta1.ReadinessCheckTypeMatchCondition:
+		if err := ValidateMatchConditionCheck(r.MatchCondition); err != nil {
+			err.Field = field.NewPath("matchCondition").Child(err.Field).String()
+			return err
+		}
+		return nil
+	case v1beta1.ReadinessCheckTypeNonEmpty, v1beta1.ReadinessCheckTypeMatchFalse, v1beta1.ReadinessCheckTypeMatchTrue:
+		// No specific validation required.
+	}
+	if r.FieldPath == nil {
+		return field.Required(field.NewPath("fieldPath"), "cannot be empty")
+	}
+
+	return nil
+}
+
+// ValidateMatchConditionCheck checks if the match condition is
+// logically valid.
+func ValidateMatchConditionCheck(m *v1beta1.MatchConditionReadinessCheck) *field.Error {
+	if m == nil {
+		return nil
+	}
+	if m.Type == "" {
+		return field.Required(field.NewPath("type"), "cannot be empty for type MatchCondition")
+	}
+	if m.Status == "" {
+		return field.Required(field.NewPath("status"), "cannot be empty for type MatchCondition")
+	}
+	return nil
+}
@@ -1,11 +1,13 @@
-package main
+package readiness
 
 import (
 	"context"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -17,7 +19,7 @@ import (
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
-var _ ReadinessChecker = ReadinessCheckerFn(IsReady)
+var _ Checker = CheckerFn(IsReady)
 
 func TestIsReady(t *testing.T) {
 	type args struct {
@@ -367,3 +369,91 @@ func TestIsReady(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCheck(t *testing.T) {
+	type args struct {
+		r v1beta1.ReadinessCheck
+	}
+	type want struct {
+		output *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidTypeNone": {
+			reason: "Type none should be valid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: v1beta1.ReadinessCheckTypeNone,
+				},
+			},
+		},
+		"ValidTypeMatchString": {
+			reason: "Type matchString should be valid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type:        v1beta1.ReadinessCheckTypeMatchString,
+					MatchString: ptr.To[string]("foo"),
+					FieldPath:   ptr.To[string]("spec.foo"),
+				},
+			},
+		},
+		"ValidTypeMatchCondition": {
+			reason: "Type matchCondition should be valid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: v1beta1.ReadinessCheckTypeMatchCondition,
+					MatchCondition: &v1beta1.MatchConditionReadinessCheck{
+						Type:   "someType",
+						Status: "someStatus",
+					},
+					FieldPath: ptr.To[string]("spec.foo"),
+				},
+			},
+		},
+		"ValidTypeMatchTrue": {
+			reason: "Type matchTrue should be valid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type:      v1beta1.ReadinessCheckTypeMatchTrue,
+					FieldPath: ptr.To[string]("spec.foo"),
+				},
+			},
+		},
+		"ValidTypeMatchFalse": {
+			reason: "Type matchFalse should be valid",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type:      v1beta1.ReadinessCheckTypeMatchFalse,
+					FieldPath: ptr.To[string]("spec.foo"),
+				},
+			},
+		},
+		"InvalidType": {
+			reason: "Invalid type",
+			args: args{
+				r: v1beta1.ReadinessCheck{
+					Type: "foo",
+				},
+			},
+			want: want{
+				output: &field.Error{
+					Type:     field.ErrorTypeInvalid,
+					Field:    "type",
+					BadValue: "foo",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := ValidateCheck(tc.args.r)
+			if diff := cmp.Diff(tc.want.output, got, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateCheck(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
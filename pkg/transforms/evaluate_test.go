@@ -0,0 +1,150 @@
+package transforms_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+func TestRegistryEvaluate(t *testing.T) {
+	suffix := v1beta1.TransformType("suffix")
+	r := transforms.NewRegistry(map[v1beta1.TransformType]transforms.Definition{
+		suffix: {
+			Resolve: func(_ context.Context, _ v1beta1.Transform, input any) (any, error) {
+				return fmt.Sprintf("%v!", input), nil
+			},
+		},
+	})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type want struct {
+		out any
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ctx    context.Context
+		chain  []v1beta1.Transform
+		input  any
+		want   want
+	}{
+		"Chain": {
+			reason: "Each transform should be resolved using the registry, reading named outputs where asked.",
+			chain: []v1beta1.Transform{
+				{Type: suffix},
+				{Type: suffix, FromName: ptr.To(v1beta1.TransformNameInput)},
+			},
+			input: "cool",
+			want: want{
+				out: "cool!",
+			},
+		},
+		"Cancelled": {
+			reason: "A chain of transforms should stop once its context is done.",
+			ctx:    cancelled,
+			chain: []v1beta1.Transform{
+				{Type: suffix},
+			},
+			input: "cool",
+			want: want{
+				err: errors.Wrap(context.Canceled, "transform at index 0 returned error"),
+			},
+		},
+		"NotRegistered": {
+			reason: "A transform type that isn't in the registry should return an error, even if it's built-in.",
+			chain: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeString},
+			},
+			input: "cool",
+			want: want{
+				err: errors.Wrap(errors.New("transform type string is not supported"), "transform at index 0 returned error"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			out, err := r.Evaluate(ctx, tc.chain, tc.input)
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nEvaluate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nEvaluate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRegistryEvaluateWithin(t *testing.T) {
+	// The slow transform blocks until its context is done, like a webhook
+	// transform waiting for a response.
+	slow := v1beta1.TransformType("slow")
+	r := transforms.NewRegistry(map[v1beta1.TransformType]transforms.Definition{
+		slow: {
+			Resolve: func(ctx context.Context, _ v1beta1.Transform, _ any) (any, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		},
+		v1beta1.TransformTypeString: transforms.Builtin()[v1beta1.TransformTypeString],
+	})
+
+	type want struct {
+		out any
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		chain  []v1beta1.Transform
+		want   want
+	}{
+		"WithinTimeout": {
+			reason: "Transforms that resolve within the timeout should return their output.",
+			chain: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeString, String: &v1beta1.StringTransform{Type: v1beta1.StringTransformTypeFormat, Format: ptr.To("%s!")}},
+			},
+			want: want{
+				out: "cool!",
+			},
+		},
+		"TimeoutExceeded": {
+			reason: "Transforms that don't resolve within the timeout should be cancelled and return an error.",
+			chain: []v1beta1.Transform{
+				{Type: slow},
+			},
+			want: want{
+				err: errors.New("transforms did not resolve within 10ms"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := r.EvaluateWithin(context.Background(), tc.chain, "cool", 10*time.Millisecond)
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nEvaluateWithin(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nEvaluateWithin(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// Error strings.
+const (
+	errFormatStar            = "format cannot read its width or precision from an argument using *"
+	errFmtFormatIncomplete   = "format ends with an incomplete verb %q"
+	errFmtFormatUnknownVerb  = "unknown verb %%%c"
+	errFmtFormatArgIndex     = "invalid argument index %q"
+	errFmtFormatExtraArg     = "verb %%%c formats argument %d, but a format only has one argument: its input"
+	errFmtFormatVerbMismatch = "verb %%%c cannot format %s input"
+)
+
+// formatVerbs are the verbs a format may use.
+const formatVerbs = "vTtbcdoOqxXUeEfFgGs"
+
+// FormatVerbs returns the verbs of the supplied Go format string, e.g. d for
+// %05d. A format without verbs, like static-name, is a constant. It returns an
+// error if the format formats any argument other than its input, or uses a
+// verb that isn't supported. A format may format its input
+// more than once using an explicit argument index, e.g. %[1]s-%[1]s.
+func FormatVerbs(format string) ([]rune, error) {
+	verbs := make([]rune, 0)
+	arg := 1
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		start := i
+		i++
+
+		// Flags, argument index, width, precision, and argument index
+		// again, in the order fmt parses them.
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		n, err := formatArgIndex(format, &i)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			arg = n
+		}
+		for i < len(format) && (format[i] >= '0' && format[i] <= '9' || format[i] == '*' || format[i] == '.') {
+			if format[i] == '*' {
+				return nil, errors.New(errFormatStar)
+			}
+			i++
+		}
+		n, err = formatArgIndex(format, &i)
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			arg = n
+		}
+
+		if i >= len(format) {
+			return nil, errors.Errorf(errFmtFormatIncomplete, format[start:])
+		}
+		v, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		if v == '%' {
+			continue
+		}
+		if !strings.ContainsRune(formatVerbs, v) {
+			return nil, errors.Errorf(errFmtFormatUnknownVerb, v)
+		}
+		if arg != 1 {
+			return nil, errors.Errorf(errFmtFormatExtraArg, v, arg)
+		}
+		verbs = append(verbs, v)
+		arg++
+	}
+	return verbs, nil
+}
+
+// formatArgIndex parses an explicit argument index like [1] at the supplied
+// position of the supplied format, advancing the position past it. It returns
+// 0 if there's no argument index at the position.
+func formatArgIndex(format string, i *int) (int, error) {
+	if *i >= len(format) || format[*i] != '[' {
+		return 0, nil
+	}
+	end := strings.IndexByte(format[*i:], ']')
+	if end < 0 {
+		return 0, errors.Errorf(errFmtFormatArgIndex, format[*i:])
+	}
+	idx := format[*i : *i+end+1]
+	n, err := strconv.Atoi(idx[1 : len(idx)-1])
+	if err != nil || n < 1 {
+		return 0, errors.Errorf(errFmtFormatArgIndex, idx)
+	}
+	*i += end + 1
+	return n, nil
+}
+
+// FormatAccepts returns true if the supplied verb can format input of the
+// supplied type. Numbers are accepted by the verbs of the other kind of number,
+// because Format converts them. Integer verbs only accept floating point
+// numbers that are whole numbers, so they're accepted here but may still fail
+// when the input is formatted.
+func FormatAccepts(v rune, t v1beta1.TransformIOType) bool {
+	switch t { //nolint:exhaustive // Other types are handled below.
+	case v1beta1.TransformIOTypeString:
+		return strings.ContainsRune("vTsqxX", v)
+	case v1beta1.TransformIOTypeBool:
+		return strings.ContainsRune("vTstq", v)
+	case v1beta1.TransformIOTypeInt, v1beta1.TransformIOTypeInt64, v1beta1.TransformIOTypeFloat64:
+		return strings.ContainsRune("vTsqbcdoOxXUeEfFgG", v)
+	case v1beta1.TransformIOTypeObject, v1beta1.TransformIOTypeArray:
+		return strings.ContainsRune("vTsq", v)
+	}
+	return strings.ContainsRune("vT", v)
+}
+
+// Format the supplied input using the supplied Go format string. Unlike
+// fmt.Sprintf it returns an error rather than an output like %!d(string=foo)
+// when a verb can't format the input. Numbers are converted to suit integer
+// and floating point verbs, so %d formats 3.0 as 3 and %.2f formats 3 as 3.00.
+// The s and q verbs format numbers and booleans as they'd be written in JSON.
+func Format(format string, input any) (string, error) {
+	verbs, err := FormatVerbs(format)
+	if err != nil {
+		return "", err
+	}
+
+	// A constant format doesn't format its input. Passing it anyway would
+	// append %!(EXTRA ...) to the output.
+	if len(verbs) == 0 {
+		return fmt.Sprintf(format), nil //nolint:govet // The format is validated above.
+	}
+	for _, v := range verbs {
+		if input, err = formatInput(v, input); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf(format, input), nil
+}
+
+// formatInput returns the supplied input converted so the supplied verb can
+// format it.
+func formatInput(v rune, input any) (any, error) {
+	t := inputType(input)
+	if !FormatAccepts(v, t) {
+		return nil, typeMismatchError(errFmtFormatVerbMismatch, v, t)
+	}
+
+	switch {
+	case strings.ContainsRune("sq", v):
+		switch i := input.(type) {
+		case int:
+			return strconv.Itoa(i), nil
+		case int64:
+			return strconv.FormatInt(i, 10), nil
+		case float64:
+			return strconv.FormatFloat(i, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(i), nil
+		}
+	case strings.ContainsRune("cdoOU", v):
+		if f, ok := input.(float64); ok {
+			if f != math.Trunc(f) || math.IsInf(f, 0) {
+				return nil, typeMismatchError(errFmtFormatVerbMismatch, v, fmt.Sprintf("non-integer %s", t))
+			}
+			return int64(f), nil
+		}
+	case strings.ContainsRune("eEfFgG", v):
+		switch i := input.(type) {
+		case int:
+			return float64(i), nil
+		case int64:
+			return float64(i), nil
+		}
+	}
+	return input, nil
+}
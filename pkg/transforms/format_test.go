@@ -0,0 +1,194 @@
+package transforms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestFormatVerbs(t *testing.T) {
+	type want struct {
+		verbs []rune
+		err   error
+	}
+
+	cases := map[string]struct {
+		reason string
+		format string
+		want   want
+	}{
+		"Simple": {
+			reason: "We should return the verb of a simple format.",
+			format: "arn:aws:s3:::%s",
+			want: want{
+				verbs: []rune{'s'},
+			},
+		},
+		"FlagsWidthAndPrecision": {
+			reason: "We should return the verb of a format with flags, a width, and a precision.",
+			format: "%+08.3f%%",
+			want: want{
+				verbs: []rune{'f'},
+			},
+		},
+		"ExplicitIndex": {
+			reason: "We should allow a format to format its input more than once using an explicit argument index.",
+			format: "%s-%[1]q",
+			want: want{
+				verbs: []rune{'s', 'q'},
+			},
+		},
+		"NoVerb": {
+			reason: "We should allow a constant format that doesn't format its input.",
+			format: "static-name",
+			want: want{
+				verbs: []rune{},
+			},
+		},
+		"ExtraArgument": {
+			reason: "We should return an error if a format formats more than one argument.",
+			format: "%s-%s",
+			want: want{
+				err: errors.Errorf(errFmtFormatExtraArg, 's', 2),
+			},
+		},
+		"UnknownVerb": {
+			reason: "We should return an error if a format uses an unknown verb.",
+			format: "%y",
+			want: want{
+				err: errors.Errorf(errFmtFormatUnknownVerb, 'y'),
+			},
+		},
+		"Star": {
+			reason: "We should return an error if a format reads its width from an argument.",
+			format: "%*d",
+			want: want{
+				err: errors.New(errFormatStar),
+			},
+		},
+		"Incomplete": {
+			reason: "We should return an error if a format ends with an incomplete verb.",
+			format: "size-%05",
+			want: want{
+				err: errors.Errorf(errFmtFormatIncomplete, "%05"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			verbs, err := FormatVerbs(tc.format)
+			if diff := cmp.Diff(tc.want.verbs, verbs); diff != "" {
+				t.Errorf("\n%s\nFormatVerbs(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFormatVerbs(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	type args struct {
+		format string
+		input  any
+	}
+	type want struct {
+		out string
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"String": {
+			reason: "We should format a string using a string verb.",
+			args: args{
+				format: "prefix-%s",
+				input:  "cool",
+			},
+			want: want{
+				out: "prefix-cool",
+			},
+		},
+		"Constant": {
+			reason: "We should return a format without verbs as is, rather than appending the input.",
+			args: args{
+				format: "static-name-100%%",
+				input:  "cool",
+			},
+			want: want{
+				out: "static-name-100%",
+			},
+		},
+		"ZeroPaddedInteger": {
+			reason: "We should format a whole floating point number using an integer verb.",
+			args: args{
+				format: "node-%03d",
+				input:  float64(7),
+			},
+			want: want{
+				out: "node-007",
+			},
+		},
+		"IntegerPrecision": {
+			reason: "We should format an integer using a floating point verb.",
+			args: args{
+				format: "%.2f",
+				input:  int64(3),
+			},
+			want: want{
+				out: "3.00",
+			},
+		},
+		"NumberAsString": {
+			reason: "We should format a number using a string verb as it'd be written in JSON.",
+			args: args{
+				format: "%s GiB",
+				input:  int64(20),
+			},
+			want: want{
+				out: "20 GiB",
+			},
+		},
+		"StringAsInteger": {
+			reason: "We should return an error rather than format a string using an integer verb.",
+			args: args{
+				format: "%d",
+				input:  "twenty",
+			},
+			want: want{
+				err: typeMismatchError(errFmtFormatVerbMismatch, 'd', v1beta1.TransformIOTypeString),
+			},
+		},
+		"FractionAsInteger": {
+			reason: "We should return an error rather than format a fraction using an integer verb.",
+			args: args{
+				format: "%d",
+				input:  1.5,
+			},
+			want: want{
+				err: typeMismatchError(errFmtFormatVerbMismatch, 'd', "non-integer float64"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := Format(tc.args.format, tc.args.input)
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("\n%s\nFormat(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFormat(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
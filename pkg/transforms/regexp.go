@@ -0,0 +1,53 @@
+package transforms
+
+import (
+	"regexp"
+	"sync"
+)
+
+// maxCachedRegexps is the maximum number of compiled regular expressions we'll
+// cache. Patterns come from Compositions, so we expect relatively few distinct
+// patterns. The limit just guards against unbounded growth.
+const maxCachedRegexps = 1024
+
+// regexps caches compiled regular expressions across RunFunction calls.
+var regexps = newRegexpCache(maxCachedRegexps)
+
+// A regexpCache caches compiled regular expressions by their source text. A
+// compiled *regexp.Regexp is safe for concurrent use.
+type regexpCache struct {
+	mu    sync.RWMutex
+	cache map[string]*regexp.Regexp
+	size  int
+}
+
+func newRegexpCache(size int) *regexpCache {
+	return &regexpCache{cache: make(map[string]*regexp.Regexp), size: size}
+}
+
+// Compile returns the compiled form of the supplied regular expression,
+// compiling it only if it isn't already cached. Expressions that fail to
+// compile aren't cached.
+func (c *regexpCache) Compile(expr string) (*regexp.Regexp, error) {
+	c.mu.RLock()
+	re, ok := c.cache[expr]
+	c.mu.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= c.size {
+		// Start over rather than tracking which expressions were least
+		// recently used. This should rarely, if ever, happen.
+		c.cache = make(map[string]*regexp.Regexp)
+	}
+	c.cache[expr] = re
+	return re, nil
+}
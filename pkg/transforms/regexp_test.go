@@ -0,0 +1,49 @@
+package transforms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegexpCacheCompile(t *testing.T) {
+	c := newRegexpCache(2)
+
+	a, err := c.Compile("a+")
+	if err != nil {
+		t.Fatalf("Compile(...): %v", err)
+	}
+	again, err := c.Compile("a+")
+	if err != nil {
+		t.Fatalf("Compile(...): %v", err)
+	}
+	if a != again {
+		t.Errorf("Compile(...): want cached regexp, got a new one")
+	}
+
+	if _, err := c.Compile("("); err == nil {
+		t.Errorf("Compile(...): want error compiling an invalid regexp")
+	}
+	if diff := cmp.Diff(1, len(c.cache)); diff != "" {
+		t.Errorf("Compile(...): invalid regexps should not be cached: -want, +got:\n%s", diff)
+	}
+
+	// Exceeding the maximum size should reset the cache.
+	for _, expr := range []string{"b+", "c+"} {
+		if _, err := c.Compile(expr); err != nil {
+			t.Fatalf("Compile(...): %v", err)
+		}
+	}
+	if diff := cmp.Diff(1, len(c.cache)); diff != "" {
+		t.Errorf("Compile(...): -want cache size, +got cache size:\n%s", diff)
+	}
+}
+
+func BenchmarkRegexpCacheCompile(b *testing.B) {
+	c := newRegexpCache(maxCachedRegexps)
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Compile(`^arn:aws:iam::([0-9]{12}):role/(.+)$`); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,488 @@
+// Package transforms validates and resolves the transforms of
+// function-patch-and-transform. Other functions can import it to evaluate
+// transforms exactly as function-patch-and-transform's patches do.
+package transforms
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// A Definition defines how to validate and resolve a type of transform.
+type Definition struct {
+	// Validate the supplied transform. Optional - transforms are considered
+	// valid if no validate function is supplied.
+	Validate func(t v1beta1.Transform) *field.Error
+
+	// Resolve the supplied transform, producing an output given the supplied
+	// input. Transforms that do I/O must stop when the supplied context is
+	// done. Required.
+	Resolve func(ctx context.Context, t v1beta1.Transform, input any) (any, error)
+
+	// Output returns the type of the output the supplied transform produces,
+	// or an empty type if it can't be known without resolving the transform.
+	// Optional - the output of transforms without an output function is
+	// considered unknown.
+	Output func(t v1beta1.Transform) v1beta1.TransformIOType
+
+	// Accepts returns true if the supplied transform can resolve input of the
+	// supplied type. It's used to validate that a chain of transforms can
+	// succeed before any of them are resolved. Optional - transforms without
+	// an accepts function are considered to accept input of any type.
+	Accepts func(t v1beta1.Transform, in v1beta1.TransformIOType) bool
+}
+
+// A Registry maps transform types to their definitions. The zero value isn't
+// usable; use NewRegistry.
+type Registry struct {
+	mu   sync.RWMutex
+	defs map[v1beta1.TransformType]Definition
+}
+
+// NewRegistry returns a registry of the supplied transform types.
+func NewRegistry(defs map[v1beta1.TransformType]Definition) *Registry {
+	r := &Registry{defs: make(map[v1beta1.TransformType]Definition, len(defs))}
+	for tt, d := range defs {
+		r.defs[tt] = d
+	}
+	return r
+}
+
+// Register the supplied transform type. Registering a type that's already
+// registered replaces its definition.
+func (r *Registry) Register(tt v1beta1.TransformType, d Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defs[tt] = d
+}
+
+// Unregister the supplied transform type.
+func (r *Registry) Unregister(tt v1beta1.TransformType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.defs, tt)
+}
+
+// Get the definition of the supplied transform type.
+func (r *Registry) Get(tt v1beta1.TransformType) (Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.defs[tt]
+	return d, ok
+}
+
+// Types returns the transform types registered in this registry, sorted by
+// name.
+func (r *Registry) Types() []v1beta1.TransformType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]v1beta1.TransformType, 0, len(r.defs))
+	for tt := range r.defs {
+		types = append(types, tt)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Output returns the type of the output the supplied transform produces, or an
+// empty type if it's unknown.
+func (r *Registry) Output(t v1beta1.Transform) v1beta1.TransformIOType {
+	d, ok := r.Get(t.Type)
+	if !ok || d.Output == nil {
+		return ""
+	}
+	return d.Output(t)
+}
+
+// Accepts returns true if the supplied transform can resolve input of the
+// supplied type. Input of an unknown (i.e. empty) type is always accepted.
+func (r *Registry) Accepts(t v1beta1.Transform, in v1beta1.TransformIOType) bool {
+	d, ok := r.Get(t.Type)
+	if in == "" || !ok || d.Accepts == nil {
+		return true
+	}
+	return d.Accepts(t, in)
+}
+
+// Resolve the supplied transform using its definition in this registry.
+func (r *Registry) Resolve(ctx context.Context, t v1beta1.Transform, input any) (any, error) {
+	d, ok := r.Get(t.Type)
+	if !ok {
+		return nil, errors.Errorf(errFmtTypeNotSupported, string(t.Type))
+	}
+	out, err := d.Resolve(ctx, t, input)
+	return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
+}
+
+// Evaluate the supplied chain of transforms using the definitions in this
+// registry. Each transform's input is the output of the previous transform,
+// unless it uses fromName to refer to a named output of an earlier transform.
+// Combine transforms combine named outputs. The first transform's input is the
+// supplied input. Evaluation stops with an error once the supplied context is
+// done. Embedders can call Evaluate to get exactly the semantics of a patch's
+// transforms.
+func (r *Registry) Evaluate(ctx context.Context, chain []v1beta1.Transform, input any) (any, error) {
+	named := map[string]any{v1beta1.TransformNameInput: input}
+	var err error
+	for i, t := range chain {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
+		}
+		if input, err = resolveNamedInput(t, input, named); err != nil {
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
+		}
+		if input, err = r.Resolve(ctx, t, input); err != nil {
+			if t.IsSensitive() {
+				return nil, errors.Errorf(errFmtSensitiveTransformAtIndex, i)
+			}
+			// TODO(negz): Including the type might help find the offending transform faster.
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
+		}
+		if t.Name != nil {
+			named[*t.Name] = input
+		}
+	}
+	return input, nil
+}
+
+// EvaluateWithin is like Evaluate, but returns an error if the supplied chain
+// of transforms doesn't resolve within the supplied timeout. The timeout
+// cancels any in-flight webhook transform, and stops the chain before its next
+// transform. Other transforms don't do I/O, so they run until they return.
+func (r *Registry) EvaluateWithin(ctx context.Context, chain []v1beta1.Transform, input any, timeout time.Duration) (any, error) {
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out, err := r.Evaluate(tctx, chain, input)
+	if err != nil && ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return nil, errors.Errorf(errFmtTransformTimeout, timeout)
+	}
+	return out, err
+}
+
+// resolveNamedInput returns the input of the supplied transform. This is the
+// named output the transform refers to using fromName, or the list of named
+// outputs a combine transform combines. Otherwise it's the supplied input.
+func resolveNamedInput(t v1beta1.Transform, input any, named map[string]any) (any, error) {
+	if t.Type == v1beta1.TransformTypeCombine && t.Combine != nil {
+		vars := make([]any, len(t.Combine.Names))
+		for i, n := range t.Combine.Names {
+			v, ok := named[n]
+			if !ok {
+				return nil, errors.Errorf(errFmtUnknownTransformName, n)
+			}
+			vars[i] = v
+		}
+		return vars, nil
+	}
+	if t.FromName == nil {
+		return input, nil
+	}
+	v, ok := named[*t.FromName]
+	if !ok {
+		return nil, errors.Errorf(errFmtUnknownTransformName, *t.FromName)
+	}
+	return v, nil
+}
+
+// Default is the registry used to validate and resolve transforms by
+// default. It contains the built-in transform types. Forks and embedders can
+// register additional transform types, typically from an init function. Note
+// that the Function input's CRD only accepts the built-in transform types.
+var Default = NewRegistry(Builtin())
+
+// Builtin returns the definitions of the built-in transform types.
+func Builtin() map[v1beta1.TransformType]Definition {
+	return map[v1beta1.TransformType]Definition{
+		v1beta1.TransformTypeMath: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Math == nil {
+					return field.Required(field.NewPath("math"), "given transform type math requires configuration")
+				}
+				return wrapFieldError(ValidateMathTransform(t.Math), field.NewPath("math"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Math == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveMath(t.Math, input)
+			},
+			Output: func(t v1beta1.Transform) v1beta1.TransformIOType {
+				// Other math transforms preserve the type of their
+				// input, or produce their integer configuration.
+				if t.Math != nil && t.Math.MultiplyFloat != nil {
+					return v1beta1.TransformIOTypeFloat64
+				}
+				return ""
+			},
+			Accepts: func(_ v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				return isNumber(in)
+			},
+		},
+		v1beta1.TransformTypeMap: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Map != nil && t.MapFromEnvironment != nil {
+					return field.Forbidden(field.NewPath("mapFromEnvironment"), "map and mapFromEnvironment are mutually exclusive")
+				}
+				if t.MapFromData != nil && (t.Map != nil || t.MapFromEnvironment != nil) {
+					return field.Forbidden(field.NewPath("mapFromData"), "mapFromData is mutually exclusive with map and mapFromEnvironment")
+				}
+				if t.MapFromEnvironment != nil {
+					if t.MapFromEnvironment.FieldPath == "" {
+						return field.Required(field.NewPath("mapFromEnvironment", "fieldPath"), "fieldPath must be set")
+					}
+					return nil
+				}
+				if t.MapFromData != nil {
+					if t.MapFromData.Name == "" {
+						return field.Required(field.NewPath("mapFromData", "name"), "name must be set")
+					}
+					return nil
+				}
+				if t.Map == nil {
+					return field.Required(field.NewPath("map"), "given transform type map requires configuration")
+				}
+				if t.GetMapInvert() {
+					return wrapFieldError(ValidateInverseMapTransform(t.Map, t.GetMapIgnoreCase()), field.NewPath("map"))
+				}
+				return wrapFieldError(ValidateMapTransform(t.Map), field.NewPath("map"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Map == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				if t.GetMapInvert() {
+					return ResolveInverseMap(t.Map, input, t.GetMapIgnoreCase())
+				}
+				return ResolveMap(t.Map, input, t.GetMapIgnoreCase())
+			},
+			Output: func(t v1beta1.Transform) v1beta1.TransformIOType {
+				if t.Map == nil {
+					return ""
+				}
+				if t.GetMapInvert() {
+					return v1beta1.TransformIOTypeString
+				}
+				return mapOutput(t.Map)
+			},
+			Accepts: func(t v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				return t.GetMapInvert() || in == v1beta1.TransformIOTypeString
+			},
+		},
+		v1beta1.TransformTypeMatch: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Match == nil {
+					return field.Required(field.NewPath("match"), "given transform type match requires configuration")
+				}
+				return wrapFieldError(ValidateMatchTransform(t.Match), field.NewPath("match"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Match == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveMatch(t.Match, input)
+			},
+		},
+		v1beta1.TransformTypeString: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.String == nil {
+					return field.Required(field.NewPath("string"), "given transform type string requires configuration")
+				}
+				return wrapFieldError(ValidateStringTransform(t.String), field.NewPath("string"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.String == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				if t.String.Type == v1beta1.StringTransformTypeLength {
+					return ResolveStringLength(t.String, input)
+				}
+				return ResolveString(t.String, input)
+			},
+			Output: func(t v1beta1.Transform) v1beta1.TransformIOType {
+				if t.String != nil && t.String.Type == v1beta1.StringTransformTypeLength {
+					return v1beta1.TransformIOTypeInt64
+				}
+				return v1beta1.TransformIOTypeString
+			},
+			Accepts: func(t v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				if t.String == nil {
+					return true
+				}
+				switch t.String.Type { //nolint:exhaustive // Other string transforms format any input using %v.
+				case v1beta1.StringTransformTypeJoin:
+					return in == v1beta1.TransformIOTypeArray
+				case v1beta1.StringTransformTypeFormat:
+					if t.String.Format == nil {
+						return true
+					}
+					verbs, err := FormatVerbs(*t.String.Format)
+					if err != nil {
+						return true
+					}
+					for _, v := range verbs {
+						if !FormatAccepts(v, in) {
+							return false
+						}
+					}
+				}
+				return true
+			},
+		},
+		v1beta1.TransformTypeConvert: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Convert == nil {
+					return field.Required(field.NewPath("convert"), "given transform type convert requires configuration")
+				}
+				return wrapFieldError(ValidateConvertTransform(t.Convert), field.NewPath("convert"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Convert == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveConvert(t.Convert, input)
+			},
+			Output: func(t v1beta1.Transform) v1beta1.TransformIOType {
+				if t.Convert == nil {
+					return ""
+				}
+				return t.Convert.ToType
+			},
+			Accepts: func(t v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				if t.Convert == nil {
+					return true
+				}
+				_, err := GetConversionFunc(t.Convert, in)
+				return err == nil
+			},
+		},
+		v1beta1.TransformTypeBool: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Bool == nil {
+					return field.Required(field.NewPath("bool"), "given transform type bool requires configuration")
+				}
+				return wrapFieldError(ValidateBoolTransform(t.Bool), field.NewPath("bool"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Bool == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveBool(t.Bool, input)
+			},
+			Output: func(_ v1beta1.Transform) v1beta1.TransformIOType {
+				return v1beta1.TransformIOTypeBool
+			},
+			Accepts: func(t v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				return t.Bool == nil || t.Bool.Type != v1beta1.BoolTransformTypeNot || in == v1beta1.TransformIOTypeBool
+			},
+		},
+		v1beta1.TransformTypeParse: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Parse == nil {
+					return field.Required(field.NewPath("parse"), "given transform type parse requires configuration")
+				}
+				return wrapFieldError(ValidateParseTransform(t.Parse), field.NewPath("parse"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Parse == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveParse(t.Parse, input)
+			},
+			Output: func(t v1beta1.Transform) v1beta1.TransformIOType {
+				if t.Parse == nil {
+					return ""
+				}
+				switch t.Parse.Type {
+				case v1beta1.ParseTransformTypeInt:
+					return v1beta1.TransformIOTypeInt64
+				case v1beta1.ParseTransformTypeFloat:
+					return v1beta1.TransformIOTypeFloat64
+				}
+				return ""
+			},
+			Accepts: func(_ v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				return in == v1beta1.TransformIOTypeString
+			},
+		},
+		v1beta1.TransformTypeChecksum: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Checksum == nil {
+					return field.Required(field.NewPath("checksum"), "given transform type checksum requires configuration")
+				}
+				return wrapFieldError(ValidateChecksumTransform(t.Checksum), field.NewPath("checksum"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Checksum == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveChecksum(t.Checksum, input)
+			},
+			Output: func(_ v1beta1.Transform) v1beta1.TransformIOType {
+				return v1beta1.TransformIOTypeString
+			},
+			Accepts: func(_ v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				return isObjectOrArray(in)
+			},
+		},
+		v1beta1.TransformTypeCombine: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Combine == nil {
+					return field.Required(field.NewPath("combine"), "given transform type combine requires configuration")
+				}
+				return wrapFieldError(ValidateCombineTransform(t.Combine), field.NewPath("combine"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Combine == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveCombine(t.Combine, input)
+			},
+			Output: func(t v1beta1.Transform) v1beta1.TransformIOType {
+				if t.Combine != nil && t.Combine.Strategy == v1beta1.CombineStrategyString {
+					return v1beta1.TransformIOTypeString
+				}
+				return ""
+			},
+		},
+		v1beta1.TransformTypeWebhook: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Webhook == nil {
+					return field.Required(field.NewPath("webhook"), "given transform type webhook requires configuration")
+				}
+				return wrapFieldError(ValidateWebhookTransform(t.Webhook), field.NewPath("webhook"))
+			},
+			Resolve: func(ctx context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Webhook == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveWebhook(ctx, t.Webhook, input)
+			},
+		},
+		v1beta1.TransformTypeDig: {
+			Validate: func(t v1beta1.Transform) *field.Error {
+				if t.Dig == nil {
+					return field.Required(field.NewPath("dig"), "given transform type dig requires configuration")
+				}
+				return wrapFieldError(ValidateDigTransform(t.Dig), field.NewPath("dig"))
+			},
+			Resolve: func(_ context.Context, t v1beta1.Transform, input any) (any, error) {
+				if t.Dig == nil {
+					return nil, errors.Errorf(errFmtTransformConfigMissing, t.Type)
+				}
+				return ResolveDig(t.Dig, input)
+			},
+			Accepts: func(_ v1beta1.Transform, in v1beta1.TransformIOType) bool {
+				return isObjectOrArray(in)
+			},
+		},
+	}
+}
@@ -0,0 +1,274 @@
+package transforms
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestRegister(t *testing.T) {
+	upper := v1beta1.TransformType("upper")
+	Default.Register(upper, Definition{
+		Validate: func(t v1beta1.Transform) *field.Error {
+			if t.FromName != nil {
+				return field.Forbidden(field.NewPath("fromName"), "upper transforms can't read named outputs")
+			}
+			return nil
+		},
+		Resolve: func(_ context.Context, _ v1beta1.Transform, input any) (any, error) {
+			s, ok := input.(string)
+			if !ok {
+				return nil, errors.New("input must be a string")
+			}
+			return strings.ToUpper(s), nil
+		},
+	})
+	t.Cleanup(func() { Default.Unregister(upper) })
+
+	type want struct {
+		validate *field.Error
+		out      any
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		t      v1beta1.Transform
+		input  any
+		want   want
+	}{
+		"Registered": {
+			reason: "A registered transform type should be validated and resolved using its definition.",
+			t:      v1beta1.Transform{Type: upper},
+			input:  "cool",
+			want: want{
+				out: "COOL",
+			},
+		},
+		"RegisteredInvalid": {
+			reason: "A registered transform type should be validated using its definition.",
+			t:      v1beta1.Transform{Type: upper, FromName: ptr.To("input")},
+			input:  "cool",
+			want: want{
+				validate: field.Forbidden(field.NewPath("fromName"), "upper transforms can't read named outputs"),
+				out:      "COOL",
+			},
+		},
+		"RegisteredError": {
+			reason: "Errors returned by a registered transform type should be wrapped.",
+			t:      v1beta1.Transform{Type: upper},
+			input:  42,
+			want: want{
+				err: errors.Wrapf(errors.New("input must be a string"), errFmtTransformTypeFailed, "upper"),
+			},
+		},
+		"Unregistered": {
+			reason: "An unregistered transform type should be invalid.",
+			t:      v1beta1.Transform{Type: "lower"},
+			input:  "COOL",
+			want: want{
+				validate: field.Invalid(field.NewPath("type"), v1beta1.TransformType("lower"), "unknown transform type"),
+				err:      errors.Errorf(errFmtTypeNotSupported, "lower"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			verr := ValidateTransform(tc.t)
+			if diff := cmp.Diff(tc.want.validate, verr); diff != "" {
+				t.Errorf("%s\nValidateTransform(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			out, err := Resolve(context.Background(), tc.t, tc.input)
+			if diff := cmp.Diff(tc.want.out, out); diff != "" {
+				t.Errorf("%s\nResolve(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nResolve(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	type args struct {
+		ts    []v1beta1.Transform
+		input any
+	}
+	type want struct {
+		output any
+		err    error
+	}
+	tests := []struct {
+		name string
+		args args
+		want want
+	}{
+		{
+			name: "NoTransforms",
+			args: args{
+				ts: nil,
+				input: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"parameters": map[string]interface{}{
+							"test": "test",
+						},
+					},
+				},
+			},
+			want: want{
+				output: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"parameters": map[string]interface{}{
+							"test": "test",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "SensitiveTransformError",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type: v1beta1.TransformTypeMap,
+					Map: &v1beta1.MapTransform{
+						Pairs: map[string]extv1.JSON{"a": {Raw: []byte(`"b"`)}},
+					},
+					Sensitive: ptr.To(true),
+				}},
+				input: "hunter2",
+			},
+			want: want{
+				err: errors.Errorf(errFmtSensitiveTransformAtIndex, 0),
+			},
+		},
+		{
+			name: "MathTransformWithConversionToFloat64",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						ToType: v1beta1.TransformIOTypeFloat64,
+					},
+				}, {
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeMultiply,
+						Multiply: ptr.To[int64](2),
+					},
+				}},
+				input: int64(2),
+			},
+			want: want{
+				output: float64(4),
+			},
+		},
+		{
+			name: "MathTransformWithConversionToInt64",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						ToType: v1beta1.TransformIOTypeInt64,
+					},
+				}, {
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeMultiply,
+						Multiply: ptr.To[int64](2),
+					},
+				}},
+				input: int64(2),
+			},
+			want: want{
+				output: int64(4),
+			},
+		},
+		{
+			name: "CombineNamedOutputs",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type: v1beta1.TransformTypeString,
+					Name: ptr.To("hash"),
+					String: &v1beta1.StringTransform{
+						Type:    v1beta1.StringTransformTypeConvert,
+						Convert: ptr.To(v1beta1.StringConversionTypeToAdler32),
+					},
+				}, {
+					Type: v1beta1.TransformTypeCombine,
+					Combine: &v1beta1.CombineTransform{
+						Names:    []string{v1beta1.TransformNameInput, "hash"},
+						Strategy: v1beta1.CombineStrategyString,
+						String:   &v1beta1.StringCombine{Format: "%s-%s"},
+					},
+				}},
+				input: "cool",
+			},
+			want: want{
+				output: "cool-69665198",
+			},
+		},
+		{
+			name: "FromName",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type:    v1beta1.StringTransformTypeConvert,
+						Convert: ptr.To(v1beta1.StringConversionTypeToUpper),
+					},
+				}, {
+					Type:     v1beta1.TransformTypeString,
+					FromName: ptr.To(v1beta1.TransformNameInput),
+					String: &v1beta1.StringTransform{
+						Type:   v1beta1.StringTransformTypeFormat,
+						Format: ptr.To("%s!"),
+					},
+				}},
+				input: "cool",
+			},
+			want: want{
+				output: "cool!",
+			},
+		},
+		{
+			name: "UnknownName",
+			args: args{
+				ts: []v1beta1.Transform{{
+					Type:     v1beta1.TransformTypeString,
+					FromName: ptr.To("nope"),
+					String: &v1beta1.StringTransform{
+						Type:   v1beta1.StringTransformTypeFormat,
+						Format: ptr.To("%s!"),
+					},
+				}},
+				input: "cool",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtUnknownTransformName, "nope"), errFmtTransformAtIndex, 0),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Default.Evaluate(context.Background(), tt.args.ts, tt.args.input)
+			if diff := cmp.Diff(tt.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Evaluate(...): -want error, +got error:\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.want.output, got); diff != "" {
+				t.Errorf("Evaluate(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,1408 @@
+package transforms
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // Not used for secure hashing
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/adler32"
+	"math"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-version"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+const (
+	errMathTransformTypeFailed = "type %s is not supported for math transform type"
+	errFmtMathInputNonNumber   = "input is required to be a number for math transformer, got %T"
+	errFmtMathMultiplyFloat    = "cannot parse multiplyFloat %q as a number"
+
+	errFmtRequiredField                 = "%s is required by type %s"
+	errFmtConvertInputTypeNotSupported  = "invalid input type %T"
+	errFmtConvertFormatPairNotSupported = "conversion from %s to %s is not supported with format %s"
+	errFmtConvertLossy                  = "cannot convert %v to %s without losing information"
+	errFmtTransformAtIndex              = "transform at index %d returned error"
+	errFmtSensitiveTransformAtIndex     = "sensitive transform at index %d returned error (error omitted because it may contain sensitive values)"
+	errFmtUnknownTransformName          = "no earlier transform output is named %q"
+	errFmtTransformTimeout              = "transforms did not resolve within %s"
+	errFmtTypeNotSupported              = "transform type %s is not supported"
+	errFmtTransformConfigMissing        = "given transform type %s requires configuration"
+	errFmtTransformTypeFailed           = "%s transform could not resolve"
+	errFmtMapTypeNotSupported           = "type %s is not supported for map transform"
+	errFmtMapNotFound                   = "key %s is not found in map"
+	errFmtMapInvalidJSON                = "value for key %s is not valid JSON"
+	errFmtMapValueNotFound              = "value %s is not found in map"
+	errFmtMapValueAmbiguous             = "value %s is found at more than one key in map: %s"
+	errFmtMapInvalidInput               = "cannot encode input of inverted map transform"
+	errFmtMapFromEnvironment            = "cannot read map from environment field path %q"
+	errFmtMapFromEnvironmentNotObject   = "environment field path %q must be an object, got %T"
+	errFmtMapFromData                   = "cannot read map from data document %q"
+	errFmtMapFromDataNotFound           = "data document %q not found"
+	errFmtMapFromDataNotObject          = "data document %q must be an object at field path %q, got %T"
+
+	errFmtMatchPattern            = "cannot match pattern at index %d"
+	errFmtMatchParseResult        = "cannot parse result of pattern at index %d"
+	errMatchParseFallbackValue    = "cannot parse fallback value"
+	errMatchFallbackBoth          = "cannot set both a fallback value and the fallback to input flag"
+	errFmtMatchFallbackFrom       = "cannot read match fallback value from field path %q"
+	errFmtMatchPatternTypeInvalid = "unsupported pattern type '%s'"
+	errFmtMatchInputTypeInvalid   = "unsupported input type '%s'"
+	errMatchRegexpCompile         = "cannot compile regexp"
+	errMatchCIDRParse             = "cannot parse CIDR"
+	errFmtMatchInputNotIP         = "input %q is not an IP address or CIDR"
+	errMatchSemverParse           = "cannot parse semver constraint"
+	errFmtMatchInputNotVersion    = "input %q is not a version"
+
+	errStringTransformTypeFailed         = "type %s is not supported for string transform type"
+	errStringTransformTypeFormat         = "string transform of type %s fmt is not set"
+	errStringTransformTypeConvert        = "string transform of type %s convert is not set"
+	errStringTransformTypeTrim           = "string transform of type %s trim is not set"
+	errStringTransformTypeEnsure         = "string transform of type %s ensure is not set"
+	errStringTransformTypePad            = "string transform of type %s pad is not set"
+	errStringTransformTypePadFill        = "pad fill %q must be a single character"
+	errStringTransformTypeSubstring      = "string transform of type %s substring is not set"
+	errStringTransformTypeSubstringRange = "invalid substring range [%d:%d]"
+	errStringTransformTypeTruncate       = "string transform of type %s truncate is not set"
+	errStringTransformTypeTruncateSuffix = "truncate suffix %q is longer than length %d"
+	errStringTransformTypeLengthUnit     = "unknown string length unit %q"
+	errStringTransformTypeJoin           = "string transform of type %s join is not set"
+	errStringTransformTypeJoinInput      = "input of type %s cannot be joined; it must be an array"
+	errFmtStringJoinElement              = "cannot format element %d"
+	errStringTransformTypeJoinQuote      = "unknown join quote %q"
+	errStringTransformTypeRegexp         = "string transform of type %s regexp is not set"
+	errStringTransformTypeRegexpFailed   = "could not compile regexp"
+	errStringTransformTypeRegexpNoMatch  = "regexp %q had no matches for group %d"
+	errStringConvertTypeFailed           = "type %s is not supported for string convert"
+
+	errBoolTransformTypeFailed        = "type %s is not supported for bool transform type"
+	errBoolTransformTypeValue         = "bool transform of type %s value is not set"
+	errBoolTransformTypeMatchesFailed = "could not compile regexp"
+	errFmtBoolInputNotBool            = "input is required to be a boolean for bool transform of type Not, got %T"
+
+	errParseTransformTypeFailed = "type %s is not supported for parse transform type"
+	errFmtParseInputNotString   = "input is required to be a string for parse transform, got %T"
+	errFmtParseFailed           = "cannot parse %q"
+
+	errChecksumAlgorithmFailed   = "algorithm %s is not supported for checksum transform"
+	errFmtChecksumInputNotObject = "input is required to be an object or array for checksum transform, got %T"
+
+	errFmtCombineInputNotList         = "input is required to be a list of named outputs for combine transform, got %T"
+	errFmtCombineStrategyNotSupported = "combine strategy %s is not supported"
+	errFmtCombineConfigMissing        = "given combine strategy %s requires configuration"
+	errFmtCombineStrategyFailed       = "%s strategy could not combine"
+
+	errFmtDigInputNotObject = "input is required to be an object or array for dig transform, got %T"
+	errFmtDigFieldPath      = "cannot read field path %q"
+	errFmtDigInvalidDefault = "default for field path %q is not valid JSON"
+
+	errDecodeString = "string is not valid base64"
+	errMarshalJSON  = "cannot marshal to JSON"
+	errHash         = "cannot generate hash"
+	errAdler        = "unable to generate Adler checksum"
+)
+
+// A TypeMismatchError indicates a transform's input isn't of a type the
+// transform supports.
+type TypeMismatchError struct {
+	err error
+}
+
+func (e *TypeMismatchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TypeMismatchError) Unwrap() error {
+	return e.err
+}
+
+// typeMismatchError returns a *TypeMismatchError.
+func typeMismatchError(format string, args ...any) error {
+	return &TypeMismatchError{err: errors.Errorf(format, args...)}
+}
+
+// Resolve the supplied Transform using the default transform registry.
+func Resolve(ctx context.Context, t v1beta1.Transform, input any) (any, error) {
+	return Default.Resolve(ctx, t, input)
+}
+
+// ResolveMath resolves a Math transform.
+func ResolveMath(t *v1beta1.MathTransform, input any) (any, error) {
+	if err := ValidateMathTransform(t); err != nil {
+		return nil, err
+	}
+	switch input.(type) {
+	case int, int64, float64:
+	default:
+		return nil, typeMismatchError(errFmtMathInputNonNumber, input)
+	}
+	var out any
+	var err error
+	switch t.Type {
+	case v1beta1.MathTransformTypeMultiply:
+		out, err = resolveMathMultiply(t, input)
+	case v1beta1.MathTransformTypeClampMin, v1beta1.MathTransformTypeClampMax:
+		out, err = resolveMathClamp(t, input)
+	default:
+		return nil, errors.Errorf(errMathTransformTypeFailed, string(t.Type))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return roundMath(t, out), nil
+}
+
+// resolveMathMultiply resolves a multiply transform, returning an error if the
+// input is not a number. If the input is a float, the result will be a float64, otherwise
+// it will be an int64.
+func resolveMathMultiply(t *v1beta1.MathTransform, input any) (any, error) {
+	if t.MultiplyFloat != nil {
+		return resolveMathMultiplyFloat(t, input)
+	}
+	switch i := input.(type) {
+	case int:
+		return int64(i) * *t.Multiply, nil
+	case int64:
+		return i * *t.Multiply, nil
+	case float64:
+		return i * float64(*t.Multiply), nil
+	default:
+		return nil, typeMismatchError(errFmtMathInputNonNumber, input)
+	}
+}
+
+// resolveMathMultiplyFloat resolves a multiply transform with a float
+// multiplier. The result is always a float64.
+func resolveMathMultiplyFloat(t *v1beta1.MathTransform, input any) (any, error) {
+	m, err := strconv.ParseFloat(*t.MultiplyFloat, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtMathMultiplyFloat, *t.MultiplyFloat)
+	}
+	switch i := input.(type) {
+	case int:
+		return float64(i) * m, nil
+	case int64:
+		return float64(i) * m, nil
+	case float64:
+		return i * m, nil
+	default:
+		return nil, typeMismatchError(errFmtMathInputNonNumber, input)
+	}
+}
+
+// roundMath rounds a float64 result to the transform's precision, if any.
+// Other results are returned unchanged.
+func roundMath(t *v1beta1.MathTransform, out any) any {
+	f, ok := out.(float64)
+	if !ok || t.Precision == nil {
+		return out
+	}
+	p := math.Pow10(*t.Precision)
+	switch t.GetRounding() {
+	case v1beta1.MathRoundingFloor:
+		return math.Floor(f*p) / p
+	case v1beta1.MathRoundingCeil:
+		return math.Ceil(f*p) / p
+	case v1beta1.MathRoundingTruncate:
+		return math.Trunc(f*p) / p
+	default: // MathRoundingRound
+		return math.Round(f*p) / p
+	}
+}
+
+// resolveMathClamp resolves a clamp transform, returning an error if the input
+// is not a number. depending on the type of clamp, the result will be either
+// the input or the clamp value, preserving their original types.
+func resolveMathClamp(t *v1beta1.MathTransform, input any) (any, error) {
+	in := int64(0)
+	switch i := input.(type) {
+	case int:
+		in = int64(i)
+	case int64:
+		in = i
+	case float64:
+		in = int64(i)
+	default:
+		// should never happen as we validate the input type in ResolveMath
+		return nil, typeMismatchError(errFmtMathInputNonNumber, input)
+	}
+	switch t.Type { //nolint:exhaustive // We validate the type in ResolveMath
+	case v1beta1.MathTransformTypeClampMin:
+		if in < *t.ClampMin {
+			return *t.ClampMin, nil
+		}
+	case v1beta1.MathTransformTypeClampMax:
+		if in > *t.ClampMax {
+			return *t.ClampMax, nil
+		}
+	default:
+		return nil, errors.Errorf(errMathTransformTypeFailed, string(t.Type))
+	}
+	return input, nil
+}
+
+// ResolveMap resolves a Map transform.
+func ResolveMap(t *v1beta1.MapTransform, input any, ignoreCase bool) (any, error) {
+	switch i := input.(type) {
+	case string:
+		p, ok := t.Pairs[i]
+		if !ok && ignoreCase {
+			p, ok = lookupIgnoreCase(t.Pairs, i)
+		}
+		if !ok {
+			return nil, errors.Errorf(errFmtMapNotFound, i)
+		}
+		var val interface{}
+		if err := json.Unmarshal(p.Raw, &val); err != nil {
+			return nil, errors.Wrapf(err, errFmtMapInvalidJSON, i)
+		}
+		return val, nil
+	default:
+		return nil, typeMismatchError(errFmtMapTypeNotSupported, fmt.Sprintf("%T", input))
+	}
+}
+
+// ResolveInverseMap resolves a Map transform in reverse. It looks the input up
+// among the map's values and returns the key of the value that matches it. The
+// input must match exactly one value. Values are compared by their JSON
+// encoding, or case-insensitively if ignoreCase is true and both are strings.
+func ResolveInverseMap(t *v1beta1.MapTransform, input any, ignoreCase bool) (any, error) {
+	in, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.Wrap(err, errFmtMapInvalidInput)
+	}
+
+	keys := make([]string, 0, len(t.Pairs))
+	for k := range t.Pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var match []string
+	for _, k := range keys {
+		var v any
+		if err := json.Unmarshal(t.Pairs[k].Raw, &v); err != nil {
+			return nil, errors.Wrapf(err, errFmtMapInvalidJSON, k)
+		}
+		if mapValueMatches(in, input, v, ignoreCase) {
+			match = append(match, k)
+		}
+	}
+
+	switch len(match) {
+	case 0:
+		return nil, errors.Errorf(errFmtMapValueNotFound, in)
+	case 1:
+		return match[0], nil
+	default:
+		return nil, errors.Errorf(errFmtMapValueAmbiguous, in, strings.Join(match, ", "))
+	}
+}
+
+// mapValueMatches returns true if the supplied input, whose JSON encoding is
+// supplied, matches the supplied map value.
+func mapValueMatches(encoded []byte, input, v any, ignoreCase bool) bool {
+	if s, ok := input.(string); ok && ignoreCase {
+		vs, ok := v.(string)
+		return ok && strings.EqualFold(s, vs)
+	}
+	b, err := json.Marshal(v)
+	return err == nil && string(b) == string(encoded)
+}
+
+// lookupIgnoreCase returns the value of the first key, in sorted order, that
+// case-insensitively matches the supplied key.
+func lookupIgnoreCase(pairs map[string]extv1.JSON, key string) (extv1.JSON, bool) {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return pairs[k], true
+		}
+	}
+	return extv1.JSON{}, false
+}
+
+// ResolveEnvironmentMaps reads the pairs of any map transforms that specify
+// mapFromEnvironment from the supplied environment. The transforms are updated
+// in place.
+func ResolveEnvironmentMaps(env *unstructured.Unstructured, ts []v1beta1.Transform) error {
+	return NewMapLookups().ResolveEnvironmentMaps(env, ts)
+}
+
+// MapLookups memoizes the pairs that map transforms read from the environment
+// and the Input's data documents during a RunFunction call. Many transforms
+// typically read the same field, for example because they're part of a
+// PatchSet. They share its pairs, rather than each encoding them again.
+type MapLookups struct {
+	env  map[string]map[string]extv1.JSON
+	data map[string]map[string]extv1.JSON
+}
+
+// NewMapLookups returns MapLookups that haven't memoized any pairs.
+func NewMapLookups() *MapLookups {
+	return &MapLookups{
+		env:  map[string]map[string]extv1.JSON{},
+		data: map[string]map[string]extv1.JSON{},
+	}
+}
+
+// ForgetEnvironment forgets the pairs read from the environment. Call it when
+// the environment changes, e.g. once patches to the environment are rendered.
+func (l *MapLookups) ForgetEnvironment() {
+	l.env = map[string]map[string]extv1.JSON{}
+}
+
+// ResolveEnvironmentMaps reads the pairs of any map transforms that specify
+// mapFromEnvironment from the supplied environment, reusing pairs already read
+// from the same field. The transforms are updated in place.
+func (l *MapLookups) ResolveEnvironmentMaps(env *unstructured.Unstructured, ts []v1beta1.Transform) error {
+	for i := range ts {
+		t := &ts[i]
+		if t.Type != v1beta1.TransformTypeMap || t.MapFromEnvironment == nil {
+			continue
+		}
+		fp := t.MapFromEnvironment.FieldPath
+		if pairs, ok := l.env[fp]; ok {
+			t.Map = &v1beta1.MapTransform{Pairs: pairs}
+			continue
+		}
+		v, err := fieldpath.Pave(env.Object).GetValue(fp)
+		if err != nil {
+			return errors.Wrapf(err, errFmtMapFromEnvironment, fp)
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return errors.Errorf(errFmtMapFromEnvironmentNotObject, fp, v)
+		}
+		pairs, err := mapPairs(m)
+		if err != nil {
+			return errors.Wrapf(err, errFmtMapFromEnvironment, fp)
+		}
+		l.env[fp] = pairs
+		t.Map = &v1beta1.MapTransform{Pairs: pairs}
+	}
+	return nil
+}
+
+// ResolveMatchFallbacks reads the fallback value of any match transforms that
+// specify fallbackFrom from the supplied composite resource or environment.
+// Transforms whose fallback field doesn't exist keep falling back as they
+// otherwise would. The transforms are updated in place.
+func ResolveMatchFallbacks(xr, env *unstructured.Unstructured, ts []v1beta1.Transform) error {
+	for i := range ts {
+		t := &ts[i]
+		if t.Type != v1beta1.TransformTypeMatch || t.Match == nil || t.Match.FallbackFrom == nil {
+			continue
+		}
+		f := t.Match.FallbackFrom
+		from := xr
+		if f.GetSource() == v1beta1.MatchFallbackSourceEnvironment {
+			from = env
+		}
+
+		m := t.Match.DeepCopy()
+		m.FallbackFrom = nil
+		t.Match = m
+		if from == nil {
+			continue
+		}
+
+		v, err := fieldpath.Pave(from.Object).GetValue(f.FieldPath)
+		if fieldpath.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, errFmtMatchFallbackFrom, f.FieldPath)
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return errors.Wrapf(err, errFmtMatchFallbackFrom, f.FieldPath)
+		}
+		m.FallbackTo = v1beta1.MatchFallbackToTypeValue
+		m.FallbackValue = extv1.JSON{Raw: raw}
+	}
+	return nil
+}
+
+// ResolveDataMaps reads the pairs of any map transforms that specify
+// mapFromData from the supplied data documents. The transforms are updated in
+// place.
+func ResolveDataMaps(data map[string]any, ts []v1beta1.Transform) error {
+	return NewMapLookups().ResolveDataMaps(data, ts)
+}
+
+// ResolveDataMaps reads the pairs of any map transforms that specify
+// mapFromData from the supplied data documents, reusing pairs already read
+// from the same field of the same document. The transforms are updated in
+// place.
+func (l *MapLookups) ResolveDataMaps(data map[string]any, ts []v1beta1.Transform) error {
+	for i := range ts {
+		t := &ts[i]
+		if t.Type != v1beta1.TransformTypeMap || t.MapFromData == nil {
+			continue
+		}
+		name := t.MapFromData.Name
+		key := name + "/" + ptr.Deref(t.MapFromData.FieldPath, "")
+		if pairs, ok := l.data[key]; ok {
+			t.Map = &v1beta1.MapTransform{Pairs: pairs}
+			continue
+		}
+		v, ok := data[name]
+		if !ok {
+			return errors.Errorf(errFmtMapFromDataNotFound, name)
+		}
+		fp := ptr.Deref(t.MapFromData.FieldPath, "")
+		if fp != "" {
+			var err error
+			v, err = fieldpath.Pave(map[string]any{name: v}).GetValue(name + "." + fp)
+			if err != nil {
+				return errors.Wrapf(err, errFmtMapFromData, name)
+			}
+		}
+		m, ok := v.(map[string]any)
+		if !ok {
+			return errors.Errorf(errFmtMapFromDataNotObject, name, fp, v)
+		}
+		pairs, err := mapPairs(m)
+		if err != nil {
+			return errors.Wrapf(err, errFmtMapFromData, name)
+		}
+		l.data[key] = pairs
+		t.Map = &v1beta1.MapTransform{Pairs: pairs}
+	}
+	return nil
+}
+
+// mapPairs returns the pairs of a map transform that reads from the supplied
+// object.
+func mapPairs(m map[string]any) (map[string]extv1.JSON, error) {
+	pairs := make(map[string]extv1.JSON, len(m))
+	for k, v := range m {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		pairs[k] = extv1.JSON{Raw: raw}
+	}
+	return pairs, nil
+}
+
+// ResolveMatch resolves a Match transform.
+func ResolveMatch(t *v1beta1.MatchTransform, input any) (any, error) {
+	var output any
+	for i, p := range t.Patterns {
+		matches, err := Matches(p, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtMatchPattern, i)
+		}
+		if matches {
+			if err := unmarshalJSON(p.Result, &output); err != nil {
+				return nil, errors.Wrapf(err, errFmtMatchParseResult, i)
+			}
+			return output, nil
+		}
+	}
+
+	// Fallback to input if no pattern matches and fallback to input is set
+	if t.FallbackTo == v1beta1.MatchFallbackToTypeInput {
+		if t.FallbackValue.Size() != 0 {
+			return nil, errors.New(errMatchFallbackBoth)
+		}
+
+		return input, nil
+	}
+
+	// Use fallback value if no pattern matches (or if there are no patterns)
+	if err := unmarshalJSON(t.FallbackValue, &output); err != nil {
+		return nil, errors.Wrap(err, errMatchParseFallbackValue)
+	}
+	return output, nil
+}
+
+// Matches returns true if the pattern matches the supplied input.
+func Matches(p v1beta1.MatchTransformPattern, input any) (bool, error) {
+	switch p.Type {
+	case v1beta1.MatchTransformPatternTypeLiteral:
+		return matchesLiteral(p, input)
+	case v1beta1.MatchTransformPatternTypeRegexp:
+		return matchesRegexp(p, input)
+	case v1beta1.MatchTransformPatternTypeRange:
+		return matchesRange(p, input)
+	case v1beta1.MatchTransformPatternTypeCIDR:
+		return matchesCIDR(p, input)
+	case v1beta1.MatchTransformPatternTypeSemver:
+		return matchesSemver(p, input)
+	}
+	return false, errors.Errorf(errFmtMatchPatternTypeInvalid, string(p.Type))
+}
+
+func matchesLiteral(p v1beta1.MatchTransformPattern, input any) (bool, error) {
+	if p.Literal == nil {
+		return false, errors.Errorf(errFmtRequiredField, "literal", v1beta1.MatchTransformPatternTypeLiteral)
+	}
+	inputStr, ok := input.(string)
+	if !ok {
+		return false, typeMismatchError(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	}
+	return inputStr == *p.Literal, nil
+}
+
+func matchesRegexp(p v1beta1.MatchTransformPattern, input any) (bool, error) {
+	if p.Regexp == nil {
+		return false, errors.Errorf(errFmtRequiredField, "regexp", v1beta1.MatchTransformPatternTypeRegexp)
+	}
+	re, err := regexps.Compile(*p.Regexp)
+	if err != nil {
+		return false, errors.Wrap(err, errMatchRegexpCompile)
+	}
+	if input == nil {
+		return false, typeMismatchError(errFmtMatchInputTypeInvalid, "null")
+	}
+	inputStr, ok := input.(string)
+	if !ok {
+		return false, typeMismatchError(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	}
+	return re.MatchString(inputStr), nil
+}
+
+func matchesRange(p v1beta1.MatchTransformPattern, input any) (bool, error) {
+	if p.Range == nil {
+		return false, errors.Errorf(errFmtRequiredField, "range", v1beta1.MatchTransformPatternTypeRange)
+	}
+	var n float64
+	switch i := input.(type) {
+	case int:
+		n = float64(i)
+	case int64:
+		n = float64(i)
+	case float64:
+		n = i
+	default:
+		return false, typeMismatchError(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	}
+	if p.Range.Gte != nil && n < float64(*p.Range.Gte) {
+		return false, nil
+	}
+	if p.Range.Lte != nil && n > float64(*p.Range.Lte) {
+		return false, nil
+	}
+	return true, nil
+}
+
+func matchesCIDR(p v1beta1.MatchTransformPattern, input any) (bool, error) {
+	if p.CIDR == nil {
+		return false, errors.Errorf(errFmtRequiredField, "cidr", v1beta1.MatchTransformPatternTypeCIDR)
+	}
+	cidr, err := netip.ParsePrefix(*p.CIDR)
+	if err != nil {
+		return false, errors.Wrap(err, errMatchCIDRParse)
+	}
+	inputStr, ok := input.(string)
+	if !ok {
+		return false, typeMismatchError(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	}
+	if addr, err := netip.ParseAddr(inputStr); err == nil {
+		return cidr.Contains(addr), nil
+	}
+	in, err := netip.ParsePrefix(inputStr)
+	if err != nil {
+		return false, errors.Errorf(errFmtMatchInputNotIP, inputStr)
+	}
+	// The input CIDR is within the pattern's CIDR if it's no bigger, and its
+	// first address is within the pattern's CIDR.
+	return in.Bits() >= cidr.Bits() && cidr.Contains(in.Masked().Addr()), nil
+}
+
+func matchesSemver(p v1beta1.MatchTransformPattern, input any) (bool, error) {
+	if p.Semver == nil {
+		return false, errors.Errorf(errFmtRequiredField, "semver", v1beta1.MatchTransformPatternTypeSemver)
+	}
+	c, err := semverConstraints(*p.Semver)
+	if err != nil {
+		return false, errors.Wrap(err, errMatchSemverParse)
+	}
+	inputStr, ok := input.(string)
+	if !ok {
+		return false, typeMismatchError(errFmtMatchInputTypeInvalid, fmt.Sprintf("%T", input))
+	}
+	v, err := version.NewVersion(inputStr)
+	if err != nil {
+		return false, errors.Errorf(errFmtMatchInputNotVersion, inputStr)
+	}
+	return c.Check(v), nil
+}
+
+// semverConstraints parses constraints separated by spaces or commas, e.g.
+// ">=14 <16" or ">= 14, < 16".
+func semverConstraints(s string) (version.Constraints, error) {
+	var cs []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		// Join an operator that's separated from its version by a space.
+		if n := len(cs); n > 0 && strings.Trim(cs[n-1], "<>=!~") == "" {
+			cs[n-1] += f
+			continue
+		}
+		cs = append(cs, f)
+	}
+	return version.NewConstraint(strings.Join(cs, ","))
+}
+
+// unmarshalJSON is a small utility function that returns nil if j contains no
+// data. json.Unmarshal seems to not be able to handle this.
+func unmarshalJSON(j extv1.JSON, output *any) error {
+	if len(j.Raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(j.Raw, output)
+}
+
+// ResolveString resolves a String transform.
+func ResolveString(t *v1beta1.StringTransform, input any) (string, error) {
+	switch t.Type {
+	case v1beta1.StringTransformTypeFormat:
+		if t.Format == nil {
+			return "", errors.Errorf(errStringTransformTypeFormat, string(t.Type))
+		}
+		return Format(*t.Format, input)
+	case v1beta1.StringTransformTypeConvert:
+		if t.Convert == nil {
+			return "", errors.Errorf(errStringTransformTypeConvert, string(t.Type))
+		}
+		return stringConvertTransform(t.Convert, input)
+	case v1beta1.StringTransformTypeTrimPrefix, v1beta1.StringTransformTypeTrimSuffix:
+		if t.Trim == nil {
+			return "", errors.Errorf(errStringTransformTypeTrim, string(t.Type))
+		}
+		return stringTrimTransform(input, t.Type, *t.Trim), nil
+	case v1beta1.StringTransformTypeRegexp:
+		if t.Regexp == nil {
+			return "", errors.Errorf(errStringTransformTypeRegexp, string(t.Type))
+		}
+		return stringRegexpTransform(input, *t.Regexp)
+	case v1beta1.StringTransformTypeEnsurePrefix, v1beta1.StringTransformTypeEnsureSuffix:
+		if t.Ensure == nil {
+			return "", errors.Errorf(errStringTransformTypeEnsure, string(t.Type))
+		}
+		return stringEnsureTransform(input, t.Type, *t.Ensure), nil
+	case v1beta1.StringTransformTypePadLeft, v1beta1.StringTransformTypePadRight:
+		if t.Pad == nil {
+			return "", errors.Errorf(errStringTransformTypePad, string(t.Type))
+		}
+		return stringPadTransform(input, t.Type, *t.Pad)
+	case v1beta1.StringTransformTypeSubstring:
+		if t.Substring == nil {
+			return "", errors.Errorf(errStringTransformTypeSubstring, string(t.Type))
+		}
+		return stringSubstringTransform(input, *t.Substring)
+	case v1beta1.StringTransformTypeTruncate:
+		if t.Truncate == nil {
+			return "", errors.Errorf(errStringTransformTypeTruncate, string(t.Type))
+		}
+		return stringTruncateTransform(input, *t.Truncate)
+	case v1beta1.StringTransformTypeJoin:
+		if t.Join == nil {
+			return "", errors.Errorf(errStringTransformTypeJoin, string(t.Type))
+		}
+		return stringJoinTransform(input, *t.Join)
+	default:
+		return "", errors.Errorf(errStringTransformTypeFailed, string(t.Type))
+	}
+}
+
+func stringConvertTransform(t *v1beta1.StringConversionType, input any) (string, error) {
+	str := fmt.Sprintf("%v", input)
+	switch *t {
+	case v1beta1.StringConversionTypeToUpper:
+		return strings.ToUpper(str), nil
+	case v1beta1.StringConversionTypeToLower:
+		return strings.ToLower(str), nil
+	case v1beta1.StringConversionTypeToJSON:
+		raw, err := json.Marshal(input)
+		return string(raw), errors.Wrap(err, errMarshalJSON)
+	case v1beta1.StringConversionTypeToBase64:
+		return base64.StdEncoding.EncodeToString([]byte(str)), nil
+	case v1beta1.StringConversionTypeFromBase64:
+		s, err := base64.StdEncoding.DecodeString(str)
+		return string(s), errors.Wrap(err, errDecodeString)
+	case v1beta1.StringConversionTypeToSHA1:
+		hash, err := stringGenerateHash(input, sha1.Sum)
+		return hex.EncodeToString(hash[:]), errors.Wrap(err, errHash)
+	case v1beta1.StringConversionTypeToSHA256:
+		hash, err := stringGenerateHash(input, sha256.Sum256)
+		return hex.EncodeToString(hash[:]), errors.Wrap(err, errHash)
+	case v1beta1.StringConversionTypeToSHA512:
+		hash, err := stringGenerateHash(input, sha512.Sum512)
+		return hex.EncodeToString(hash[:]), errors.Wrap(err, errHash)
+	case v1beta1.StringConversionTypeToAdler32:
+		checksum, err := stringGenerateHash(input, adler32.Checksum)
+		return strconv.FormatUint(uint64(checksum), 10), errors.Wrap(err, errAdler)
+	case v1beta1.StringConversionTypeCollapseWhitespace:
+		return strings.Join(strings.Fields(str), " "), nil
+	case v1beta1.StringConversionTypeStripControl:
+		return stripControl(str), nil
+	case v1beta1.StringConversionTypeCapitalizeWords:
+		return capitalizeWords(str), nil
+	default:
+		return "", errors.Errorf(errStringConvertTypeFailed, *t)
+	}
+}
+
+// stripControl removes control characters from the supplied string. Control
+// characters that are also whitespace, like tabs and newlines, are replaced
+// with a space so that the words they separate remain separate.
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case !unicode.IsControl(r):
+			return r
+		case unicode.IsSpace(r):
+			return ' '
+		default:
+			return -1
+		}
+	}, s)
+}
+
+// capitalizeWords title cases the first letter of each word in the supplied
+// string, leaving other letters unchanged. A word starts with any letter that
+// doesn't follow another letter, a digit, or an apostrophe.
+func capitalizeWords(s string) string {
+	b := &strings.Builder{}
+	b.Grow(len(s))
+	prev := ' '
+	for _, r := range s {
+		if unicode.IsLetter(r) && !unicode.IsLetter(prev) && !unicode.IsDigit(prev) && prev != '\'' && prev != '’' {
+			r = unicode.ToTitle(r)
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+func stringGenerateHash[THash any](input any, hashFunc func([]byte) THash) (THash, error) {
+	var b []byte
+	var err error
+	switch v := input.(type) {
+	case string:
+		b = []byte(v)
+	default:
+		b, err = json.Marshal(input)
+		if err != nil {
+			var ret THash
+			return ret, errors.Wrap(err, errMarshalJSON)
+		}
+	}
+	return hashFunc(b), nil
+}
+
+func stringTrimTransform(input any, t v1beta1.StringTransformType, trim string) string {
+	str := fmt.Sprintf("%v", input)
+	if t == v1beta1.StringTransformTypeTrimPrefix {
+		return strings.TrimPrefix(str, trim)
+	}
+	if t == v1beta1.StringTransformTypeTrimSuffix {
+		return strings.TrimSuffix(str, trim)
+	}
+	return str
+}
+
+func stringEnsureTransform(input any, t v1beta1.StringTransformType, affix string) string {
+	str := fmt.Sprintf("%v", input)
+	if t == v1beta1.StringTransformTypeEnsurePrefix && !strings.HasPrefix(str, affix) {
+		return affix + str
+	}
+	if t == v1beta1.StringTransformTypeEnsureSuffix && !strings.HasSuffix(str, affix) {
+		return str + affix
+	}
+	return str
+}
+
+func stringPadTransform(input any, t v1beta1.StringTransformType, p v1beta1.StringTransformPad) (string, error) {
+	fill := []rune(p.GetFill())
+	if len(fill) != 1 {
+		return "", errors.Errorf(errStringTransformTypePadFill, p.GetFill())
+	}
+	str := fmt.Sprintf("%v", input)
+	n := p.Width - utf8.RuneCountInString(str)
+	if n <= 0 {
+		return str, nil
+	}
+	pad := strings.Repeat(string(fill), n)
+	if t == v1beta1.StringTransformTypePadLeft {
+		return pad + str, nil
+	}
+	return str + pad, nil
+}
+
+func stringSubstringTransform(input any, r v1beta1.StringTransformSubstring) (string, error) {
+	str := []rune(fmt.Sprintf("%v", input))
+	if r.Start < 0 || (r.End != nil && *r.End < r.Start) {
+		return "", errors.Errorf(errStringTransformTypeSubstringRange, r.Start, ptr.Deref[int](r.End, len(str)))
+	}
+	if r.Start >= len(str) {
+		return "", nil
+	}
+	end := ptr.Deref[int](r.End, len(str))
+	if end > len(str) {
+		end = len(str)
+	}
+	return string(str[r.Start:end]), nil
+}
+
+func stringJoinTransform(input any, j v1beta1.StringTransformJoin) (string, error) {
+	elems, ok := input.([]any)
+	if !ok {
+		return "", typeMismatchError(errStringTransformTypeJoinInput, fmt.Sprintf("%T", input))
+	}
+	format := ptr.Deref(j.Format, "%v")
+	out := make([]string, len(elems))
+	for i, e := range elems {
+		s, err := Format(format, e)
+		if err != nil {
+			return "", errors.Wrapf(err, errFmtStringJoinElement, i)
+		}
+		switch j.GetQuote() {
+		case v1beta1.StringJoinQuoteNone:
+		case v1beta1.StringJoinQuoteSingle:
+			s = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+		case v1beta1.StringJoinQuoteDouble:
+			s = jsonQuote(s)
+		default:
+			return "", errors.Errorf(errStringTransformTypeJoinQuote, j.GetQuote())
+		}
+		out[i] = s
+	}
+	return strings.Join(out, j.Separator), nil
+}
+
+// jsonQuote quotes the supplied string as a JSON string. Unlike json.Marshal
+// it doesn't escape HTML characters like <, >, and &.
+func jsonQuote(s string) string {
+	b := &bytes.Buffer{}
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	_ = e.Encode(s) // Encoding a string can't fail.
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ResolveStringLength resolves a String transform of type Length. It returns
+// the length of the input in the configured unit.
+func ResolveStringLength(t *v1beta1.StringTransform, input any) (int64, error) {
+	if t.Type != v1beta1.StringTransformTypeLength {
+		return 0, errors.Errorf(errStringTransformTypeFailed, string(t.Type))
+	}
+	segs, err := stringSegments(fmt.Sprintf("%v", input), t.Length.GetUnit())
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(segs)), nil
+}
+
+func stringTruncateTransform(input any, t v1beta1.StringTransformTruncate) (string, error) {
+	unit := t.GetUnit()
+	str := fmt.Sprintf("%v", input)
+	segs, err := stringSegments(str, unit)
+	if err != nil {
+		return "", err
+	}
+	if len(segs) <= t.Length {
+		return str, nil
+	}
+
+	suffix := ptr.Deref(t.Suffix, "")
+	if t.HashLength != nil && *t.HashLength > 0 {
+		h := sha256.Sum256([]byte(str))
+		suffix += hex.EncodeToString(h[:])[:min(*t.HashLength, sha256.Size*2)]
+	}
+	ssegs, err := stringSegments(suffix, unit)
+	if err != nil {
+		return "", err
+	}
+	n := t.Length - len(ssegs)
+	if n < 0 {
+		return "", errors.Errorf(errStringTransformTypeTruncateSuffix, suffix, t.Length)
+	}
+
+	if unit != v1beta1.StringLengthUnitBytes {
+		return strings.Join(segs[:n], "") + suffix, nil
+	}
+
+	// Don't split a multi-byte rune. Back off to the start of the rune that
+	// would otherwise be split.
+	for n > 0 && n < len(str) && !utf8.RuneStart(str[n]) {
+		n--
+	}
+	return str[:n] + suffix, nil
+}
+
+// stringSegments splits the supplied string into segments of the supplied
+// unit. Byte segments are single bytes, and may not be valid UTF-8.
+func stringSegments(s string, u v1beta1.StringLengthUnit) ([]string, error) {
+	switch u {
+	case v1beta1.StringLengthUnitBytes:
+		segs := make([]string, len(s))
+		for i := 0; i < len(s); i++ {
+			segs[i] = s[i : i+1]
+		}
+		return segs, nil
+	case v1beta1.StringLengthUnitRunes:
+		segs := make([]string, 0, utf8.RuneCountInString(s))
+		for _, r := range s {
+			segs = append(segs, string(r))
+		}
+		return segs, nil
+	case v1beta1.StringLengthUnitGraphemes:
+		return graphemes(s), nil
+	default:
+		return nil, errors.Errorf(errStringTransformTypeLengthUnit, u)
+	}
+}
+
+const (
+	zeroWidthJoiner        = '\u200D'
+	variationSelector16    = '\uFE0F'
+	emojiModifierFirst     = '\U0001F3FB'
+	emojiModifierLast      = '\U0001F3FF'
+	regionalIndicatorFirst = '\U0001F1E6'
+	regionalIndicatorLast  = '\U0001F1FF'
+)
+
+// graphemes approximates splitting the supplied string into extended grapheme
+// clusters, per Unicode Standard Annex #29. It handles combining marks,
+// variation selectors, emoji modifiers, zero width joiner sequences, flags
+// (regional indicator pairs), and CRLF. It doesn't handle less common cases
+// like Hangul syllable sequences or Indic conjuncts.
+func graphemes(s string) []string {
+	rs := []rune(s)
+	out := make([]string, 0, len(rs))
+	for i := 0; i < len(rs); {
+		j := i + 1
+		switch {
+		case rs[i] == '\r' && j < len(rs) && rs[j] == '\n':
+			j++
+		case isRegionalIndicator(rs[i]) && j < len(rs) && isRegionalIndicator(rs[j]):
+			j++
+		}
+		for j < len(rs) {
+			if isGraphemeExtend(rs[j]) {
+				j++
+				continue
+			}
+			if rs[j-1] == zeroWidthJoiner && !unicode.IsControl(rs[j]) {
+				j++
+				continue
+			}
+			break
+		}
+		out = append(out, string(rs[i:j]))
+		i = j
+	}
+	return out
+}
+
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		r == variationSelector16 ||
+		(r >= emojiModifierFirst && r <= emojiModifierLast)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorFirst && r <= regionalIndicatorLast
+}
+
+func stringRegexpTransform(input any, r v1beta1.StringTransformRegexp) (string, error) {
+	re, err := regexps.Compile(r.Match)
+	if err != nil {
+		return "", errors.Wrap(err, errStringTransformTypeRegexpFailed)
+	}
+
+	groups := re.FindStringSubmatch(fmt.Sprintf("%v", input))
+
+	// Return the entire match (group zero) by default.
+	g := ptr.Deref[int](r.Group, 0)
+	if len(groups) == 0 || g < 0 || g >= len(groups) {
+		return "", errors.Errorf(errStringTransformTypeRegexpNoMatch, r.Match, g)
+	}
+
+	return groups[g], nil
+}
+
+// ResolveBool resolves a Bool transform.
+func ResolveBool(t *v1beta1.BoolTransform, input any) (bool, error) {
+	if t.Type == v1beta1.BoolTransformTypeNot {
+		b, ok := input.(bool)
+		if !ok {
+			return false, typeMismatchError(errFmtBoolInputNotBool, input)
+		}
+		return !b, nil
+	}
+
+	if t.Value == nil {
+		return false, errors.Errorf(errBoolTransformTypeValue, string(t.Type))
+	}
+	str := fmt.Sprintf("%v", input)
+
+	switch t.Type {
+	case v1beta1.BoolTransformTypeContains:
+		return strings.Contains(str, *t.Value), nil
+	case v1beta1.BoolTransformTypeHasPrefix:
+		return strings.HasPrefix(str, *t.Value), nil
+	case v1beta1.BoolTransformTypeHasSuffix:
+		return strings.HasSuffix(str, *t.Value), nil
+	case v1beta1.BoolTransformTypeMatches:
+		re, err := regexps.Compile(*t.Value)
+		if err != nil {
+			return false, errors.Wrap(err, errBoolTransformTypeMatchesFailed)
+		}
+		return re.MatchString(str), nil
+	case v1beta1.BoolTransformTypeNot:
+		// Handled above.
+	}
+	return false, errors.Errorf(errBoolTransformTypeFailed, string(t.Type))
+}
+
+// ResolveParse resolves a Parse transform.
+func ResolveParse(t *v1beta1.ParseTransform, input any) (any, error) {
+	if err := ValidateParseTransform(t); err != nil {
+		return nil, err
+	}
+	str, ok := input.(string)
+	if !ok {
+		return nil, typeMismatchError(errFmtParseInputNotString, input)
+	}
+
+	var out any
+	var err error
+	switch t.Type {
+	case v1beta1.ParseTransformTypeInt:
+		out, err = strconv.ParseInt(str, t.GetBase(), t.GetBitSize())
+		if err != nil {
+			out = int64(0)
+		}
+	case v1beta1.ParseTransformTypeFloat:
+		out, err = strconv.ParseFloat(str, t.GetBitSize())
+		if err != nil {
+			out = float64(0)
+		}
+	default:
+		return nil, errors.Errorf(errParseTransformTypeFailed, string(t.Type))
+	}
+
+	if err != nil && t.GetErrorPolicy() == v1beta1.ParseErrorPolicyError {
+		return nil, errors.Wrapf(err, errFmtParseFailed, str)
+	}
+	return out, nil
+}
+
+// ResolveChecksum resolves a Checksum transform.
+func ResolveChecksum(t *v1beta1.ChecksumTransform, input any) (string, error) {
+	switch input.(type) {
+	case map[string]any, []any:
+	default:
+		return "", typeMismatchError(errFmtChecksumInputNotObject, input)
+	}
+
+	// encoding/json sorts object keys, so this canonicalizes the input.
+	b, err := json.Marshal(input)
+	if err != nil {
+		return "", errors.Wrap(err, errMarshalJSON)
+	}
+
+	switch t.GetAlgorithm() {
+	case v1beta1.ChecksumAlgorithmSHA1:
+		h := sha1.Sum(b)
+		return hex.EncodeToString(h[:]), nil
+	case v1beta1.ChecksumAlgorithmSHA256:
+		h := sha256.Sum256(b)
+		return hex.EncodeToString(h[:]), nil
+	case v1beta1.ChecksumAlgorithmSHA512:
+		h := sha512.Sum512(b)
+		return hex.EncodeToString(h[:]), nil
+	}
+	return "", errors.Errorf(errChecksumAlgorithmFailed, string(t.GetAlgorithm()))
+}
+
+// ResolveCombine resolves a Combine transform. The input must be the list of
+// named outputs to combine.
+func ResolveCombine(t *v1beta1.CombineTransform, input any) (any, error) {
+	vars, ok := input.([]any)
+	if !ok {
+		return nil, typeMismatchError(errFmtCombineInputNotList, input)
+	}
+	return Combine(v1beta1.Combine{Strategy: t.Strategy, String: t.String}, vars)
+}
+
+// ResolveDig resolves a Dig transform. The default, if any, is produced if
+// any segment of the field path doesn't exist.
+func ResolveDig(t *v1beta1.DigTransform, input any) (any, error) {
+	switch input.(type) {
+	case map[string]any, []any:
+	default:
+		return nil, typeMismatchError(errFmtDigInputNotObject, input)
+	}
+
+	// Wrap the input in an object so that we can also dig into arrays.
+	path := "input." + t.FieldPath
+	if strings.HasPrefix(t.FieldPath, "[") {
+		path = "input" + t.FieldPath
+	}
+	out, err := fieldpath.Pave(map[string]any{"input": input}).GetValue(path)
+	if fieldpath.IsNotFound(err) && t.Default != nil {
+		var def any
+		if err := json.Unmarshal(t.Default.Raw, &def); err != nil {
+			return nil, errors.Wrapf(err, errFmtDigInvalidDefault, t.FieldPath)
+		}
+		return def, nil
+	}
+	return out, errors.Wrapf(err, errFmtDigFieldPath, t.FieldPath)
+}
+
+// ResolveConvert resolves a Convert transform by looking up the appropriate
+// conversion function for the given input type and invoking it.
+func ResolveConvert(t *v1beta1.ConvertTransform, input any) (any, error) {
+	if err := ValidateConvertTransform(t); err != nil {
+		return nil, err
+	}
+
+	from := inputType(input)
+	if !from.IsValid() {
+		return nil, typeMismatchError(errFmtConvertInputTypeNotSupported, input)
+	}
+	f, err := GetConversionFunc(t, from)
+	if err != nil {
+		return nil, err
+	}
+
+	// Conversion functions expect an int64 rather than an int.
+	if i, ok := input.(int); ok {
+		input = int64(i)
+	}
+	return f(input)
+}
+
+// inputType returns the TransformIOType of the supplied input. Objects and
+// arrays are represented as map[string]any and []any respectively.
+func inputType(input any) v1beta1.TransformIOType {
+	switch input.(type) {
+	case map[string]any:
+		return v1beta1.TransformIOTypeObject
+	case []any:
+		return v1beta1.TransformIOTypeArray
+	}
+	return v1beta1.TransformIOType(fmt.Sprintf("%T", input))
+}
+
+// isNumber returns true if the supplied type is a number.
+func isNumber(t v1beta1.TransformIOType) bool {
+	return t == v1beta1.TransformIOTypeInt || t == v1beta1.TransformIOTypeInt64 || t == v1beta1.TransformIOTypeFloat64
+}
+
+// isObjectOrArray returns true if the supplied type is an object or an array.
+func isObjectOrArray(t v1beta1.TransformIOType) bool {
+	return t == v1beta1.TransformIOTypeObject || t == v1beta1.TransformIOTypeArray
+}
+
+// mapOutput returns the type of the values of the supplied map transform, or an
+// empty type if they're not all of the same type. JSON numbers are float64s.
+func mapOutput(t *v1beta1.MapTransform) v1beta1.TransformIOType {
+	var out v1beta1.TransformIOType
+	for _, p := range t.Pairs {
+		var v any
+		if err := json.Unmarshal(p.Raw, &v); err != nil {
+			return ""
+		}
+		vt := inputType(v)
+		if !vt.IsValid() || (out != "" && vt != out) {
+			return ""
+		}
+		out = vt
+	}
+	return out
+}
+
+type conversionPair struct {
+	from   v1beta1.TransformIOType
+	to     v1beta1.TransformIOType
+	format v1beta1.ConvertTransformFormat
+}
+
+// GetConversionFunc returns the conversion function for the given input and output types, or an error if no conversion is
+// supported. Will return a no-op conversion if the input and output types are the same.
+func GetConversionFunc(t *v1beta1.ConvertTransform, from v1beta1.TransformIOType) (func(any) (any, error), error) {
+	originalFrom := from
+	to := t.ToType
+	if to == v1beta1.TransformIOTypeInt {
+		to = v1beta1.TransformIOTypeInt64
+	}
+	if from == v1beta1.TransformIOTypeInt {
+		from = v1beta1.TransformIOTypeInt64
+	}
+	if to == from {
+		return func(input any) (any, error) {
+			return input, nil
+		}, nil
+	}
+	f, ok := conversions[conversionPair{from: from, to: to, format: t.GetFormat()}]
+	if !ok {
+		return nil, errors.Errorf(v1beta1.ErrFmtConvertFormatPairNotSupported, originalFrom, to, t.GetFormat())
+	}
+	return func(input any) (any, error) {
+		return f(t, input)
+	}, nil
+}
+
+// The unparam linter is complaining that these functions always return a nil
+// error, but we need this to be the case given some other functions in the map
+// may return an error.
+var conversions = map[conversionPair]func(*v1beta1.ConvertTransform, any) (any, error){
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeInt64, format: v1beta1.ConvertTransformFormatNone}: func(t *v1beta1.ConvertTransform, i any) (any, error) {
+		return strconv.ParseInt(i.(string), t.GetBase(), 64)
+	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeBool, format: v1beta1.ConvertTransformFormatNone}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		return strconv.ParseBool(i.(string))
+	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeFloat64, format: v1beta1.ConvertTransformFormatNone}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		return strconv.ParseFloat(i.(string), 64)
+	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeFloat64, format: v1beta1.ConvertTransformFormatQuantity}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		q, err := resource.ParseQuantity(i.(string))
+		if err != nil {
+			return nil, err
+		}
+		return q.AsApproximateFloat64(), nil
+	},
+
+	{from: v1beta1.TransformIOTypeInt64, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatNone}: func(t *v1beta1.ConvertTransform, i any) (any, error) { //nolint:unparam // See note above.
+		s := strconv.FormatInt(i.(int64), t.GetBase())
+		w := ptr.Deref[int](t.Width, 0)
+		neg := strings.HasPrefix(s, "-")
+		digits := strings.TrimPrefix(s, "-")
+		if len(digits) >= w {
+			return s, nil
+		}
+		digits = strings.Repeat("0", w-len(digits)) + digits
+		if neg {
+			return "-" + digits, nil
+		}
+		return digits, nil
+	},
+	{from: v1beta1.TransformIOTypeInt64, to: v1beta1.TransformIOTypeBool, format: v1beta1.ConvertTransformFormatNone}: func(t *v1beta1.ConvertTransform, i any) (any, error) {
+		v := i.(int64)
+		if v != 0 && v != 1 && t.GetLossyPolicy() == v1beta1.ConvertLossyPolicyError {
+			return nil, errors.Errorf(errFmtConvertLossy, i, v1beta1.TransformIOTypeBool)
+		}
+		return v == 1, nil
+	},
+	{from: v1beta1.TransformIOTypeInt64, to: v1beta1.TransformIOTypeFloat64, format: v1beta1.ConvertTransformFormatNone}: func(_ *v1beta1.ConvertTransform, i any) (any, error) { //nolint:unparam // See note above.
+		return float64(i.(int64)), nil
+	},
+
+	{from: v1beta1.TransformIOTypeBool, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatNone}: func(_ *v1beta1.ConvertTransform, i any) (any, error) { //nolint:unparam // See note above.
+		return strconv.FormatBool(i.(bool)), nil
+	},
+	{from: v1beta1.TransformIOTypeBool, to: v1beta1.TransformIOTypeInt64, format: v1beta1.ConvertTransformFormatNone}: func(_ *v1beta1.ConvertTransform, i any) (any, error) { //nolint:unparam // See note above.
+		if i.(bool) {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	},
+	{from: v1beta1.TransformIOTypeBool, to: v1beta1.TransformIOTypeFloat64, format: v1beta1.ConvertTransformFormatNone}: func(_ *v1beta1.ConvertTransform, i any) (any, error) { //nolint:unparam // See note above.
+		if i.(bool) {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	},
+
+	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatNone}: func(t *v1beta1.ConvertTransform, i any) (any, error) { //nolint:unparam // See note above.
+		f := byte('f')
+		if t.GetFloatFormat() == v1beta1.ConvertFloatFormatScientific {
+			f = 'e'
+		}
+		return strconv.FormatFloat(i.(float64), f, ptr.Deref[int](t.Precision, -1), 64), nil
+	},
+	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeInt64, format: v1beta1.ConvertTransformFormatNone}: func(t *v1beta1.ConvertTransform, i any) (any, error) {
+		v := i.(float64)
+		// NaN, infinities, and values outside the range of an int64 have no
+		// int64 equivalent, so we can't convert them whatever the policy.
+		if math.IsNaN(v) || v < -(1<<63) || v >= 1<<63 {
+			return nil, errors.Errorf(errFmtConvertLossy, i, v1beta1.TransformIOTypeInt64)
+		}
+		if v != math.Trunc(v) && t.GetLossyPolicy() == v1beta1.ConvertLossyPolicyError {
+			return nil, errors.Errorf(errFmtConvertLossy, i, v1beta1.TransformIOTypeInt64)
+		}
+		return int64(v), nil
+	},
+	{from: v1beta1.TransformIOTypeFloat64, to: v1beta1.TransformIOTypeBool, format: v1beta1.ConvertTransformFormatNone}: func(t *v1beta1.ConvertTransform, i any) (any, error) {
+		v := i.(float64)
+		if v != 0 && v != 1 && t.GetLossyPolicy() == v1beta1.ConvertLossyPolicyError {
+			return nil, errors.Errorf(errFmtConvertLossy, i, v1beta1.TransformIOTypeBool)
+		}
+		return v == float64(1), nil
+	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeObject, format: v1beta1.ConvertTransformFormatJSON}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		o := map[string]any{}
+		return o, json.Unmarshal([]byte(i.(string)), &o)
+	},
+	{from: v1beta1.TransformIOTypeString, to: v1beta1.TransformIOTypeArray, format: v1beta1.ConvertTransformFormatJSON}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		var o []any
+		return o, json.Unmarshal([]byte(i.(string)), &o)
+	},
+	{from: v1beta1.TransformIOTypeObject, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatJSON}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		b, err := json.Marshal(i)
+		return string(b), errors.Wrap(err, errMarshalJSON)
+	},
+	{from: v1beta1.TransformIOTypeArray, to: v1beta1.TransformIOTypeString, format: v1beta1.ConvertTransformFormatJSON}: func(_ *v1beta1.ConvertTransform, i any) (any, error) {
+		b, err := json.Marshal(i)
+		return string(b), errors.Wrap(err, errMarshalJSON)
+	},
+}
+
+// Combine calls the appropriate combiner.
+func Combine(c v1beta1.Combine, vars []any) (any, error) {
+	var out any
+	var err error
+
+	switch c.Strategy {
+	case v1beta1.CombineStrategyString:
+		if c.String == nil {
+			return nil, errors.Errorf(errFmtCombineConfigMissing, c.Strategy)
+		}
+		out = CombineString(c.String.Format, vars)
+	default:
+		return nil, errors.Errorf(errFmtCombineStrategyNotSupported, c.Strategy)
+	}
+
+	// Note: There are currently no tests or triggers to exercise this error as
+	// our only strategy ("String") uses fmt.Sprintf, which cannot return an error.
+	return out, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
+}
+
+// CombineString returns a single output by running a string format with all of
+// its input variables.
+func CombineString(format string, vars []any) string {
+	return fmt.Sprintf(format, vars...)
+}
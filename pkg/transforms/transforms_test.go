@@ -0,0 +1,3140 @@
+package transforms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestMapResolve(t *testing.T) {
+	asJSON := func(val interface{}) extv1.JSON {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := extv1.JSON{}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	type args struct {
+		t          *v1beta1.MapTransform
+		i          any
+		ignoreCase bool
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"NonStringInput": {
+			args: args{
+				t: &v1beta1.MapTransform{},
+				i: 5,
+			},
+			want: want{
+				err: typeMismatchError(errFmtMapTypeNotSupported, "int"),
+			},
+		},
+		"KeyNotFound": {
+			args: args{
+				t: &v1beta1.MapTransform{},
+				i: "ola",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapNotFound, "ola"),
+			},
+		},
+		"SuccessString": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON("voila")}},
+				i: "ola",
+			},
+			want: want{
+				o: "voila",
+			},
+		},
+		"SuccessNumber": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON(1.0)}},
+				i: "ola",
+			},
+			want: want{
+				o: 1.0,
+			},
+		},
+		"SuccessBoolean": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON(true)}},
+				i: "ola",
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"SuccessObject": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON(map[string]interface{}{"foo": "bar"})}},
+				i: "ola",
+			},
+			want: want{
+				o: map[string]interface{}{"foo": "bar"},
+			},
+		},
+		"SuccessSlice": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON([]string{"foo", "bar"})}},
+				i: "ola",
+			},
+			want: want{
+				o: []interface{}{"foo", "bar"},
+			},
+		},
+		"CaseMismatch": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON("voila")}},
+				i: "OLA",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapNotFound, "OLA"),
+			},
+		},
+		"IgnoreCase": {
+			args: args{
+				t:          &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON("voila")}},
+				i:          "OLA",
+				ignoreCase: true,
+			},
+			want: want{
+				o: "voila",
+			},
+		},
+		"IgnoreCasePrefersExactMatch": {
+			args: args{
+				t:          &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"OLA": asJSON("shout"), "Ola": asJSON("voila")}},
+				i:          "Ola",
+				ignoreCase: true,
+			},
+			want: want{
+				o: "voila",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveMap(tc.t, tc.i, tc.ignoreCase)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInverseMapResolve(t *testing.T) {
+	asJSON := func(val interface{}) extv1.JSON {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return extv1.JSON{Raw: raw}
+	}
+
+	type args struct {
+		t          *v1beta1.MapTransform
+		i          any
+		ignoreCase bool
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"ValueNotFound": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": asJSON("t3.small")}},
+				i: "m5.large",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapValueNotFound, `"m5.large"`),
+			},
+		},
+		"SuccessString": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": asJSON("t3.small"), "large": asJSON("m5.large")}},
+				i: "m5.large",
+			},
+			want: want{
+				o: "large",
+			},
+		},
+		"SuccessNumber": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"one": asJSON(1), "two": asJSON(2)}},
+				i: int64(2),
+			},
+			want: want{
+				o: "two",
+			},
+		},
+		"SuccessObject": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"ola": asJSON(map[string]any{"foo": "bar"})}},
+				i: map[string]any{"foo": "bar"},
+			},
+			want: want{
+				o: "ola",
+			},
+		},
+		"CaseMismatch": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": asJSON("t3.small")}},
+				i: "T3.SMALL",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapValueNotFound, `"T3.SMALL"`),
+			},
+		},
+		"IgnoreCase": {
+			args: args{
+				t:          &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": asJSON("t3.small")}},
+				i:          "T3.SMALL",
+				ignoreCase: true,
+			},
+			want: want{
+				o: "small",
+			},
+		},
+		"Ambiguous": {
+			args: args{
+				t: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": asJSON("t3.small"), "tiny": asJSON("t3.small")}},
+				i: "t3.small",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapValueAmbiguous, `"t3.small"`, "small, tiny"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveInverseMap(tc.t, tc.i, tc.ignoreCase)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveEnvironmentMaps(t *testing.T) {
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"sizes": map[string]any{
+			"small": "t3.small",
+			"large": map[string]any{"class": "m5.large"},
+		},
+		"notAMap": "nope",
+	}}
+
+	type want struct {
+		ts  []v1beta1.Transform
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ts     []v1beta1.Transform
+		want   want
+	}{
+		"NoMapFromEnvironment": {
+			reason: "Transforms that don't read a map from the environment should be unchanged.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, Map: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"a": {Raw: []byte(`"b"`)}}}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMap, Map: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"a": {Raw: []byte(`"b"`)}}}},
+				},
+			},
+		},
+		"MapFromEnvironment": {
+			reason: "The pairs of a map transform should be read from the environment.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "sizes"}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{
+						Type:               v1beta1.TransformTypeMap,
+						MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "sizes"},
+						Map: &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{
+							"small": {Raw: []byte(`"t3.small"`)},
+							"large": {Raw: []byte(`{"class":"m5.large"}`)},
+						}},
+					},
+				},
+			},
+		},
+		"FieldPathNotFound": {
+			reason: "We should return an error if the map doesn't exist in the environment.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "regions"}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMap, MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "regions"}},
+				},
+				err: errors.Wrapf(errors.New("regions: no such field"), errFmtMapFromEnvironment, "regions"),
+			},
+		},
+		"NotAMap": {
+			reason: "We should return an error if the field path isn't an object.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "notAMap"}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMap, MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "notAMap"}},
+				},
+				err: errors.Errorf(errFmtMapFromEnvironmentNotObject, "notAMap", "nope"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ResolveEnvironmentMaps(env, tc.ts)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResolveEnvironmentMaps(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ts, tc.ts); diff != "" {
+				t.Errorf("\n%s\nResolveEnvironmentMaps(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMapLookupsForgetEnvironment(t *testing.T) {
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"sizes": map[string]any{"small": "t3.small"},
+	}}
+	ts := func() []v1beta1.Transform {
+		return []v1beta1.Transform{
+			{Type: v1beta1.TransformTypeMap, MapFromEnvironment: &v1beta1.MapFromEnvironmentTransform{FieldPath: "sizes"}},
+		}
+	}
+
+	l := NewMapLookups()
+	first := ts()
+	if err := l.ResolveEnvironmentMaps(env, first); err != nil {
+		t.Fatalf("ResolveEnvironmentMaps(...): %v", err)
+	}
+
+	// A later change to the environment shouldn't be read until the lookups
+	// forget the environment.
+	env.Object["sizes"] = map[string]any{"small": "t3.micro"}
+	second := ts()
+	if err := l.ResolveEnvironmentMaps(env, second); err != nil {
+		t.Fatalf("ResolveEnvironmentMaps(...): %v", err)
+	}
+	if diff := cmp.Diff(first[0].Map, second[0].Map); diff != "" {
+		t.Errorf("ResolveEnvironmentMaps(...): transforms that read the same field should share its pairs: -first, +second:\n%s", diff)
+	}
+
+	l.ForgetEnvironment()
+	third := ts()
+	if err := l.ResolveEnvironmentMaps(env, third); err != nil {
+		t.Fatalf("ResolveEnvironmentMaps(...): %v", err)
+	}
+	want := &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": {Raw: []byte(`"t3.micro"`)}}}
+	if diff := cmp.Diff(want, third[0].Map); diff != "" {
+		t.Errorf("ResolveEnvironmentMaps(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestResolveMatchFallbacks(t *testing.T) {
+	xr := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"instanceType": "m5.xlarge",
+		},
+	}}
+	env := &unstructured.Unstructured{Object: map[string]any{
+		"defaultInstanceType": "t3.medium",
+	}}
+	patterns := []v1beta1.MatchTransformPattern{{
+		Type:    v1beta1.MatchTransformPatternTypeLiteral,
+		Literal: ptr.To("small"),
+		Result:  extv1.JSON{Raw: []byte(`"t3.small"`)},
+	}}
+
+	type want struct {
+		ts  []v1beta1.Transform
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ts     []v1beta1.Transform
+		want   want
+	}{
+		"NoFallbackFrom": {
+			reason: "Match transforms that don't read their fallback value from a field should be unchanged.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{Patterns: patterns, FallbackTo: v1beta1.MatchFallbackToTypeInput}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{Patterns: patterns, FallbackTo: v1beta1.MatchFallbackToTypeInput}},
+				},
+			},
+		},
+		"FallbackFromComposite": {
+			reason: "The fallback value should be read from the composite resource, replacing fallbackTo.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{
+					Patterns:     patterns,
+					FallbackTo:   v1beta1.MatchFallbackToTypeInput,
+					FallbackFrom: &v1beta1.MatchFallbackFrom{FieldPath: "spec.instanceType"},
+				}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{
+						Patterns:      patterns,
+						FallbackTo:    v1beta1.MatchFallbackToTypeValue,
+						FallbackValue: extv1.JSON{Raw: []byte(`"m5.xlarge"`)},
+					}},
+				},
+			},
+		},
+		"FallbackFromEnvironment": {
+			reason: "The fallback value should be read from the environment.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{
+					Patterns:     patterns,
+					FallbackFrom: &v1beta1.MatchFallbackFrom{Source: v1beta1.MatchFallbackSourceEnvironment, FieldPath: "defaultInstanceType"},
+				}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{
+						Patterns:      patterns,
+						FallbackTo:    v1beta1.MatchFallbackToTypeValue,
+						FallbackValue: extv1.JSON{Raw: []byte(`"t3.medium"`)},
+					}},
+				},
+			},
+		},
+		"FieldPathNotFound": {
+			reason: "The transform should keep its literal fallback if the field doesn't exist.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{
+					Patterns:      patterns,
+					FallbackValue: extv1.JSON{Raw: []byte(`"t3.large"`)},
+					FallbackFrom:  &v1beta1.MatchFallbackFrom{FieldPath: "spec.size"},
+				}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMatch, Match: &v1beta1.MatchTransform{
+						Patterns:      patterns,
+						FallbackValue: extv1.JSON{Raw: []byte(`"t3.large"`)},
+					}},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ResolveMatchFallbacks(xr, env, tc.ts)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResolveMatchFallbacks(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ts, tc.ts); diff != "" {
+				t.Errorf("\n%s\nResolveMatchFallbacks(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestResolveDataMaps(t *testing.T) {
+	data := map[string]any{
+		"sizes": map[string]any{
+			"small": "t3.small",
+		},
+		"regions": map[string]any{
+			"aws": map[string]any{"us": "us-east-1"},
+		},
+	}
+
+	type want struct {
+		ts  []v1beta1.Transform
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		ts     []v1beta1.Transform
+		want   want
+	}{
+		"Document": {
+			reason: "The pairs of a map transform should be read from the named data document.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromData: &v1beta1.MapFromDataTransform{Name: "sizes"}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{
+						Type:        v1beta1.TransformTypeMap,
+						MapFromData: &v1beta1.MapFromDataTransform{Name: "sizes"},
+						Map:         &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"small": {Raw: []byte(`"t3.small"`)}}},
+					},
+				},
+			},
+		},
+		"FieldPath": {
+			reason: "The pairs of a map transform should be read from a field path within the named data document.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromData: &v1beta1.MapFromDataTransform{Name: "regions", FieldPath: ptr.To("aws")}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{
+						Type:        v1beta1.TransformTypeMap,
+						MapFromData: &v1beta1.MapFromDataTransform{Name: "regions", FieldPath: ptr.To("aws")},
+						Map:         &v1beta1.MapTransform{Pairs: map[string]extv1.JSON{"us": {Raw: []byte(`"us-east-1"`)}}},
+					},
+				},
+			},
+		},
+		"DocumentNotFound": {
+			reason: "We should return an error if the named data document doesn't exist.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromData: &v1beta1.MapFromDataTransform{Name: "tiers"}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMap, MapFromData: &v1beta1.MapFromDataTransform{Name: "tiers"}},
+				},
+				err: errors.Errorf(errFmtMapFromDataNotFound, "tiers"),
+			},
+		},
+		"NotAMap": {
+			reason: "We should return an error if the field path isn't an object.",
+			ts: []v1beta1.Transform{
+				{Type: v1beta1.TransformTypeMap, MapFromData: &v1beta1.MapFromDataTransform{Name: "sizes", FieldPath: ptr.To("small")}},
+			},
+			want: want{
+				ts: []v1beta1.Transform{
+					{Type: v1beta1.TransformTypeMap, MapFromData: &v1beta1.MapFromDataTransform{Name: "sizes", FieldPath: ptr.To("small")}},
+				},
+				err: errors.Errorf(errFmtMapFromDataNotObject, "sizes", "small", "t3.small"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ResolveDataMaps(data, tc.ts)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nResolveDataMaps(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ts, tc.ts); diff != "" {
+				t.Errorf("\n%s\nResolveDataMaps(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMatchResolve(t *testing.T) {
+	asJSON := func(val interface{}) extv1.JSON {
+		raw, err := json.Marshal(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := extv1.JSON{}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	type args struct {
+		t *v1beta1.MatchTransform
+		i any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"ErrNonStringInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("5"),
+						},
+					},
+				},
+				i: 5,
+			},
+			want: want{
+				err: errors.Wrapf(typeMismatchError(errFmtMatchInputTypeInvalid, "int"), errFmtMatchPattern, 0),
+			},
+		},
+		"ErrFallbackValueAndToInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns:      []v1beta1.MatchTransformPattern{},
+					FallbackValue: asJSON("foo"),
+					FallbackTo:    "Input",
+				},
+				i: "foo",
+			},
+			want: want{
+				err: errors.New(errMatchFallbackBoth),
+			},
+		},
+		"NoPatternsFallback": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns:      []v1beta1.MatchTransformPattern{},
+					FallbackValue: asJSON("bar"),
+				},
+				i: "foo",
+			},
+			want: want{
+				o: "bar",
+			},
+		},
+		"NoPatternsFallbackToValueExplicit": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns:      []v1beta1.MatchTransformPattern{},
+					FallbackValue: asJSON("bar"),
+					FallbackTo:    "Value", // Explicitly set to Value, unnecessary but valid.
+				},
+				i: "foo",
+			},
+			want: want{
+				o: "bar",
+			},
+		},
+		"NoPatternsFallbackNil": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns:      []v1beta1.MatchTransformPattern{},
+					FallbackValue: asJSON(nil),
+				},
+				i: "foo",
+			},
+			want: want{},
+		},
+		"NoPatternsFallbackToInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns:   []v1beta1.MatchTransformPattern{},
+					FallbackTo: "Input",
+				},
+				i: "foo",
+			},
+			want: want{
+				o: "foo",
+			},
+		},
+		"NoPatternsFallbackNilToInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns:      []v1beta1.MatchTransformPattern{},
+					FallbackValue: asJSON(nil),
+					FallbackTo:    "Input",
+				},
+				i: "foo",
+			},
+			want: want{
+				o: "foo",
+			},
+		},
+		"MatchLiteral": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result:  asJSON("bar"),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{
+				o: "bar",
+			},
+		},
+		"MatchLiteralFirst": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result:  asJSON("bar"),
+						},
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result:  asJSON("not this"),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{
+				o: "bar",
+			},
+		},
+		"MatchLiteralWithResultStruct": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result: asJSON(map[string]interface{}{
+								"Hello": "World",
+							}),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{
+				o: map[string]interface{}{
+					"Hello": "World",
+				},
+			},
+		},
+		"MatchLiteralWithResultSlice": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result: asJSON([]string{
+								"Hello", "World",
+							}),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{
+				o: []any{
+					"Hello", "World",
+				},
+			},
+		},
+		"MatchLiteralWithResultNumber": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result:  asJSON(5),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{
+				o: 5.0,
+			},
+		},
+		"MatchLiteralWithResultBool": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result:  asJSON(true),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"MatchLiteralWithResultNil": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:    v1beta1.MatchTransformPatternTypeLiteral,
+							Literal: ptr.To[string]("foo"),
+							Result:  asJSON(nil),
+						},
+					},
+				},
+				i: "foo",
+			},
+			want: want{},
+		},
+		"MatchRegexp": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp: ptr.To[string]("^foo.*$"),
+							Result: asJSON("Hello World"),
+						},
+					},
+				},
+				i: "foobar",
+			},
+			want: want{
+				o: "Hello World",
+			},
+		},
+		"ErrMissingRegexp": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type: v1beta1.MatchTransformPatternTypeRegexp,
+						},
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtRequiredField, "regexp", string(v1beta1.MatchTransformPatternTypeRegexp)), errFmtMatchPattern, 0),
+			},
+		},
+		"ErrInvalidRegexp": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRegexp,
+							Regexp: ptr.To[string]("?="),
+						},
+					},
+				},
+			},
+			want: want{
+				// This might break if Go's regexp changes its internal error
+				// messages:
+				err: errors.Wrapf(errors.Wrapf(errors.Wrap(errors.Wrap(errors.New("`?`"), "missing argument to repetition operator"), "error parsing regexp"), errMatchRegexpCompile), errFmtMatchPattern, 0),
+			},
+		},
+		"ErrMissingLiteral": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type: v1beta1.MatchTransformPatternTypeLiteral,
+						},
+					},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtRequiredField, "literal", string(v1beta1.MatchTransformPatternTypeLiteral)), errFmtMatchPattern, 0),
+			},
+		},
+		"MatchRange": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRange,
+							Range:  &v1beta1.MatchTransformRange{Lte: ptr.To[int64](16)},
+							Result: asJSON("small"),
+						},
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRange,
+							Range:  &v1beta1.MatchTransformRange{Gte: ptr.To[int64](17), Lte: ptr.To[int64](64)},
+							Result: asJSON("medium"),
+						},
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRange,
+							Range:  &v1beta1.MatchTransformRange{Gte: ptr.To[int64](65)},
+							Result: asJSON("large"),
+						},
+					},
+				},
+				i: int64(64),
+			},
+			want: want{
+				o: "medium",
+			},
+		},
+		"MatchRangeFloat": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeRange,
+							Range:  &v1beta1.MatchTransformRange{Gte: ptr.To[int64](1), Lte: ptr.To[int64](2)},
+							Result: asJSON("matched"),
+						},
+					},
+				},
+				i: 2.5,
+			},
+			want: want{},
+		},
+		"ErrRangeNonNumberInput": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:  v1beta1.MatchTransformPatternTypeRange,
+							Range: &v1beta1.MatchTransformRange{Gte: ptr.To[int64](1)},
+						},
+					},
+				},
+				i: "5",
+			},
+			want: want{
+				err: errors.Wrapf(typeMismatchError(errFmtMatchInputTypeInvalid, "string"), errFmtMatchPattern, 0),
+			},
+		},
+		"MatchCIDRAddress": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeCIDR,
+							CIDR:   ptr.To[string]("10.0.0.0/8"),
+							Result: asJSON("private"),
+						},
+					},
+				},
+				i: "10.1.2.3",
+			},
+			want: want{
+				o: "private",
+			},
+		},
+		"MatchCIDRSubnet": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeCIDR,
+							CIDR:   ptr.To[string]("10.0.0.0/8"),
+							Result: asJSON("private"),
+						},
+					},
+				},
+				i: "10.1.0.0/16",
+			},
+			want: want{
+				o: "private",
+			},
+		},
+		"NoMatchCIDRSupernet": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeCIDR,
+							CIDR:   ptr.To[string]("10.1.0.0/16"),
+							Result: asJSON("private"),
+						},
+					},
+				},
+				i: "10.0.0.0/8",
+			},
+			want: want{},
+		},
+		"ErrCIDRInputNotIP": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type: v1beta1.MatchTransformPatternTypeCIDR,
+							CIDR: ptr.To[string]("10.0.0.0/8"),
+						},
+					},
+				},
+				i: "example.org",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtMatchInputNotIP, "example.org"), errFmtMatchPattern, 0),
+			},
+		},
+		"MatchSemver": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeSemver,
+							Semver: ptr.To[string](">=16"),
+							Result: asJSON("postgres16"),
+						},
+						{
+							Type:   v1beta1.MatchTransformPatternTypeSemver,
+							Semver: ptr.To[string](">= 14, < 16"),
+							Result: asJSON("postgres14"),
+						},
+					},
+				},
+				i: "15.4",
+			},
+			want: want{
+				o: "postgres14",
+			},
+		},
+		"NoMatchSemver": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeSemver,
+							Semver: ptr.To[string](">=14 <16"),
+							Result: asJSON("postgres14"),
+						},
+					},
+				},
+				i: "16.1",
+			},
+			want: want{},
+		},
+		"ErrSemverInputNotVersion": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type:   v1beta1.MatchTransformPatternTypeSemver,
+							Semver: ptr.To[string](">=14"),
+						},
+					},
+				},
+				i: "latest",
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtMatchInputNotVersion, "latest"), errFmtMatchPattern, 0),
+			},
+		},
+		"ErrMissingRange": {
+			args: args{
+				t: &v1beta1.MatchTransform{
+					Patterns: []v1beta1.MatchTransformPattern{
+						{
+							Type: v1beta1.MatchTransformPatternTypeRange,
+						},
+					},
+				},
+				i: 5,
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtRequiredField, "range", string(v1beta1.MatchTransformPatternTypeRange)), errFmtMatchPattern, 0),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveMatch(tc.args.t, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMathResolve(t *testing.T) {
+	two := int64(2)
+
+	type args struct {
+		mathType      v1beta1.MathTransformType
+		multiplier    *int64
+		multiplyFloat *string
+		clampMin      *int64
+		clampMax      *int64
+		precision     *int
+		rounding      *v1beta1.MathRounding
+		i             any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"InvalidType": {
+			args: args{
+				mathType: "bad",
+				i:        25,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
+				},
+			},
+		},
+		"NonNumberInput": {
+			args: args{
+				mathType:   v1beta1.MathTransformTypeMultiply,
+				multiplier: &two,
+				i:          "ola",
+			},
+			want: want{
+				err: typeMismatchError(errFmtMathInputNonNumber, "ola"),
+			},
+		},
+		"MultiplyNoConfig": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeMultiply,
+				i:        25,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "multiply",
+				},
+			},
+		},
+		"MultiplySuccess": {
+			args: args{
+				mathType:   v1beta1.MathTransformTypeMultiply,
+				multiplier: &two,
+				i:          3,
+			},
+			want: want{
+				o: 3 * two,
+			},
+		},
+		"MultiplySuccessInt64": {
+			args: args{
+				mathType:   v1beta1.MathTransformTypeMultiply,
+				multiplier: &two,
+				i:          int64(3),
+			},
+			want: want{
+				o: 3 * two,
+			},
+		},
+		"ClampMinSuccess": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMin,
+				clampMin: &two,
+				i:        1,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMinSuccessNoChangeInt": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMin,
+				clampMin: &two,
+				i:        3,
+			},
+			want: want{
+				o: 3,
+			},
+		},
+		"ClampMinSuccessNoChangeInt64": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMin,
+				clampMin: &two,
+				i:        int64(3),
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"ClampMinSuccessInt64": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMin,
+				clampMin: &two,
+				i:        int64(1),
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMinNoConfig": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMin,
+				i:        25,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "clampMin",
+				},
+			},
+		},
+		"ClampMaxSuccess": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMax,
+				clampMax: &two,
+				i:        3,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMaxSuccessNoChange": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMax,
+				clampMax: &two,
+				i:        int64(1),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"ClampMaxSuccessInt64": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMax,
+				clampMax: &two,
+				i:        int64(3),
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMaxNoConfig": {
+			args: args{
+				mathType: v1beta1.MathTransformTypeClampMax,
+				i:        25,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "clampMax",
+				},
+			},
+		},
+		"MultiplyFloatInt": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("0.5"),
+				i:             int64(3),
+			},
+			want: want{
+				o: 1.5,
+			},
+		},
+		"MultiplyFloatFloat": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("0.15"),
+				i:             float64(200),
+			},
+			want: want{
+				o: float64(30),
+			},
+		},
+		"MultiplyFloatInvalid": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("lots"),
+				i:             int64(3),
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "multiplyFloat",
+				},
+			},
+		},
+		"MultiplyAndMultiplyFloat": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplier:    &two,
+				multiplyFloat: ptr.To("0.5"),
+				i:             int64(3),
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeForbidden,
+					Field: "multiplyFloat",
+				},
+			},
+		},
+		"PrecisionRound": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("0.333"),
+				precision:     ptr.To(1),
+				i:             int64(5),
+			},
+			want: want{
+				o: 1.7,
+			},
+		},
+		"PrecisionFloor": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("0.333"),
+				precision:     ptr.To(1),
+				rounding:      ptr.To(v1beta1.MathRoundingFloor),
+				i:             int64(5),
+			},
+			want: want{
+				o: 1.6,
+			},
+		},
+		"PrecisionCeil": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("0.25"),
+				precision:     ptr.To(0),
+				rounding:      ptr.To(v1beta1.MathRoundingCeil),
+				i:             int64(5),
+			},
+			want: want{
+				o: float64(2),
+			},
+		},
+		"PrecisionTruncate": {
+			args: args{
+				mathType:      v1beta1.MathTransformTypeMultiply,
+				multiplyFloat: ptr.To("-0.333"),
+				precision:     ptr.To(1),
+				rounding:      ptr.To(v1beta1.MathRoundingTruncate),
+				i:             int64(5),
+			},
+			want: want{
+				o: -1.6,
+			},
+		},
+		"PrecisionIgnoresInt": {
+			args: args{
+				mathType:   v1beta1.MathTransformTypeMultiply,
+				multiplier: &two,
+				precision:  ptr.To(2),
+				i:          int64(5),
+			},
+			want: want{
+				o: int64(10),
+			},
+		},
+		"InvalidRounding": {
+			args: args{
+				mathType:   v1beta1.MathTransformTypeMultiply,
+				multiplier: &two,
+				rounding:   ptr.To(v1beta1.MathRounding("Sideways")),
+				i:          int64(5),
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "rounding",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr := &v1beta1.MathTransform{
+				Type:          tc.mathType,
+				Multiply:      tc.multiplier,
+				MultiplyFloat: tc.multiplyFloat,
+				ClampMin:      tc.clampMin,
+				ClampMax:      tc.clampMax,
+				Precision:     tc.precision,
+				Rounding:      tc.rounding,
+			}
+			got, err := ResolveMath(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			fieldErr := &field.Error{}
+			if err != nil && errors.As(err, &fieldErr) {
+				fieldErr.Detail = ""
+				fieldErr.BadValue = nil
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStringResolve(t *testing.T) {
+
+	type args struct {
+		stype     v1beta1.StringTransformType
+		fmts      *string
+		convert   *v1beta1.StringConversionType
+		trim      *string
+		ensure    *string
+		regexp    *v1beta1.StringTransformRegexp
+		pad       *v1beta1.StringTransformPad
+		substring *v1beta1.StringTransformSubstring
+		truncate  *v1beta1.StringTransformTruncate
+		join      *v1beta1.StringTransformJoin
+		i         any
+	}
+	type want struct {
+		o   string
+		err error
+	}
+	sFmt := "verycool%s"
+	iFmt := "the largest %d"
+
+	upper := v1beta1.StringConversionTypeToUpper
+	lower := v1beta1.StringConversionTypeToLower
+	tobase64 := v1beta1.StringConversionTypeToBase64
+	frombase64 := v1beta1.StringConversionTypeFromBase64
+	toJSON := v1beta1.StringConversionTypeToJSON
+	wrongConvertType := v1beta1.StringConversionType("Something")
+	toSha1 := v1beta1.StringConversionTypeToSHA1
+	toSha256 := v1beta1.StringConversionTypeToSHA256
+	toSha512 := v1beta1.StringConversionTypeToSHA512
+	toAdler32 := v1beta1.StringConversionTypeToAdler32
+	collapseWhitespace := v1beta1.StringConversionTypeCollapseWhitespace
+	stripControl := v1beta1.StringConversionTypeStripControl
+	capitalizeWords := v1beta1.StringConversionTypeCapitalizeWords
+
+	prefix := "https://"
+	suffix := "-test"
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"NotSupportedType": {
+			args: args{
+				stype: "Something",
+				i:     "value",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeFailed, "Something"),
+			},
+		},
+		"FmtFailed": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				i:     "value",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeFormat, string(v1beta1.StringTransformTypeFormat)),
+			},
+		},
+		"FmtString": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  &sFmt,
+				i:     "thing",
+			},
+			want: want{
+				o: "verycoolthing",
+			},
+		},
+		"FmtInteger": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  &iFmt,
+				i:     8,
+			},
+			want: want{
+				o: "the largest 8",
+			},
+		},
+		"FmtIntegerFromFloat": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  ptr.To("%04d"),
+				i:     float64(42),
+			},
+			want: want{
+				o: "0042",
+			},
+		},
+		"FmtIntegerMismatch": {
+			args: args{
+				stype: v1beta1.StringTransformTypeFormat,
+				fmts:  &iFmt,
+				i:     "eight",
+			},
+			want: want{
+				err: typeMismatchError(errFmtFormatVerbMismatch, 'd', v1beta1.TransformIOTypeString),
+			},
+		},
+		"ConvertNotSet": {
+			args: args{
+				stype: v1beta1.StringTransformTypeConvert,
+				i:     "crossplane",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeConvert, string(v1beta1.StringTransformTypeConvert)),
+			},
+		},
+		"ConvertTypFailed": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &wrongConvertType,
+				i:       "crossplane",
+			},
+			want: want{
+				err: errors.Errorf(errStringConvertTypeFailed, wrongConvertType),
+			},
+		},
+		"ConvertToUpper": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &upper,
+				i:       "crossplane",
+			},
+			want: want{
+				o: "CROSSPLANE",
+			},
+		},
+		"ConvertToLower": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &lower,
+				i:       "CrossPlane",
+			},
+			want: want{
+				o: "crossplane",
+			},
+		},
+		"ConvertCollapseWhitespace": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &collapseWhitespace,
+				i:       "  my   very\tcool \n\u00a0 database ",
+			},
+			want: want{
+				o: "my very cool database",
+			},
+		},
+		"ConvertStripControl": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &stripControl,
+				i:       "my\x00 cool\x1b\tdatabase\r\n",
+			},
+			want: want{
+				o: "my cool database  ",
+			},
+		},
+		"ConvertCapitalizeWords": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &capitalizeWords,
+				i:       "the team's eu-west-1 database, ǆungla and élan",
+			},
+			want: want{
+				o: "The Team's Eu-West-1 Database, ǅungla And Élan",
+			},
+		},
+		"ConvertToBase64": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &tobase64,
+				i:       "CrossPlane",
+			},
+			want: want{
+				o: "Q3Jvc3NQbGFuZQ==",
+			},
+		},
+		"ConvertFromBase64": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &frombase64,
+				i:       "Q3Jvc3NQbGFuZQ==",
+			},
+			want: want{
+				o: "CrossPlane",
+			},
+		},
+		"ConvertFromBase64Error": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &frombase64,
+				i:       "ThisStringIsNotBase64",
+			},
+			want: want{
+				o:   "N\x18\xacJ\xda\xe2\x9e\x02,6\x8bAjǺ",
+				err: errors.Wrap(errors.New("illegal base64 data at input byte 20"), errDecodeString),
+			},
+		},
+		"ConvertToSha1": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha1,
+				i:       "Crossplane",
+			},
+			want: want{
+				o: "3b683dc8ff44122b331a5e4f253dd69d90726d75",
+			},
+		},
+		"ConvertToSha1Error": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha1,
+				i:       func() {},
+			},
+			want: want{
+				o:   "0000000000000000000000000000000000000000",
+				err: errors.Wrap(errors.Wrap(errors.New("json: unsupported type: func()"), errMarshalJSON), errHash),
+			},
+		},
+		"ConvertToSha256": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha256,
+				i:       "Crossplane",
+			},
+			want: want{
+				o: "19c8a7c24ed0067f606815b59e5b82d92935ff69deed04171457a55018e31224",
+			},
+		},
+		"ConvertToSha256Error": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha256,
+				i:       func() {},
+			},
+			want: want{
+				o:   "0000000000000000000000000000000000000000000000000000000000000000",
+				err: errors.Wrap(errors.Wrap(errors.New("json: unsupported type: func()"), errMarshalJSON), errHash),
+			},
+		},
+		"ConvertToSha512": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha512,
+				i:       "Crossplane",
+			},
+			want: want{
+				o: "0016037c62c92b5cc4a282fbe30cdd228fa001624b26fd31baa9fcb76a9c60d48e2e7a16cf8729a2d9cba3d23e1d846e7721a5381b9a92dd813178e9a6686205",
+			},
+		},
+		"ConvertToSha512Int": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha512,
+				i:       1234,
+			},
+			want: want{
+				o: "d404559f602eab6fd602ac7680dacbfaadd13630335e951f097af3900e9de176b6db28512f2e000b9d04fba5133e8b1c6e8df59db3a8ab9d60be4b97cc9e81db",
+			},
+		},
+		"ConvertToSha512IntStr": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha512,
+				i:       "1234",
+			},
+			want: want{
+				o: "d404559f602eab6fd602ac7680dacbfaadd13630335e951f097af3900e9de176b6db28512f2e000b9d04fba5133e8b1c6e8df59db3a8ab9d60be4b97cc9e81db",
+			},
+		},
+		"ConvertToSha512Error": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toSha512,
+				i:       func() {},
+			},
+			want: want{
+				o:   "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+				err: errors.Wrap(errors.Wrap(errors.New("json: unsupported type: func()"), errMarshalJSON), errHash),
+			},
+		},
+		"ConvertToAdler32": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toAdler32,
+				i:       "Crossplane",
+			},
+			want: want{
+				o: "373097499",
+			},
+		},
+		"ConvertToAdler32Unicode": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toAdler32,
+				i:       "⡌⠁⠧⠑ ⠼⠁⠒  ⡍⠜⠇⠑⠹⠰⠎ ⡣⠕⠌",
+			},
+			want: want{
+				o: "4110427190",
+			},
+		},
+		"ConvertToAdler32Error": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toAdler32,
+				i:       func() {},
+			},
+			want: want{
+				o:   "0",
+				err: errors.Wrap(errors.Wrap(errors.New("json: unsupported type: func()"), errMarshalJSON), errAdler),
+			},
+		},
+		"TrimPrefix": {
+			args: args{
+				stype: v1beta1.StringTransformTypeTrimPrefix,
+				trim:  &prefix,
+				i:     "https://crossplane.io",
+			},
+			want: want{
+				o: "crossplane.io",
+			},
+		},
+		"TrimSuffix": {
+			args: args{
+				stype: v1beta1.StringTransformTypeTrimSuffix,
+				trim:  &suffix,
+				i:     "my-string-test",
+			},
+			want: want{
+				o: "my-string",
+			},
+		},
+		"TrimPrefixWithoutMatch": {
+			args: args{
+				stype: v1beta1.StringTransformTypeTrimPrefix,
+				trim:  &prefix,
+				i:     "crossplane.io",
+			},
+			want: want{
+				o: "crossplane.io",
+			},
+		},
+		"TrimSuffixWithoutMatch": {
+			args: args{
+				stype: v1beta1.StringTransformTypeTrimSuffix,
+				trim:  &suffix,
+				i:     "my-string",
+			},
+			want: want{
+				o: "my-string",
+			},
+		},
+		"EnsurePrefix": {
+			args: args{
+				stype:  v1beta1.StringTransformTypeEnsurePrefix,
+				ensure: &prefix,
+				i:      "crossplane.io",
+			},
+			want: want{
+				o: "https://crossplane.io",
+			},
+		},
+		"EnsurePrefixWithMatch": {
+			args: args{
+				stype:  v1beta1.StringTransformTypeEnsurePrefix,
+				ensure: &prefix,
+				i:      "https://crossplane.io",
+			},
+			want: want{
+				o: "https://crossplane.io",
+			},
+		},
+		"EnsureSuffix": {
+			args: args{
+				stype:  v1beta1.StringTransformTypeEnsureSuffix,
+				ensure: &suffix,
+				i:      "my-string",
+			},
+			want: want{
+				o: "my-string-test",
+			},
+		},
+		"EnsureSuffixWithMatch": {
+			args: args{
+				stype:  v1beta1.StringTransformTypeEnsureSuffix,
+				ensure: &suffix,
+				i:      "my-string-test",
+			},
+			want: want{
+				o: "my-string-test",
+			},
+		},
+		"EnsureSuffixMissing": {
+			args: args{
+				stype: v1beta1.StringTransformTypeEnsureSuffix,
+				i:     "my-string",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeEnsure, v1beta1.StringTransformTypeEnsureSuffix),
+			},
+		},
+		"PadLeft": {
+			args: args{
+				stype: v1beta1.StringTransformTypePadLeft,
+				pad:   &v1beta1.StringTransformPad{Width: 5, Fill: ptr.To[string]("0")},
+				i:     42,
+			},
+			want: want{
+				o: "00042",
+			},
+		},
+		"PadRight": {
+			args: args{
+				stype: v1beta1.StringTransformTypePadRight,
+				pad:   &v1beta1.StringTransformPad{Width: 5},
+				i:     "ab",
+			},
+			want: want{
+				o: "ab   ",
+			},
+		},
+		"PadAlreadyWide": {
+			args: args{
+				stype: v1beta1.StringTransformTypePadLeft,
+				pad:   &v1beta1.StringTransformPad{Width: 2, Fill: ptr.To[string]("0")},
+				i:     "12345",
+			},
+			want: want{
+				o: "12345",
+			},
+		},
+		"PadInvalidFill": {
+			args: args{
+				stype: v1beta1.StringTransformTypePadLeft,
+				pad:   &v1beta1.StringTransformPad{Width: 5, Fill: ptr.To[string]("ab")},
+				i:     "12",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypePadFill, "ab"),
+			},
+		},
+		"Substring": {
+			args: args{
+				stype:     v1beta1.StringTransformTypeSubstring,
+				substring: &v1beta1.StringTransformSubstring{Start: 3, End: ptr.To[int](7)},
+				i:         "eu-west-1",
+			},
+			want: want{
+				o: "west",
+			},
+		},
+		"SubstringToEnd": {
+			args: args{
+				stype:     v1beta1.StringTransformTypeSubstring,
+				substring: &v1beta1.StringTransformSubstring{Start: 8, End: ptr.To[int](42)},
+				i:         "eu-west-1",
+			},
+			want: want{
+				o: "1",
+			},
+		},
+		"SubstringPastEnd": {
+			args: args{
+				stype:     v1beta1.StringTransformTypeSubstring,
+				substring: &v1beta1.StringTransformSubstring{Start: 42},
+				i:         "eu-west-1",
+			},
+			want: want{
+				o: "",
+			},
+		},
+		"SubstringInvalidRange": {
+			args: args{
+				stype:     v1beta1.StringTransformTypeSubstring,
+				substring: &v1beta1.StringTransformSubstring{Start: 3, End: ptr.To[int](1)},
+				i:         "eu-west-1",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeSubstringRange, 3, 1),
+			},
+		},
+		"TruncateRunes": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 4},
+				i:        "日本語のテキスト",
+			},
+			want: want{
+				o: "日本語の",
+			},
+		},
+		"TruncateShortInput": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 12, Suffix: ptr.To("...")},
+				i:        "eu-west-1",
+			},
+			want: want{
+				o: "eu-west-1",
+			},
+		},
+		"TruncateWithSuffix": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 6, Suffix: ptr.To("…")},
+				i:        "café-au-lait",
+			},
+			want: want{
+				o: "café-…",
+			},
+		},
+		"TruncateWithHash": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 16, Suffix: ptr.To("-"), HashLength: ptr.To(5)},
+				i:        "my-very-long-database-name",
+			},
+			want: want{
+				o: "my-very-lo-7cb8b",
+			},
+		},
+		"TruncateBytesDoesNotSplitRune": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 4, Unit: ptr.To(v1beta1.StringLengthUnitBytes)},
+				i:        "café",
+			},
+			want: want{
+				o: "caf",
+			},
+		},
+		"TruncateGraphemes": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 2, Unit: ptr.To(v1beta1.StringLengthUnitGraphemes)},
+				i:        "👍🏽e\u0301🇬🇧x",
+			},
+			want: want{
+				o: "👍🏽e\u0301",
+			},
+		},
+		"TruncateSuffixTooLong": {
+			args: args{
+				stype:    v1beta1.StringTransformTypeTruncate,
+				truncate: &v1beta1.StringTransformTruncate{Length: 2, Suffix: ptr.To("...")},
+				i:        "eu-west-1",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeTruncateSuffix, "...", 2),
+			},
+		},
+		"TruncateNotSet": {
+			args: args{
+				stype: v1beta1.StringTransformTypeTruncate,
+				i:     "eu-west-1",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeTruncate, v1beta1.StringTransformTypeTruncate),
+			},
+		},
+		"Join": {
+			args: args{
+				stype: v1beta1.StringTransformTypeJoin,
+				join:  &v1beta1.StringTransformJoin{Separator: ","},
+				i:     []any{"a", int64(2), true},
+			},
+			want: want{
+				o: "a,2,true",
+			},
+		},
+		"JoinSingleQuoted": {
+			args: args{
+				stype: v1beta1.StringTransformTypeJoin,
+				join:  &v1beta1.StringTransformJoin{Separator: ",", Quote: ptr.To(v1beta1.StringJoinQuoteSingle)},
+				i:     []any{"a", "b", "o'brien"},
+			},
+			want: want{
+				o: "'a','b','o''brien'",
+			},
+		},
+		"JoinFormattedDoubleQuoted": {
+			args: args{
+				stype: v1beta1.StringTransformTypeJoin,
+				join: &v1beta1.StringTransformJoin{
+					Separator: ", ",
+					Format:    ptr.To("arn:aws:s3:::%s/*"),
+					Quote:     ptr.To(v1beta1.StringJoinQuoteDouble),
+				},
+				i: []any{"bucket-a", "bucket-\"b\"&c"},
+			},
+			want: want{
+				o: `"arn:aws:s3:::bucket-a/*", "arn:aws:s3:::bucket-\"b\"&c/*"`,
+			},
+		},
+		"JoinEmpty": {
+			args: args{
+				stype: v1beta1.StringTransformTypeJoin,
+				join:  &v1beta1.StringTransformJoin{Separator: ","},
+				i:     []any{},
+			},
+			want: want{
+				o: "",
+			},
+		},
+		"JoinNotArray": {
+			args: args{
+				stype: v1beta1.StringTransformTypeJoin,
+				join:  &v1beta1.StringTransformJoin{Separator: ","},
+				i:     "a,b,c",
+			},
+			want: want{
+				err: typeMismatchError(errStringTransformTypeJoinInput, "string"),
+			},
+		},
+		"JoinNotSet": {
+			args: args{
+				stype: v1beta1.StringTransformTypeJoin,
+				i:     []any{"a"},
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeJoin, v1beta1.StringTransformTypeJoin),
+			},
+		},
+		"RegexpNotCompiling": {
+			args: args{
+				stype: v1beta1.StringTransformTypeRegexp,
+				regexp: &v1beta1.StringTransformRegexp{
+					Match: "[a-z",
+				},
+				i: "my-string",
+			},
+			want: want{
+				err: errors.Wrap(errors.New("error parsing regexp: missing closing ]: `[a-z`"), errStringTransformTypeRegexpFailed),
+			},
+		},
+		"RegexpSimpleMatch": {
+			args: args{
+				stype: v1beta1.StringTransformTypeRegexp,
+				regexp: &v1beta1.StringTransformRegexp{
+					Match: "[0-9]",
+				},
+				i: "my-1-string",
+			},
+			want: want{
+				o: "1",
+			},
+		},
+		"RegexpCaptureGroup": {
+			args: args{
+				stype: v1beta1.StringTransformTypeRegexp,
+				regexp: &v1beta1.StringTransformRegexp{
+					Match: "my-([0-9]+)-string",
+					Group: ptr.To[int](1),
+				},
+				i: "my-1-string",
+			},
+			want: want{
+				o: "1",
+			},
+		},
+		"RegexpNoSuchCaptureGroup": {
+			args: args{
+				stype: v1beta1.StringTransformTypeRegexp,
+				regexp: &v1beta1.StringTransformRegexp{
+					Match: "my-([0-9]+)-string",
+					Group: ptr.To[int](2),
+				},
+				i: "my-1-string",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeRegexpNoMatch, "my-([0-9]+)-string", 2),
+			},
+		},
+		"RegexpNegativeCaptureGroup": {
+			args: args{
+				stype: v1beta1.StringTransformTypeRegexp,
+				regexp: &v1beta1.StringTransformRegexp{
+					Match: "my-([0-9]+)-string",
+					Group: ptr.To[int](-1),
+				},
+				i: "my-1-string",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeRegexpNoMatch, "my-([0-9]+)-string", -1),
+			},
+		},
+		"ConvertToJSONSuccess": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toJSON,
+				i: map[string]any{
+					"foo": "bar",
+				},
+			},
+			want: want{
+				o: "{\"foo\":\"bar\"}",
+			},
+		},
+		"ConvertToJSONFail": {
+			args: args{
+				stype:   v1beta1.StringTransformTypeConvert,
+				convert: &toJSON,
+				i: map[string]any{
+					"foo": func() {},
+				},
+			},
+			want: want{
+				o:   "",
+				err: errors.Wrap(errors.New("json: unsupported type: func()"), errMarshalJSON),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			tr := &v1beta1.StringTransform{Type: tc.stype,
+				Format:    tc.fmts,
+				Convert:   tc.convert,
+				Trim:      tc.trim,
+				Ensure:    tc.ensure,
+				Regexp:    tc.regexp,
+				Pad:       tc.pad,
+				Substring: tc.substring,
+				Truncate:  tc.truncate,
+				Join:      tc.join,
+			}
+
+			got, err := ResolveString(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStringLengthResolve(t *testing.T) {
+	type args struct {
+		t *v1beta1.StringTransform
+		i any
+	}
+	type want struct {
+		o   int64
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"DefaultRunes": {
+			reason: "Length should count runes by default.",
+			args: args{
+				t: &v1beta1.StringTransform{Type: v1beta1.StringTransformTypeLength},
+				i: "café",
+			},
+			want: want{
+				o: 4,
+			},
+		},
+		"Bytes": {
+			reason: "Length should count bytes of UTF-8 when asked to.",
+			args: args{
+				t: &v1beta1.StringTransform{
+					Type:   v1beta1.StringTransformTypeLength,
+					Length: &v1beta1.StringTransformLength{Unit: ptr.To(v1beta1.StringLengthUnitBytes)},
+				},
+				i: "café",
+			},
+			want: want{
+				o: 5,
+			},
+		},
+		"Graphemes": {
+			reason: "Length should count combining sequences, emoji modifier sequences, ZWJ sequences, and flags as one grapheme each.",
+			args: args{
+				t: &v1beta1.StringTransform{
+					Type:   v1beta1.StringTransformTypeLength,
+					Length: &v1beta1.StringTransformLength{Unit: ptr.To(v1beta1.StringLengthUnitGraphemes)},
+				},
+				i: "e\u0301👍🏽👩\u200D💻🇬🇧\r\nx",
+			},
+			want: want{
+				o: 6,
+			},
+		},
+		"NonStringInput": {
+			reason: "Length should measure the string representation of non-string inputs.",
+			args: args{
+				t: &v1beta1.StringTransform{Type: v1beta1.StringTransformTypeLength},
+				i: int64(12345),
+			},
+			want: want{
+				o: 5,
+			},
+		},
+		"UnknownUnit": {
+			reason: "Length should return an error for an unknown unit.",
+			args: args{
+				t: &v1beta1.StringTransform{
+					Type:   v1beta1.StringTransformTypeLength,
+					Length: &v1beta1.StringTransformLength{Unit: ptr.To(v1beta1.StringLengthUnit("Words"))},
+				},
+				i: "café",
+			},
+			want: want{
+				err: errors.Errorf(errStringTransformTypeLengthUnit, "Words"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveStringLength(tc.args.t, tc.args.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("%s\nResolveStringLength(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nResolveStringLength(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestBoolResolve(t *testing.T) {
+	type args struct {
+		btype v1beta1.BoolTransformType
+		value *string
+		i     any
+	}
+	type want struct {
+		o   bool
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"Contains": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeContains,
+				value: ptr.To[string]("west"),
+				i:     "eu-west-1",
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"HasPrefix": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeHasPrefix,
+				value: ptr.To[string]("us-"),
+				i:     "eu-west-1",
+			},
+			want: want{
+				o: false,
+			},
+		},
+		"HasSuffix": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeHasSuffix,
+				value: ptr.To[string]("-1"),
+				i:     "eu-west-1",
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"Matches": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeMatches,
+				value: ptr.To[string]("^[a-z]{2}-[a-z]+-[0-9]$"),
+				i:     "eu-west-1",
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"MatchesNotCompiling": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeMatches,
+				value: ptr.To[string]("[a-z"),
+				i:     "eu-west-1",
+			},
+			want: want{
+				err: errors.Wrap(errors.New("error parsing regexp: missing closing ]: `[a-z`"), errBoolTransformTypeMatchesFailed),
+			},
+		},
+		"MissingValue": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeContains,
+				i:     "eu-west-1",
+			},
+			want: want{
+				err: errors.Errorf(errBoolTransformTypeValue, v1beta1.BoolTransformTypeContains),
+			},
+		},
+		"Not": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeNot,
+				i:     false,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"NotNonBoolean": {
+			args: args{
+				btype: v1beta1.BoolTransformTypeNot,
+				i:     "false",
+			},
+			want: want{
+				err: typeMismatchError(errFmtBoolInputNotBool, "false"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr := &v1beta1.BoolTransform{Type: tc.btype, Value: tc.value}
+			got, err := ResolveBool(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseResolve(t *testing.T) {
+	type args struct {
+		ptype       v1beta1.ParseTransformType
+		base        *int
+		bitSize     *int
+		errorPolicy *v1beta1.ParseErrorPolicy
+		i           any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"Int": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeInt,
+				i:     "42",
+			},
+			want: want{
+				o: int64(42),
+			},
+		},
+		"IntHex": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeInt,
+				base:  ptr.To[int](16),
+				i:     "ff",
+			},
+			want: want{
+				o: int64(255),
+			},
+		},
+		"IntInferredBase": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeInt,
+				base:  ptr.To[int](0),
+				i:     "0o755",
+			},
+			want: want{
+				o: int64(493),
+			},
+		},
+		"IntOutOfRange": {
+			args: args{
+				ptype:   v1beta1.ParseTransformTypeInt,
+				bitSize: ptr.To[int](8),
+				i:       "300",
+			},
+			want: want{
+				err: errors.Wrapf(&strconv.NumError{Func: "ParseInt", Num: "300", Err: strconv.ErrRange}, errFmtParseFailed, "300"),
+			},
+		},
+		"IntInvalidZero": {
+			args: args{
+				ptype:       v1beta1.ParseTransformTypeInt,
+				errorPolicy: ptr.To[v1beta1.ParseErrorPolicy](v1beta1.ParseErrorPolicyZero),
+				i:           "nope",
+			},
+			want: want{
+				o: int64(0),
+			},
+		},
+		"Float": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeFloat,
+				i:     "1.5",
+			},
+			want: want{
+				o: float64(1.5),
+			},
+		},
+		"FloatInvalid": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeFloat,
+				i:     "nope",
+			},
+			want: want{
+				err: errors.Wrapf(&strconv.NumError{Func: "ParseFloat", Num: "nope", Err: strconv.ErrSyntax}, errFmtParseFailed, "nope"),
+			},
+		},
+		"FloatWithBase": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeFloat,
+				base:  ptr.To[int](16),
+				i:     "1.5",
+			},
+			want: want{
+				err: &field.Error{
+					Type:     field.ErrorTypeInvalid,
+					Field:    "base",
+					BadValue: 16,
+					Detail:   "base is only supported by parse transforms of type Int",
+				},
+			},
+		},
+		"InputNotString": {
+			args: args{
+				ptype: v1beta1.ParseTransformTypeInt,
+				i:     42,
+			},
+			want: want{
+				err: typeMismatchError(errFmtParseInputNotString, 42),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr := &v1beta1.ParseTransform{Type: tc.ptype, Base: tc.base, BitSize: tc.bitSize, ErrorPolicy: tc.errorPolicy}
+			got, err := ResolveParse(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChecksumResolve(t *testing.T) {
+	type args struct {
+		algorithm *v1beta1.ChecksumAlgorithm
+		i         any
+	}
+	type want struct {
+		o   string
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"Object": {
+			args: args{
+				i: map[string]any{"b": "two", "a": map[string]any{"d": "four", "c": "three"}},
+			},
+			want: want{
+				// sha256sum of {"a":{"c":"three","d":"four"},"b":"two"}
+				o: "2dd85161356f82535046d2b3597efa0467fc2cea20d07f28e2b3f48d6cc687f5",
+			},
+		},
+		"Array": {
+			args: args{
+				algorithm: ptr.To[v1beta1.ChecksumAlgorithm](v1beta1.ChecksumAlgorithmSHA1),
+				i:         []any{"a", "b"},
+			},
+			want: want{
+				// sha1sum of ["a","b"]
+				o: "d12e223825a919e00efdbec820e0a557953b2ad5",
+			},
+		},
+		"InputNotObject": {
+			args: args{
+				i: "a",
+			},
+			want: want{
+				err: typeMismatchError(errFmtChecksumInputNotObject, "a"),
+			},
+		},
+		"UnknownAlgorithm": {
+			args: args{
+				algorithm: ptr.To[v1beta1.ChecksumAlgorithm]("Md5"),
+				i:         map[string]any{},
+			},
+			want: want{
+				err: errors.Errorf(errChecksumAlgorithmFailed, "Md5"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr := &v1beta1.ChecksumTransform{Algorithm: tc.algorithm}
+			got, err := ResolveChecksum(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(c): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(c): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDigResolve(t *testing.T) {
+	type args struct {
+		fieldPath string
+		def       *extv1.JSON
+		i         any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	obj := map[string]any{
+		"spec": map[string]any{
+			"rules": []any{
+				map[string]any{"name": "cool-rule"},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"Object": {
+			args: args{
+				fieldPath: "spec.rules[0].name",
+				i:         obj,
+			},
+			want: want{
+				o: "cool-rule",
+			},
+		},
+		"Array": {
+			args: args{
+				fieldPath: "[0].name",
+				i:         []any{map[string]any{"name": "cool-rule"}},
+			},
+			want: want{
+				o: "cool-rule",
+			},
+		},
+		"MissingWithDefault": {
+			args: args{
+				fieldPath: "spec.rules[1].name",
+				def:       &extv1.JSON{Raw: []byte(`"default-rule"`)},
+				i:         obj,
+			},
+			want: want{
+				o: "default-rule",
+			},
+		},
+		"MissingWithObjectDefault": {
+			args: args{
+				fieldPath: "spec.limits.cpu",
+				def:       &extv1.JSON{Raw: []byte(`{"cores":2}`)},
+				i:         obj,
+			},
+			want: want{
+				o: map[string]any{"cores": float64(2)},
+			},
+		},
+		"MissingWithoutDefault": {
+			args: args{
+				fieldPath: "spec.limits",
+				i:         obj,
+			},
+			want: want{
+				err: errors.Wrapf(errors.New("input.spec.limits: no such field"), errFmtDigFieldPath, "spec.limits"),
+			},
+		},
+		"InputNotObject": {
+			args: args{
+				fieldPath: "spec",
+				i:         "a",
+			},
+			want: want{
+				err: typeMismatchError(errFmtDigInputNotObject, "a"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr := &v1beta1.DigTransform{FieldPath: tc.fieldPath, Default: tc.def}
+			got, err := ResolveDig(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(d): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(d): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertResolve(t *testing.T) {
+	type args struct {
+		to          v1beta1.TransformIOType
+		format      *v1beta1.ConvertTransformFormat
+		base        *int
+		width       *int
+		floatFormat *v1beta1.ConvertFloatFormat
+		precision   *int
+		lossyPolicy *v1beta1.ConvertLossyPolicy
+		i           any
+	}
+	type want struct {
+		o   any
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"StringToBool": {
+			args: args{
+				i:  "true",
+				to: v1beta1.TransformIOTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"IntToString": {
+			args: args{
+				i:  100,
+				to: v1beta1.TransformIOTypeString,
+			},
+			want: want{
+				o: "100",
+			},
+		},
+		"StringToFloat64": {
+			args: args{
+				i:  "1000",
+				to: v1beta1.TransformIOTypeFloat64,
+			},
+			want: want{
+				o: 1000.0,
+			},
+		},
+		"StringToQuantityFloat64": {
+			args: args{
+				i:      "1000m",
+				to:     v1beta1.TransformIOTypeFloat64,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatQuantity))),
+			},
+			want: want{
+				o: 1.0,
+			},
+		},
+		"StringToQuantityFloat64InvalidFormat": {
+			args: args{
+				i:      "1000 blabla",
+				to:     v1beta1.TransformIOTypeFloat64,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatQuantity))),
+			},
+			want: want{
+				err: resource.ErrFormatWrong,
+			},
+		},
+		"SameTypeNoOp": {
+			args: args{
+				i:  true,
+				to: v1beta1.TransformIOTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"IntAliasToInt64": {
+			args: args{
+				i:  int64(1),
+				to: v1beta1.TransformIOTypeInt,
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"StringToObject": {
+			args: args{
+				i:      "{\"foo\":\"bar\"}",
+				to:     v1beta1.TransformIOTypeObject,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatJSON))),
+			},
+			want: want{
+				o: map[string]any{
+					"foo": "bar",
+				},
+			},
+		},
+		"StringToList": {
+			args: args{
+				i:      "[\"foo\", \"bar\", \"baz\"]",
+				to:     v1beta1.TransformIOTypeArray,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatJSON))),
+			},
+			want: want{
+				o: []any{
+					"foo", "bar", "baz",
+				},
+			},
+		},
+		"ObjectToJSONString": {
+			args: args{
+				i: map[string]any{
+					"Version":   "2012-10-17",
+					"Statement": []any{map[string]any{"Effect": "Allow"}},
+				},
+				to:     v1beta1.TransformIOTypeString,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatJSON))),
+			},
+			want: want{
+				o: `{"Statement":[{"Effect":"Allow"}],"Version":"2012-10-17"}`,
+			},
+		},
+		"ListToJSONString": {
+			args: args{
+				i:      []any{"foo", "bar", "baz"},
+				to:     v1beta1.TransformIOTypeString,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatJSON))),
+			},
+			want: want{
+				o: `["foo","bar","baz"]`,
+			},
+		},
+		"ObjectToStringMissingFormat": {
+			args: args{
+				i:  map[string]any{"foo": "bar"},
+				to: v1beta1.TransformIOTypeString,
+			},
+			want: want{
+				err: errors.Errorf(v1beta1.ErrFmtConvertFormatPairNotSupported, v1beta1.TransformIOTypeObject, v1beta1.TransformIOTypeString, v1beta1.ConvertTransformFormatNone),
+			},
+		},
+		"InputTypeNotSupported": {
+			args: args{
+				i:  []int{64},
+				to: v1beta1.TransformIOTypeString,
+			},
+			want: want{
+				err: typeMismatchError(errFmtConvertInputTypeNotSupported, []int{}),
+			},
+		},
+		"ConversionPairFormatNotSupported": {
+			args: args{
+				i:      100,
+				to:     v1beta1.TransformIOTypeString,
+				format: (*v1beta1.ConvertTransformFormat)(ptr.To[string](string(v1beta1.ConvertTransformFormatQuantity))),
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertFormatPairNotSupported, "int", "string", string(v1beta1.ConvertTransformFormatQuantity)),
+			},
+		},
+		"ConversionPairNotSupported": {
+			args: args{
+				i:  "[64]",
+				to: "[]int",
+			},
+			want: want{
+				err: &field.Error{
+					Type:     field.ErrorTypeInvalid,
+					Field:    "toType",
+					BadValue: v1beta1.TransformIOType("[]int"),
+					Detail:   "invalid type",
+				},
+			},
+		},
+		"ConversionPairSupportedFloat64Int64": {
+			args: args{
+				i:  float64(1.1),
+				to: v1beta1.TransformIOTypeInt64,
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"Float64Int64LossyError": {
+			args: args{
+				i:           float64(1.1),
+				to:          v1beta1.TransformIOTypeInt64,
+				lossyPolicy: ptr.To[v1beta1.ConvertLossyPolicy](v1beta1.ConvertLossyPolicyError),
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertLossy, float64(1.1), v1beta1.TransformIOTypeInt64),
+			},
+		},
+		"Float64Int64NaN": {
+			args: args{
+				i:  math.NaN(),
+				to: v1beta1.TransformIOTypeInt64,
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertLossy, math.NaN(), v1beta1.TransformIOTypeInt64),
+			},
+		},
+		"Float64Int64Inf": {
+			args: args{
+				i:           math.Inf(-1),
+				to:          v1beta1.TransformIOTypeInt64,
+				lossyPolicy: ptr.To[v1beta1.ConvertLossyPolicy](v1beta1.ConvertLossyPolicyError),
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertLossy, math.Inf(-1), v1beta1.TransformIOTypeInt64),
+			},
+		},
+		"Float64Int64OutOfRange": {
+			args: args{
+				i:  float64(1 << 63),
+				to: v1beta1.TransformIOTypeInt64,
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertLossy, float64(1<<63), v1beta1.TransformIOTypeInt64),
+			},
+		},
+		"Float64Int64MinInt64": {
+			args: args{
+				i:  float64(math.MinInt64),
+				to: v1beta1.TransformIOTypeInt64,
+			},
+			want: want{
+				o: int64(math.MinInt64),
+			},
+		},
+		"Float64Int64LosslessError": {
+			args: args{
+				i:           float64(2),
+				to:          v1beta1.TransformIOTypeInt64,
+				lossyPolicy: ptr.To[v1beta1.ConvertLossyPolicy](v1beta1.ConvertLossyPolicyError),
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"Int64BoolLossyError": {
+			args: args{
+				i:           int64(2),
+				to:          v1beta1.TransformIOTypeBool,
+				lossyPolicy: ptr.To[v1beta1.ConvertLossyPolicy](v1beta1.ConvertLossyPolicyError),
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertLossy, int64(2), v1beta1.TransformIOTypeBool),
+			},
+		},
+		"Float64StringPrecision": {
+			args: args{
+				i:         float64(1.23456),
+				to:        v1beta1.TransformIOTypeString,
+				precision: ptr.To[int](2),
+			},
+			want: want{
+				o: "1.23",
+			},
+		},
+		"Float64StringScientific": {
+			args: args{
+				i:           float64(123.45),
+				to:          v1beta1.TransformIOTypeString,
+				floatFormat: ptr.To[v1beta1.ConvertFloatFormat](v1beta1.ConvertFloatFormatScientific),
+			},
+			want: want{
+				o: "1.2345e+02",
+			},
+		},
+		"Int64StringBaseAndWidth": {
+			args: args{
+				i:     int64(255),
+				to:    v1beta1.TransformIOTypeString,
+				base:  ptr.To[int](16),
+				width: ptr.To[int](4),
+			},
+			want: want{
+				o: "00ff",
+			},
+		},
+		"NegativeInt64StringWidth": {
+			args: args{
+				i:     int64(-7),
+				to:    v1beta1.TransformIOTypeString,
+				width: ptr.To[int](3),
+			},
+			want: want{
+				o: "-007",
+			},
+		},
+		"StringInt64Base": {
+			args: args{
+				i:    "0755",
+				to:   v1beta1.TransformIOTypeInt64,
+				base: ptr.To[int](8),
+			},
+			want: want{
+				o: int64(493),
+			},
+		},
+		"InvalidBase": {
+			args: args{
+				i:    "42",
+				to:   v1beta1.TransformIOTypeInt64,
+				base: ptr.To[int](1),
+			},
+			want: want{
+				err: &field.Error{
+					Type:     field.ErrorTypeInvalid,
+					Field:    "base",
+					BadValue: 1,
+					Detail:   "base must be between 2 and 36",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tr := &v1beta1.ConvertTransform{
+				ToType:      tc.args.to,
+				Format:      tc.format,
+				Base:        tc.base,
+				Width:       tc.width,
+				FloatFormat: tc.floatFormat,
+				Precision:   tc.precision,
+				LossyPolicy: tc.lossyPolicy,
+			}
+			got, err := ResolveConvert(tr, tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertTransformGetConversionFunc(t *testing.T) {
+	type args struct {
+		ct   *v1beta1.ConvertTransform
+		from v1beta1.TransformIOType
+	}
+	type want struct {
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"IntToString": {
+			reason: "Int to String should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeString,
+				},
+				from: v1beta1.TransformIOTypeInt,
+			},
+		},
+		"IntToInt": {
+			reason: "Int to Int should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt,
+				},
+				from: v1beta1.TransformIOTypeInt,
+			},
+		},
+		"IntToInt64": {
+			reason: "Int to Int64 should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt,
+				},
+				from: v1beta1.TransformIOTypeInt64,
+			},
+		},
+		"Int64ToInt": {
+			reason: "Int64 to Int should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt64,
+				},
+				from: v1beta1.TransformIOTypeInt,
+			},
+		},
+		"IntToFloat": {
+			reason: "Int to Float should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt,
+				},
+				from: v1beta1.TransformIOTypeFloat64,
+			},
+		},
+		"IntToBool": {
+			reason: "Int to Bool should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt,
+				},
+				from: v1beta1.TransformIOTypeBool,
+			},
+		},
+		"JSONStringToObject": {
+			reason: "JSON string to Object should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeObject,
+					Format: &[]v1beta1.ConvertTransformFormat{v1beta1.ConvertTransformFormatJSON}[0],
+				},
+				from: v1beta1.TransformIOTypeString,
+			},
+		},
+		"JSONStringToArray": {
+			reason: "JSON string to Array should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeArray,
+					Format: &[]v1beta1.ConvertTransformFormat{v1beta1.ConvertTransformFormatJSON}[0],
+				},
+				from: v1beta1.TransformIOTypeString,
+			},
+		},
+		"ObjectToJSONString": {
+			reason: "Object to JSON string should be valid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeString,
+					Format: &[]v1beta1.ConvertTransformFormat{v1beta1.ConvertTransformFormatJSON}[0],
+				},
+				from: v1beta1.TransformIOTypeObject,
+			},
+		},
+		"StringToObjectMissingFormat": {
+			reason: "String to Object without format should be invalid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeObject,
+				},
+				from: v1beta1.TransformIOTypeString,
+			},
+			want: want{
+				err: fmt.Errorf("conversion from string to object is not supported with format none"),
+			},
+		},
+		"StringToIntInvalidFormat": {
+			reason: "String to Int with invalid format should be invalid",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt,
+					Format: &[]v1beta1.ConvertTransformFormat{"wrong"}[0],
+				},
+				from: v1beta1.TransformIOTypeString,
+			},
+			want: want{
+				err: fmt.Errorf("conversion from string to int64 is not supported with format wrong"),
+			},
+		},
+		"IntToIntInvalidFormat": {
+			reason: "Int to Int, invalid format ignored because it is the same type",
+			args: args{
+				ct: &v1beta1.ConvertTransform{
+					ToType: v1beta1.TransformIOTypeInt,
+					Format: &[]v1beta1.ConvertTransformFormat{"wrong"}[0],
+				},
+				from: v1beta1.TransformIOTypeInt,
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := GetConversionFunc(tc.args.ct, tc.args.from)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nGetConversionFunc(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func FuzzEvaluate(f *testing.F) {
+	f.Add([]byte(`[{"type":"math","math":{"type":"Multiply","multiply":2}}]`), []byte(`2`))
+	f.Add([]byte(`[{"type":"string","string":{"type":"Regexp","regexp":{"match":"a(b)","group":1}}}]`), []byte(`"ab"`))
+	f.Add([]byte(`[{"type":"convert","convert":{"toType":"int64"}}]`), []byte(`"42"`))
+	f.Add([]byte(`[{"type":"bool","bool":{"type":"Matches","value":"^a"}},{"type":"bool","bool":{"type":"Not"}}]`), []byte(`"ab"`))
+	f.Add([]byte(`[{"type":"parse","parse":{"type":"Int","base":16,"bitSize":32}}]`), []byte(`"ff"`))
+	f.Add([]byte(`[{"type":"checksum","checksum":{"algorithm":"Sha512"}}]`), []byte(`{"a":"b"}`))
+	f.Add([]byte(`[{"type":"map","map":{"a":"b"}},{"type":"match","match":{"patterns":[{"type":"literal","literal":"b","result":1}]}}]`), []byte(`"a"`))
+
+	// Webhook transforms would make real HTTP requests.
+	r := NewRegistry(Builtin())
+	r.Unregister(v1beta1.TransformTypeWebhook)
+
+	f.Fuzz(func(_ *testing.T, transforms, input []byte) {
+		ts := []v1beta1.Transform{}
+		if err := json.Unmarshal(transforms, &ts); err != nil {
+			return
+		}
+		var in any
+		if err := json.Unmarshal(input, &in); err != nil {
+			return
+		}
+
+		// We only care that we don't panic.
+		_, _ = r.Evaluate(context.Background(), ts, in)
+	})
+}
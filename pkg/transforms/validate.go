@@ -0,0 +1,501 @@
+package transforms
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+)
+
+// wrapFieldError wraps the given field.Error adding the given field.Path as
+// root of the Field.
+func wrapFieldError(err *field.Error, path *field.Path) *field.Error {
+	if err == nil {
+		return nil
+	}
+	if path == nil {
+		return err
+	}
+	err.Field = path.Child(err.Field).String()
+	return err
+}
+
+// ValidateTransformNames validates that a Transform only refers to the named
+// outputs of earlier transforms, and that its own name is unique.
+func ValidateTransformNames(t v1beta1.Transform, named map[string]bool) *field.Error {
+	if t.Name != nil && named[*t.Name] {
+		return field.Duplicate(field.NewPath("name"), *t.Name)
+	}
+	if t.FromName != nil && !named[*t.FromName] {
+		return field.NotFound(field.NewPath("fromName"), *t.FromName)
+	}
+	if t.Type == v1beta1.TransformTypeCombine && t.Combine != nil {
+		for i, n := range t.Combine.Names {
+			if !named[n] {
+				return field.NotFound(field.NewPath("combine", "names").Index(i), n)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateTransformInput validates that a Transform can accept input of the
+// supplied type, i.e. that resolving it won't always fail. Any input is valid
+// if its type is unknown.
+func ValidateTransformInput(t v1beta1.Transform, in v1beta1.TransformIOType) *field.Error {
+	if in == "" {
+		return nil
+	}
+	if t.Type == v1beta1.TransformTypeString && t.String != nil && t.String.Type == v1beta1.StringTransformTypeFormat && t.String.Format != nil {
+		// Report the verb that can't format the input, if any.
+		verbs, _ := FormatVerbs(*t.String.Format)
+		for _, v := range verbs {
+			if !FormatAccepts(v, in) {
+				return field.Invalid(field.NewPath("string", "fmt"), *t.String.Format, fmt.Sprintf("verb %%%c cannot format %s input", v, in))
+			}
+		}
+	}
+	if !Default.Accepts(t, in) {
+		return field.Invalid(field.NewPath("type"), t.Type, fmt.Sprintf("%s transform cannot resolve %s input", t.Type, in))
+	}
+	return nil
+}
+
+// ValidateTransform validates a Transform.
+func ValidateTransform(t v1beta1.Transform) *field.Error {
+	d, ok := Default.Get(t.Type)
+	if !ok {
+		// Should never happen
+		return field.Invalid(field.NewPath("type"), t.Type, "unknown transform type")
+	}
+	if d.Validate == nil {
+		return nil
+	}
+	return d.Validate(t)
+}
+
+// ValidateMathTransform validates a MathTransform.
+func ValidateMathTransform(m *v1beta1.MathTransform) *field.Error {
+	if m.Type == "" {
+		return field.Required(field.NewPath("type"), "math transform type is required")
+	}
+	switch m.Type {
+	case v1beta1.MathTransformTypeMultiply:
+		if m.Multiply != nil && m.MultiplyFloat != nil {
+			return field.Forbidden(field.NewPath("multiplyFloat"), "multiply and multiplyFloat are mutually exclusive")
+		}
+		if m.Multiply == nil && m.MultiplyFloat == nil {
+			return field.Required(field.NewPath("multiply"), "must specify a value if a multiply math transform is specified")
+		}
+		if m.MultiplyFloat != nil {
+			if _, err := strconv.ParseFloat(*m.MultiplyFloat, 64); err != nil {
+				return field.Invalid(field.NewPath("multiplyFloat"), *m.MultiplyFloat, "must be a number")
+			}
+		}
+	case v1beta1.MathTransformTypeClampMin:
+		if m.ClampMin == nil {
+			return field.Required(field.NewPath("clampMin"), "must specify a value if a clamp min math transform is specified")
+		}
+	case v1beta1.MathTransformTypeClampMax:
+		if m.ClampMax == nil {
+			return field.Required(field.NewPath("clampMax"), "must specify a value if a clamp max math transform is specified")
+		}
+	default:
+		return field.Invalid(field.NewPath("type"), m.Type, "unknown math transform type")
+	}
+	// A float64 has at most 17 significant decimal digits, and rounding to
+	// more than 308 decimal places would overflow to NaN.
+	if m.Precision != nil && (*m.Precision < 0 || *m.Precision > 17) {
+		return field.Invalid(field.NewPath("precision"), *m.Precision, "must be between 0 and 17")
+	}
+	switch m.GetRounding() {
+	case v1beta1.MathRoundingRound, v1beta1.MathRoundingFloor, v1beta1.MathRoundingCeil, v1beta1.MathRoundingTruncate:
+	default:
+		return field.Invalid(field.NewPath("rounding"), m.GetRounding(), "unknown rounding")
+	}
+	return nil
+}
+
+// ValidateMapTransform validates MapTransform.
+func ValidateMapTransform(m *v1beta1.MapTransform) *field.Error {
+	if len(m.Pairs) == 0 {
+		return field.Required(field.NewPath("pairs"), "at least one pair must be specified if a map transform is specified")
+	}
+	return nil
+}
+
+// ValidateInverseMapTransform validates a MapTransform that is looked up in
+// reverse. Each value must identify exactly one key.
+func ValidateInverseMapTransform(m *v1beta1.MapTransform, ignoreCase bool) *field.Error {
+	if err := ValidateMapTransform(m); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(m.Pairs))
+	for k := range m.Pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		var v any
+		if err := json.Unmarshal(m.Pairs[k].Raw, &v); err != nil {
+			return field.Invalid(field.NewPath("pairs").Key(k), string(m.Pairs[k].Raw), "value must be valid JSON")
+		}
+		if s, ok := v.(string); ok && ignoreCase {
+			v = strings.ToLower(s)
+		}
+		b, _ := json.Marshal(v) //nolint:errchkjson // Decoded from JSON, so it can be encoded.
+		if seen[string(b)] {
+			return field.Duplicate(field.NewPath("pairs").Key(k), string(m.Pairs[k].Raw))
+		}
+		seen[string(b)] = true
+	}
+	return nil
+}
+
+// ValidateMatchTransform validates a MatchTransform.
+func ValidateMatchTransform(m *v1beta1.MatchTransform) *field.Error {
+	if len(m.Patterns) == 0 {
+		return field.Required(field.NewPath("patterns"), "at least one pattern must be specified if a match transform is specified")
+	}
+	for i, p := range m.Patterns {
+		if err := ValidateMatchTransformPattern(p); err != nil {
+			return wrapFieldError(err, field.NewPath("patterns").Index(i))
+		}
+	}
+	if f := m.FallbackFrom; f != nil {
+		switch f.GetSource() {
+		case v1beta1.MatchFallbackSourceComposite, v1beta1.MatchFallbackSourceEnvironment:
+		default:
+			return field.Invalid(field.NewPath("fallbackFrom", "source"), f.Source, "unknown fallback source")
+		}
+		if f.FieldPath == "" {
+			return field.Required(field.NewPath("fallbackFrom", "fieldPath"), "cannot be empty")
+		}
+		if err := fieldpaths.ValidateKeys(f.FieldPath); err != nil {
+			return field.Invalid(field.NewPath("fallbackFrom", "fieldPath"), f.FieldPath, err.Error())
+		}
+	}
+	return nil
+}
+
+// ValidateMatchTransformPattern validates a MatchTransformPattern.
+func ValidateMatchTransformPattern(p v1beta1.MatchTransformPattern) *field.Error {
+	switch p.Type {
+	case v1beta1.MatchTransformPatternTypeLiteral, "":
+		if p.Literal == nil {
+			return field.Required(field.NewPath("literal"), "literal pattern type requires a literal")
+		}
+	case v1beta1.MatchTransformPatternTypeRegexp:
+		if p.Regexp == nil {
+			return field.Required(field.NewPath("regexp"), "regexp pattern type requires a regexp")
+		}
+		if _, err := regexps.Compile(*p.Regexp); err != nil {
+			return field.Invalid(field.NewPath("regexp"), *p.Regexp, "invalid regexp")
+		}
+	case v1beta1.MatchTransformPatternTypeRange:
+		if p.Range == nil {
+			return field.Required(field.NewPath("range"), "range pattern type requires a range")
+		}
+		if p.Range.Gte == nil && p.Range.Lte == nil {
+			return field.Required(field.NewPath("range"), "range must specify gte, lte, or both")
+		}
+		if p.Range.Gte != nil && p.Range.Lte != nil && *p.Range.Gte > *p.Range.Lte {
+			return field.Invalid(field.NewPath("range", "lte"), *p.Range.Lte, "lte must not be less than gte")
+		}
+	case v1beta1.MatchTransformPatternTypeCIDR:
+		if p.CIDR == nil {
+			return field.Required(field.NewPath("cidr"), "cidr pattern type requires a cidr")
+		}
+		if _, err := netip.ParsePrefix(*p.CIDR); err != nil {
+			return field.Invalid(field.NewPath("cidr"), *p.CIDR, "invalid CIDR")
+		}
+	case v1beta1.MatchTransformPatternTypeSemver:
+		if p.Semver == nil {
+			return field.Required(field.NewPath("semver"), "semver pattern type requires a semver constraint")
+		}
+		if _, err := semverConstraints(*p.Semver); err != nil {
+			return field.Invalid(field.NewPath("semver"), *p.Semver, "invalid semver constraint")
+		}
+	default:
+		return field.Invalid(field.NewPath("type"), p.Type, "unknown pattern type")
+	}
+	return nil
+}
+
+// ValidateStringTransform validates a StringTransform.
+func ValidateStringTransform(s *v1beta1.StringTransform) *field.Error { //nolint:gocyclo // just a switch
+	if s.Type == "" {
+		return field.Required(field.NewPath("type"), "string transform type is required")
+	}
+	switch s.Type {
+	case v1beta1.StringTransformTypeFormat:
+		if s.Format == nil {
+			return field.Required(field.NewPath("fmt"), "format transform requires a format")
+		}
+		if _, err := FormatVerbs(*s.Format); err != nil {
+			return field.Invalid(field.NewPath("fmt"), *s.Format, err.Error())
+		}
+	case v1beta1.StringTransformTypeConvert:
+		if s.Convert == nil {
+			return field.Required(field.NewPath("convert"), "convert transform requires a conversion type")
+		}
+	case v1beta1.StringTransformTypeTrimPrefix, v1beta1.StringTransformTypeTrimSuffix:
+		if s.Trim == nil {
+			return field.Required(field.NewPath("trim"), "trim transform requires a trim value")
+		}
+	case v1beta1.StringTransformTypeEnsurePrefix, v1beta1.StringTransformTypeEnsureSuffix:
+		if s.Ensure == nil {
+			return field.Required(field.NewPath("ensure"), "ensure transform requires an ensure value")
+		}
+	case v1beta1.StringTransformTypePadLeft, v1beta1.StringTransformTypePadRight:
+		if s.Pad == nil {
+			return field.Required(field.NewPath("pad"), "pad transform requires a pad")
+		}
+		if s.Pad.Width < 0 || s.Pad.Width > 4096 {
+			return field.Invalid(field.NewPath("pad", "width"), s.Pad.Width, "width must be between 0 and 4096")
+		}
+		if utf8.RuneCountInString(s.Pad.GetFill()) != 1 {
+			return field.Invalid(field.NewPath("pad", "fill"), s.Pad.GetFill(), "fill must be a single character")
+		}
+	case v1beta1.StringTransformTypeSubstring:
+		if s.Substring == nil {
+			return field.Required(field.NewPath("substring"), "substring transform requires a substring")
+		}
+		if s.Substring.Start < 0 {
+			return field.Invalid(field.NewPath("substring", "start"), s.Substring.Start, "start must not be negative")
+		}
+		if s.Substring.End != nil && *s.Substring.End < s.Substring.Start {
+			return field.Invalid(field.NewPath("substring", "end"), *s.Substring.End, "end must not be less than start")
+		}
+	case v1beta1.StringTransformTypeTruncate:
+		if s.Truncate == nil {
+			return field.Required(field.NewPath("truncate"), "truncate transform requires a truncate")
+		}
+		if s.Truncate.Length < 0 {
+			return field.Invalid(field.NewPath("truncate", "length"), s.Truncate.Length, "length must not be negative")
+		}
+		if err := ValidateStringLengthUnit(s.Truncate.GetUnit()); err != nil {
+			return wrapFieldError(err, field.NewPath("truncate"))
+		}
+		if h := s.Truncate.HashLength; h != nil && (*h < 1 || *h > 64) {
+			return field.Invalid(field.NewPath("truncate", "hashLength"), *h, "hashLength must be between 1 and 64")
+		}
+		if s.Truncate.Suffix != nil {
+			// Use the unit of the truncate transform to measure the suffix,
+			// since that's how it'll be measured when resolved.
+			n, _ := ResolveStringLength(&v1beta1.StringTransform{
+				Type:   v1beta1.StringTransformTypeLength,
+				Length: &v1beta1.StringTransformLength{Unit: s.Truncate.Unit},
+			}, *s.Truncate.Suffix)
+			if n > int64(s.Truncate.Length) {
+				return field.Invalid(field.NewPath("truncate", "suffix"), *s.Truncate.Suffix, "suffix must not be longer than length")
+			}
+			// Each character of a hash is one rune, grapheme, and byte.
+			if h := s.Truncate.HashLength; h != nil && n+int64(*h) > int64(s.Truncate.Length) {
+				return field.Invalid(field.NewPath("truncate", "hashLength"), *h, "suffix and hash must not be longer than length")
+			}
+		}
+		if h := s.Truncate.HashLength; h != nil && s.Truncate.Suffix == nil && *h > s.Truncate.Length {
+			return field.Invalid(field.NewPath("truncate", "hashLength"), *h, "hash must not be longer than length")
+		}
+	case v1beta1.StringTransformTypeJoin:
+		if s.Join == nil {
+			return field.Required(field.NewPath("join"), "join transform requires a join")
+		}
+		switch s.Join.GetQuote() {
+		case v1beta1.StringJoinQuoteNone, v1beta1.StringJoinQuoteSingle, v1beta1.StringJoinQuoteDouble:
+		default:
+			return field.Invalid(field.NewPath("join", "quote"), s.Join.GetQuote(), "unknown join quote")
+		}
+		if s.Join.Format != nil {
+			if _, err := FormatVerbs(*s.Join.Format); err != nil {
+				return field.Invalid(field.NewPath("join", "fmt"), *s.Join.Format, err.Error())
+			}
+		}
+	case v1beta1.StringTransformTypeLength:
+		if s.Length != nil {
+			if err := ValidateStringLengthUnit(s.Length.GetUnit()); err != nil {
+				return wrapFieldError(err, field.NewPath("length"))
+			}
+		}
+	case v1beta1.StringTransformTypeRegexp:
+		if s.Regexp == nil {
+			return field.Required(field.NewPath("regexp"), "regexp transform requires a regexp")
+		}
+		if s.Regexp.Match == "" {
+			return field.Required(field.NewPath("regexp", "match"), "regexp transform requires a match")
+		}
+		if _, err := regexps.Compile(s.Regexp.Match); err != nil {
+			return field.Invalid(field.NewPath("regexp", "match"), s.Regexp.Match, "invalid regexp")
+		}
+	default:
+		return field.Invalid(field.NewPath("type"), s.Type, "unknown string transform type")
+	}
+	return nil
+}
+
+// ValidateStringLengthUnit validates a StringLengthUnit.
+func ValidateStringLengthUnit(u v1beta1.StringLengthUnit) *field.Error {
+	switch u {
+	case v1beta1.StringLengthUnitRunes, v1beta1.StringLengthUnitGraphemes, v1beta1.StringLengthUnitBytes:
+		return nil
+	default:
+		return field.Invalid(field.NewPath("unit"), u, "unknown string length unit")
+	}
+}
+
+// ValidateBoolTransform validates a BoolTransform.
+func ValidateBoolTransform(b *v1beta1.BoolTransform) *field.Error {
+	switch b.Type {
+	case v1beta1.BoolTransformTypeNot:
+		return nil
+	case v1beta1.BoolTransformTypeContains, v1beta1.BoolTransformTypeHasPrefix, v1beta1.BoolTransformTypeHasSuffix, v1beta1.BoolTransformTypeMatches:
+		if b.Value == nil {
+			return field.Required(field.NewPath("value"), "bool transform requires a value")
+		}
+	case "":
+		return field.Required(field.NewPath("type"), "bool transform type is required")
+	default:
+		return field.Invalid(field.NewPath("type"), b.Type, "unknown bool transform type")
+	}
+	if b.Type == v1beta1.BoolTransformTypeMatches {
+		if _, err := regexps.Compile(*b.Value); err != nil {
+			return field.Invalid(field.NewPath("value"), *b.Value, "invalid regexp")
+		}
+	}
+	return nil
+}
+
+// ValidateParseTransform validates a ParseTransform.
+func ValidateParseTransform(p *v1beta1.ParseTransform) *field.Error {
+	switch p.Type {
+	case v1beta1.ParseTransformTypeInt:
+		if b := p.GetBase(); b != 0 && (b < 2 || b > 36) {
+			return field.Invalid(field.NewPath("base"), b, "base must be 0, or between 2 and 36")
+		}
+		switch p.GetBitSize() {
+		case 8, 16, 32, 64:
+		default:
+			return field.Invalid(field.NewPath("bitSize"), p.GetBitSize(), "bit size must be 8, 16, 32, or 64")
+		}
+	case v1beta1.ParseTransformTypeFloat:
+		if p.Base != nil {
+			return field.Invalid(field.NewPath("base"), *p.Base, "base is only supported by parse transforms of type Int")
+		}
+		switch p.GetBitSize() {
+		case 32, 64:
+		default:
+			return field.Invalid(field.NewPath("bitSize"), p.GetBitSize(), "bit size must be 32 or 64")
+		}
+	case "":
+		return field.Required(field.NewPath("type"), "parse transform type is required")
+	default:
+		return field.Invalid(field.NewPath("type"), p.Type, "unknown parse transform type")
+	}
+	if !p.GetErrorPolicy().IsValid() {
+		return field.Invalid(field.NewPath("errorPolicy"), p.GetErrorPolicy(), "invalid error policy")
+	}
+	return nil
+}
+
+// ValidateCombineTransform validates a CombineTransform.
+func ValidateCombineTransform(c *v1beta1.CombineTransform) *field.Error {
+	if len(c.Names) == 0 {
+		return field.Required(field.NewPath("names"), "at least one name must be specified if a combine transform is specified")
+	}
+	switch c.Strategy {
+	case v1beta1.CombineStrategyString:
+		if c.String == nil {
+			return field.Required(field.NewPath("string"), "string combine strategy requires configuration")
+		}
+	case "":
+		return field.Required(field.NewPath("strategy"), "combine strategy is required")
+	default:
+		return field.Invalid(field.NewPath("strategy"), c.Strategy, "unknown combine strategy")
+	}
+	return nil
+}
+
+// ValidateChecksumTransform validates a ChecksumTransform.
+func ValidateChecksumTransform(c *v1beta1.ChecksumTransform) *field.Error {
+	switch c.GetAlgorithm() {
+	case v1beta1.ChecksumAlgorithmSHA1, v1beta1.ChecksumAlgorithmSHA256, v1beta1.ChecksumAlgorithmSHA512:
+		return nil
+	}
+	return field.Invalid(field.NewPath("algorithm"), c.GetAlgorithm(), "unknown checksum algorithm")
+}
+
+// ValidateDigTransform validates a DigTransform.
+func ValidateDigTransform(d *v1beta1.DigTransform) *field.Error {
+	if d.FieldPath == "" {
+		return field.Required(field.NewPath("fieldPath"), "fieldPath is required")
+	}
+	if _, err := fieldpath.Parse(d.FieldPath); err != nil {
+		return field.Invalid(field.NewPath("fieldPath"), d.FieldPath, err.Error())
+	}
+	if d.Default != nil && !json.Valid(d.Default.Raw) {
+		return field.Invalid(field.NewPath("default"), string(d.Default.Raw), "default must be valid JSON")
+	}
+	return nil
+}
+
+// ValidateWebhookTransform validates a WebhookTransform.
+func ValidateWebhookTransform(w *v1beta1.WebhookTransform) *field.Error {
+	if w.URL == "" {
+		return field.Required(field.NewPath("url"), "url is required")
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return field.Invalid(field.NewPath("url"), w.URL, "url must be an absolute http or https URL")
+	}
+	if w.GetTimeout() <= 0 {
+		return field.Invalid(field.NewPath("timeout"), w.GetTimeout().String(), "timeout must be positive")
+	}
+	if w.CABundle != nil && !x509.NewCertPool().AppendCertsFromPEM([]byte(*w.CABundle)) {
+		return field.Invalid(field.NewPath("caBundle"), "(omitted)", "caBundle must contain at least one PEM encoded certificate")
+	}
+	switch w.GetFailurePolicy() {
+	case v1beta1.WebhookFailurePolicyFail, v1beta1.WebhookFailurePolicyIgnore:
+		return nil
+	}
+	return field.Invalid(field.NewPath("failurePolicy"), w.GetFailurePolicy(), "unknown failure policy")
+}
+
+// ValidateConvertTransform validates a ConvertTransform.
+func ValidateConvertTransform(t *v1beta1.ConvertTransform) *field.Error {
+	if !t.GetFormat().IsValid() {
+		return field.Invalid(field.NewPath("format"), t.Format, "invalid format")
+	}
+	if !t.ToType.IsValid() {
+		return field.Invalid(field.NewPath("toType"), t.ToType, "invalid type")
+	}
+	if b := t.GetBase(); b < 2 || b > 36 {
+		return field.Invalid(field.NewPath("base"), b, "base must be between 2 and 36")
+	}
+	if t.Width != nil && (*t.Width < 0 || *t.Width > 4096) {
+		return field.Invalid(field.NewPath("width"), *t.Width, "width must be between 0 and 4096")
+	}
+	if t.Precision != nil && (*t.Precision < 0 || *t.Precision > 4096) {
+		return field.Invalid(field.NewPath("precision"), *t.Precision, "precision must be between 0 and 4096")
+	}
+	if !t.GetFloatFormat().IsValid() {
+		return field.Invalid(field.NewPath("floatFormat"), t.FloatFormat, "invalid float format")
+	}
+	if !t.GetLossyPolicy().IsValid() {
+		return field.Invalid(field.NewPath("lossyPolicy"), t.LossyPolicy, "invalid lossy policy")
+	}
+	return nil
+}
@@ -0,0 +1,562 @@
+package transforms
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestValidateTransform(t *testing.T) {
+	type args struct {
+		transform v1beta1.Transform
+	}
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ValidMathMultiply": {
+			reason: "Math transform with MathTransform Multiply set should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeMultiply,
+						Multiply: ptr.To[int64](2),
+					},
+				},
+			},
+		},
+		"ValidMathClampMin": {
+			reason: "Math transform with valid MathTransform ClampMin set should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeClampMin,
+						ClampMin: ptr.To[int64](10),
+					},
+				},
+			},
+		},
+		"InvalidMathWrongSpec": {
+			reason: "Math transform with invalid MathTransform set should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:     v1beta1.MathTransformTypeMultiply,
+						ClampMin: ptr.To[int64](10),
+					},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "math.multiply",
+				},
+			},
+		},
+		"InvalidMathTooPrecise": {
+			reason: "Math transform rounding to more decimal places than a float64 has should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMath,
+					Math: &v1beta1.MathTransform{
+						Type:      v1beta1.MathTransformTypeMultiply,
+						Multiply:  ptr.To[int64](2),
+						Precision: ptr.To(309),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "math.precision",
+				},
+			},
+		},
+		"InvalidMathNotDefinedAtAll": {
+			reason: "Math transform with no MathTransform set should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMath,
+					Math: nil,
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "math",
+				},
+			},
+		},
+		"ValidMap": {
+			reason: "Map transform with MapTransform set should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMap,
+					Map: &v1beta1.MapTransform{
+						Pairs: map[string]extv1.JSON{
+							"foo": {Raw: []byte(`"bar"`)},
+						},
+					},
+				},
+			},
+		},
+		"InvalidMapNoMap": {
+			reason: "Map transform with no map set should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMap,
+					Map:  nil,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "map",
+				},
+			},
+		},
+		"InvalidMapNoPairs": {
+			reason: "Map transform with no pairs in map should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMap,
+					Map:  &v1beta1.MapTransform{},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "map.pairs",
+				},
+			},
+		},
+		"InvalidInvertedMapDuplicateValues": {
+			reason: "Inverted map transform with duplicate values should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:      v1beta1.TransformTypeMap,
+					MapInvert: ptr.To(true),
+					Map: &v1beta1.MapTransform{
+						Pairs: map[string]extv1.JSON{
+							"foo": {Raw: []byte(`"bar"`)},
+							"baz": {Raw: []byte(`"bar"`)},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeDuplicate,
+					Field: "map.pairs[foo]",
+				},
+			},
+		},
+		"InvalidMatchNoMatch": {
+			reason: "Match transform with no match set should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:  v1beta1.TransformTypeMatch,
+					Match: nil,
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "match",
+				},
+			},
+		},
+		"InvalidMatchEmptyTransform": {
+			reason: "Match transform with empty MatchTransform should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:  v1beta1.TransformTypeMatch,
+					Match: &v1beta1.MatchTransform{},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "match.patterns",
+				},
+			},
+		},
+		"ValidMatchTransformRegexp": {
+			reason: "Match transform with valid MatchTransform of type regexp should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMatch,
+					Match: &v1beta1.MatchTransform{
+						Patterns: []v1beta1.MatchTransformPattern{
+							{
+								Type:   v1beta1.MatchTransformPatternTypeRegexp,
+								Regexp: ptr.To[string](".*"),
+							},
+						},
+					},
+				},
+			},
+		},
+		"InvalidMatchTransformRegexp": {
+			reason: "Match transform with an invalid MatchTransform of type regexp with a bad regexp should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMatch,
+					Match: &v1beta1.MatchTransform{
+						Patterns: []v1beta1.MatchTransformPattern{
+							{
+								Type:   v1beta1.MatchTransformPatternTypeRegexp,
+								Regexp: ptr.To[string]("?"),
+							},
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "match.patterns[0].regexp",
+				},
+			},
+		},
+		"ValidMatchTransformString": {
+			reason: "Match transform with valid MatchTransform of type literal should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMatch,
+					Match: &v1beta1.MatchTransform{
+						Patterns: []v1beta1.MatchTransformPattern{
+							{
+								Type:    v1beta1.MatchTransformPatternTypeLiteral,
+								Literal: ptr.To[string]("foo"),
+							},
+							{
+								Literal: ptr.To[string]("bar"),
+							},
+						},
+					},
+				},
+			},
+		},
+		"InvalidMatchTransformFallbackFromNoFieldPath": {
+			reason: "Match transform that reads its fallback value from a field without a field path should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeMatch,
+					Match: &v1beta1.MatchTransform{
+						Patterns: []v1beta1.MatchTransformPattern{
+							{
+								Literal: ptr.To[string]("foo"),
+							},
+						},
+						FallbackFrom: &v1beta1.MatchFallbackFrom{Source: v1beta1.MatchFallbackSourceEnvironment},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "match.fallbackFrom.fieldPath",
+				},
+			},
+		},
+		"InvalidStringNoString": {
+			reason: "String transform with no string set should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:   v1beta1.TransformTypeString,
+					String: nil,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "string",
+				},
+			},
+		},
+		"ValidString": {
+			reason: "String transform with set string should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type:   v1beta1.StringTransformTypeFormat,
+						Format: ptr.To[string]("foo-%s"),
+					},
+				},
+			},
+		},
+		"ValidStringFormatNoVerb": {
+			reason: "String format transform with a constant format should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type:   v1beta1.StringTransformTypeFormat,
+						Format: ptr.To[string]("static-name"),
+					},
+				},
+			},
+		},
+		"InvalidStringTruncateSuffixTooLong": {
+			reason: "String truncate transform with a suffix longer than its length should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type: v1beta1.StringTransformTypeTruncate,
+						Truncate: &v1beta1.StringTransformTruncate{
+							Length: 2,
+							Suffix: ptr.To[string]("..."),
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "string.truncate.suffix",
+				},
+			},
+		},
+		"InvalidStringTruncateHashTooLong": {
+			reason: "String truncate transform with a suffix and hash longer than its length should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type: v1beta1.StringTransformTypeTruncate,
+						Truncate: &v1beta1.StringTransformTruncate{
+							Length:     8,
+							Suffix:     ptr.To[string]("-"),
+							HashLength: ptr.To[int](8),
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "string.truncate.hashLength",
+				},
+			},
+		},
+		"InvalidStringJoinMissingJoin": {
+			reason: "String join transform missing join should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type: v1beta1.StringTransformTypeJoin,
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "string.join",
+				},
+			},
+		},
+		"InvalidStringLengthUnknownUnit": {
+			reason: "String length transform with an unknown unit should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type: v1beta1.StringTransformTypeLength,
+						Length: &v1beta1.StringTransformLength{
+							Unit: ptr.To[v1beta1.StringLengthUnit]("Words"),
+						},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "string.length.unit",
+				},
+			},
+		},
+		"InvalidStringPadTooWide": {
+			reason: "String pad transform wider than the maximum width should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeString,
+					String: &v1beta1.StringTransform{
+						Type: v1beta1.StringTransformTypePadLeft,
+						Pad:  &v1beta1.StringTransformPad{Width: 1 << 30},
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "string.pad.width",
+				},
+			},
+		},
+		"InvalidConvertMissingConvert": {
+			reason: "Convert transform missing Convert should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:    v1beta1.TransformTypeConvert,
+					Convert: nil,
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "convert",
+				},
+			},
+		},
+		"InvalidConvertUnknownFormat": {
+			reason: "Convert transform with unknown format should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						Format: &[]v1beta1.ConvertTransformFormat{"foo"}[0],
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "convert.format",
+				},
+			},
+		},
+		"InvalidConvertUnknownToType": {
+			reason: "Convert transform with unknown toType should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						ToType: v1beta1.TransformIOType("foo"),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "convert.toType",
+				},
+			},
+		},
+		"InvalidConvertTooWide": {
+			reason: "Convert transform wider than the maximum width should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						ToType: v1beta1.TransformIOTypeString,
+						Width:  ptr.To(2000000000),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "convert.width",
+				},
+			},
+		},
+		"InvalidConvertTooPrecise": {
+			reason: "Convert transform more precise than the maximum precision should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						ToType:    v1beta1.TransformIOTypeString,
+						Precision: ptr.To(2000000000),
+					},
+				},
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "convert.precision",
+				},
+			},
+		},
+		"ValidConvert": {
+			reason: "Convert transform with valid format and toType should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeConvert,
+					Convert: &v1beta1.ConvertTransform{
+						Format: &[]v1beta1.ConvertTransformFormat{v1beta1.ConvertTransformFormatNone}[0],
+						ToType: v1beta1.TransformIOTypeInt,
+					},
+				},
+			},
+		},
+		"ValidWebhook": {
+			reason: "Webhook transform with an https URL should be valid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:    v1beta1.TransformTypeWebhook,
+					Webhook: &v1beta1.WebhookTransform{URL: "https://ipam.example.org/allocate"},
+				},
+			},
+		},
+		"InvalidWebhookURL": {
+			reason: "Webhook transform with a relative URL should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type:    v1beta1.TransformTypeWebhook,
+					Webhook: &v1beta1.WebhookTransform{URL: "/allocate"},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "webhook.url",
+				},
+			},
+		},
+		"InvalidWebhookCABundle": {
+			reason: "Webhook transform with a CA bundle that contains no certificates should be invalid",
+			args: args{
+				transform: v1beta1.Transform{
+					Type: v1beta1.TransformTypeWebhook,
+					Webhook: &v1beta1.WebhookTransform{
+						URL:      "https://ipam.example.org/allocate",
+						CABundle: ptr.To("not a certificate"),
+					},
+				},
+			},
+			want: want{
+				&field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "webhook.caBundle",
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateTransform(tc.args.transform)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateTransform(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
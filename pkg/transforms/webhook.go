@@ -0,0 +1,191 @@
+package transforms
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+// maxWebhookResponseBytes is the maximum size of a webhook transform response
+// we'll read.
+const maxWebhookResponseBytes = 1 << 20
+
+// Error strings
+const (
+	errWebhookCABundle      = "cannot parse webhook CA bundle"
+	errFmtWebhookRequest    = "cannot POST to webhook %s"
+	errFmtWebhookStatus     = "webhook %s returned status %d: %s"
+	errFmtWebhookResponse   = "cannot decode response from webhook %s"
+	errFmtWebhookNoOutput   = "response from webhook %s has no output"
+	errFmtWebhookMarshalReq = "cannot encode request to webhook %s"
+)
+
+// A webhookRequest is the body POSTed to a webhook transform's endpoint.
+//
+// TODO(negz): Let webhook transforms authenticate with an API token, and let
+// other transforms and combine patches read values like salts, from named
+// variables read from Secrets. Their values must always be treated as
+// sensitive. Reading Secrets requires the Function to ask Crossplane for extra
+// resources using requirements, which the version of the Function SDK (and
+// RunFunctionRequest) we use doesn't support yet.
+type webhookRequest struct {
+	Input   any               `json:"input"`
+	Context map[string]string `json:"context,omitempty"`
+}
+
+// A webhookResponse is the body returned by a webhook transform's endpoint.
+type webhookResponse struct {
+	Output *json.RawMessage `json:"output"`
+}
+
+// ResolveWebhook resolves a Webhook transform by POSTing the input to its
+// endpoint. The request is cancelled when the supplied context is done, or
+// when the transform's timeout passes. If the transform's failure policy is
+// Ignore the input is returned unchanged when the request fails, unless it
+// failed because the supplied context is done.
+func ResolveWebhook(ctx context.Context, t *v1beta1.WebhookTransform, input any) (any, error) {
+	out, err := callWebhook(ctx, t, input)
+	if err != nil && ctx.Err() == nil && t.GetFailurePolicy() == v1beta1.WebhookFailurePolicyIgnore {
+		return input, nil
+	}
+	return out, err
+}
+
+func callWebhook(ctx context.Context, t *v1beta1.WebhookTransform, input any) (any, error) {
+	c, err := webhookClient(t)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(webhookRequest{Input: input, Context: t.Context})
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtWebhookMarshalReq, t.URL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.GetTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtWebhookRequest, t.URL)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := c.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtWebhookRequest, t.URL)
+	}
+	defer rsp.Body.Close() //nolint:errcheck // Nothing useful to do with this error.
+
+	b, err := io.ReadAll(io.LimitReader(rsp.Body, maxWebhookResponseBytes))
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtWebhookResponse, t.URL)
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, errors.Errorf(errFmtWebhookStatus, t.URL, rsp.StatusCode, bytes.TrimSpace(b))
+	}
+
+	r := &webhookResponse{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, errors.Wrapf(err, errFmtWebhookResponse, t.URL)
+	}
+	if r.Output == nil {
+		return nil, errors.Errorf(errFmtWebhookNoOutput, t.URL)
+	}
+	var out any
+	if err := json.Unmarshal(*r.Output, &out); err != nil {
+		return nil, errors.Wrapf(err, errFmtWebhookResponse, t.URL)
+	}
+	return out, nil
+}
+
+// webhookClient returns an HTTP client configured per the supplied webhook
+// transform's TLS settings. Clients are reused across calls, so their idle
+// connections are too.
+func webhookClient(t *v1beta1.WebhookTransform) (*http.Client, error) {
+	if t.CABundle == nil && (t.InsecureSkipTLSVerify == nil || !*t.InsecureSkipTLSVerify) {
+		return http.DefaultClient, nil
+	}
+	k := webhookClientKey{insecure: t.InsecureSkipTLSVerify != nil && *t.InsecureSkipTLSVerify}
+	if t.CABundle != nil {
+		k.caBundle = *t.CABundle
+	}
+	return webhookClients.Get(k)
+}
+
+// maxCachedWebhookClients is the maximum number of webhook HTTP clients we'll
+// cache. Each distinct combination of TLS settings needs its own client, and
+// they come from Compositions, so we expect relatively few. The limit just
+// guards against unbounded growth.
+const maxCachedWebhookClients = 64
+
+// webhookClients caches webhook HTTP clients across RunFunction calls.
+var webhookClients = newWebhookClientCache(maxCachedWebhookClients)
+
+// A webhookClientKey identifies the TLS settings of a webhook HTTP client.
+type webhookClientKey struct {
+	caBundle string
+	insecure bool
+}
+
+// A webhookClientCache caches webhook HTTP clients by their TLS settings. An
+// *http.Client is safe for concurrent use.
+type webhookClientCache struct {
+	mu    sync.RWMutex
+	cache map[webhookClientKey]*http.Client
+	size  int
+}
+
+func newWebhookClientCache(size int) *webhookClientCache {
+	return &webhookClientCache{cache: make(map[webhookClientKey]*http.Client), size: size}
+}
+
+// Get returns an HTTP client with the supplied TLS settings, creating it only
+// if it isn't already cached. Settings with an invalid CA bundle aren't cached.
+func (c *webhookClientCache) Get(k webhookClientKey) (*http.Client, error) {
+	c.mu.RLock()
+	hc, ok := c.cache[k]
+	c.mu.RUnlock()
+	if ok {
+		return hc, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: k.insecure} //nolint:gosec // Explicitly requested by the user.
+	if k.caBundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(k.caBundle)) {
+			return nil, errors.New(errWebhookCABundle)
+		}
+		cfg.RootCAs = pool
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hc, ok := c.cache[k]; ok {
+		// Another call created a client while we weren't holding the lock.
+		return hc, nil
+	}
+	if len(c.cache) >= c.size {
+		// Start over rather than tracking which clients were least recently
+		// used. This should rarely, if ever, happen. Close the idle
+		// connections of the clients we drop so they don't leak.
+		for _, old := range c.cache {
+			old.CloseIdleConnections()
+		}
+		c.cache = make(map[webhookClientKey]*http.Client)
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // DefaultTransport is always an *http.Transport.
+	tr.TLSClientConfig = cfg
+	hc = &http.Client{Transport: tr}
+	c.cache[k] = hc
+	return hc, nil
+}
@@ -0,0 +1,222 @@
+package transforms
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestResolveWebhook(t *testing.T) {
+	// The endpoint echoes the request's input and context.
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		req := &webhookRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"output": map[string]any{"input": req.Input, "context": req.Context}})
+	}
+	fail := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("try again later\n"))
+	}
+	empty := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type args struct {
+		ctx     context.Context
+		handler http.HandlerFunc
+		tls     bool
+		t       *v1beta1.WebhookTransform
+		input   any
+	}
+	type want struct {
+		out any
+		// err returns the expected error given the endpoint's URL.
+		err func(url string) error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Success": {
+			reason: "We should return the output of the endpoint.",
+			args: args{
+				handler: echo,
+				t: &v1beta1.WebhookTransform{
+					Context: map[string]string{"pool": "cool-pool"},
+				},
+				input: "cool-input",
+			},
+			want: want{
+				out: map[string]any{"input": "cool-input", "context": map[string]any{"pool": "cool-pool"}},
+			},
+		},
+		"TLS": {
+			reason: "We should verify the endpoint's certificate using the CA bundle.",
+			args: args{
+				handler: echo,
+				tls:     true,
+				t:       &v1beta1.WebhookTransform{},
+				input:   "cool-input",
+			},
+			want: want{
+				out: map[string]any{"input": "cool-input", "context": nil},
+			},
+		},
+		"ErrorStatus": {
+			reason: "We should return an error if the endpoint returns a non-2xx status.",
+			args: args{
+				handler: fail,
+				t:       &v1beta1.WebhookTransform{},
+				input:   "cool-input",
+			},
+			want: want{
+				err: func(url string) error {
+					return errors.Errorf(errFmtWebhookStatus, url, http.StatusServiceUnavailable, []byte("try again later"))
+				},
+			},
+		},
+		"NoOutput": {
+			reason: "We should return an error if the response has no output.",
+			args: args{
+				handler: empty,
+				t:       &v1beta1.WebhookTransform{},
+				input:   "cool-input",
+			},
+			want: want{
+				err: func(url string) error { return errors.Errorf(errFmtWebhookNoOutput, url) },
+			},
+		},
+		"Cancelled": {
+			reason: "We should not call the endpoint if the context is done.",
+			args: args{
+				ctx:     cancelled,
+				handler: echo,
+				t:       &v1beta1.WebhookTransform{},
+				input:   "cool-input",
+			},
+			want: want{
+				err: func(url string) error {
+					return errors.Wrapf(errors.Errorf("Post %q: %s", url, context.Canceled), errFmtWebhookRequest, url)
+				},
+			},
+		},
+		"CancelledIgnoreFailure": {
+			reason: "We should return an error if the context is done, even if the failure policy is Ignore.",
+			args: args{
+				ctx:     cancelled,
+				handler: echo,
+				t: &v1beta1.WebhookTransform{
+					FailurePolicy: ptr.To(v1beta1.WebhookFailurePolicyIgnore),
+				},
+				input: "cool-input",
+			},
+			want: want{
+				err: func(url string) error {
+					return errors.Wrapf(errors.Errorf("Post %q: %s", url, context.Canceled), errFmtWebhookRequest, url)
+				},
+			},
+		},
+		"IgnoreFailure": {
+			reason: "We should return the input unchanged if the request fails and the failure policy is Ignore.",
+			args: args{
+				handler: fail,
+				t: &v1beta1.WebhookTransform{
+					FailurePolicy: ptr.To(v1beta1.WebhookFailurePolicyIgnore),
+				},
+				input: "cool-input",
+			},
+			want: want{
+				out: "cool-input",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var srv *httptest.Server
+			if tc.args.tls {
+				srv = httptest.NewTLSServer(tc.args.handler)
+				ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+				tc.args.t.CABundle = ptr.To(string(ca))
+			} else {
+				srv = httptest.NewServer(tc.args.handler)
+			}
+			defer srv.Close()
+			tc.args.t.URL = srv.URL
+
+			var want error
+			if tc.want.err != nil {
+				want = tc.want.err(srv.URL)
+			}
+
+			ctx := tc.args.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			got, err := ResolveWebhook(ctx, tc.args.t, tc.args.input)
+			if diff := cmp.Diff(tc.want.out, got); diff != "" {
+				t.Errorf("%s\nResolveWebhook(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nResolveWebhook(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWebhookClientCacheGet(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ca := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+
+	c := newWebhookClientCache(2)
+
+	a, err := c.Get(webhookClientKey{caBundle: ca})
+	if err != nil {
+		t.Fatalf("Get(...): %v", err)
+	}
+	again, err := c.Get(webhookClientKey{caBundle: ca})
+	if err != nil {
+		t.Fatalf("Get(...): %v", err)
+	}
+	if a != again {
+		t.Errorf("Get(...): want cached client, got a new one")
+	}
+
+	if _, err := c.Get(webhookClientKey{caBundle: "not-a-ca"}); err == nil {
+		t.Errorf("Get(...): want error parsing an invalid CA bundle")
+	}
+	if diff := cmp.Diff(1, len(c.cache)); diff != "" {
+		t.Errorf("Get(...): invalid CA bundles should not be cached: -want, +got:\n%s", diff)
+	}
+
+	// Exceeding the maximum size should reset the cache.
+	for _, k := range []webhookClientKey{{caBundle: ca, insecure: true}, {insecure: true}} {
+		if _, err := c.Get(k); err != nil {
+			t.Fatalf("Get(...): %v", err)
+		}
+	}
+	if diff := cmp.Diff(1, len(c.cache)); diff != "" {
+		t.Errorf("Get(...): -want cache size, +got cache size:\n%s", diff)
+	}
+}
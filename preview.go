@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+)
+
+// PreviewPath is the HTTP path at which the Function serves previews of the
+// resources it would render.
+const PreviewPath = "/preview"
+
+// maxPreviewRequestBytes is the maximum size of a preview request we'll read.
+const maxPreviewRequestBytes = 4 << 20
+
+// Error strings
+const (
+	errServePreview       = "cannot serve HTTP previews"
+	errDecodePreview      = "cannot decode preview request"
+	errPreviewNoComposite = "preview request must include a composite resource"
+	errPreviewNoInput     = "preview request must include the Function's input"
+	errFmtPreviewMethod   = "preview requests must use method %s"
+	errFmtPreviewContext  = "cannot convert context key %q to protobuf Value well-known type"
+)
+
+// A PreviewRequest asks the Function to render the supplied input.
+type PreviewRequest struct {
+	// Composite resource (XR) to render.
+	Composite map[string]any `json:"composite"`
+
+	// Input of the Function, i.e. a Resources object.
+	Input map[string]any `json:"input"`
+
+	// Observed composed resources. Each must be annotated with the name of
+	// the resource template that produced it.
+	Observed []map[string]any `json:"observed,omitempty"`
+
+	// Context of the pipeline, for example the Composition environment
+	// under the apiextensions.crossplane.io/environment key.
+	Context map[string]any `json:"context,omitempty"`
+}
+
+// A PreviewResponse contains the resources the Function rendered, and a trace
+// of each patch it applied while rendering them.
+type PreviewResponse struct {
+	// Composite resource the Function desires.
+	Composite map[string]any `json:"composite,omitempty"`
+
+	// Resources the Function desires, by resource template name.
+	Resources map[string]map[string]any `json:"resources,omitempty"`
+
+	// Results the Function returned.
+	Results []PreviewResult `json:"results,omitempty"`
+
+	// Traces of each patch the Function applied, in the order it applied
+	// them.
+	Traces []PatchTrace `json:"traces,omitempty"`
+}
+
+// A PreviewResult is a result returned by the Function.
+type PreviewResult struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// A PatchTrace describes a patch the Function applied. It includes the patch's
+// type and index, the values it read and wrote, and context like the name of
+// the resource template it belongs to. Sensitive values are redacted.
+type PatchTrace map[string]any
+
+// NewPreviewHandler returns an HTTP handler that renders the input POSTed to
+// it and responds with the rendered resources. It's intended to let tools like
+// IDE plugins preview a Composition while it's being written, and isn't meant
+// to be exposed to untrusted clients.
+func NewPreviewHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, fmt.Sprintf(errFmtPreviewMethod, http.MethodPost), http.StatusMethodNotAllowed)
+			return
+		}
+
+		pr := &PreviewRequest{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreviewRequestBytes)).Decode(pr); err != nil {
+			http.Error(w, errors.Wrap(err, errDecodePreview).Error(), http.StatusBadRequest)
+			return
+		}
+
+		rsp, err := Preview(r.Context(), pr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rsp)
+	})
+}
+
+// Preview renders the supplied preview request. It only returns an error if
+// the request is invalid. Failures to render the input are returned as
+// results, like they would be by the Function.
+func Preview(ctx context.Context, pr *PreviewRequest) (*PreviewResponse, error) {
+	if len(pr.Composite) == 0 {
+		return nil, errors.New(errPreviewNoComposite)
+	}
+	if len(pr.Input) == 0 {
+		return nil, errors.New(errPreviewNoInput)
+	}
+
+	observed := make([]*unstructured.Unstructured, len(pr.Observed))
+	for i := range pr.Observed {
+		observed[i] = &unstructured.Unstructured{Object: pr.Observed[i]}
+	}
+	req, err := NewRenderRequest(&unstructured.Unstructured{Object: pr.Composite}, observed, &unstructured.Unstructured{Object: pr.Input})
+	if err != nil {
+		return nil, err
+	}
+	req.Meta.Tag = "preview"
+	if len(pr.Context) > 0 {
+		req.Context = &structpb.Struct{Fields: make(map[string]*structpb.Value, len(pr.Context))}
+		for k, v := range pr.Context {
+			sv, err := structpb.NewValue(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, errFmtPreviewContext, k)
+			}
+			req.Context.Fields[k] = sv
+		}
+	}
+
+	// Process one resource template at a time, so traces are recorded in
+	// the order the patches are defined.
+	t := &traceLogger{traces: &patchTraces{}}
+	f := &Function{log: t, debugPatches: true, maxConcurrency: 1}
+	rsp, err := f.RunFunction(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, errRunFunction)
+	}
+
+	out := &PreviewResponse{
+		Composite: rsp.GetDesired().GetComposite().GetResource().AsMap(),
+		Resources: make(map[string]map[string]any, len(rsp.GetDesired().GetResources())),
+		Results:   make([]PreviewResult, len(rsp.GetResults())),
+		Traces:    t.traces.list(),
+	}
+	for name, cd := range rsp.GetDesired().GetResources() {
+		out.Resources[name] = cd.GetResource().AsMap()
+	}
+	for i, res := range rsp.GetResults() {
+		out.Results[i] = PreviewResult{Severity: res.GetSeverity().String(), Message: res.GetMessage()}
+	}
+	return out, nil
+}
+
+// ServePreview serves previews of rendered resources at the supplied address.
+// Blocks until the server returns an error.
+func ServePreview(address string) error {
+	mux := http.NewServeMux()
+	mux.Handle(PreviewPath, NewPreviewHandler())
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return errors.Wrap(srv.ListenAndServe(), errServePreview)
+}
+
+// patchTraces are recorded by a traceLogger and its descendants.
+type patchTraces struct {
+	mu     sync.Mutex
+	traces []PatchTrace
+}
+
+func (p *patchTraces) add(t PatchTrace) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.traces = append(p.traces, t)
+}
+
+func (p *patchTraces) list() []PatchTrace {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PatchTrace(nil), p.traces...)
+}
+
+// A traceLogger records the patches logged by debugPatch as traces, and
+// discards all other logs.
+type traceLogger struct {
+	traces *patchTraces
+	values []any
+}
+
+func (l *traceLogger) Info(_ string, _ ...any) {}
+
+func (l *traceLogger) Debug(msg string, keysAndValues ...any) {
+	if msg != msgAppliedPatch {
+		return
+	}
+	t := PatchTrace{}
+	kv := append(append([]any{}, l.values...), keysAndValues...)
+	for i := 0; i+1 < len(kv); i += 2 {
+		t[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+	l.traces.add(t)
+}
+
+func (l *traceLogger) WithValues(keysAndValues ...any) logging.Logger {
+	return &traceLogger{traces: l.traces, values: append(append([]any{}, l.values...), keysAndValues...)}
+}
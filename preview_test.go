@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPreviewHandler(t *testing.T) {
+	input := `{
+		"apiVersion": "pt.fn.crossplane.io/v1beta1",
+		"kind": "Resources",
+		"resources": [{
+			"name": "cool-resource",
+			"base": {"apiVersion": "example.org/v1", "kind": "CD"},
+			"patches": [{"type": "FromCompositeFieldPath", "fromFieldPath": "spec.widgets", "toFieldPath": "spec.watchers"}]
+		}]
+	}`
+
+	type args struct {
+		method string
+		body   string
+	}
+	type want struct {
+		status int
+		rsp    *PreviewResponse
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"WrongMethod": {
+			reason: "We should only accept POST requests.",
+			args: args{
+				method: http.MethodGet,
+			},
+			want: want{
+				status: http.StatusMethodNotAllowed,
+			},
+		},
+		"InvalidBody": {
+			reason: "We should return a bad request status if we can't decode the request.",
+			args: args{
+				method: http.MethodPost,
+				body:   `{`,
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+		"NoComposite": {
+			reason: "We should return a bad request status if the request doesn't include an XR.",
+			args: args{
+				method: http.MethodPost,
+				body:   `{"input":` + input + `}`,
+			},
+			want: want{
+				status: http.StatusBadRequest,
+			},
+		},
+		"Success": {
+			reason: "We should return the rendered resources, and a trace of each patch.",
+			args: args{
+				method: http.MethodPost,
+				body:   `{"composite":{"apiVersion":"example.org/v1","kind":"XR","metadata":{"name":"cool-xr"},"spec":{"widgets":"10"}},"input":` + input + `}`,
+			},
+			want: want{
+				status: http.StatusOK,
+				rsp: &PreviewResponse{
+					Composite: map[string]any{"apiVersion": "example.org/v1", "kind": "XR"},
+					Resources: map[string]map[string]any{
+						"cool-resource": {"apiVersion": "example.org/v1", "kind": "CD", "spec": map[string]any{"watchers": "10"}},
+					},
+					Traces: []PatchTrace{{
+						"tag":                    "preview",
+						"xr-version":             "example.org/v1",
+						"xr-kind":                "XR",
+						"xr-name":                "cool-xr",
+						"composition-name":       "",
+						"step-name":              "",
+						"resource-template-name": "cool-resource",
+						"patch-type":             "FromCompositeFieldPath",
+						"patch-index":            float64(0),
+						"from-field-path":        "spec.widgets",
+						"from-value":             "10",
+						"to-field-path":          "spec.watchers",
+						"to-value":               "10",
+					}},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewPreviewHandler().ServeHTTP(rec, httptest.NewRequest(tc.args.method, PreviewPath, strings.NewReader(tc.args.body)))
+
+			if diff := cmp.Diff(tc.want.status, rec.Code); diff != "" {
+				t.Fatalf("%s\nServeHTTP(...): -want status, +got status:\n%s\n%s", tc.reason, diff, rec.Body.String())
+			}
+			if tc.want.rsp == nil {
+				return
+			}
+
+			got := &PreviewResponse{}
+			if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+				t.Fatalf("json.Unmarshal(...): %v", err)
+			}
+			if diff := cmp.Diff(tc.want.rsp, got); diff != "" {
+				t.Errorf("%s\nServeHTTP(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -1,25 +1,57 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/json"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 
 	"github.com/crossplane/function-sdk-go/resource/composed"
 	"github.com/crossplane/function-sdk-go/resource/composite"
 
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/patch"
 )
 
+// AnnotationKeyMemoizedPatches is the annotation of a composed resource that
+// records the hash of each memoized patch to the composed resource, by the
+// patch's toFieldPath. The function's context doesn't survive between
+// reconciles, so the hashes are stored on the composed resource itself.
+const AnnotationKeyMemoizedPatches = "pt.fn.crossplane.io/memoized-patches"
+
+// AnnotationKeyFieldProvenance is the annotation of a composed resource that
+// records which patch last wrote each field of the composed resource, by the
+// patch's toFieldPath.
+const AnnotationKeyFieldProvenance = "pt.fn.crossplane.io/field-provenance"
+
+// redacted replaces sensitive values in debug logs.
+const redacted = "(redacted)"
+
 // Error strings
 const (
-	errUnmarshalJSON = "cannot unmarshal JSON data"
+	errUnmarshalJSON         = "cannot unmarshal JSON data"
+	errSetProviderConfigName = "cannot set ProviderConfig name"
+	errHashSources           = "cannot hash patch source values"
+	errMemoizePatch          = "cannot record memoized patch hash"
 
-	errFmtKindChanged     = "cannot change the kind of a composed resource from %s to %s (possible composed resource template mismatch)"
-	errFmtNamePrefixLabel = "cannot find top-level composite resource name label %q in composite resource metadata"
+	errFmtKindChanged        = "cannot change the kind of a composed resource from %s to %s (possible composed resource template mismatch)"
+	errFmtNamePrefixLabel    = "cannot find top-level composite resource name label %q in composite resource metadata"
+	errFmtProviderConfigName = "cannot read ProviderConfig name from field %q"
+	errFmtNamespaceNotString = "field %q of the composite resource must be a string, not %T"
 
 	// TODO(negz): Include more detail such as field paths if they exist.
 	// Perhaps require each patch type to have a String() method to help
@@ -27,7 +59,13 @@ const (
 	errFmtPatch = "cannot apply the %q patch at index %d"
 )
 
-// RenderFromJSON renders the supplied resource from JSON bytes.
+// RenderFromJSON renders the supplied resource from JSON bytes. Fields that
+// aren't part of the resource's schema and null values are preserved. Integers
+// are decoded as int64 and other numbers as float64, so an integer in a base
+// template stays an integer. Note that key ordering, and the distinction
+// between e.g. 1 and 1.0, can't survive being sent to Crossplane, because
+// composed resources are sent as protobuf Struct messages, which represent
+// objects as maps and all numbers as doubles.
 func RenderFromJSON(o resource.Object, data []byte) error {
 	gvk := o.GetObjectKind().GroupVersionKind()
 	name := o.GetName()
@@ -60,43 +98,211 @@ func RenderFromJSON(o resource.Object, data []byte) error {
 	return nil
 }
 
+// RenderPropagatedMetadata copies the labels and annotations of the supplied
+// XR that match the supplied propagation config to the supplied composed
+// resource. It doesn't overwrite labels or annotations the composed resource
+// already has.
+func RenderPropagatedMetadata(xr, cd resource.Object, p *v1beta1.Propagate) {
+	if p == nil {
+		return
+	}
+	if l := propagate(xr.GetLabels(), cd.GetLabels(), p.LabelPrefixes); l != nil {
+		cd.SetLabels(l)
+	}
+	if a := propagate(xr.GetAnnotations(), cd.GetAnnotations(), p.AnnotationPrefixes); a != nil {
+		cd.SetAnnotations(a)
+	}
+}
+
+// propagate returns dst with any entries of src whose key starts with one of
+// the supplied prefixes added. It returns nil if no entries were added.
+func propagate(src, dst map[string]string, prefixes []string) map[string]string {
+	var out map[string]string
+	for k, v := range src {
+		if _, ok := dst[k]; ok || !hasAnyPrefix(k, prefixes) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(dst)+1)
+			for dk, dv := range dst {
+				out[dk] = dv
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderNamespace sets the namespace of the supplied composed resource to the
+// namespace determined by the supplied config. It does nothing if the config
+// is nil.
+func RenderNamespace(xr *composite.Unstructured, cd resource.Object, n *v1beta1.ComposedNamespace) error {
+	if n == nil {
+		return nil
+	}
+	if n.FromFieldPath != nil {
+		v, err := xr.GetValue(*n.FromFieldPath)
+		if err != nil && !fieldpath.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			ns, ok := v.(string)
+			if !ok {
+				return errors.Errorf(errFmtNamespaceNotString, *n.FromFieldPath, v)
+			}
+			cd.SetNamespace(ns)
+			return nil
+		}
+	}
+	if n.Value != nil {
+		cd.SetNamespace(*n.Value)
+	}
+	return nil
+}
+
+// RenderProviderConfigRef sets spec.providerConfigRef.name of the supplied
+// composed resource to the name read from the supplied XR or environment. It
+// does nothing if the supplied config is nil or its source field doesn't
+// exist.
+func RenderProviderConfigRef(xr, env runtime.Object, cd *composed.Unstructured, r *v1beta1.ProviderConfigRef) error {
+	if r == nil {
+		return nil
+	}
+
+	from, path := xr, ""
+	switch {
+	case r.FromFieldPath != nil:
+		path = *r.FromFieldPath
+	case r.FromEnvironmentFieldPath != nil:
+		from, path = env, *r.FromEnvironmentFieldPath
+	default:
+		return nil
+	}
+
+	p, err := fieldpaths.Pave(from)
+	if err != nil {
+		return err
+	}
+	name, err := p.GetString(path)
+	if fieldpath.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, errFmtProviderConfigName, path)
+	}
+	return errors.Wrap(cd.SetValue("spec.providerConfigRef.name", name), errSetProviderConfigName)
+}
+
 // RenderEnvironmentPatches renders the supplied environment by applying all
-// patches that are to the environment, from the supplied XR.
-func RenderEnvironmentPatches(env *unstructured.Unstructured, oxr, dxr *composite.Unstructured, ps []v1beta1.EnvironmentPatch) error {
-	for i, p := range ps {
-		p := p
+// patches that are to the environment, from the supplied XR. If debug is not
+// nil each patch that is applied is logged to it.
+func RenderEnvironmentPatches(ctx context.Context, env *unstructured.Unstructured, oxr, dxr *composite.Unstructured, ps []v1beta1.EnvironmentPatch, debug logging.Logger) error {
+	for i := range ps {
+		p := &ps[i]
 		switch p.Type {
 		case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment:
-			if err := ApplyToObjects(&p, env, oxr); err != nil {
+			if err := patch.ApplyToObjects(ctx, p, env, oxr); err != nil {
 				return errors.Wrapf(err, errFmtPatch, p.Type, i)
 			}
+			debugPatch(debug, p, i, env, oxr)
 		case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
-			if err := ApplyToObjects(&p, env, dxr); err != nil {
+			if err := patch.ApplyToObjects(ctx, p, env, dxr); err != nil {
 				return errors.Wrapf(err, errFmtPatch, p.Type, i)
 			}
-		case v1beta1.PatchTypePatchSet, v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
+			debugPatch(debug, p, i, env, dxr)
+		case v1beta1.PatchTypePatchSet, v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeConditionToComposite:
 			// nothing to do
 		}
 	}
 	return nil
 }
 
+// RenderCompositePatches renders the supplied desired composite resource by
+// applying all patches from the supplied observed composite resource and
+// environment in the order they were defined. If debug is not nil each patch
+// that is applied is logged to it.
+func RenderCompositePatches(ctx context.Context, env *unstructured.Unstructured, oxr, dxr *composite.Unstructured, ps []v1beta1.CompositePatch, debug logging.Logger) error {
+	for i := range ps {
+		p := &ps[i]
+		switch p.GetType() { //nolint:exhaustive // Only these patch types are valid.
+		case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite:
+			if err := patch.ApplyToObjects(ctx, p, oxr, dxr); err != nil {
+				return errors.Wrapf(err, errFmtPatch, p.GetType(), i)
+			}
+			debugPatch(debug, p, i, oxr, dxr)
+		case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
+			if err := patch.ApplyToObjects(ctx, p, env, dxr); err != nil {
+				return errors.Wrapf(err, errFmtPatch, p.GetType(), i)
+			}
+			debugPatch(debug, p, i, env, dxr)
+		}
+	}
+	return nil
+}
+
 // RenderComposedPatches renders the supplied composed resource by applying all
-// patches that are to or from the supplied composite resource and environment
-// in the order they were defined. Properly selecting the right source or
-// destination between observed and desired resources.
+// patches of the supplied stage that are to or from the supplied composite
+// resource and environment in the order they were defined. Properly selecting
+// the right source or destination between observed and desired resources. If
+// debug is not nil each patch that is applied is logged to it. If prov is not
+// nil each patch that writes to the desired composed resource is recorded in
+// it. Values patched to the desired composed resource are accumulated and
+// written in one pass, unless debug is not nil or a patch's policy depends on
+// the resource's current value.
 func RenderComposedPatches( //nolint:gocyclo // just a switch
+	ctx context.Context,
 	ocd *composed.Unstructured,
 	dcd *composed.Unstructured,
 	oxr *composite.Unstructured,
 	dxr *composite.Unstructured,
 	env *unstructured.Unstructured,
+	conn managed.ConnectionDetails,
 	ps []v1beta1.ComposedPatch,
+	stage v1beta1.PatchStage,
+	debug logging.Logger,
+	prov FieldProvenance,
 ) (errs []error, store bool) {
-	for i, p := range ps {
-		p := p
+	w := &patch.Batch{}
+
+	// add accumulates the value of the supplied patch. If the patch fails the
+	// values of earlier patches are written first, so the error is the same
+	// as if each patch had been applied in turn.
+	add := func(p *v1beta1.ComposedPatch, i int, from runtime.Object) error {
+		err := w.Add(ctx, p, i, from)
+		if err == nil {
+			return nil
+		}
+		if werr := w.Write(dcd); werr != nil {
+			return werr
+		}
+		return errors.Wrapf(err, errFmtPatch, p.GetType(), i)
+	}
+
+	for i := range ps {
+		p := &ps[i]
+		if p.GetStage() != stage {
+			continue
+		}
+		// Write the accumulated values before applying any other patch, so
+		// patches take effect (and fail) in the order they were defined.
+		batch := debug == nil && patch.Batchable(p)
+		if !batch {
+			if err := w.Write(dcd); err != nil {
+				errs = append(errs, err)
+				return errs, false
+			}
+		}
 		switch t := p.Type; t {
-		case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite:
+		case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeConditionToComposite:
 			// TODO(negz): Should failures to patch the XR be terminal? It could
 			// indicate a required patch failed. A required patch means roughly
 			// "this patch has to succeed before you mutate the resource". This
@@ -111,9 +317,11 @@ func RenderComposedPatches( //nolint:gocyclo // just a switch
 			if ocd == nil {
 				continue
 			}
-			if err := ApplyToObjects(&p, dxr, ocd); err != nil {
+			if err := patch.ApplyToObjects(ctx, p, dxr, ocd); err != nil {
 				errs = append(errs, errors.Wrapf(err, errFmtPatch, t, i))
+				continue
 			}
+			debugPatch(debug, p, i, dxr, ocd)
 		case v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment:
 			// TODO(negz): Same as above, but for the Environment. What does it
 			// mean for a required patch to the environment to fail? Should it
@@ -124,28 +332,406 @@ func RenderComposedPatches( //nolint:gocyclo // just a switch
 			if ocd == nil {
 				continue
 			}
-			if err := ApplyToObjects(&p, env, ocd); err != nil {
+			if err := patch.ApplyToObjects(ctx, p, env, ocd); err != nil {
 				errs = append(errs, errors.Wrapf(err, errFmtPatch, t, i))
+				continue
+			}
+			debugPatch(debug, p, i, env, ocd)
+		case v1beta1.PatchTypeToConnectionDetail, v1beta1.PatchTypeCombineToConnectionDetail:
+			// Like patches to the XR, patches to its connection details are
+			// from the observed composed resource.
+			if ocd == nil {
+				continue
 			}
+			if err := patch.ApplyToConnectionDetails(ctx, p, ocd, conn); err != nil {
+				errs = append(errs, errors.Wrapf(err, errFmtPatch, t, i))
+				continue
+			}
+			debugPatch(debug, p, i, ocd, nil)
 		// If either of the below renderings return an error, most likely a
 		// required FromComposite or FromEnvironment patch failed. A required
 		// patch means roughly "this patch has to succeed before you mutate the
 		// resource." This is useful to make sure we never create a composed
 		// resource in the wrong state. To that end, we don't want to add this
 		// resource to our accumulated desired state.
-		case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite:
-			if err := ApplyToObjects(&p, oxr, dcd); err != nil {
+		case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCopyFromCompositeFieldPath:
+			if batch {
+				if err := add(p, i, oxr); err != nil {
+					errs = append(errs, err)
+					return errs, false
+				}
+				prov.Record(p, i, oxr, ocd)
+				continue
+			}
+			if err := applyToComposed(ctx, p, oxr, ocd, dcd); err != nil {
 				errs = append(errs, errors.Wrapf(err, errFmtPatch, t, i))
 				return errs, false
 			}
+			debugPatch(debug, p, i, oxr, dcd)
+			prov.Record(p, i, oxr, ocd)
 		case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
-			if err := ApplyToObjects(&p, env, dcd); err != nil {
+			if batch {
+				if err := add(p, i, env); err != nil {
+					errs = append(errs, err)
+					return errs, false
+				}
+				prov.Record(p, i, env, ocd)
+				continue
+			}
+			if err := applyToComposed(ctx, p, env, ocd, dcd); err != nil {
 				errs = append(errs, errors.Wrapf(err, errFmtPatch, t, i))
 				return errs, false
 			}
+			debugPatch(debug, p, i, env, dcd)
+			prov.Record(p, i, env, ocd)
 		case v1beta1.PatchTypePatchSet:
 			// Already resolved - nothing to do.
 		}
 	}
+	if err := w.Write(dcd); err != nil {
+		errs = append(errs, err)
+		return errs, false
+	}
 	return errs, true
 }
+
+// overlay returns the supplied base object with the supplied object overlaid
+// on it. Objects are merged recursively; any other value of the overlaid
+// object replaces the value of the base object.
+func overlay(base, o map[string]any) map[string]any {
+	for k, v := range o {
+		bm, bok := base[k].(map[string]any)
+		om, ook := v.(map[string]any)
+		if bok && ook {
+			base[k] = overlay(bm, om)
+			continue
+		}
+		base[k] = v
+	}
+	return base
+}
+
+// applyToComposed applies the supplied patch from the supplied object to the
+// desired composed resource. If the patch's policy is create only, the desired
+// composed resource keeps the observed value instead of being patched once the
+// composed resource exists, and if it's frozen it keeps the observed value
+// once the observed composed resource has one. If the patch is memoized, the
+// desired composed resource keeps the observed value when the patch and its
+// source values are unchanged since the observed composed resource was patched.
+func applyToComposed(ctx context.Context, p *v1beta1.ComposedPatch, from runtime.Object, ocd, dcd *composed.Unstructured) error {
+	if ocd != nil && p.GetPolicy().GetCreateOnly() {
+		return preserve(p.GetToFieldPath(), ocd, dcd)
+	}
+	if frozen(p, ocd) {
+		return preserve(p.GetToFieldPath(), ocd, dcd)
+	}
+	if !p.GetPolicy().GetMemoize() {
+		return patchComposed(ctx, p, from, ocd, dcd)
+	}
+
+	h, err := SourceHash(p, from)
+	if err != nil {
+		return errors.Wrap(err, errHashSources)
+	}
+	to := p.GetToFieldPath()
+	if ocd != nil && memoizedPatches(ocd)[to] == h {
+		err = preserve(to, ocd, dcd)
+	} else {
+		err = patchComposed(ctx, p, from, ocd, dcd)
+	}
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(memoizePatch(dcd, to, h), errMemoizePatch)
+}
+
+// patchComposed applies the supplied patch from the supplied object to the
+// desired composed resource, unless the patch's policy is to skip unchanged
+// values and the observed composed resource already has the patched value.
+func patchComposed(ctx context.Context, p *v1beta1.ComposedPatch, from runtime.Object, ocd, dcd *composed.Unstructured) error {
+	if ocd == nil || !p.GetPolicy().GetSkipUnchanged() {
+		return patch.ApplyToObjects(ctx, p, from, dcd)
+	}
+	patched := dcd.DeepCopy()
+	if err := patch.ApplyToObjects(ctx, p, from, patched); err != nil {
+		return err
+	}
+	if unchanged(p.GetToFieldPath(), patched, ocd) {
+		return nil
+	}
+	dcd.Object = patched.Object
+	return nil
+}
+
+// SourceHash returns a hash of the supplied patch and the values of its source
+// fields in the supplied object. The hash changes if either the patch (e.g.
+// its transforms) or any of its source values change. Missing source fields
+// are hashed as null.
+func SourceHash(p patch.Interface, from runtime.Object) (string, error) {
+	paved, err := fieldpaths.Pave(from)
+	if err != nil {
+		return "", err
+	}
+
+	paths := []string{p.GetFromFieldPath()}
+	if c := p.GetCombine(); c != nil {
+		paths = make([]string, len(c.Variables))
+		for i, v := range c.Variables {
+			paths[i] = v.FromFieldPath
+		}
+	}
+
+	values := make([]any, 0, len(paths))
+	for _, path := range paths {
+		expanded, err := fieldpaths.ExpandWildcards(paved, path)
+		if err != nil {
+			return "", err
+		}
+		if len(expanded) == 0 {
+			values = append(values, nil)
+			continue
+		}
+		for _, e := range expanded {
+			v, err := paved.GetValue(e)
+			if err != nil && !fieldpath.IsNotFound(err) {
+				return "", err
+			}
+			values = append(values, v)
+		}
+	}
+
+	j, err := json.Marshal(map[string]any{"patch": p, "values": values})
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(j)
+	return hex.EncodeToString(h[:8]), nil
+}
+
+// memoizedPatches returns the hashes of the memoized patches to the supplied
+// composed resource, by toFieldPath. It returns an empty map if the composed
+// resource has no (valid) hashes.
+func memoizedPatches(cd *composed.Unstructured) map[string]string {
+	hashes := map[string]string{}
+	a, ok := cd.GetAnnotations()[AnnotationKeyMemoizedPatches]
+	if !ok {
+		return hashes
+	}
+	if err := json.Unmarshal([]byte(a), &hashes); err != nil {
+		return map[string]string{}
+	}
+	return hashes
+}
+
+// memoizePatch records the supplied hash of the memoized patch to the supplied
+// field path of the supplied composed resource.
+func memoizePatch(cd *composed.Unstructured, toFieldPath, hash string) error {
+	hashes := memoizedPatches(cd)
+	hashes[toFieldPath] = hash
+	j, err := json.Marshal(hashes)
+	if err != nil {
+		return err
+	}
+	a := cd.GetAnnotations()
+	if a == nil {
+		a = map[string]string{}
+	}
+	a[AnnotationKeyMemoizedPatches] = string(j)
+	cd.SetAnnotations(a)
+	return nil
+}
+
+// FieldProvenance records which patch last wrote each field of a desired
+// composed resource, by the patch's toFieldPath. Recording to a nil
+// FieldProvenance does nothing.
+type FieldProvenance map[string]string
+
+// Record that the supplied patch, at the supplied index of its resource
+// template's patches, wrote to the desired composed resource. Patches that were
+// skipped because an optional source field was missing, or that only preserved
+// the observed value because their policy is create only or they're frozen,
+// wrote nothing and aren't recorded.
+func (fp FieldProvenance) Record(p *v1beta1.ComposedPatch, i int, from runtime.Object, ocd *composed.Unstructured) {
+	if fp == nil {
+		return
+	}
+	if ocd != nil && p.GetPolicy().GetCreateOnly() {
+		return
+	}
+	if frozen(p, ocd) {
+		return
+	}
+	if len(MissingOptionalSources(p, from)) > 0 {
+		return
+	}
+
+	src := p.GetFromFieldPath()
+	if c := p.GetCombine(); c != nil {
+		paths := make([]string, len(c.Variables))
+		for j, v := range c.Variables {
+			paths[j] = v.FromFieldPath
+		}
+		src = strings.Join(paths, ", ")
+	}
+	fp[p.GetToFieldPath()] = fmt.Sprintf("patch %d (%s from %s)", i, p.GetType(), src)
+}
+
+// Annotate the supplied composed resource with the recorded provenance. It
+// does nothing if no provenance was recorded.
+func (fp FieldProvenance) Annotate(cd *composed.Unstructured) error {
+	if len(fp) == 0 {
+		return nil
+	}
+	j, err := json.Marshal(fp)
+	if err != nil {
+		return err
+	}
+	a := cd.GetAnnotations()
+	if a == nil {
+		a = map[string]string{}
+	}
+	a[AnnotationKeyFieldProvenance] = string(j)
+	cd.SetAnnotations(a)
+	return nil
+}
+
+// frozen returns true if the supplied patch is frozen, and the supplied
+// observed composed resource already has a value at its toFieldPath.
+func frozen(p *v1beta1.ComposedPatch, ocd *composed.Unstructured) bool {
+	if ocd == nil || !p.GetPolicy().GetFreeze() {
+		return false
+	}
+	po := fieldpath.Pave(ocd.Object)
+	paths, err := fieldpaths.ExpandWildcards(po, p.GetToFieldPath())
+	if err != nil || len(paths) == 0 {
+		return false
+	}
+	for _, path := range paths {
+		if _, err := po.GetValue(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// preserve sets the supplied field path, which may contain wildcards, of the
+// desired composed resource to its value in the observed composed resource.
+// Fields the observed composed resource doesn't have aren't set.
+func preserve(fieldPath string, ocd, dcd *composed.Unstructured) error {
+	po, pd := fieldpath.Pave(ocd.Object), fieldpath.Pave(dcd.Object)
+	paths, err := fieldpaths.ExpandWildcards(po, fieldPath)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		v, err := po.GetValue(path)
+		if err != nil {
+			return err
+		}
+		if err := pd.SetValue(path, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unchanged returns true if the supplied objects have the same value at the
+// supplied field path, which may contain wildcards. Values are compared by
+// their JSON encoding, so an integer equals a float with the same value.
+func unchanged(fieldPath string, a, b *composed.Unstructured) bool {
+	pa, pb := fieldpath.Pave(a.Object), fieldpath.Pave(b.Object)
+	paths, err := fieldpaths.ExpandWildcards(pa, fieldPath)
+	if err != nil || len(paths) == 0 {
+		return false
+	}
+	for _, path := range paths {
+		va, err := pa.GetValue(path)
+		if err != nil {
+			return false
+		}
+		vb, err := pb.GetValue(path)
+		if err != nil {
+			return false
+		}
+		ja, err := json.Marshal(va)
+		if err != nil {
+			return false
+		}
+		jb, err := json.Marshal(vb)
+		if err != nil {
+			return false
+		}
+		if !bytes.Equal(ja, jb) {
+			return false
+		}
+	}
+	return true
+}
+
+// msgAppliedPatch is the message debugPatch logs for each patch.
+const msgAppliedPatch = "Applied patch"
+
+// debugPatch logs the value(s) the supplied patch read, the transforms it
+// applied, and the value it wrote. The supplied objects must be passed in the
+// same order they were passed to ApplyToObjects. Values read or written by a
+// sensitive patch are redacted. It does nothing if log is nil.
+func debugPatch(log logging.Logger, p patch.Interface, i int, a, b runtime.Object) {
+	if log == nil {
+		return
+	}
+
+	from, to := a, b
+	switch p.GetType() { //nolint:exhaustive // Only patches to the XR or environment are reversed.
+	case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeConditionToComposite, v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment:
+		from, to = b, a
+	}
+
+	value := fieldValue
+	if p.IsSensitive() {
+		value = func(runtime.Object, string) any { return redacted }
+	}
+
+	kv := []any{"patch-type", p.GetType(), "patch-index", i}
+
+	if c := p.GetCombine(); c != nil {
+		vals := make([]any, len(c.Variables))
+		for j, v := range c.Variables {
+			vals[j] = value(from, v.FromFieldPath)
+		}
+		kv = append(kv, "combine-strategy", c.Strategy, "from-values", vals)
+	} else if cp, ok := p.(patch.WithConditionSelector); ok && cp.GetCondition() != nil {
+		kv = append(kv, "condition-type", cp.GetCondition().Type, "condition-field", cp.GetCondition().GetField())
+	} else {
+		kv = append(kv, "from-field-path", p.GetFromFieldPath(), "from-value", value(from, p.GetFromFieldPath()))
+	}
+
+	if len(p.GetTransforms()) > 0 {
+		ts := make([]string, len(p.GetTransforms()))
+		for j, t := range p.GetTransforms() {
+			ts[j] = string(t.Type)
+		}
+		kv = append(kv, "transforms", ts)
+	}
+
+	if cd, ok := p.(patch.WithConnectionDetailName); ok && cd.GetConnectionDetailName() != "" {
+		kv = append(kv, "to-connection-detail", cd.GetConnectionDetailName())
+	} else {
+		kv = append(kv, "to-field-path", p.GetToFieldPath(), "to-value", value(to, p.GetToFieldPath()))
+	}
+
+	log.Debug(msgAppliedPatch, kv...)
+}
+
+// fieldValue returns the value at the supplied field path of the supplied
+// object, or nil if it can't be read.
+func fieldValue(o runtime.Object, path string) any {
+	p, err := fieldpaths.Pave(o)
+	if err != nil {
+		return nil
+	}
+	v, err := p.GetValue(path)
+	if err != nil {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// AnnotationKeyCompositionResourceName is the annotation Crossplane uses to
+// record which resource template produced a composed resource.
+const AnnotationKeyCompositionResourceName = "crossplane.io/composition-resource-name"
+
+// Error strings
+const (
+	errReadYAML          = "cannot read YAML documents"
+	errParseXR           = "cannot parse composite resource"
+	errParseInput        = "cannot parse Function input"
+	errParseObserved     = "cannot parse observed composed resources"
+	errParseNow          = "cannot parse --now as an RFC 3339 timestamp"
+	errRunFunction       = "cannot run Function"
+	errWriteRendered     = "cannot write rendered resources"
+	errWriteDiff         = "cannot write diff"
+	errFatalResult       = "Function returned a fatal result"
+	errOneCompositeOnly  = "composite resource file must contain exactly one composite resource"
+	errOneInputOnly      = "input file must contain exactly one Function input"
+	errFmtConvertToProto = "cannot convert %s to protobuf Struct well-known type"
+	errFmtNoResourceName = "observed composed resource %q is not annotated with %q"
+)
+
+// RenderCmd renders the Function's input locally.
+type RenderCmd struct {
+	CompositeResource kong.FileContentFlag `arg:"" help:"A YAML file containing the composite resource (XR) to render."`
+	Input             kong.FileContentFlag `arg:"" help:"A YAML file containing the Function's input (i.e. a Resources object)."`
+
+	ObservedResources kong.FileContentFlag `short:"o" help:"A YAML file containing observed composed resources. Each must be annotated with the name of the resource template that produced it."`
+	Diff              bool                 `help:"Print a field-level diff between observed and desired composed resources instead of the rendered resources."`
+	Now               string               `help:"Render as if the current time were this RFC 3339 timestamp, e.g. 2024-01-02T15:04:05Z. Defaults to the actual current time."`
+}
+
+// Run the render command.
+func (c *RenderCmd) Run(k *kong.Context) error {
+	ocds, err := ParseYAMLDocuments(c.ObservedResources)
+	if err != nil {
+		return errors.Wrap(err, errParseObserved)
+	}
+
+	ctx := context.Background()
+	if c.Now != "" {
+		t, err := time.Parse(time.RFC3339, c.Now)
+		if err != nil {
+			return errors.Wrap(err, errParseNow)
+		}
+		ctx = WithNow(ctx, t)
+	}
+
+	rsp, err := RenderYAML(ctx, c.CompositeResource, c.Input, c.ObservedResources)
+	for _, r := range rsp.GetResults() {
+		fmt.Fprintf(k.Stderr, "%s: %s\n", r.GetSeverity(), r.GetMessage())
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.Diff {
+		return errors.Wrap(WriteDiff(k.Stdout, ocds, rsp), errWriteDiff)
+	}
+
+	return errors.Wrap(WriteRendered(k.Stdout, rsp), errWriteRendered)
+}
+
+// RenderYAML is like Render, but parses the XR, input, and observed composed
+// resources from the supplied YAML. It returns an error if the Function
+// returns a fatal result. The response is returned along with the error so
+// that its results can be inspected.
+func RenderYAML(ctx context.Context, xr, input, observed []byte) (*fnv1beta1.RunFunctionResponse, error) {
+	xrs, err := ParseYAMLDocuments(xr)
+	if err != nil {
+		return nil, errors.Wrap(err, errParseXR)
+	}
+	if len(xrs) != 1 {
+		return nil, errors.New(errOneCompositeOnly)
+	}
+
+	ins, err := ParseYAMLDocuments(input)
+	if err != nil {
+		return nil, errors.Wrap(err, errParseInput)
+	}
+	if len(ins) != 1 {
+		return nil, errors.New(errOneInputOnly)
+	}
+
+	ocds, err := ParseYAMLDocuments(observed)
+	if err != nil {
+		return nil, errors.Wrap(err, errParseObserved)
+	}
+
+	rsp, err := Render(ctx, xrs[0], ocds, ins[0])
+	if err != nil {
+		return nil, errors.Wrap(err, errRunFunction)
+	}
+
+	for _, r := range rsp.GetResults() {
+		if r.GetSeverity() == fnv1beta1.Severity_SEVERITY_FATAL {
+			return rsp, errors.New(errFatalResult)
+		}
+	}
+
+	return rsp, nil
+}
+
+// Render runs the Function with the supplied input, using the supplied XR and
+// observed composed resources. Each observed composed resource must be
+// annotated with the name of the resource template that produced it.
+func Render(ctx context.Context, xr *unstructured.Unstructured, observed []*unstructured.Unstructured, input *unstructured.Unstructured) (*fnv1beta1.RunFunctionResponse, error) {
+	req, err := NewRenderRequest(xr, observed, input)
+	if err != nil {
+		return nil, err
+	}
+	f := &Function{log: logging.NewNopLogger()}
+	return f.RunFunction(ctx, req)
+}
+
+// NewRenderRequest returns a request to run the Function with the supplied
+// input, using the supplied XR and observed composed resources. Each observed
+// composed resource must be annotated with the name of the resource template
+// that produced it.
+func NewRenderRequest(xr *unstructured.Unstructured, observed []*unstructured.Unstructured, input *unstructured.Unstructured) (*fnv1beta1.RunFunctionRequest, error) {
+	in, err := resource.AsStruct(input)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtConvertToProto, "Function input")
+	}
+	oxr, err := resource.AsStruct(xr)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtConvertToProto, "composite resource")
+	}
+
+	ocds := make(map[string]*fnv1beta1.Resource, len(observed))
+	for _, cd := range observed {
+		name := cd.GetAnnotations()[AnnotationKeyCompositionResourceName]
+		if name == "" {
+			return nil, errors.Errorf(errFmtNoResourceName, cd.GetName(), AnnotationKeyCompositionResourceName)
+		}
+		s, err := resource.AsStruct(cd)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConvertToProto, "observed composed resource")
+		}
+		ocds[name] = &fnv1beta1.Resource{Resource: s}
+	}
+
+	return &fnv1beta1.RunFunctionRequest{
+		Meta:  &fnv1beta1.RequestMeta{Tag: "render"},
+		Input: in,
+		Observed: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{Resource: oxr},
+			Resources: ocds,
+		},
+	}, nil
+}
+
+// WriteRendered writes the desired composite resource and composed resources
+// in the supplied response to the supplied writer as a stream of YAML
+// documents. Composed resources are written in order of their names, and
+// annotated with those names.
+func WriteRendered(w io.Writer, rsp *fnv1beta1.RunFunctionResponse) error {
+	docs := []map[string]any{rsp.GetDesired().GetComposite().GetResource().AsMap()}
+
+	names := make([]string, 0, len(rsp.GetDesired().GetResources()))
+	for name := range rsp.GetDesired().GetResources() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cd := &unstructured.Unstructured{Object: rsp.GetDesired().GetResources()[name].GetResource().AsMap()}
+		meta := cd.GetAnnotations()
+		if meta == nil {
+			meta = map[string]string{}
+		}
+		meta[AnnotationKeyCompositionResourceName] = name
+		cd.SetAnnotations(meta)
+		docs = append(docs, cd.Object)
+	}
+
+	for _, d := range docs {
+		y, err := yaml.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", y); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseYAMLDocuments parses the supplied stream of YAML documents into
+// unstructured objects. Empty documents are skipped.
+func ParseYAMLDocuments(data []byte) ([]*unstructured.Unstructured, error) {
+	out := make([]*unstructured.Unstructured, 0)
+	r := kyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errReadYAML)
+		}
+		u := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &u.Object); err != nil {
+			return nil, errors.Wrap(err, errReadYAML)
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		out = append(out, u)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+func TestRender(t *testing.T) {
+	fromYAML := func(y string) *unstructured.Unstructured {
+		u, err := ParseYAMLDocuments([]byte(y))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u[0]
+	}
+
+	input := fromYAML(`
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - type: FromCompositeFieldPath
+    fromFieldPath: spec.widgets
+    toFieldPath: spec.watchers
+  - type: ToCompositeFieldPath
+    fromFieldPath: status.id
+    toFieldPath: status.id
+`)
+
+	type args struct {
+		xr       *unstructured.Unstructured
+		observed []*unstructured.Unstructured
+		input    *unstructured.Unstructured
+	}
+	type want struct {
+		desired *fnv1beta1.State
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ObservedResourceNotAnnotated": {
+			reason: "We should return an error if we can't tell which template produced an observed resource.",
+			args: args{
+				xr:       fromYAML(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+				observed: []*unstructured.Unstructured{fromYAML(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-cd"}}`)},
+				input:    input,
+			},
+			want: want{
+				err: errors.Errorf(errFmtNoResourceName, "cool-cd", AnnotationKeyCompositionResourceName),
+			},
+		},
+		"Success": {
+			reason: "We should render desired state using the supplied XR and observed composed resources.",
+			args: args{
+				xr: fromYAML(`{"apiVersion":"example.org/v1","kind":"XR","spec":{"widgets":"10"}}`),
+				observed: []*unstructured.Unstructured{fromYAML(`
+apiVersion: example.org/v1
+kind: CD
+metadata:
+  name: cool-cd
+  annotations:
+    crossplane.io/composition-resource-name: cool-resource
+status:
+  id: cool-id
+`)},
+				input: input,
+			},
+			want: want{
+				desired: &fnv1beta1.State{
+					Composite: &fnv1beta1.Resource{
+						Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR","status":{"id":"cool-id"}}`),
+					},
+					Resources: map[string]*fnv1beta1.Resource{
+						"cool-resource": {
+							Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD","metadata":{"name":"cool-cd"},"spec":{"watchers":"10"}}`),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rsp, err := Render(context.Background(), tc.args.xr, tc.args.observed, tc.args.input)
+
+			if diff := cmp.Diff(tc.want.desired, rsp.GetDesired(), protocmp.Transform()); diff != "" {
+				t.Errorf("%s\nRender(...): -want desired, +got desired:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestWriteRendered(t *testing.T) {
+	rsp := &fnv1beta1.RunFunctionResponse{
+		Desired: &fnv1beta1.State{
+			Composite: &fnv1beta1.Resource{
+				Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"XR"}`),
+			},
+			Resources: map[string]*fnv1beta1.Resource{
+				"b": {Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+				"a": {Resource: resource.MustStructJSON(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
+			},
+		},
+	}
+
+	want := `---
+apiVersion: example.org/v1
+kind: XR
+---
+apiVersion: example.org/v1
+kind: CD
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: a
+---
+apiVersion: example.org/v1
+kind: CD
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: b
+`
+
+	b := &bytes.Buffer{}
+	if err := WriteRendered(b, rsp); err != nil {
+		t.Fatalf("WriteRendered(...): %v", err)
+	}
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteRendered(...): -want, +got:\n%s", diff)
+	}
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	pkgerrors "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 
 	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	fncomposed "github.com/crossplane/function-sdk-go/resource/composed"
+	fncomposite "github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/patch"
 )
 
 func TestRenderFromJSON(t *testing.T) {
@@ -77,6 +89,28 @@ func TestRenderFromJSON(t *testing.T) {
 				}},
 			},
 		},
+		"PreserveFieldsAndNumbers": {
+			reason: "Unknown fields, null values, and the distinction between integers and floats should be preserved",
+			args: args{
+				o:    composed.New(),
+				data: []byte(`{"apiVersion": "example.org/v1", "kind": "Potato", "spec": {"unknown": {"nested": null}, "count": 3, "ratio": 0.5}}`),
+			},
+			want: want{
+				o: &composed.Unstructured{Unstructured: unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "example.org/v1",
+						"kind":       "Potato",
+						"spec": map[string]any{
+							"unknown": map[string]any{
+								"nested": nil,
+							},
+							"count": int64(3),
+							"ratio": 0.5,
+						},
+					},
+				}},
+			},
+		},
 		"ExistingComposedResource": {
 			reason: "A valid base template should apply successfully to a new (empty) composed resource",
 			args: args{
@@ -115,3 +149,835 @@ func TestRenderFromJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderPropagatedMetadata(t *testing.T) {
+	type args struct {
+		xr resource.Object
+		cd resource.Object
+		p  *v1beta1.Propagate
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   resource.Object
+	}{
+		"NoPropagation": {
+			reason: "We shouldn't copy any labels or annotations if propagation isn't configured.",
+			args: args{
+				xr: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+					"metadata": {"labels": {"example.org/team": "cool"}}
+				}`)}},
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+			},
+			want: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+		},
+		"PropagateByPrefix": {
+			reason: "We should copy labels and annotations that match a prefix, without overwriting existing ones.",
+			args: args{
+				xr: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+					"metadata": {
+						"labels": {
+							"example.org/team": "cool",
+							"example.org/owner": "xr",
+							"crossplane.io/composite": "cool-xr"
+						},
+						"annotations": {
+							"billing.example.org/cost-center": "42",
+							"crossplane.io/external-name": "cool"
+						}
+					}
+				}`)}},
+				cd: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+					"metadata": {
+						"labels": {
+							"example.org/owner": "cd"
+						}
+					}
+				}`)}},
+				p: &v1beta1.Propagate{
+					LabelPrefixes:      []string{"example.org/"},
+					AnnotationPrefixes: []string{"billing.example.org/"},
+				},
+			},
+			want: &composed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+				"metadata": {
+					"labels": {
+						"example.org/team": "cool",
+						"example.org/owner": "cd"
+					},
+					"annotations": {
+						"billing.example.org/cost-center": "42"
+					}
+				}
+			}`)}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			RenderPropagatedMetadata(tc.args.xr, tc.args.cd, tc.args.p)
+			if diff := cmp.Diff(tc.want, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRenderPropagatedMetadata(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+type debugLog struct {
+	logging.Logger
+	kv []any
+}
+
+func (l *debugLog) Debug(_ string, kv ...any) { l.kv = append(l.kv, kv...) }
+
+func TestRenderNamespace(t *testing.T) {
+	type args struct {
+		xr *fncomposite.Unstructured
+		cd resource.Object
+		n  *v1beta1.ComposedNamespace
+	}
+	type want struct {
+		cd  resource.Object
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoNamespace": {
+			reason: "We shouldn't set a namespace if none is configured.",
+			args: args{
+				xr: &fncomposite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+			},
+		},
+		"FromFieldPath": {
+			reason: "We should set the namespace read from a field of the composite resource.",
+			args: args{
+				xr: &fncomposite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{"claim": {"namespace": "cool"}}`)}},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				n:  &v1beta1.ComposedNamespace{FromFieldPath: ptr.To("claim.namespace"), Value: ptr.To("default")},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{"metadata": {"namespace": "cool"}}`)}},
+			},
+		},
+		"FallBackToValue": {
+			reason: "We should set the namespace to the value if the field doesn't exist.",
+			args: args{
+				xr: &fncomposite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				n:  &v1beta1.ComposedNamespace{FromFieldPath: ptr.To("claim.namespace"), Value: ptr.To("default")},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{"metadata": {"namespace": "default"}}`)}},
+			},
+		},
+		"NotAString": {
+			reason: "We should return an error if the field isn't a string.",
+			args: args{
+				xr: &fncomposite.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"namespace": true}}`)}},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				n:  &v1beta1.ComposedNamespace{FromFieldPath: ptr.To("spec.namespace")},
+			},
+			want: want{
+				cd:  &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				err: errors.Errorf(errFmtNamespaceNotString, "spec.namespace", true),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := RenderNamespace(tc.args.xr, tc.args.cd, tc.args.n)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderNamespace(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRenderNamespace(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderProviderConfigRef(t *testing.T) {
+	type args struct {
+		xr  runtime.Object
+		env runtime.Object
+		cd  *fncomposed.Unstructured
+		r   *v1beta1.ProviderConfigRef
+	}
+	type want struct {
+		cd  *fncomposed.Unstructured
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoProviderConfigRef": {
+			reason: "We shouldn't inject a ProviderConfig if none is configured.",
+			args: args{
+				xr: &unstructured.Unstructured{Object: MustObject(`{"spec": {"providerConfigName": "cool"}}`)},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+			},
+		},
+		"FromFieldPath": {
+			reason: "We should inject the ProviderConfig named by a field of the composite resource.",
+			args: args{
+				xr: &unstructured.Unstructured{Object: MustObject(`{"spec": {"providerConfigName": "cool"}}`)},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{"spec": {"forProvider": {}}}`)}},
+				r:  &v1beta1.ProviderConfigRef{FromFieldPath: ptr.To("spec.providerConfigName")},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+					"spec": {"forProvider": {}, "providerConfigRef": {"name": "cool"}}
+				}`)}},
+			},
+		},
+		"FromEnvironmentFieldPath": {
+			reason: "We should inject the ProviderConfig named by a field of the environment.",
+			args: args{
+				xr:  &unstructured.Unstructured{Object: MustObject(`{}`)},
+				env: &unstructured.Unstructured{Object: MustObject(`{"providerConfigName": "cool"}`)},
+				cd:  &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				r:   &v1beta1.ProviderConfigRef{FromEnvironmentFieldPath: ptr.To("providerConfigName")},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+					"spec": {"providerConfigRef": {"name": "cool"}}
+				}`)}},
+			},
+		},
+		"MissingField": {
+			reason: "We shouldn't inject a ProviderConfig if the source field doesn't exist.",
+			args: args{
+				xr: &unstructured.Unstructured{Object: MustObject(`{}`)},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				r:  &v1beta1.ProviderConfigRef{FromFieldPath: ptr.To("spec.providerConfigName")},
+			},
+			want: want{
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+			},
+		},
+		"NotAString": {
+			reason: "We should return an error if the source field isn't a string.",
+			args: args{
+				xr: &unstructured.Unstructured{Object: MustObject(`{"spec": {"providerConfigName": 42}}`)},
+				cd: &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				r:  &v1beta1.ProviderConfigRef{FromFieldPath: ptr.To("spec.providerConfigName")},
+			},
+			want: want{
+				cd:  &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{}`)}},
+				err: errors.Wrapf(errors.New("spec.providerConfigName: not a string"), errFmtProviderConfigName, "spec.providerConfigName"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := RenderProviderConfigRef(tc.args.xr, tc.args.env, tc.args.cd, tc.args.r)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderProviderConfigRef(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRenderProviderConfigRef(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDebugPatch(t *testing.T) {
+	type args struct {
+		p    patch.Interface
+		a, b runtime.Object
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []any
+	}{
+		"FromComposite": {
+			reason: "We should log the value read from the first object and written to the second.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.widgets"),
+						ToFieldPath:   ptr.To("spec.watchers"),
+						Transforms:    []v1beta1.Transform{{Type: v1beta1.TransformTypeConvert}},
+					},
+				},
+				a: &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"widgets": "10"}}},
+				b: &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"watchers": int64(10)}}},
+			},
+			want: []any{
+				"patch-type", v1beta1.PatchTypeFromCompositeFieldPath, "patch-index", 0,
+				"from-field-path", "spec.widgets", "from-value", "10",
+				"transforms", []string{"convert"},
+				"to-field-path", "spec.watchers", "to-value", int64(10),
+			},
+		},
+		"ToComposite": {
+			reason: "We should log the value read from the second object and written to the first.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeToCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("status.id"),
+					},
+				},
+				a: &unstructured.Unstructured{Object: map[string]any{"status": map[string]any{"id": "b"}}},
+				b: &unstructured.Unstructured{Object: map[string]any{"status": map[string]any{"id": "a"}}},
+			},
+			want: []any{
+				"patch-type", v1beta1.PatchTypeToCompositeFieldPath, "patch-index", 0,
+				"from-field-path", "status.id", "from-value", "a",
+				"to-field-path", "status.id", "to-value", "b",
+			},
+		},
+		"Sensitive": {
+			reason: "We should redact the values read and written by a sensitive patch.",
+			args: args{
+				p: &v1beta1.ComposedPatch{
+					Type: v1beta1.PatchTypeFromCompositeFieldPath,
+					Patch: v1beta1.Patch{
+						FromFieldPath: ptr.To("spec.password"),
+						Sensitive:     ptr.To(true),
+					},
+				},
+				a: &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"password": "hunter2"}}},
+				b: &unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"password": "hunter2"}}},
+			},
+			want: []any{
+				"patch-type", v1beta1.PatchTypeFromCompositeFieldPath, "patch-index", 0,
+				"from-field-path", "spec.password", "from-value", redacted,
+				"to-field-path", "spec.password", "to-value", redacted,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			log := &debugLog{}
+			debugPatch(log, tc.args.p, 0, tc.args.a, tc.args.b)
+			if diff := cmp.Diff(tc.want, log.kv); diff != "" {
+				t.Errorf("%s\ndebugPatch(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestApplyToComposed(t *testing.T) {
+	type args struct {
+		p    *v1beta1.ComposedPatch
+		from runtime.Object
+		ocd  *fncomposed.Unstructured
+		dcd  *fncomposed.Unstructured
+	}
+	type want struct {
+		dcd *fncomposed.Unstructured
+		err error
+	}
+
+	patch := func(skip bool) *v1beta1.ComposedPatch {
+		return &v1beta1.ComposedPatch{
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To("spec.size"),
+				ToFieldPath:   ptr.To("spec.forProvider.size"),
+				Policy:        &v1beta1.PatchPolicy{SkipUnchanged: ptr.To(skip)},
+			},
+		}
+	}
+	createOnly := &v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To("spec.size"),
+			ToFieldPath:   ptr.To("spec.forProvider.size"),
+			Policy:        &v1beta1.PatchPolicy{CreateOnly: ptr.To(true)},
+		},
+	}
+	freeze := &v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To("spec.size"),
+			ToFieldPath:   ptr.To("spec.forProvider.size"),
+			Policy:        &v1beta1.PatchPolicy{Freeze: ptr.To(true)},
+		},
+	}
+	memoize := &v1beta1.ComposedPatch{
+		Type: v1beta1.PatchTypeFromCompositeFieldPath,
+		Patch: v1beta1.Patch{
+			FromFieldPath: ptr.To("spec.size"),
+			ToFieldPath:   ptr.To("spec.forProvider.size"),
+			Policy:        &v1beta1.PatchPolicy{Memoize: ptr.To(true)},
+		},
+	}
+	xr := &unstructured.Unstructured{Object: MustObject(`{"spec":{"size":"large"}}`)}
+	cd := func(o string) *fncomposed.Unstructured {
+		return &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(o)}}
+	}
+	memoized := func(o, hash string) *fncomposed.Unstructured {
+		c := cd(o)
+		c.SetAnnotations(map[string]string{AnnotationKeyMemoizedPatches: fmt.Sprintf(`{"spec.forProvider.size":%q}`, hash)})
+		return c
+	}
+	hash, err := SourceHash(memoize, xr)
+	if err != nil {
+		t.Fatalf("SourceHash(...): %v", err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"SkipUnchangedDisabled": {
+			reason: "We should patch the desired composed resource if the patch doesn't skip unchanged values.",
+			args: args{
+				p:    patch(false),
+				from: xr,
+				ocd:  cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+			},
+		},
+		"Unchanged": {
+			reason: "We shouldn't patch the desired composed resource if the observed composed resource already has the patched value.",
+			args: args{
+				p:    patch(true),
+				from: xr,
+				ocd:  cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{}`),
+			},
+		},
+		"Changed": {
+			reason: "We should patch the desired composed resource if the observed composed resource has a different value.",
+			args: args{
+				p:    patch(true),
+				from: xr,
+				ocd:  cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+			},
+		},
+		"NotObserved": {
+			reason: "We should patch the desired composed resource if it hasn't been observed yet.",
+			args: args{
+				p:    patch(true),
+				from: xr,
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+			},
+		},
+		"CreateOnlyNotObserved": {
+			reason: "We should patch the desired composed resource if the patch is create only and it hasn't been observed yet.",
+			args: args{
+				p:    createOnly,
+				from: xr,
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+			},
+		},
+		"CreateOnlyObserved": {
+			reason: "We should keep the observed value if the patch is create only and the composed resource exists.",
+			args: args{
+				p:    createOnly,
+				from: xr,
+				ocd:  cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+			},
+		},
+		"CreateOnlyObservedWithoutField": {
+			reason: "We shouldn't patch the desired composed resource if the patch is create only and the existing composed resource doesn't have the field.",
+			args: args{
+				p:    createOnly,
+				from: xr,
+				ocd:  cd(`{"spec":{}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{}`),
+			},
+		},
+		"FrozenWritten": {
+			reason: "We should keep the observed value if the patch is frozen and the observed composed resource has the field.",
+			args: args{
+				p:    freeze,
+				from: xr,
+				ocd:  cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+			},
+		},
+		"FrozenNotWritten": {
+			reason: "We should patch the desired composed resource if the patch is frozen but the observed composed resource doesn't have the field yet.",
+			args: args{
+				p:    freeze,
+				from: xr,
+				ocd:  cd(`{"spec":{}}`),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+			},
+		},
+		"MemoizedUnchanged": {
+			reason: "We should keep the observed value if a memoized patch and its source values haven't changed.",
+			args: args{
+				p:    memoize,
+				from: xr,
+				ocd:  memoized(`{"spec":{"forProvider":{"size":"cached"}}}`, hash),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: memoized(`{"spec":{"forProvider":{"size":"cached"}}}`, hash),
+			},
+		},
+		"MemoizedChanged": {
+			reason: "We should patch the desired composed resource and record the new hash if a memoized patch or its source values changed.",
+			args: args{
+				p:    memoize,
+				from: xr,
+				ocd:  memoized(`{"spec":{"forProvider":{"size":"cached"}}}`, "stale"),
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: memoized(`{"spec":{"forProvider":{"size":"large"}}}`, hash),
+			},
+		},
+		"MemoizedNotObserved": {
+			reason: "We should patch the desired composed resource and record the hash if it hasn't been observed yet.",
+			args: args{
+				p:    memoize,
+				from: xr,
+				dcd:  cd(`{}`),
+			},
+			want: want{
+				dcd: memoized(`{"spec":{"forProvider":{"size":"large"}}}`, hash),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := applyToComposed(context.Background(), tc.args.p, tc.args.from, tc.args.ocd, tc.args.dcd)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\napplyToComposed(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.dcd, tc.args.dcd); diff != "" {
+				t.Errorf("%s\napplyToComposed(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestFieldProvenance(t *testing.T) {
+	type args struct {
+		ps  []v1beta1.ComposedPatch
+		ocd *fncomposed.Unstructured
+	}
+	type want struct {
+		dcd *fncomposed.Unstructured
+		err error
+	}
+
+	from := func(path string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To(path),
+				ToFieldPath:   ptr.To("spec.forProvider.size"),
+			},
+		}
+	}
+	xr := &unstructured.Unstructured{Object: MustObject(`{"spec":{"size":"large","region":"us-east-2"}}`)}
+	cd := func(o string) *fncomposed.Unstructured {
+		return &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(o)}}
+	}
+	annotated := func(o, prov string) *fncomposed.Unstructured {
+		c := cd(o)
+		c.SetAnnotations(map[string]string{AnnotationKeyFieldProvenance: prov})
+		return c
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"LastPatchWins": {
+			reason: "We should record the last patch that wrote a field.",
+			args: args{
+				ps: []v1beta1.ComposedPatch{from("spec.size"), from("spec.region")},
+			},
+			want: want{
+				dcd: annotated(`{"spec":{"forProvider":{"size":"us-east-2"}}}`, `{"spec.forProvider.size":"patch 1 (FromCompositeFieldPath from spec.region)"}`),
+			},
+		},
+		"SkippedPatch": {
+			reason: "We shouldn't record a patch that was skipped because its optional source field doesn't exist.",
+			args: args{
+				ps: []v1beta1.ComposedPatch{from("spec.size"), from("spec.nonexistent")},
+			},
+			want: want{
+				dcd: annotated(`{"spec":{"forProvider":{"size":"large"}}}`, `{"spec.forProvider.size":"patch 0 (FromCompositeFieldPath from spec.size)"}`),
+			},
+		},
+		"CreateOnlyObserved": {
+			reason: "We shouldn't record a create only patch to a composed resource that exists, because it only preserves the observed value.",
+			args: args{
+				ps: []v1beta1.ComposedPatch{func() v1beta1.ComposedPatch {
+					p := from("spec.size")
+					p.Policy = &v1beta1.PatchPolicy{CreateOnly: ptr.To(true)}
+					return p
+				}()},
+				ocd: cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"small"}}}`),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dcd := cd(`{}`)
+			oxr := &fncomposite.Unstructured{Unstructured: *xr.DeepCopy()}
+			prov := FieldProvenance{}
+			RenderComposedPatches(context.Background(), tc.args.ocd, dcd, oxr, oxr, nil, nil, tc.args.ps, v1beta1.PatchStageDefault, nil, prov)
+			err := prov.Annotate(dcd)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nAnnotate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.dcd, dcd); diff != "" {
+				t.Errorf("%s\nRenderComposedPatches(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderComposedPatchesWrites(t *testing.T) {
+	type want struct {
+		dcd  *fncomposed.Unstructured
+		errs []error
+	}
+
+	from := func(from, to string) v1beta1.ComposedPatch {
+		return v1beta1.ComposedPatch{
+			Type: v1beta1.PatchTypeFromCompositeFieldPath,
+			Patch: v1beta1.Patch{
+				FromFieldPath: ptr.To(from),
+				ToFieldPath:   ptr.To(to),
+			},
+		}
+	}
+	xr := &unstructured.Unstructured{Object: MustObject(`{"spec":{"size":"large","count":3,"region":"us-east-2"}}`)}
+	cd := func(o string) *fncomposed.Unstructured {
+		return &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(o)}}
+	}
+
+	cases := map[string]struct {
+		reason string
+		ps     []v1beta1.ComposedPatch
+		want   want
+	}{
+		"WritesInOrder": {
+			reason: "Values should be written in the order their patches were defined, so a later patch to the same field wins.",
+			ps: []v1beta1.ComposedPatch{
+				from("spec.size", "spec.forProvider.size"),
+				from("spec.region", "spec.forProvider.size"),
+				{
+					Type: v1beta1.PatchTypeCombineFromComposite,
+					Patch: v1beta1.Patch{
+						ToFieldPath: ptr.To("spec.forProvider.name"),
+						Combine: &v1beta1.Combine{
+							Variables: []v1beta1.CombineVariable{{FromFieldPath: "spec.size"}, {FromFieldPath: "spec.count"}},
+							Strategy:  v1beta1.CombineStrategyString,
+							String:    &v1beta1.StringCombine{Format: "%s-%v"},
+						},
+					},
+				},
+				from("spec.region", "spec.forProvider.zones[1]"),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"us-east-2","name":"large-3","zones":[null,"us-east-2"]}}}`),
+			},
+		},
+		"PolicyReadsEarlierWrites": {
+			reason: "A patch whose policy depends on the existing value should see the values written by earlier patches.",
+			ps: []v1beta1.ComposedPatch{
+				from("spec.size", "spec.forProvider.count"),
+				func() v1beta1.ComposedPatch {
+					p := from("spec.count", "spec.forProvider.count")
+					p.Policy = &v1beta1.PatchPolicy{CoerceToExistingType: ptr.To(true)}
+					return p
+				}(),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"count":"3"}}}`),
+			},
+		},
+		"ParentNotAnObject": {
+			reason: "A value that can't be written should be reported as an error of the patch that produced it.",
+			ps: []v1beta1.ComposedPatch{
+				from("spec.size", "spec.forProvider"),
+				from("spec.region", "spec.forProvider.region"),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":"large"}}`),
+				errs: []error{
+					// Batched writes are applied by patches.go, which uses
+					// github.com/pkg/errors.
+					pkgerrors.Wrapf(pkgerrors.Wrap(pkgerrors.New("spec.forProvider is not an object"), "cannot patch to object"), errFmtPatch, v1beta1.PatchTypeFromCompositeFieldPath, 1),
+				},
+			},
+		},
+		"PatchFailsAfterWrites": {
+			reason: "The values of patches before a failed patch should be written.",
+			ps: []v1beta1.ComposedPatch{
+				from("spec.size", "spec.forProvider.size"),
+				func() v1beta1.ComposedPatch {
+					p := from("spec.nonexistent", "spec.forProvider.secret")
+					p.Sensitive = ptr.To(true)
+					p.Policy = &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)}
+					return p
+				}(),
+			},
+			want: want{
+				dcd: cd(`{"spec":{"forProvider":{"size":"large"}}}`),
+				errs: []error{
+					errors.Wrapf(errors.New("cannot apply sensitive patch to spec.forProvider.secret (error omitted because it may contain sensitive values)"), errFmtPatch, v1beta1.PatchTypeFromCompositeFieldPath, 1),
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dcd := cd(`{}`)
+			oxr := &fncomposite.Unstructured{Unstructured: *xr.DeepCopy()}
+			errs, _ := RenderComposedPatches(context.Background(), nil, dcd, oxr, oxr, nil, nil, tc.ps, v1beta1.PatchStageDefault, nil, nil)
+			if diff := cmp.Diff(tc.want.errs, errs, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nRenderComposedPatches(...): -want errs, +got errs:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.dcd, dcd); diff != "" {
+				t.Errorf("%s\nRenderComposedPatches(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func BenchmarkRenderComposedPatches(b *testing.B) {
+	// A big XR, patched to and from by many composed resources, to simulate
+	// rendering a large Composition.
+	params := map[string]any{}
+	for i := 0; i < 100; i++ {
+		params[fmt.Sprintf("param%d", i)] = map[string]any{
+			"name":  fmt.Sprintf("value-%d", i),
+			"tags":  []any{"a", "b", "c"},
+			"count": int64(i),
+		}
+	}
+	oxr := &fncomposite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.org/v1",
+		"kind":       "XR",
+		"metadata":   map[string]any{"name": "cool-xr"},
+		"spec":       map[string]any{"parameters": params},
+	}}}
+	ocd := &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: MustObject(`{
+		"apiVersion": "example.org/v1",
+		"kind": "Composed",
+		"status": {
+			"atProvider": {"id": "cool-id"},
+			"conditions": [
+				{"type": "Ready", "status": "True"},
+				{"type": "Synced", "status": "False", "message": "cool error"}
+			]
+		}
+	}`)}}
+
+	ps := make([]v1beta1.ComposedPatch, 0, 40)
+	for i := 0; i < 10; i++ {
+		ps = append(ps,
+			v1beta1.ComposedPatch{
+				Type: v1beta1.PatchTypeFromCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To(fmt.Sprintf("spec.parameters.param%d.name", i)),
+					ToFieldPath:   ptr.To(fmt.Sprintf("spec.forProvider.param%d", i)),
+				},
+			},
+			v1beta1.ComposedPatch{
+				Type: v1beta1.PatchTypeCombineFromComposite,
+				Patch: v1beta1.Patch{
+					ToFieldPath: ptr.To(fmt.Sprintf("spec.forProvider.combined%d", i)),
+					Combine: &v1beta1.Combine{
+						Variables: []v1beta1.CombineVariable{
+							{FromFieldPath: fmt.Sprintf("spec.parameters.param%d.name", i)},
+							{FromFieldPath: fmt.Sprintf("spec.parameters.param%d.count", i)},
+						},
+						Strategy: v1beta1.CombineStrategyString,
+						String:   &v1beta1.StringCombine{Format: "%s-%d"},
+					},
+				},
+			},
+			v1beta1.ComposedPatch{
+				Type: v1beta1.PatchTypeToCompositeFieldPath,
+				Patch: v1beta1.Patch{
+					FromFieldPath: ptr.To("status.atProvider.id"),
+					ToFieldPath:   ptr.To(fmt.Sprintf("status.ids.resource%d", i)),
+				},
+			},
+			v1beta1.ComposedPatch{
+				Type:      v1beta1.PatchTypeConditionToComposite,
+				Condition: &v1beta1.ConditionSelector{Type: "Synced"},
+				Patch: v1beta1.Patch{
+					ToFieldPath: ptr.To(fmt.Sprintf("status.errors.resource%d", i)),
+				},
+			},
+		)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		dxr := &fncomposite.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}}
+		for i := 0; i < 50; i++ {
+			dcd := &fncomposed.Unstructured{Unstructured: unstructured.Unstructured{Object: map[string]any{}}}
+			if errs, _ := RenderComposedPatches(context.Background(), ocd, dcd, oxr, dxr, nil, nil, ps, v1beta1.PatchStageDefault, nil, nil); len(errs) > 0 {
+				b.Fatal(errs)
+			}
+		}
+	}
+}
+
+func MustObject(j string) map[string]any {
+	out := map[string]any{}
+	if err := json.Unmarshal([]byte(j), &out); err != nil {
+		panic(err)
+	}
+	return out
+}
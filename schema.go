@@ -0,0 +1,83 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// The generated CRD describing our input. See input/generate.go.
+//
+//go:embed package/input/pt.fn.crossplane.io_resources.yaml
+var inputCRD []byte
+
+// The JSON Schema dialect we claim to emit. OpenAPI v3 schemas are a superset
+// of a subset of JSON Schema, but in practice editors and linters that accept
+// draft-07 handle them fine.
+const jsonSchemaDialect = "http://json-schema.org/draft-07/schema#"
+
+// Error strings
+const (
+	errUnmarshalCRD     = "cannot unmarshal input CRD"
+	errMarshalSchema    = "cannot marshal input schema"
+	errNoStorageVersion = "input CRD has no storage version"
+
+	errFmtNoSchema = "input CRD has no OpenAPI v3 schema for version %q"
+)
+
+// InputVersions returns the API versions of this Function's input that it
+// serves, e.g. pt.fn.crossplane.io/v1beta1.
+func InputVersions() ([]string, error) {
+	crd := &extv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(inputCRD, crd); err != nil {
+		return nil, errors.Wrap(err, errUnmarshalCRD)
+	}
+	versions := make([]string, 0, len(crd.Spec.Versions))
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			versions = append(versions, crd.Spec.Group+"/"+v.Name)
+		}
+	}
+	return versions, nil
+}
+
+// InputSchema returns a JSON Schema document describing this Function's input.
+// The schema is derived from the OpenAPI v3 schema of the input's generated
+// CRD, so it's only as up to date as the last run of go generate.
+func InputSchema() ([]byte, error) {
+	crd := &extv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(inputCRD, crd); err != nil {
+		return nil, errors.Wrap(err, errUnmarshalCRD)
+	}
+
+	for _, v := range crd.Spec.Versions {
+		if !v.Storage {
+			continue
+		}
+		if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+			return nil, errors.Errorf(errFmtNoSchema, v.Name)
+		}
+
+		// Round-trip through a map so we can add JSON Schema specific keys,
+		// and so that the output has a stable (sorted) key order.
+		j, err := json.Marshal(v.Schema.OpenAPIV3Schema)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalSchema)
+		}
+		s := map[string]any{}
+		if err := json.Unmarshal(j, &s); err != nil {
+			return nil, errors.Wrap(err, errMarshalSchema)
+		}
+		s["$schema"] = jsonSchemaDialect
+		s["title"] = crd.Spec.Names.Kind
+
+		out, err := json.MarshalIndent(s, "", "  ")
+		return append(out, '\n'), errors.Wrap(err, errMarshalSchema)
+	}
+
+	return nil, errors.New(errNoStorageVersion)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInputSchema(t *testing.T) {
+	s, err := InputSchema()
+	if err != nil {
+		t.Fatalf("InputSchema(): %v", err)
+	}
+
+	got := map[string]any{}
+	if err := json.Unmarshal(s, &got); err != nil {
+		t.Fatalf("InputSchema(): returned invalid JSON: %v", err)
+	}
+
+	if diff := cmp.Diff(jsonSchemaDialect, got["$schema"]); diff != "" {
+		t.Errorf("InputSchema(): -want $schema, +got $schema:\n%s", diff)
+	}
+	if diff := cmp.Diff("Resources", got["title"]); diff != "" {
+		t.Errorf("InputSchema(): -want title, +got title:\n%s", diff)
+	}
+	// Resources may be omitted if composite is set.
+	if diff := cmp.Diff(nil, got["required"]); diff != "" {
+		t.Errorf("InputSchema(): -want required, +got required:\n%s", diff)
+	}
+
+	props, ok := got["properties"].(map[string]any)
+	if !ok {
+		t.Fatalf("InputSchema(): properties is a %T, not an object", got["properties"])
+	}
+	for _, p := range []string{"apiVersion", "kind", "resources", "patchSets", "environment", "composite"} {
+		if _, ok := props[p]; !ok {
+			t.Errorf("InputSchema(): missing property %q", p)
+		}
+	}
+}
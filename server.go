@@ -0,0 +1,188 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/transforms"
+)
+
+// HealthPath is the HTTP path at which the Function serves health checks.
+const HealthPath = "/healthz"
+
+// Files loaded from the mTLS certificates directory.
+const (
+	TLSFileCert = "tls.crt"
+	TLSFileKey  = "tls.key"
+	TLSFileCA   = "ca.crt"
+)
+
+// Error strings
+const (
+	errNoCredentials  = "no credentials provided - did you specify the --insecure or --tls-server-certs-dir flags?"
+	errLoadKeyPair    = "cannot load X509 keypair"
+	errReadCA         = "cannot read CA certificate"
+	errInvalidCA      = "invalid CA certificate"
+	errStatCerts      = "cannot stat mTLS certificates"
+	errServe          = "cannot serve gRPC connections"
+	errServeHealth    = "cannot serve HTTP health checks"
+	errFmtListen      = "cannot listen for %s connections at address %q"
+	errFmtLoadTLSCert = "cannot load mTLS certificates from %q"
+)
+
+// Serve the supplied Function by creating a gRPC server and listening for
+// RunFunctionRequests. It works like the Function SDK's Serve, but supports
+// arbitrary gRPC server options. Blocks until the server returns an error.
+func Serve(fn fnv1beta1.FunctionRunnerServiceServer, network, address string, creds credentials.TransportCredentials, o ...grpc.ServerOption) error {
+	if creds == nil {
+		return errors.New(errNoCredentials)
+	}
+
+	lis, err := net.Listen(network, address)
+	if err != nil {
+		return errors.Wrapf(err, errFmtListen, network, address)
+	}
+
+	srv := grpc.NewServer(append(o, grpc.Creds(creds))...)
+	reflection.Register(srv)
+	fnv1beta1.RegisterFunctionRunnerServiceServer(srv, fn)
+
+	// The health server reports the overall server (i.e. service "") as
+	// serving by default.
+	hs := health.NewServer()
+	hs.SetServingStatus(fnv1beta1.FunctionRunnerService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+
+	return errors.Wrap(srv.Serve(lis), errServe)
+}
+
+// ServeHealth serves HTTP health checks, and information about the Function's
+// capabilities, at the supplied address. The Function is healthy as long as
+// its process is able to serve HTTP requests. Blocks until the server returns
+// an error.
+func ServeHealth(address string) error {
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           NewHealthHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return errors.Wrap(srv.ListenAndServe(), errServeHealth)
+}
+
+// NewHealthHandler returns an HTTP handler that serves health checks. It also
+// serves information about the Function's capabilities at InfoPath.
+func NewHealthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.Handle(InfoPath, NewInfoHandler(transforms.Default))
+	return mux
+}
+
+// NewMTLSCredentials returns mTLS credentials that load the server certificate
+// (tls.crt and tls.key) and the CA certificate used to authenticate clients
+// (ca.crt) from the supplied directory. The certificates are reloaded when
+// they change, so they may be rotated without restarting the Function.
+func NewMTLSCredentials(dir string) (credentials.TransportCredentials, error) {
+	r := &certReloader{dir: dir}
+	if _, err := r.Config(); err != nil {
+		return nil, errors.Wrapf(err, errFmtLoadTLSCert, dir)
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.Config()
+		},
+	}), nil
+}
+
+// A certReloader loads mTLS certificates from a directory, reloading them
+// when they change.
+type certReloader struct {
+	dir string
+
+	mu      sync.Mutex
+	cfg     *tls.Config
+	modTime time.Time
+}
+
+// Config returns a TLS config that uses the certificates in the reloader's
+// directory. It only reloads the certificates if their modification times
+// changed since they were last loaded. If reloading fails it returns an error
+// rather than continuing to use stale certificates.
+func (r *certReloader) Config() (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, errors.Wrap(err, errStatCerts)
+	}
+	if r.cfg != nil && modTime.Equal(r.modTime) {
+		return r.cfg, nil
+	}
+
+	crt, err := tls.LoadX509KeyPair(
+		filepath.Clean(filepath.Join(r.dir, TLSFileCert)),
+		filepath.Clean(filepath.Join(r.dir, TLSFileKey)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errLoadKeyPair)
+	}
+
+	ca, err := os.ReadFile(filepath.Clean(filepath.Join(r.dir, TLSFileCA)))
+	if err != nil {
+		return nil, errors.Wrap(err, errReadCA)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New(errInvalidCA)
+	}
+
+	r.cfg = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{crt},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	r.modTime = modTime
+	return r.cfg, nil
+}
+
+// latestModTime returns the most recent modification time of the certificates
+// in the reloader's directory. Certificates mounted from a Kubernetes Secret
+// are symlinks that are atomically swapped when the Secret changes, so we stat
+// (i.e. follow) rather than lstat them.
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{TLSFileCert, TLSFileKey, TLSFileCA} {
+		fi, err := os.Stat(filepath.Join(r.dir, f))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+)
+
+// writeCerts writes a self-signed certificate, its key, and a CA bundle
+// containing it to the supplied directory. It returns the certificate.
+func writeCerts(t *testing.T, dir, cn string, modTime time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kder, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	files := map[string][]byte{
+		TLSFileCert: crt,
+		TLSFileKey:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kder}),
+		TLSFileCA:   crt,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return der
+}
+
+func TestCertReloaderConfig(t *testing.T) {
+	dir := t.TempDir()
+	r := &certReloader{dir: dir}
+
+	if _, err := r.Config(); err == nil {
+		t.Errorf("r.Config(): want error loading missing certificates")
+	}
+
+	now := time.Now()
+	first := writeCerts(t, dir, "first", now)
+	cfg, err := r.Config()
+	if err != nil {
+		t.Fatalf("r.Config(): %v", err)
+	}
+	if diff := cmp.Diff(first, leaf(cfg)); diff != "" {
+		t.Errorf("r.Config(): -want first certificate, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(tls.RequireAndVerifyClientCert, cfg.ClientAuth); diff != "" {
+		t.Errorf("r.Config(): -want client auth, +got:\n%s", diff)
+	}
+
+	again, err := r.Config()
+	if err != nil {
+		t.Fatalf("r.Config(): %v", err)
+	}
+	if again != cfg {
+		t.Errorf("r.Config(): want cached config when certificates haven't changed")
+	}
+
+	second := writeCerts(t, dir, "second", now.Add(time.Minute))
+	cfg, err = r.Config()
+	if err != nil {
+		t.Fatalf("r.Config(): %v", err)
+	}
+	if diff := cmp.Diff(second, leaf(cfg)); diff != "" {
+		t.Errorf("r.Config(): -want reloaded certificate, +got:\n%s", diff)
+	}
+
+	// A CA that isn't valid PEM should be an error, not a stale config.
+	ca := filepath.Join(dir, TLSFileCA)
+	if err := os.WriteFile(ca, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(ca, now.Add(2*time.Minute), now.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Config(); err == nil {
+		t.Errorf("r.Config(): want error loading invalid CA certificate")
+	}
+}
+
+func leaf(cfg *tls.Config) []byte {
+	if len(cfg.Certificates) == 0 || len(cfg.Certificates[0].Certificate) == 0 {
+		return nil
+	}
+	return cfg.Certificates[0].Certificate[0]
+}
+
+func TestServeHealth(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "fn.sock")
+	go func() {
+		_ = Serve(&Function{}, "unix", sock, insecure.NewCredentials())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "unix://"+sock, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("grpc.DialContext(...): %v", err)
+	}
+	defer conn.Close() //nolint:errcheck // Not much we can do.
+
+	for _, svc := range []string{"", fnv1beta1.FunctionRunnerService_ServiceDesc.ServiceName} {
+		rsp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: svc})
+		if err != nil {
+			t.Fatalf("Check(%q): %v", svc, err)
+		}
+		if diff := cmp.Diff(healthpb.HealthCheckResponse_SERVING, rsp.GetStatus()); diff != "" {
+			t.Errorf("Check(%q): -want, +got:\n%s", svc, diff)
+		}
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	if diff := cmp.Diff(http.StatusOK, rec.Code); diff != "" {
+		t.Errorf("ServeHTTP(...): -want status, +got status:\n%s", diff)
+	}
+
+	rec = httptest.NewRecorder()
+	NewHealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InfoPath, nil))
+	if diff := cmp.Diff(http.StatusOK, rec.Code); diff != "" {
+		t.Errorf("ServeHTTP(%s): -want status, +got status:\n%s", InfoPath, diff)
+	}
+
+	rec = httptest.NewRecorder()
+	NewHealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nope", nil))
+	if diff := cmp.Diff(http.StatusNotFound, rec.Code); diff != "" {
+		t.Errorf("ServeHTTP(...): -want status, +got status:\n%s", diff)
+	}
+}
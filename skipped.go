@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/patch"
+)
+
+// SkippedPatches describes the supplied patches that are skipped because an
+// optional source field doesn't exist. Patches from an observed composed
+// resource are only described if the composed resource exists. The
+// descriptions are sorted, and each is unique.
+func SkippedPatches(cts []v1beta1.ComposedTemplate, c *v1beta1.Composite, oxr *composite.Unstructured, env *unstructured.Unstructured, observed map[resource.Name]resource.ObservedComposed) []string {
+	seen := map[string]bool{}
+
+	for _, t := range cts {
+		for i := range t.Patches {
+			p := &t.Patches[i]
+
+			var from runtime.Object
+			switch p.GetType() {
+			case v1beta1.PatchTypeFromCompositeFieldPath, v1beta1.PatchTypeCombineFromComposite, v1beta1.PatchTypeCopyFromCompositeFieldPath:
+				from = oxr
+			case v1beta1.PatchTypeFromEnvironmentFieldPath, v1beta1.PatchTypeCombineFromEnvironment:
+				from = env
+			case v1beta1.PatchTypeToCompositeFieldPath, v1beta1.PatchTypeCombineToComposite, v1beta1.PatchTypeConditionToComposite,
+				v1beta1.PatchTypeToEnvironmentFieldPath, v1beta1.PatchTypeCombineToEnvironment,
+				v1beta1.PatchTypeToConnectionDetail, v1beta1.PatchTypeCombineToConnectionDetail:
+				ocd, ok := observed[resource.Name(t.Name)]
+				if !ok {
+					continue
+				}
+				from = ocd.Resource
+			case v1beta1.PatchTypePatchSet:
+				continue
+			}
+
+			for _, path := range MissingOptionalSources(p, from) {
+				seen[fmt.Sprintf("resource template %q patch %d (%s)", t.Name, i, path)] = true
+			}
+		}
+	}
+
+	if c != nil {
+		for i := range c.Patches {
+			p := &c.Patches[i]
+
+			var from runtime.Object = oxr
+			if p.GetType() == v1beta1.PatchTypeFromEnvironmentFieldPath || p.GetType() == v1beta1.PatchTypeCombineFromEnvironment {
+				from = env
+			}
+
+			for _, path := range MissingOptionalSources(p, from) {
+				seen[fmt.Sprintf("composite patch %d (%s)", i, path)] = true
+			}
+		}
+	}
+
+	skipped := make([]string, 0, len(seen))
+	for s := range seen {
+		skipped = append(skipped, s)
+	}
+	sort.Strings(skipped)
+	return skipped
+}
+
+// MissingOptionalSources returns the source field paths of the supplied patch
+// that don't exist on the supplied object, and that the patch's policy says
+// are optional. A patch with any missing optional source field is skipped.
+func MissingOptionalSources(p patch.Interface, from runtime.Object) []string {
+	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	if err != nil {
+		return nil
+	}
+	paved := fieldpath.Pave(fromMap)
+
+	var missing []string
+	if c := p.GetCombine(); c != nil {
+		for _, v := range c.Variables {
+			if _, ok, err := patch.GetFromFieldPath(paved, v.FromFieldPath, p.GetPolicy(), false); err == nil && !ok {
+				missing = append(missing, v.FromFieldPath)
+			}
+		}
+		return missing
+	}
+
+	if p.GetFromFieldPath() == "" {
+		return nil
+	}
+	if _, ok, err := patch.GetFromFieldPath(paved, p.GetFromFieldPath(), p.GetPolicy(), true); err == nil && !ok {
+		missing = append(missing, p.GetFromFieldPath())
+	}
+	return missing
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+	"github.com/crossplane/function-sdk-go/resource/composite"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+)
+
+func TestSkippedPatches(t *testing.T) {
+	oxr := composite.New()
+	oxr.Object["spec"] = map[string]any{"size": "large", "name": ""}
+
+	env := &unstructured.Unstructured{Object: map[string]any{"region": "us-west-2"}}
+
+	ocd := composed.New()
+	ocd.Object["status"] = map[string]any{"id": "cool-42"}
+
+	type args struct {
+		cts      []v1beta1.ComposedTemplate
+		c        *v1beta1.Composite
+		observed map[resource.Name]resource.ObservedComposed
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   []string
+	}{
+		"NoneSkipped": {
+			reason: "Patches whose source fields exist shouldn't be described.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{
+					Name: "cool-resource",
+					Patches: []v1beta1.ComposedPatch{
+						{
+							Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+							Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.size")},
+						},
+						{
+							Type:  v1beta1.PatchTypeFromEnvironmentFieldPath,
+							Patch: v1beta1.Patch{FromFieldPath: ptr.To("region")},
+						},
+					},
+				}},
+			},
+			want: []string{},
+		},
+		"Skipped": {
+			reason: "Patches with a missing, or empty if their policy says so, optional source field should be described.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{
+					Name: "cool-resource",
+					Patches: []v1beta1.ComposedPatch{
+						{
+							Type:  v1beta1.PatchTypeFromCompositeFieldPath,
+							Patch: v1beta1.Patch{FromFieldPath: ptr.To("spec.sise")},
+						},
+						{
+							Type: v1beta1.PatchTypeCombineFromEnvironment,
+							Patch: v1beta1.Patch{Combine: &v1beta1.Combine{
+								Variables: []v1beta1.CombineVariable{{FromFieldPath: "region"}, {FromFieldPath: "zone"}},
+							}},
+						},
+						{
+							Type:  v1beta1.PatchTypeToCompositeFieldPath,
+							Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.arn")},
+						},
+					},
+				}},
+				c: &v1beta1.Composite{
+					Patches: []v1beta1.CompositePatch{{
+						Type: v1beta1.PatchTypeFromCompositeFieldPath,
+						Patch: v1beta1.Patch{
+							FromFieldPath: ptr.To("spec.name"),
+							Policy:        &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyOptionalNonEmpty)},
+						},
+					}},
+				},
+				observed: map[resource.Name]resource.ObservedComposed{
+					"cool-resource": {Resource: ocd},
+				},
+			},
+			want: []string{
+				"composite patch 0 (spec.name)",
+				`resource template "cool-resource" patch 0 (spec.sise)`,
+				`resource template "cool-resource" patch 1 (zone)`,
+				`resource template "cool-resource" patch 2 (status.arn)`,
+			},
+		},
+		"NotSkipped": {
+			reason: "Patches from composed resources that don't exist, with a default value, or with a required source field shouldn't be described.",
+			args: args{
+				cts: []v1beta1.ComposedTemplate{{
+					Name: "cool-resource",
+					Patches: []v1beta1.ComposedPatch{
+						{
+							Type:  v1beta1.PatchTypeToCompositeFieldPath,
+							Patch: v1beta1.Patch{FromFieldPath: ptr.To("status.arn")},
+						},
+						{
+							Type: v1beta1.PatchTypeFromCompositeFieldPath,
+							Patch: v1beta1.Patch{
+								FromFieldPath: ptr.To("spec.sise"),
+								Policy:        &v1beta1.PatchPolicy{FromFieldPathDefault: &extv1.JSON{Raw: []byte(`"small"`)}},
+							},
+						},
+						{
+							Type: v1beta1.PatchTypeFromCompositeFieldPath,
+							Patch: v1beta1.Patch{
+								FromFieldPath: ptr.To("spec.sise"),
+								Policy:        &v1beta1.PatchPolicy{FromFieldPath: ptr.To(v1beta1.FromFieldPathPolicyRequired)},
+							},
+						},
+					},
+				}},
+			},
+			want: []string{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := SkippedPatches(tc.args.cts, tc.args.c, oxr, env, tc.args.observed)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nSkippedPatches(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alecthomas/kong"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+
+	fnv1beta1 "github.com/crossplane/function-sdk-go/proto/v1beta1"
+
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/fieldpaths"
+)
+
+// Files that make up a golden test case.
+const (
+	GoldenFileXR       = "xr.yaml"
+	GoldenFileInput    = "input.yaml"
+	GoldenFileObserved = "observed.yaml"
+	GoldenFileExpected = "expected.yaml"
+)
+
+// KindInputTest is the kind of a test case kept next to the Function's input.
+const KindInputTest = "Test"
+
+// Error strings
+const (
+	errReadTestCases     = "cannot read test cases"
+	errFmtTestsFailed    = "%d of %d test cases failed"
+	errFmtReadTestFile   = "cannot read %s"
+	errFmtWriteTestFile  = "cannot write %s"
+	errFmtGoldenMismatch = "rendered output does not match %s (-want, +got):\n%s"
+	errMarshalJSON       = "cannot marshal to JSON"
+
+	errParseInputTests    = "cannot parse input and test cases"
+	errFmtParseInputTest  = "cannot parse test case %q"
+	errFmtNoDesired       = "resource template %q produced no desired composed resource"
+	errFmtAssertReadField = "cannot read field %q of %s"
+	errFmtAssertValue     = "field %q of %s is %s, want %s"
+)
+
+// TestCmd runs golden file tests, or test cases kept next to the Function's
+// input.
+type TestCmd struct {
+	Path string `arg:"" type:"path" help:"A directory of test cases, where each subdirectory is a test case, or a YAML file containing the Function's input and test cases."`
+
+	Update bool `help:"Update the expected output of each test case instead of comparing against it."`
+}
+
+// Help prints extended help for the test command.
+func (c *TestCmd) Help() string {
+	return `Each test case is a directory containing the following files:
+
+  xr.yaml        The composite resource (XR).
+  input.yaml     The Function's input (i.e. a Resources object).
+  observed.yaml  Optional. Observed composed resources, each annotated with
+                 crossplane.io/composition-resource-name.
+  expected.yaml  The expected output of the render command.
+
+Pass --update to write expected.yaml for each test case.
+
+Alternatively, pass a YAML file containing the Function's input followed by
+test cases of kind Test. Each test case renders a composite resource and
+asserts on fields of the desired composite and composed resources.`
+}
+
+// Run the test command.
+func (c *TestCmd) Run(k *kong.Context) error {
+	fi, err := os.Stat(c.Path)
+	if err != nil {
+		return errors.Wrap(err, errReadTestCases)
+	}
+	if !fi.IsDir() {
+		return c.runInputTests(k)
+	}
+
+	entries, err := os.ReadDir(c.Path)
+	if err != nil {
+		return errors.Wrap(err, errReadTestCases)
+	}
+
+	total, failed := 0, 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		total++
+		if err := RunGoldenTest(context.Background(), filepath.Join(c.Path, e.Name()), c.Update); err != nil {
+			failed++
+			fmt.Fprintf(k.Stdout, "FAIL: %s\n%s\n", e.Name(), err)
+			continue
+		}
+		fmt.Fprintf(k.Stdout, "PASS: %s\n", e.Name())
+	}
+
+	if failed > 0 {
+		return errors.Errorf(errFmtTestsFailed, failed, total)
+	}
+	return nil
+}
+
+func (c *TestCmd) runInputTests(k *kong.Context) error {
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return errors.Wrap(err, errReadTestCases)
+	}
+	input, tests, err := ParseInputTests(data)
+	if err != nil {
+		return errors.Wrap(err, errReadTestCases)
+	}
+
+	failed := 0
+	for _, t := range tests {
+		if err := RunInputTest(context.Background(), input, t); err != nil {
+			failed++
+			fmt.Fprintf(k.Stdout, "FAIL: %s\n%s\n", t.GetName(), err)
+			continue
+		}
+		fmt.Fprintf(k.Stdout, "PASS: %s\n", t.GetName())
+	}
+
+	if failed > 0 {
+		return errors.Errorf(errFmtTestsFailed, failed, len(tests))
+	}
+	return nil
+}
+
+// An InputTest is a test case kept next to the Function's input, as a YAML
+// document of kind Test.
+type InputTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CompositeResource to render.
+	CompositeResource map[string]any `json:"compositeResource"`
+
+	// ObservedResources are the observed composed resources. Each must be
+	// annotated with the name of the resource template that produced it.
+	ObservedResources []map[string]any `json:"observedResources,omitempty"`
+
+	// Assertions about the rendered resources. All must pass.
+	Assertions []InputTestAssertion `json:"assertions"`
+}
+
+// An InputTestAssertion asserts that a field of a rendered resource exists,
+// and optionally that it has a value.
+type InputTestAssertion struct {
+	// Resource is the name of the resource template whose desired composed
+	// resource to check. Omit it to check the desired composite resource.
+	Resource string `json:"resource,omitempty"`
+
+	// FieldPath of the field to check.
+	FieldPath string `json:"fieldPath"`
+
+	// Value the field must have. Omit it to only check that the field
+	// exists.
+	Value any `json:"value,omitempty"`
+}
+
+// ParseInputTests parses the supplied stream of YAML documents into the
+// Function's input and test cases. Documents of kind Test are test cases. The
+// stream must contain exactly one other document - the input.
+func ParseInputTests(data []byte) (*unstructured.Unstructured, []InputTest, error) {
+	docs, err := ParseYAMLDocuments(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errParseInputTests)
+	}
+
+	var input *unstructured.Unstructured
+	tests := make([]InputTest, 0)
+	for _, d := range docs {
+		if d.GetKind() != KindInputTest {
+			if input != nil {
+				return nil, nil, errors.New(errOneInputOnly)
+			}
+			input = d
+			continue
+		}
+		j, err := json.Marshal(d.Object)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, errFmtParseInputTest, d.GetName())
+		}
+		t := InputTest{}
+		if err := json.Unmarshal(j, &t); err != nil {
+			return nil, nil, errors.Wrapf(err, errFmtParseInputTest, d.GetName())
+		}
+		tests = append(tests, t)
+	}
+	if input == nil {
+		return nil, nil, errors.New(errOneInputOnly)
+	}
+	return input, tests, nil
+}
+
+// RunInputTest renders the supplied test case's composite resource and
+// observed composed resources using the supplied input, then checks each of
+// the test case's assertions.
+func RunInputTest(ctx context.Context, input *unstructured.Unstructured, t InputTest) error {
+	observed := make([]*unstructured.Unstructured, len(t.ObservedResources))
+	for i := range t.ObservedResources {
+		observed[i] = &unstructured.Unstructured{Object: t.ObservedResources[i]}
+	}
+
+	rsp, err := Render(ctx, &unstructured.Unstructured{Object: t.CompositeResource}, observed, input)
+	if err != nil {
+		return errors.Wrap(err, errRunFunction)
+	}
+	for _, r := range rsp.GetResults() {
+		if r.GetSeverity() == fnv1beta1.Severity_SEVERITY_FATAL {
+			return errors.Wrap(errors.New(r.GetMessage()), errFatalResult)
+		}
+	}
+
+	for _, a := range t.Assertions {
+		if err := checkAssertion(rsp, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAssertion(rsp *fnv1beta1.RunFunctionResponse, a InputTestAssertion) error {
+	what := "the composite resource"
+	obj := rsp.GetDesired().GetComposite().GetResource().AsMap()
+	if a.Resource != "" {
+		what = fmt.Sprintf("composed resource %q", a.Resource)
+		r, ok := rsp.GetDesired().GetResources()[a.Resource]
+		if !ok {
+			return errors.Errorf(errFmtNoDesired, a.Resource)
+		}
+		obj = r.GetResource().AsMap()
+	}
+
+	got, err := fieldpath.Pave(obj).GetValue(fieldpaths.Normalize(a.FieldPath))
+	if err != nil {
+		return errors.Wrapf(err, errFmtAssertReadField, a.FieldPath, what)
+	}
+	if a.Value == nil {
+		return nil
+	}
+
+	// Compare JSON encodings, so that e.g. an integer equals a float with the
+	// same value. Composed resources are protobuf Structs, whose numbers are
+	// all floats.
+	gj, err := json.Marshal(got)
+	if err != nil {
+		return errors.Wrap(err, errMarshalJSON)
+	}
+	wj, err := json.Marshal(a.Value)
+	if err != nil {
+		return errors.Wrap(err, errMarshalJSON)
+	}
+	if !bytes.Equal(gj, wj) {
+		return errors.Errorf(errFmtAssertValue, a.FieldPath, what, gj, wj)
+	}
+	return nil
+}
+
+// RunGoldenTest runs the golden test case in the supplied directory. It
+// renders the case's XR, input, and observed composed resources and compares
+// the output to the case's expected output. If update is true it writes the
+// rendered output as the expected output instead.
+func RunGoldenTest(ctx context.Context, dir string, update bool) error {
+	read := func(name string, optional bool) ([]byte, error) {
+		b, err := os.ReadFile(filepath.Join(dir, name)) //nolint:gosec // Reading test cases is the point.
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return b, errors.Wrapf(err, errFmtReadTestFile, name)
+	}
+
+	xr, err := read(GoldenFileXR, false)
+	if err != nil {
+		return err
+	}
+	in, err := read(GoldenFileInput, false)
+	if err != nil {
+		return err
+	}
+	observed, err := read(GoldenFileObserved, true)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := RenderYAML(ctx, xr, in, observed)
+	if err != nil {
+		return err
+	}
+
+	got := &bytes.Buffer{}
+	if err := WriteRendered(got, rsp); err != nil {
+		return errors.Wrap(err, errWriteRendered)
+	}
+
+	if update {
+		return errors.Wrapf(os.WriteFile(filepath.Join(dir, GoldenFileExpected), got.Bytes(), 0o600), errFmtWriteTestFile, GoldenFileExpected)
+	}
+
+	want, err := read(GoldenFileExpected, false)
+	if err != nil {
+		return err
+	}
+	if diff := cmp.Diff(string(want), got.String()); diff != "" {
+		return errors.Errorf(errFmtGoldenMismatch, GoldenFileExpected, diff)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunGoldenTest(t *testing.T) {
+	xr := `
+apiVersion: example.org/v1
+kind: XR
+spec:
+  widgets: "10"
+`
+	input := `
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - type: FromCompositeFieldPath
+    fromFieldPath: spec.widgets
+    toFieldPath: spec.watchers
+`
+	expected := `---
+apiVersion: example.org/v1
+kind: XR
+---
+apiVersion: example.org/v1
+kind: CD
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: cool-resource
+spec:
+  watchers: "10"
+`
+
+	type args struct {
+		files  map[string]string
+		update bool
+	}
+	type want struct {
+		expected string
+		err      bool
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Match": {
+			reason: "We should return no error when the rendered output matches the expected output.",
+			args: args{
+				files: map[string]string{GoldenFileXR: xr, GoldenFileInput: input, GoldenFileExpected: expected},
+			},
+			want: want{
+				expected: expected,
+			},
+		},
+		"Mismatch": {
+			reason: "We should return an error when the rendered output doesn't match the expected output.",
+			args: args{
+				files: map[string]string{GoldenFileXR: xr, GoldenFileInput: input, GoldenFileExpected: "---\n"},
+			},
+			want: want{
+				expected: "---\n",
+				err:      true,
+			},
+		},
+		"Update": {
+			reason: "We should write the expected output when asked to update it.",
+			args: args{
+				files:  map[string]string{GoldenFileXR: xr, GoldenFileInput: input},
+				update: true,
+			},
+			want: want{
+				expected: expected,
+			},
+		},
+		"MissingInput": {
+			reason: "We should return an error when a test case has no input.",
+			args: args{
+				files: map[string]string{GoldenFileXR: xr, GoldenFileExpected: expected},
+			},
+			want: want{
+				expected: expected,
+				err:      true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for f, content := range tc.args.files {
+				if err := os.WriteFile(filepath.Join(dir, f), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := RunGoldenTest(context.Background(), dir, tc.args.update)
+			if diff := cmp.Diff(tc.want.err, err != nil); diff != "" {
+				t.Errorf("%s\nRunGoldenTest(...): -want error, +got error:\n%s\n%v", tc.reason, diff, err)
+			}
+
+			got, _ := os.ReadFile(filepath.Join(dir, GoldenFileExpected))
+			if diff := cmp.Diff(tc.want.expected, string(got)); diff != "" {
+				t.Errorf("%s\nRunGoldenTest(...): -want expected output, +got expected output:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRunInputTests(t *testing.T) {
+	doc := func(tc string) string {
+		return `
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Resources
+resources:
+- name: cool-resource
+  base:
+    apiVersion: example.org/v1
+    kind: CD
+  patches:
+  - type: FromCompositeFieldPath
+    fromFieldPath: spec.widgets
+    toFieldPath: spec.watchers
+---
+apiVersion: pt.fn.crossplane.io/v1beta1
+kind: Test
+metadata:
+  name: cool-test
+compositeResource:
+  apiVersion: example.org/v1
+  kind: XR
+  spec:
+    widgets: 10
+` + tc
+	}
+
+	cases := map[string]struct {
+		reason string
+		data   string
+		err    bool
+	}{
+		"Pass": {
+			reason: "We should return no error when every assertion passes.",
+			data: doc(`
+assertions:
+- fieldPath: apiVersion
+  value: example.org/v1
+- resource: cool-resource
+  fieldPath: spec.watchers
+  value: 10
+- resource: cool-resource
+  fieldPath: kind
+`),
+		},
+		"WrongValue": {
+			reason: "We should return an error when a field doesn't have the asserted value.",
+			data: doc(`
+assertions:
+- resource: cool-resource
+  fieldPath: spec.watchers
+  value: 42
+`),
+			err: true,
+		},
+		"MissingField": {
+			reason: "We should return an error when an asserted field doesn't exist.",
+			data: doc(`
+assertions:
+- resource: cool-resource
+  fieldPath: spec.wombats
+`),
+			err: true,
+		},
+		"MissingResource": {
+			reason: "We should return an error when an asserted resource wasn't rendered.",
+			data: doc(`
+assertions:
+- resource: uncool-resource
+  fieldPath: spec.watchers
+`),
+			err: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			input, tests, err := ParseInputTests([]byte(tc.data))
+			if err != nil {
+				t.Fatalf("ParseInputTests(...): %v", err)
+			}
+			if diff := cmp.Diff(1, len(tests)); diff != "" {
+				t.Fatalf("ParseInputTests(...): -want tests, +got tests:\n%s", diff)
+			}
+			err = RunInputTest(context.Background(), input, tests[0])
+			if diff := cmp.Diff(tc.err, err != nil); diff != "" {
+				t.Errorf("%s\nRunInputTest(...): -want error, +got error:\n%s\n%v", tc.reason, diff, err)
+			}
+		})
+	}
+}
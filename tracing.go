@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/crossplane/crossplane-runtime/pkg/errors"
+)
+
+// TracerName is the name of the OpenTelemetry tracer used by this Function.
+const TracerName = "github.com/crossplane-contrib/function-patch-and-transform"
+
+// Standard OpenTelemetry environment variables.
+// https://opentelemetry.io/docs/specs/otel/configuration/sdk-environment-variables/
+const (
+	envOTelSDKDisabled            = "OTEL_SDK_DISABLED"
+	envOTelExporterEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envOTelExporterTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+)
+
+// Error strings
+const (
+	errNewTraceExporter = "cannot create OTLP trace exporter"
+)
+
+// SetupTracing configures a global OpenTelemetry TracerProvider that exports
+// spans using OTLP over gRPC. Tracing is only enabled if an OTLP endpoint is
+// configured using the standard OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variables. The exporter,
+// sampler, and resource attributes may be configured using the other standard
+// OTEL_* environment variables. SetupTracing returns a function that flushes
+// any buffered spans and stops the exporter.
+func SetupTracing(ctx context.Context) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	if disabled, _ := strconv.ParseBool(os.Getenv(envOTelSDKDisabled)); disabled {
+		return noop, nil
+	}
+	if os.Getenv(envOTelExporterEndpoint) == "" && os.Getenv(envOTelExporterTracesEndpoint) == "" {
+		return noop, nil
+	}
+
+	exp, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errNewTraceExporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exp))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+type spanAttributesKey struct{}
+
+// WithSpanAttributes returns a context that carries the supplied attributes.
+// Spans started by this Function add the attributes carried by their context
+// using SpanAttributes, so that every span can be attributed to the XR and
+// Composition it was started for.
+func WithSpanAttributes(ctx context.Context, kv ...attribute.KeyValue) context.Context {
+	return context.WithValue(ctx, spanAttributesKey{}, append(SpanAttributes(ctx), kv...))
+}
+
+// SpanAttributes returns the attributes carried by the supplied context.
+func SpanAttributes(ctx context.Context) []attribute.KeyValue {
+	kv, _ := ctx.Value(spanAttributesKey{}).([]attribute.KeyValue)
+	return kv[:len(kv):len(kv)]
+}
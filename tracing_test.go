@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestSetupTracing(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		env    map[string]string
+		want   error
+	}{
+		"NoEndpoint": {
+			reason: "Tracing should be disabled if no OTLP endpoint is configured.",
+			env:    map[string]string{},
+		},
+		"Disabled": {
+			reason: "Tracing should be disabled if the OpenTelemetry SDK is disabled.",
+			env: map[string]string{
+				envOTelSDKDisabled:      "true",
+				envOTelExporterEndpoint: "http://localhost:4317",
+			},
+		},
+		"Enabled": {
+			reason: "Tracing should be enabled if an OTLP endpoint is configured.",
+			env: map[string]string{
+				envOTelExporterTracesEndpoint: "http://localhost:4317",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			stop, err := SetupTracing(context.Background())
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("%s\nSetupTracing(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err := stop(context.Background()); err != nil {
+				t.Errorf("%s\nstop(...): %v", tc.reason, err)
+			}
+		})
+	}
+}
+
+func TestSpanAttributes(t *testing.T) {
+	xr := attribute.String("xr-kind", "XR")
+	comp := attribute.String("composition-name", "cool-composition")
+
+	cases := map[string]struct {
+		reason string
+		ctx    context.Context
+		want   []attribute.KeyValue
+	}{
+		"NoAttributes": {
+			reason: "A context without attributes should carry no attributes.",
+			ctx:    context.Background(),
+		},
+		"Attributes": {
+			reason: "A context should carry the attributes added to it, and to its parents.",
+			ctx:    WithSpanAttributes(WithSpanAttributes(context.Background(), xr), comp),
+			want:   []attribute.KeyValue{xr, comp},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := SpanAttributes(tc.ctx)
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(attribute.Value{})); diff != "" {
+				t.Errorf("%s\nSpanAttributes(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -1,12 +1,22 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"regexp"
+	"sort"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/connection"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/patch"
+	"github.com/crossplane-contrib/function-patch-and-transform/pkg/readiness"
 )
 
 // WrapFieldError wraps the given field.Error adding the given field.Path as root of the Field.
@@ -39,7 +49,7 @@ func ValidateResources(r *v1beta1.Resources) *field.Error {
 			return err
 		}
 	}
-	if len(r.Resources) == 0 {
+	if len(r.Resources) == 0 && r.Composite == nil {
 		return field.Required(field.NewPath("resources"), "resources is required")
 	}
 	for i, r := range r.Resources {
@@ -50,329 +60,408 @@ func ValidateResources(r *v1beta1.Resources) *field.Error {
 	if err := ValidateEnvironment(r.Environment); err != nil {
 		return WrapFieldError(err, field.NewPath("environment"))
 	}
-	return nil
-}
-
-// ValidateComposedTemplate validates a ComposedTemplate.
-func ValidateComposedTemplate(t v1beta1.ComposedTemplate) *field.Error {
-	if t.Name == "" {
-		return field.Required(field.NewPath("name"), "name is required")
+	if err := ValidateComposite(r.Composite); err != nil {
+		return WrapFieldError(err, field.NewPath("composite"))
 	}
-	for i, p := range t.Patches {
-		p := p
-		if err := ValidatePatch(&p); err != nil {
-			return WrapFieldError(err, field.NewPath("patches").Index(i))
-		}
+	if err := ValidateConnectionDetailsPolicy(r.ConnectionDetails); err != nil {
+		return WrapFieldError(err, field.NewPath("connectionDetails"))
 	}
-	for i, cd := range t.ConnectionDetails {
-		if err := ValidateConnectionDetail(cd); err != nil {
-			return WrapFieldError(err, field.NewPath("connectionDetails").Index(i))
-		}
+	if err := ValidateProviderConfigRef(r.ProviderConfigRef, r.Resources); err != nil {
+		return WrapFieldError(err, field.NewPath("providerConfigRef"))
 	}
-	for i, rc := range t.ReadinessChecks {
-		if err := ValidateReadinessCheck(rc); err != nil {
-			return WrapFieldError(err, field.NewPath("readinessChecks").Index(i))
+	if err := ValidateReadinessPolicy(r.Readiness, r.Resources); err != nil {
+		return WrapFieldError(err, field.NewPath("readiness"))
+	}
+	if len(r.Assertions) > 0 {
+		// Assertions apply to each template's patches after its PatchSets
+		// are included. We report errors including PatchSets when we
+		// resolve them, so we check the patches we have if we can't.
+		cts, err := patch.ComposedTemplates(r.PatchSets, r.Resources)
+		if err != nil {
+			cts = r.Resources
+		}
+		for i, a := range r.Assertions {
+			if err := ValidateAssertion(a, cts); err != nil {
+				return WrapFieldError(err, field.NewPath("assertions").Index(i))
+			}
 		}
 	}
-	return nil
-}
-
-// ValidatePatchSet validates a PatchSet.
-func ValidatePatchSet(ps v1beta1.PatchSet) *field.Error {
-	if ps.Name == "" {
-		return field.Required(field.NewPath("name"), "name is required")
+	if d := r.WarnSkippedPatchesAfter; d != nil && d.Duration < 0 {
+		return field.Invalid(field.NewPath("warnSkippedPatchesAfter"), d.Duration.String(), "must not be negative")
 	}
-	for i, p := range ps.Patches {
-		p := p
-		if err := ValidatePatch(&p); err != nil {
-			return WrapFieldError(err, field.NewPath("patches").Index(i))
-		}
+	if err := ValidateFeatureGates(r.FeatureGates); err != nil {
+		return err
 	}
-	return nil
+	return ValidateGatedTransforms(r)
 }
 
-// ValidateEnvironment validates (patches to and from) the Environment.
-func ValidateEnvironment(e *v1beta1.Environment) *field.Error {
-	if e == nil {
-		return nil
-	}
-	for i, p := range e.Patches {
-		p := p
-		switch p.GetType() { //nolint:exhaustive // Intentionally targeting only environment patches.
-		case v1beta1.PatchTypeCombineToEnvironment,
-			v1beta1.PatchTypeCombineFromEnvironment,
-			v1beta1.PatchTypeFromEnvironmentFieldPath,
-			v1beta1.PatchTypeToEnvironmentFieldPath:
-		default:
-			return field.Invalid(field.NewPath("patches").Index(i).Key("type"), p.Type, "invalid environment patch type")
+// ValidateFeatureGates validates feature gates. Unknown feature gates are
+// valid; they're ignored with a warning so that a Composition may be used with
+// an older release of the function.
+func ValidateFeatureGates(gs []v1beta1.FeatureGate) *field.Error {
+	seen := make(map[v1beta1.FeatureGate]bool, len(gs))
+	for i, g := range gs {
+		if g == "" {
+			return field.Required(field.NewPath("featureGates").Index(i), "feature gate must not be empty")
 		}
-
-		if err := ValidatePatch(&p); err != nil {
-			return WrapFieldError(err, field.NewPath("patches").Index(i))
+		if seen[g] {
+			return field.Duplicate(field.NewPath("featureGates").Index(i), g)
 		}
+		seen[g] = true
 	}
 	return nil
 }
 
-// ValidateReadinessCheck checks if the readiness check is logically valid.
-func ValidateReadinessCheck(r v1beta1.ReadinessCheck) *field.Error { //nolint:gocyclo // This function is not that complex, just a switch
-	if !r.Type.IsValid() {
-		return field.Invalid(field.NewPath("type"), string(r.Type), "unknown readiness check type")
+// ValidateGatedTransforms validates that the supplied input only uses
+// transforms whose feature gates are enabled.
+func ValidateGatedTransforms(r *v1beta1.Resources) *field.Error {
+	if r.FeatureEnabled(v1beta1.FeatureGateWebhookTransforms) {
+		return nil
 	}
-	switch r.Type {
-	case v1beta1.ReadinessCheckTypeNone:
+	gated := func(ts []v1beta1.Transform, path *field.Path) *field.Error {
+		for i, t := range ts {
+			if t.Type == v1beta1.TransformTypeWebhook {
+				return field.Forbidden(path.Child("transforms").Index(i).Child("type"), fmt.Sprintf("%s transforms require the %s feature gate", t.Type, v1beta1.FeatureGateWebhookTransforms))
+			}
+		}
 		return nil
-	case v1beta1.ReadinessCheckTypeMatchString:
-		if r.MatchString == nil {
-			return field.Required(field.NewPath("matchString"), "cannot be nil for type MatchString")
+	}
+
+	for i, ps := range r.PatchSets {
+		for j, p := range ps.Patches {
+			if err := gated(p.Transforms, field.NewPath("patchSets").Index(i).Child("patches").Index(j)); err != nil {
+				return err
+			}
 		}
-	case v1beta1.ReadinessCheckTypeMatchInteger:
-		if r.MatchInteger == nil {
-			return field.Required(field.NewPath("matchInteger"), "cannot be nil for type MatchInteger")
+	}
+	if r.Environment != nil {
+		for i, p := range r.Environment.Patches {
+			if err := gated(p.Transforms, field.NewPath("environment", "patches").Index(i)); err != nil {
+				return err
+			}
 		}
-	case v1beta1.ReadinessCheckTypeMatchCondition:
-		if err := ValidateMatchConditionReadinessCheck(r.MatchCondition); err != nil {
-			return WrapFieldError(err, field.NewPath("matchCondition"))
+	}
+	if r.Composite != nil {
+		for i, p := range r.Composite.Patches {
+			if err := gated(p.Transforms, field.NewPath("composite", "patches").Index(i)); err != nil {
+				return err
+			}
 		}
-		return nil
-	case v1beta1.ReadinessCheckTypeNonEmpty, v1beta1.ReadinessCheckTypeMatchFalse, v1beta1.ReadinessCheckTypeMatchTrue:
-		// No specific validation required.
 	}
-	if r.FieldPath == nil {
-		return field.Required(field.NewPath("fieldPath"), "cannot be empty")
+	for i, t := range r.Resources {
+		for j, p := range t.Patches {
+			if err := gated(p.Transforms, field.NewPath("resources").Index(i).Child("patches").Index(j)); err != nil {
+				return err
+			}
+		}
 	}
-
 	return nil
 }
 
-// ValidateMatchConditionReadinessCheck checks if the match condition is
-// logically valid.
-func ValidateMatchConditionReadinessCheck(m *v1beta1.MatchConditionReadinessCheck) *field.Error {
-	if m == nil {
+// ValidateProviderConfigRef validates the ProviderConfig injected into
+// composed resources, given the resource templates it may refer to.
+func ValidateProviderConfigRef(r *v1beta1.ProviderConfigRef, cts []v1beta1.ComposedTemplate) *field.Error {
+	if r == nil {
 		return nil
 	}
-	if m.Type == "" {
-		return field.Required(field.NewPath("type"), "cannot be empty for type MatchCondition")
+	switch {
+	case r.FromFieldPath == nil && r.FromEnvironmentFieldPath == nil:
+		return field.Required(field.NewPath("fromFieldPath"), "one of fromFieldPath or fromEnvironmentFieldPath is required")
+	case r.FromFieldPath != nil && r.FromEnvironmentFieldPath != nil:
+		return field.Forbidden(field.NewPath("fromEnvironmentFieldPath"), "only one of fromFieldPath or fromEnvironmentFieldPath may be set")
+	case r.FromFieldPath != nil && *r.FromFieldPath == "":
+		return field.Required(field.NewPath("fromFieldPath"), "fromFieldPath must not be empty")
+	case r.FromEnvironmentFieldPath != nil && *r.FromEnvironmentFieldPath == "":
+		return field.Required(field.NewPath("fromEnvironmentFieldPath"), "fromEnvironmentFieldPath must not be empty")
 	}
-	if m.Status == "" {
-		return field.Required(field.NewPath("status"), "cannot be empty for type MatchCondition")
+	names := make(map[string]bool, len(cts))
+	for _, t := range cts {
+		names[t.Name] = true
+	}
+	seen := make(map[string]bool, len(r.Resources))
+	for i, name := range r.Resources {
+		if !names[name] {
+			return field.NotFound(field.NewPath("resources").Index(i), name)
+		}
+		if seen[name] {
+			return field.Duplicate(field.NewPath("resources").Index(i), name)
+		}
+		seen[name] = true
 	}
 	return nil
 }
 
-// ValidatePatch validates a ComposedPatch.
-func ValidatePatch(p PatchInterface) *field.Error { //nolint: gocyclo // This is a long but simple/same-y switch.
+// ValidateReadinessPolicy validates when the composite resource is considered
+// ready, given the resource templates it may refer to.
+func ValidateReadinessPolicy(p *v1beta1.ReadinessPolicy, cts []v1beta1.ComposedTemplate) *field.Error {
+	if p == nil {
+		return nil
+	}
 	switch p.GetType() {
-	case v1beta1.PatchTypeFromCompositeFieldPath,
-		v1beta1.PatchTypeToCompositeFieldPath,
-		v1beta1.PatchTypeFromEnvironmentFieldPath,
-		v1beta1.PatchTypeToEnvironmentFieldPath:
-		if p.GetFromFieldPath() == "" {
-			return field.Required(field.NewPath("fromFieldPath"), fmt.Sprintf("fromFieldPath must be set for patch type %s", p.GetType()))
-		}
-	case v1beta1.PatchTypePatchSet:
-		ps, ok := p.(PatchWithPatchSetName)
-		if !ok {
-			return field.Invalid(field.NewPath("type"), p.GetType(), fmt.Sprintf("patch type %T does not support patch of type %s", p, p.GetType()))
-		}
-		if ps.GetPatchSetName() == "" {
-			return field.Required(field.NewPath("patchSetName"), fmt.Sprintf("patchSetName must be set for patch type %s", p.GetType()))
-		}
-	case v1beta1.PatchTypeCombineFromComposite,
-		v1beta1.PatchTypeCombineToComposite,
-		v1beta1.PatchTypeCombineFromEnvironment,
-		v1beta1.PatchTypeCombineToEnvironment:
-		if p.GetCombine() == nil {
-			return field.Required(field.NewPath("combine"), fmt.Sprintf("combine must be set for patch type %s", p.GetType()))
-		}
-		if p.GetToFieldPath() == "" {
-			return field.Required(field.NewPath("toFieldPath"), fmt.Sprintf("toFieldPath must be set for patch type %s", p.GetType()))
+	case v1beta1.ReadinessPolicyTypeAll:
+	case v1beta1.ReadinessPolicyTypeResources:
+		if len(p.Resources) == 0 {
+			return field.Required(field.NewPath("resources"), "resources readiness policy requires at least one resource")
+		}
+		names := make(map[string]bool, len(cts))
+		for _, t := range cts {
+			names[t.Name] = true
+		}
+		seen := make(map[string]bool, len(p.Resources))
+		for i, name := range p.Resources {
+			if !names[name] {
+				return field.NotFound(field.NewPath("resources").Index(i), name)
+			}
+			if seen[name] {
+				return field.Duplicate(field.NewPath("resources").Index(i), name)
+			}
+			seen[name] = true
+		}
+	case v1beta1.ReadinessPolicyTypeQuorum:
+		if p.Quorum == nil {
+			return field.Required(field.NewPath("quorum"), "quorum readiness policy requires a quorum")
+		}
+		if *p.Quorum < 1 {
+			return field.Invalid(field.NewPath("quorum"), *p.Quorum, "quorum must be at least 1")
+		}
+		total := 0
+		for _, t := range cts {
+			total += t.GetReadinessWeight()
+		}
+		if *p.Quorum > total {
+			return field.Invalid(field.NewPath("quorum"), *p.Quorum, fmt.Sprintf("quorum must not be greater than the total readiness weight of all resources (%d)", total))
 		}
 	default:
-		// Should never happen
-		return field.Invalid(field.NewPath("type"), p.GetType(), "unknown patch type")
+		return field.Invalid(field.NewPath("type"), p.Type, "unknown readiness policy type")
 	}
-	for i, t := range p.GetTransforms() {
-		if err := ValidateTransform(t); err != nil {
-			return WrapFieldError(err, field.NewPath("transforms").Index(i))
-		}
-	}
-
 	return nil
 }
 
-// ValidateTransform validates a Transform.
-func ValidateTransform(t v1beta1.Transform) *field.Error { //nolint:gocyclo // This is a long but simple/same-y switch.
-	switch t.Type {
-	case v1beta1.TransformTypeMath:
-		if t.Math == nil {
-			return field.Required(field.NewPath("math"), "given transform type math requires configuration")
-		}
-		return WrapFieldError(ValidateMathTransform(t.Math), field.NewPath("math"))
-	case v1beta1.TransformTypeMap:
-		if t.Map == nil {
-			return field.Required(field.NewPath("map"), "given transform type map requires configuration")
-		}
-		return WrapFieldError(ValidateMapTransform(t.Map), field.NewPath("map"))
-	case v1beta1.TransformTypeMatch:
-		if t.Match == nil {
-			return field.Required(field.NewPath("match"), "given transform type match requires configuration")
-		}
-		return WrapFieldError(ValidateMatchTransform(t.Match), field.NewPath("match"))
-	case v1beta1.TransformTypeString:
-		if t.String == nil {
-			return field.Required(field.NewPath("string"), "given transform type string requires configuration")
-		}
-		return WrapFieldError(ValidateStringTransform(t.String), field.NewPath("string"))
-	case v1beta1.TransformTypeConvert:
-		if t.Convert == nil {
-			return field.Required(field.NewPath("convert"), "given transform type convert requires configuration")
-		}
-		if err := ValidateConvertTransform(t.Convert); err != nil {
-			return WrapFieldError(err, field.NewPath("convert"))
+// ValidateConnectionDetailsPolicy validates which connection details are
+// published, and the keys they're published as.
+func ValidateConnectionDetailsPolicy(p *v1beta1.ConnectionDetailsPolicy) *field.Error {
+	if p == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(p.Rename))
+	for k := range p.Rename {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	to := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		v := p.Rename[k]
+		if v == "" {
+			return field.Required(field.NewPath("rename").Key(k), "keys can't be renamed to an empty key")
 		}
-	default:
-		// Should never happen
-		return field.Invalid(field.NewPath("type"), t.Type, "unknown transform type")
+		if to[v] {
+			return field.Duplicate(field.NewPath("rename").Key(k), v)
+		}
+		to[v] = true
 	}
-
 	return nil
 }
 
-// ValidateMathTransform validates a MathTransform.
-func ValidateMathTransform(m *v1beta1.MathTransform) *field.Error {
-	if m.Type == "" {
-		return field.Required(field.NewPath("type"), "math transform type is required")
+// ValidateComposedTemplate validates a ComposedTemplate.
+func ValidateComposedTemplate(t v1beta1.ComposedTemplate) *field.Error {
+	if t.Name == "" {
+		return field.Required(field.NewPath("name"), "name is required")
 	}
-	switch m.Type {
-	case v1beta1.MathTransformTypeMultiply:
-		if m.Multiply == nil {
-			return field.Required(field.NewPath("multiply"), "must specify a value if a multiply math transform is specified")
+	if err := ValidateBase(t.Base); err != nil {
+		return WrapFieldError(err, field.NewPath("base"))
+	}
+	for i, p := range t.Patches {
+		p := p
+		if err := patch.Validate(&p); err != nil {
+			return WrapFieldError(err, field.NewPath("patches").Index(i))
 		}
-	case v1beta1.MathTransformTypeClampMin:
-		if m.ClampMin == nil {
-			return field.Required(field.NewPath("clampMin"), "must specify a value if a clamp min math transform is specified")
+	}
+	for i, cd := range t.ConnectionDetails {
+		if err := connection.ValidateDetail(cd); err != nil {
+			return WrapFieldError(err, field.NewPath("connectionDetails").Index(i))
 		}
-	case v1beta1.MathTransformTypeClampMax:
-		if m.ClampMax == nil {
-			return field.Required(field.NewPath("clampMax"), "must specify a value if a clamp max math transform is specified")
+	}
+	for i, rc := range t.ReadinessChecks {
+		if err := readiness.ValidateCheck(rc); err != nil {
+			return WrapFieldError(err, field.NewPath("readinessChecks").Index(i))
 		}
-	default:
-		return field.Invalid(field.NewPath("type"), m.Type, "unknown math transform type")
+	}
+	if t.Delete != nil && t.Delete.FromFieldPath == "" {
+		return field.Required(field.NewPath("delete", "fromFieldPath"), "fromFieldPath is required")
+	}
+	if t.ForceReady != nil && t.ForceReady.Value == nil && t.ForceReady.FromFieldPath == nil {
+		return field.Required(field.NewPath("forceReady"), "one of value or fromFieldPath is required")
+	}
+	if t.ReadinessWeight != nil && *t.ReadinessWeight < 0 {
+		return field.Invalid(field.NewPath("readinessWeight"), *t.ReadinessWeight, "must not be negative")
+	}
+	return ValidateComposedNamespace(t.Namespace, t.Base)
+}
+
+// Kinds that are known to be cluster scoped. Composed resources of these
+// kinds can't have a namespace.
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Group: "", Kind: "Namespace"}:                                                  true,
+	{Group: "", Kind: "Node"}:                                                       true,
+	{Group: "", Kind: "PersistentVolume"}:                                           true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                       true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                 true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                             true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:               true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+}
+
+// ValidateComposedNamespace validates the namespace of a composed resource,
+// given its base template. Errors are relative to the composed template.
+//
+// TODO(negz): Validate the scope of any kind, not only well known ones, using
+// its CRD. Like ValidateBase, this needs extra resources.
+func ValidateComposedNamespace(n *v1beta1.ComposedNamespace, base *runtime.RawExtension) *field.Error {
+	if n == nil {
+		return nil
+	}
+	if n.Value == nil && n.FromFieldPath == nil {
+		return field.Required(field.NewPath("namespace"), "one of value or fromFieldPath is required")
+	}
+	if base == nil || base.Raw == nil {
+		return nil
+	}
+	tm := metav1.TypeMeta{}
+	if err := json.Unmarshal(base.Raw, &tm); err != nil {
+		// Let whoever renders the base template report that it's invalid.
+		return nil //nolint:nilerr // See above.
+	}
+	if gk := tm.GroupVersionKind().GroupKind(); clusterScopedKinds[gk] {
+		return field.Forbidden(field.NewPath("namespace"), fmt.Sprintf("%s is cluster scoped, so it can't have a namespace", gk))
 	}
 	return nil
 }
 
-// ValidateMapTransform validates MapTransform.
-func ValidateMapTransform(m *v1beta1.MapTransform) *field.Error {
-	if len(m.Pairs) == 0 {
-		return field.Required(field.NewPath("pairs"), "at least one pair must be specified if a map transform is specified")
+// ValidateBase validates the base template of a composed resource. A base
+// template is optional, but if it's set it must have an apiVersion and kind.
+//
+// TODO(negz): Validate that the base template's kind is served by the cluster,
+// so we can return a fatal result naming the missing CRD instead of Crossplane
+// failing to apply the composed resource. This requires the Function to ask
+// Crossplane for extra resources using requirements, which the version of the
+// Function SDK (and RunFunctionRequest) we use doesn't support yet.
+func ValidateBase(b *runtime.RawExtension) *field.Error {
+	if b == nil || b.Raw == nil {
+		return nil
+	}
+	tm := metav1.TypeMeta{}
+	if err := json.Unmarshal(b.Raw, &tm); err != nil {
+		// Let whoever renders the base template report that it's invalid.
+		return nil //nolint:nilerr // See above.
+	}
+	if tm.APIVersion == "" {
+		return field.Required(field.NewPath("apiVersion"), "apiVersion is required")
+	}
+	if _, err := schema.ParseGroupVersion(tm.APIVersion); err != nil {
+		return field.Invalid(field.NewPath("apiVersion"), tm.APIVersion, err.Error())
+	}
+	if tm.Kind == "" {
+		return field.Required(field.NewPath("kind"), "kind is required")
 	}
 	return nil
 }
 
-// ValidateMatchTransform validates a MatchTransform.
-func ValidateMatchTransform(m *v1beta1.MatchTransform) *field.Error {
-	if len(m.Patterns) == 0 {
-		return field.Required(field.NewPath("patterns"), "at least one pattern must be specified if a match transform is specified")
+// ValidatePatchSet validates a PatchSet.
+func ValidatePatchSet(ps v1beta1.PatchSet) *field.Error {
+	if ps.Name == "" {
+		return field.Required(field.NewPath("name"), "name is required")
 	}
-	for i, p := range m.Patterns {
-		if err := ValidateMatchTransformPattern(p); err != nil {
-			return WrapFieldError(err, field.NewPath("patterns").Index(i))
+	for i, p := range ps.Patches {
+		p := p
+		if err := patch.Validate(&p); err != nil {
+			return WrapFieldError(err, field.NewPath("patches").Index(i))
 		}
 	}
 	return nil
 }
 
-// ValidateMatchTransformPattern validates a MatchTransformPattern.
-func ValidateMatchTransformPattern(p v1beta1.MatchTransformPattern) *field.Error {
-	switch p.Type {
-	case v1beta1.MatchTransformPatternTypeLiteral, "":
-		if p.Literal == nil {
-			return field.Required(field.NewPath("literal"), "literal pattern type requires a literal")
+// ValidateEnvironment validates (patches to and from) the Environment.
+func ValidateEnvironment(e *v1beta1.Environment) *field.Error {
+	if e == nil {
+		return nil
+	}
+	for i, p := range e.Patches {
+		p := p
+		switch p.GetType() { //nolint:exhaustive // Intentionally targeting only environment patches.
+		case v1beta1.PatchTypeCombineToEnvironment,
+			v1beta1.PatchTypeCombineFromEnvironment,
+			v1beta1.PatchTypeFromEnvironmentFieldPath,
+			v1beta1.PatchTypeToEnvironmentFieldPath:
+		default:
+			return field.Invalid(field.NewPath("patches").Index(i).Key("type"), p.Type, "invalid environment patch type")
 		}
-	case v1beta1.MatchTransformPatternTypeRegexp:
-		if p.Regexp == nil {
-			return field.Required(field.NewPath("regexp"), "regexp pattern type requires a regexp")
+		if p.GetStage() != v1beta1.PatchStageDefault {
+			return field.Invalid(field.NewPath("patches").Index(i).Key("stage"), p.GetStage(), "environment patches must use the Default stage")
 		}
-		if _, err := regexp.Compile(*p.Regexp); err != nil {
-			return field.Invalid(field.NewPath("regexp"), *p.Regexp, "invalid regexp")
+
+		if err := patch.Validate(&p); err != nil {
+			return WrapFieldError(err, field.NewPath("patches").Index(i))
 		}
-	default:
-		return field.Invalid(field.NewPath("type"), p.Type, "unknown pattern type")
 	}
 	return nil
 }
 
-// ValidateStringTransform validates a StringTransform.
-func ValidateStringTransform(s *v1beta1.StringTransform) *field.Error { //nolint:gocyclo // just a switch
-	if s.Type == "" {
-		return field.Required(field.NewPath("type"), "string transform type is required")
+// ValidateComposite validates patches and conditions applied directly to the
+// composite resource.
+func ValidateComposite(c *v1beta1.Composite) *field.Error {
+	if c == nil {
+		return nil
 	}
-	switch s.Type {
-	case v1beta1.StringTransformTypeFormat:
-		if s.Format == nil {
-			return field.Required(field.NewPath("fmt"), "format transform requires a format")
-		}
-	case v1beta1.StringTransformTypeConvert:
-		if s.Convert == nil {
-			return field.Required(field.NewPath("convert"), "convert transform requires a conversion type")
-		}
-	case v1beta1.StringTransformTypeTrimPrefix, v1beta1.StringTransformTypeTrimSuffix:
-		if s.Trim == nil {
-			return field.Required(field.NewPath("trim"), "trim transform requires a trim value")
+	for i, p := range c.Patches {
+		p := p
+		switch p.GetType() { //nolint:exhaustive // Intentionally targeting only patches to the composite resource.
+		case v1beta1.PatchTypeFromCompositeFieldPath,
+			v1beta1.PatchTypeCombineFromComposite,
+			v1beta1.PatchTypeFromEnvironmentFieldPath,
+			v1beta1.PatchTypeCombineFromEnvironment:
+		default:
+			return field.Invalid(field.NewPath("patches").Index(i).Key("type"), p.Type, "invalid composite patch type")
 		}
-	case v1beta1.StringTransformTypeRegexp:
-		if s.Regexp == nil {
-			return field.Required(field.NewPath("regexp"), "regexp transform requires a regexp")
+		if p.GetStage() != v1beta1.PatchStageDefault {
+			return field.Invalid(field.NewPath("patches").Index(i).Key("stage"), p.GetStage(), "composite patches must use the Default stage")
 		}
-		if s.Regexp.Match == "" {
-			return field.Required(field.NewPath("regexp", "match"), "regexp transform requires a match")
+
+		if err := patch.Validate(&p); err != nil {
+			return WrapFieldError(err, field.NewPath("patches").Index(i))
 		}
-		if _, err := regexp.Compile(s.Regexp.Match); err != nil {
-			return field.Invalid(field.NewPath("regexp", "match"), s.Regexp.Match, "invalid regexp")
+	}
+	for i, cd := range c.Conditions {
+		if err := ValidateCompositeCondition(cd); err != nil {
+			return WrapFieldError(err, field.NewPath("conditions").Index(i))
 		}
-	default:
-		return field.Invalid(field.NewPath("type"), s.Type, "unknown string transform type")
 	}
 	return nil
 }
 
-// ValidateConvertTransform validates a ConvertTransform.
-func ValidateConvertTransform(t *v1beta1.ConvertTransform) *field.Error {
-	if !t.GetFormat().IsValid() {
-		return field.Invalid(field.NewPath("format"), t.Format, "invalid format")
-	}
-	if !t.ToType.IsValid() {
-		return field.Invalid(field.NewPath("toType"), t.ToType, "invalid type")
+// ValidateCompositeCondition validates a condition set on the composite
+// resource.
+func ValidateCompositeCondition(c v1beta1.CompositeCondition) *field.Error {
+	if c.Type == "" {
+		return field.Required(field.NewPath("type"), "type is required")
 	}
-	return nil
-}
-
-// ValidateConnectionDetail checks if the connection detail is logically valid.
-func ValidateConnectionDetail(cd v1beta1.ConnectionDetail) *field.Error {
-	if cd.Type == "" {
-		return field.Required(field.NewPath("type"), "connection detail type is required")
+	if c.Type == xpv1.TypeReady || c.Type == xpv1.TypeSynced {
+		// Crossplane owns these conditions, and overwrites them.
+		return field.Invalid(field.NewPath("type"), c.Type, fmt.Sprintf("condition type %s is reserved for Crossplane", c.Type))
 	}
-	if !cd.Type.IsValid() {
-		return field.Invalid(field.NewPath("type"), string(cd.Type), "unknown connection detail type")
+	if c.Reason == "" {
+		return field.Required(field.NewPath("reason"), "reason is required")
 	}
-	if cd.Name == "" {
-		return field.Required(field.NewPath("name"), "name is required")
+	switch c.GetStatus() {
+	case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+	default:
+		return field.Invalid(field.NewPath("status"), c.GetStatus(), "unknown condition status")
 	}
-	switch cd.Type {
-	case v1beta1.ConnectionDetailTypeFromValue:
-		if cd.Value == nil {
-			return field.Required(field.NewPath("value"), "value connection detail requires a value")
-		}
-	case v1beta1.ConnectionDetailTypeFromConnectionSecretKey:
-		if cd.FromConnectionSecretKey == nil {
-			return field.Required(field.NewPath("fromConnectionSecretKey"), "from connection secret key connection detail requires a key")
-		}
-	case v1beta1.ConnectionDetailTypeFromFieldPath:
-		if cd.FromFieldPath == nil {
-			return field.Required(field.NewPath("fromFieldPath"), "from field path connection detail requires a field path")
-		}
+	switch c.GetSource() {
+	case v1beta1.ConditionSourceComposite, v1beta1.ConditionSourceEnvironment:
+	default:
+		return field.Invalid(field.NewPath("source"), c.GetSource(), "unknown condition source")
 	}
 	return nil
 }
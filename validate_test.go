@@ -5,19 +5,24 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
-	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+
 	"github.com/crossplane-contrib/function-patch-and-transform/input/v1beta1"
 )
 
-func TestValidateReadinessCheck(t *testing.T) {
+func TestValidateReadinessPolicy(t *testing.T) {
+	cts := []v1beta1.ComposedTemplate{{Name: "database"}, {Name: "dashboard"}}
+
 	type args struct {
-		r v1beta1.ReadinessCheck
+		p   *v1beta1.ReadinessPolicy
+		cts []v1beta1.ComposedTemplate
 	}
 	type want struct {
-		output *field.Error
+		err *field.Error
 	}
 
 	cases := map[string]struct {
@@ -25,87 +30,96 @@ func TestValidateReadinessCheck(t *testing.T) {
 		args   args
 		want   want
 	}{
-		"ValidTypeNone": {
-			reason: "Type none should be valid",
+		"Nil": {
+			reason: "A nil readiness policy should be valid",
 			args: args{
-				r: v1beta1.ReadinessCheck{
-					Type: v1beta1.ReadinessCheckTypeNone,
-				},
+				cts: cts,
 			},
 		},
-		"ValidTypeMatchString": {
-			reason: "Type matchString should be valid",
+		"ValidResources": {
+			reason: "A resources readiness policy naming existing resource templates should be valid",
 			args: args{
-				r: v1beta1.ReadinessCheck{
-					Type:        v1beta1.ReadinessCheckTypeMatchString,
-					MatchString: ptr.To[string]("foo"),
-					FieldPath:   ptr.To[string]("spec.foo"),
+				p: &v1beta1.ReadinessPolicy{
+					Type:      v1beta1.ReadinessPolicyTypeResources,
+					Resources: []string{"database"},
 				},
+				cts: cts,
 			},
 		},
-		"ValidTypeMatchCondition": {
-			reason: "Type matchCondition should be valid",
+		"UnknownResource": {
+			reason: "A resources readiness policy naming a resource template that doesn't exist should be invalid",
 			args: args{
-				r: v1beta1.ReadinessCheck{
-					Type: v1beta1.ReadinessCheckTypeMatchCondition,
-					MatchCondition: &v1beta1.MatchConditionReadinessCheck{
-						Type:   "someType",
-						Status: "someStatus",
-					},
-					FieldPath: ptr.To[string]("spec.foo"),
+				p: &v1beta1.ReadinessPolicy{
+					Type:      v1beta1.ReadinessPolicyTypeResources,
+					Resources: []string{"database", "cache"},
+				},
+				cts: cts,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeNotFound,
+					Field: "resources[1]",
 				},
 			},
 		},
-		"ValidTypeMatchTrue": {
-			reason: "Type matchTrue should be valid",
+		"WeightedQuorum": {
+			reason: "A quorum readiness policy should be valid if it's no larger than the total readiness weight of all resource templates",
 			args: args{
-				r: v1beta1.ReadinessCheck{
-					Type:      v1beta1.ReadinessCheckTypeMatchTrue,
-					FieldPath: ptr.To[string]("spec.foo"),
+				p: &v1beta1.ReadinessPolicy{
+					Type:   v1beta1.ReadinessPolicyTypeQuorum,
+					Quorum: ptr.To(4),
 				},
+				cts: []v1beta1.ComposedTemplate{{Name: "database", ReadinessWeight: ptr.To(3)}, {Name: "dashboard"}},
 			},
 		},
-		"ValidTypeMatchFalse": {
-			reason: "Type matchFalse should be valid",
+		"MissingQuorum": {
+			reason: "A quorum readiness policy without a quorum should be invalid",
 			args: args{
-				r: v1beta1.ReadinessCheck{
-					Type:      v1beta1.ReadinessCheckTypeMatchFalse,
-					FieldPath: ptr.To[string]("spec.foo"),
+				p: &v1beta1.ReadinessPolicy{
+					Type: v1beta1.ReadinessPolicyTypeQuorum,
+				},
+				cts: cts,
+			},
+			want: want{
+				err: &field.Error{
+					Type:  field.ErrorTypeRequired,
+					Field: "quorum",
 				},
 			},
 		},
-		"InvalidType": {
-			reason: "Invalid type",
+		"QuorumTooLarge": {
+			reason: "A quorum readiness policy with a quorum larger than the number of resource templates should be invalid",
 			args: args{
-				r: v1beta1.ReadinessCheck{
-					Type: "foo",
+				p: &v1beta1.ReadinessPolicy{
+					Type:   v1beta1.ReadinessPolicyTypeQuorum,
+					Quorum: ptr.To(3),
 				},
+				cts: cts,
 			},
 			want: want{
-				output: &field.Error{
-					Type:     field.ErrorTypeInvalid,
-					Field:    "type",
-					BadValue: "foo",
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "quorum",
 				},
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := ValidateReadinessCheck(tc.args.r)
-			if diff := cmp.Diff(tc.want.output, got, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
-				t.Errorf("%s\nValidateReadinessCheck(...): -want, +got:\n%s", tc.reason, diff)
+			err := ValidateReadinessPolicy(tc.args.p, tc.args.cts)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateReadinessPolicy(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestValidateConnectionDetail(t *testing.T) {
+func TestValidateBase(t *testing.T) {
 	type args struct {
-		cd v1beta1.ConnectionDetail
+		b *runtime.RawExtension
 	}
 	type want struct {
-		output *field.Error
+		err *field.Error
 	}
 
 	cases := map[string]struct {
@@ -113,133 +127,67 @@ func TestValidateConnectionDetail(t *testing.T) {
 		args   args
 		want   want
 	}{
-		"InvalidType": {
-			reason: "An invalid type should cause a validation error",
-			args: args{
-				cd: v1beta1.ConnectionDetail{Type: v1beta1.ConnectionDetailType("wat")},
-			},
-			want: want{
-				output: &field.Error{
-					Type:  field.ErrorTypeInvalid,
-					Field: "type",
-				},
-			},
+		"Nil": {
+			reason: "A nil base template should be valid",
 		},
-		"EmptyName": {
-			reason: "An empty name should cause a validation error",
+		"Valid": {
+			reason: "A base template with an apiVersion and kind should be valid",
 			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type: v1beta1.ConnectionDetailTypeFromValue,
-					Name: "",
-				},
-			},
-			want: want{
-				output: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "name",
-				},
+				b: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"CD"}`)},
 			},
 		},
-		"InvalidValue": {
-			reason: "An invalid value should cause a validation error",
+		"MissingAPIVersion": {
+			reason: "A base template without an apiVersion should be invalid",
 			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type: v1beta1.ConnectionDetailTypeFromValue,
-					Name: "cool",
-				},
+				b: &runtime.RawExtension{Raw: []byte(`{"kind":"CD"}`)},
 			},
 			want: want{
-				output: &field.Error{
+				err: &field.Error{
 					Type:  field.ErrorTypeRequired,
-					Field: "value",
+					Field: "apiVersion",
 				},
 			},
 		},
-		"InvalidFromConnectionSecretKey": {
-			reason: "An invalid from connection secret key should cause a validation error",
+		"InvalidAPIVersion": {
+			reason: "A base template with an unparseable apiVersion should be invalid",
 			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type: v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
-					Name: "cool",
-				},
+				b: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1/extra","kind":"CD"}`)},
 			},
 			want: want{
-				output: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "fromConnectionSecretKey",
+				err: &field.Error{
+					Type:  field.ErrorTypeInvalid,
+					Field: "apiVersion",
 				},
 			},
 		},
-		"InvalidFromFieldPath": {
-			reason: "An invalid from field path should cause a validation error",
+		"MissingKind": {
+			reason: "A base template without a kind should be invalid",
 			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type: v1beta1.ConnectionDetailTypeFromFieldPath,
-					Name: "cool",
-				},
+				b: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1"}`)},
 			},
 			want: want{
-				output: &field.Error{
+				err: &field.Error{
 					Type:  field.ErrorTypeRequired,
-					Field: "fromFieldPath",
-				},
-			},
-		},
-		"ValidValue": {
-			reason: "An valid value should not cause a validation error",
-			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type:  v1beta1.ConnectionDetailTypeFromValue,
-					Name:  "cool",
-					Value: ptr.To[string]("cooler"),
-				},
-			},
-			want: want{
-				output: nil,
-			},
-		},
-		"ValidFromConnectionSecretKey": {
-			reason: "An valid from connection secret key should not cause a validation error",
-			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type:                    v1beta1.ConnectionDetailTypeFromConnectionSecretKey,
-					Name:                    "cool",
-					FromConnectionSecretKey: ptr.To[string]("key"),
-				},
-			},
-			want: want{
-				output: nil,
-			},
-		},
-		"ValidFromFieldPath": {
-			reason: "An valid from field path should not cause a validation error",
-			args: args{
-				cd: v1beta1.ConnectionDetail{
-					Type:          v1beta1.ConnectionDetailTypeFromFieldPath,
-					Name:          "cool",
-					FromFieldPath: ptr.To[string]("status.coolness"),
+					Field: "kind",
 				},
 			},
-			want: want{
-				output: nil,
-			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := ValidateConnectionDetail(tc.args.cd)
-			if diff := cmp.Diff(tc.want.output, got, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
-				t.Errorf("%s\nValidateConnectionDetail(...): -want, +got:\n%s", tc.reason, diff)
+			err := ValidateBase(tc.args.b)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateBase(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestValidatePatch(t *testing.T) {
+func TestValidateComposedNamespace(t *testing.T) {
 	type args struct {
-		patch v1beta1.ComposedPatch
+		n    *v1beta1.ComposedNamespace
+		base *runtime.RawExtension
 	}
-
 	type want struct {
 		err *field.Error
 	}
@@ -249,140 +197,114 @@ func TestValidatePatch(t *testing.T) {
 		args   args
 		want   want
 	}{
-		"ValidFromCompositeFieldPath": {
-			reason: "FromCompositeFieldPath patch with FromFieldPath set should be valid",
-			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeFromCompositeFieldPath,
-					Patch: v1beta1.Patch{
-						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
-					},
-				},
-			},
+		"Nil": {
+			reason: "A nil namespace should be valid",
 		},
-		"FromCompositeFieldPathWithInvalidTransforms": {
-			reason: "FromCompositeFieldPath with invalid transforms should return error",
+		"Valid": {
+			reason: "A namespace of a namespaced kind should be valid",
 			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeFromCompositeFieldPath,
-					Patch: v1beta1.Patch{
-						FromFieldPath: ptr.To[string]("spec.forProvider.foo"),
-						Transforms: []v1beta1.Transform{
-							{
-								Type: v1beta1.TransformTypeMath,
-								Math: nil,
-							},
-						},
-					},
-				},
-			},
-			want: want{
-				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "transforms[0].math",
-				},
+				n:    &v1beta1.ComposedNamespace{FromFieldPath: ptr.To("claim.namespace")},
+				base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap"}`)},
 			},
 		},
-		"InvalidFromCompositeFieldPathMissingFromFieldPath": {
-			reason: "Invalid FromCompositeFieldPath missing FromFieldPath should return error",
+		"MissingSource": {
+			reason: "A namespace without a value or fromFieldPath should be invalid",
 			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeFromCompositeFieldPath,
-					Patch: v1beta1.Patch{
-						FromFieldPath: nil,
-					},
-				},
+				n: &v1beta1.ComposedNamespace{},
 			},
 			want: want{
 				err: &field.Error{
 					Type:  field.ErrorTypeRequired,
-					Field: "fromFieldPath",
+					Field: "namespace",
 				},
 			},
 		},
-		"InvalidFromCompositeFieldPathMissingToFieldPath": {
-			reason: "Invalid ToCompositeFieldPath missing ToFieldPath should return error",
+		"ClusterScoped": {
+			reason: "A namespace of a cluster scoped kind should be invalid",
 			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeToCompositeFieldPath,
-					Patch: v1beta1.Patch{
-						ToFieldPath: nil,
-					},
-				},
+				n:    &v1beta1.ComposedNamespace{Value: ptr.To("default")},
+				base: &runtime.RawExtension{Raw: []byte(`{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole"}`)},
 			},
 			want: want{
-				&field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "fromFieldPath",
+				err: &field.Error{
+					Type:  field.ErrorTypeForbidden,
+					Field: "namespace",
 				},
 			},
 		},
-		"Invalidv1beta1.PatchSetMissingv1beta1.PatchSetName": {
-			reason: "Invalid v1beta1.PatchSet missing v1beta1.PatchSetName should return error",
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateComposedNamespace(tc.args.n, tc.args.base)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateComposedNamespace(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateCompositeCondition(t *testing.T) {
+	type args struct {
+		c v1beta1.CompositeCondition
+	}
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Valid": {
+			reason: "A condition of a type Crossplane doesn't own should be valid",
 			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypePatchSet,
-				},
-			},
-			want: want{
-				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "patchSetName",
-				},
+				c: v1beta1.CompositeCondition{Type: "DatabaseReady", Reason: "Available"},
 			},
 		},
-		"InvalidCombineMissingCombine": {
-			reason: "Invalid Combine missing Combine should return error",
+		"Ready": {
+			reason: "A Ready condition should be invalid, because Crossplane owns it",
 			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeCombineToComposite,
-				},
+				c: v1beta1.CompositeCondition{Type: xpv1.TypeReady, Reason: "Available"},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "combine",
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
 				},
 			},
 		},
-		"InvalidCombineMissingToFieldPath": {
-			reason: "Invalid Combine missing ToFieldPath should return error",
+		"Synced": {
+			reason: "A Synced condition should be invalid, because Crossplane owns it",
 			args: args{
-				patch: v1beta1.ComposedPatch{
-					Type: v1beta1.PatchTypeCombineToComposite,
-					Patch: v1beta1.Patch{
-						Combine: &v1beta1.Combine{
-							Variables: []v1beta1.CombineVariable{
-								{
-									FromFieldPath: "spec.forProvider.foo",
-								},
-							},
-						},
-						ToFieldPath: nil,
-					},
-				},
+				c: v1beta1.CompositeCondition{Type: xpv1.TypeSynced, Reason: "ReconcileSuccess"},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "toFieldPath",
+					Type:  field.ErrorTypeInvalid,
+					Field: "type",
 				},
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			err := ValidatePatch(&tc.args.patch)
+			err := ValidateCompositeCondition(tc.args.c)
 			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
-				t.Errorf("%s\nValidatePatch(...): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("%s\nValidateCompositeCondition(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestValidateTransform(t *testing.T) {
+func TestValidateGatedTransforms(t *testing.T) {
+	webhook := []v1beta1.Transform{
+		{Type: v1beta1.TransformTypeString},
+		{Type: v1beta1.TransformTypeWebhook, Webhook: &v1beta1.WebhookTransform{URL: "https://example.org"}},
+	}
+
 	type args struct {
-		transform v1beta1.Transform
+		r *v1beta1.Resources
 	}
 	type want struct {
 		err *field.Error
@@ -393,287 +315,173 @@ func TestValidateTransform(t *testing.T) {
 		args   args
 		want   want
 	}{
-		"ValidMathMultiply": {
-			reason: "Math transform with MathTransform Multiply set should be valid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMath,
-					Math: &v1beta1.MathTransform{
-						Type:     v1beta1.MathTransformTypeMultiply,
-						Multiply: ptr.To[int64](2),
-					},
-				},
-			},
-		},
-		"ValidMathClampMin": {
-			reason: "Math transform with valid MathTransform ClampMin set should be valid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMath,
-					Math: &v1beta1.MathTransform{
-						Type:     v1beta1.MathTransformTypeClampMin,
-						ClampMin: ptr.To[int64](10),
-					},
-				},
-			},
-		},
-		"InvalidMathWrongSpec": {
-			reason: "Math transform with invalid MathTransform set should be invalid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMath,
-					Math: &v1beta1.MathTransform{
-						Type:     v1beta1.MathTransformTypeMultiply,
-						ClampMin: ptr.To[int64](10),
-					},
-				},
-			},
-			want: want{
-				&field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "math.multiply",
-				},
-			},
-		},
-		"InvalidMathNotDefinedAtAll": {
-			reason: "Math transform with no MathTransform set should be invalid",
+		"NoGatedTransforms": {
+			reason: "Transforms that aren't behind a feature gate should be valid",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMath,
-					Math: nil,
-				},
-			},
-			want: want{
-				&field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "math",
-				},
-			},
-		},
-		"ValidMap": {
-			reason: "Map transform with MapTransform set should be valid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMap,
-					Map: &v1beta1.MapTransform{
-						Pairs: map[string]extv1.JSON{
-							"foo": {Raw: []byte(`"bar"`)},
-						},
-					},
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{{
+						Name:    "bucket",
+						Patches: []v1beta1.ComposedPatch{{Patch: v1beta1.Patch{Transforms: webhook[:1]}}},
+					}},
 				},
 			},
 		},
-		"InvalidMapNoMap": {
-			reason: "Map transform with no map set should be invalid",
+		"WebhookEnabled": {
+			reason: "A webhook transform should be valid if its feature gate is enabled",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMap,
-					Map:  nil,
-				},
-			},
-			want: want{
-				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "map",
+				r: &v1beta1.Resources{
+					FeatureGates: []v1beta1.FeatureGate{v1beta1.FeatureGateWebhookTransforms},
+					Resources: []v1beta1.ComposedTemplate{{
+						Name:    "bucket",
+						Patches: []v1beta1.ComposedPatch{{Patch: v1beta1.Patch{Transforms: webhook}}},
+					}},
 				},
 			},
 		},
-		"InvalidMapNoPairs": {
-			reason: "Map transform with no pairs in map should be invalid",
+		"WebhookDisabled": {
+			reason: "A webhook transform should be forbidden if its feature gate isn't enabled",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMap,
-					Map:  &v1beta1.MapTransform{},
+				r: &v1beta1.Resources{
+					Resources: []v1beta1.ComposedTemplate{{
+						Name:    "bucket",
+						Patches: []v1beta1.ComposedPatch{{}, {Patch: v1beta1.Patch{Transforms: webhook}}},
+					}},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "map.pairs",
-				},
-			},
-		},
-		"InvalidMatchNoMatch": {
-			reason: "Match transform with no match set should be invalid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type:  v1beta1.TransformTypeMatch,
-					Match: nil,
-				},
-			},
-			want: want{
-				&field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "match",
+					Type:  field.ErrorTypeForbidden,
+					Field: "resources[0].patches[1].transforms[1].type",
 				},
 			},
 		},
-		"InvalidMatchEmptyTransform": {
-			reason: "Match transform with empty MatchTransform should be invalid",
+		"WebhookDisabledInPatchSet": {
+			reason: "A webhook transform in a PatchSet should be forbidden if its feature gate isn't enabled",
 			args: args{
-				transform: v1beta1.Transform{
-					Type:  v1beta1.TransformTypeMatch,
-					Match: &v1beta1.MatchTransform{},
+				r: &v1beta1.Resources{
+					PatchSets: []v1beta1.PatchSet{{
+						Name:    "naming",
+						Patches: []v1beta1.PatchSetPatch{{Patch: v1beta1.Patch{Transforms: webhook}}},
+					}},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "match.patterns",
-				},
-			},
-		},
-		"ValidMatchTransformRegexp": {
-			reason: "Match transform with valid MatchTransform of type regexp should be valid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMatch,
-					Match: &v1beta1.MatchTransform{
-						Patterns: []v1beta1.MatchTransformPattern{
-							{
-								Type:   v1beta1.MatchTransformPatternTypeRegexp,
-								Regexp: ptr.To[string](".*"),
-							},
-						},
-					},
+					Type:  field.ErrorTypeForbidden,
+					Field: "patchSets[0].patches[0].transforms[1].type",
 				},
 			},
 		},
-		"InvalidMatchTransformRegexp": {
-			reason: "Match transform with an invalid MatchTransform of type regexp with a bad regexp should be invalid",
+		"WebhookDisabledInComposite": {
+			reason: "A webhook transform in a composite patch should be forbidden if its feature gate isn't enabled",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMatch,
-					Match: &v1beta1.MatchTransform{
-						Patterns: []v1beta1.MatchTransformPattern{
-							{
-								Type:   v1beta1.MatchTransformPatternTypeRegexp,
-								Regexp: ptr.To[string]("?"),
-							},
-						},
+				r: &v1beta1.Resources{
+					Composite: &v1beta1.Composite{
+						Patches: []v1beta1.CompositePatch{{Patch: v1beta1.Patch{Transforms: webhook}}},
 					},
 				},
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeInvalid,
-					Field: "match.patterns[0].regexp",
-				},
-			},
-		},
-		"ValidMatchTransformString": {
-			reason: "Match transform with valid MatchTransform of type literal should be valid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeMatch,
-					Match: &v1beta1.MatchTransform{
-						Patterns: []v1beta1.MatchTransformPattern{
-							{
-								Type:    v1beta1.MatchTransformPatternTypeLiteral,
-								Literal: ptr.To[string]("foo"),
-							},
-							{
-								Literal: ptr.To[string]("bar"),
-							},
-						},
-					},
+					Type:  field.ErrorTypeForbidden,
+					Field: "composite.patches[0].transforms[1].type",
 				},
 			},
 		},
-		"InvalidStringNoString": {
-			reason: "String transform with no string set should be invalid",
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateGatedTransforms(tc.args.r)
+			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
+				t.Errorf("%s\nValidateGatedTransforms(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestValidateProviderConfigRef(t *testing.T) {
+	cts := []v1beta1.ComposedTemplate{{Name: "bucket"}, {Name: "dashboard"}}
+
+	type args struct {
+		r   *v1beta1.ProviderConfigRef
+		cts []v1beta1.ComposedTemplate
+	}
+	type want struct {
+		err *field.Error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Nil": {
+			reason: "A nil ProviderConfig reference should be valid",
 			args: args{
-				transform: v1beta1.Transform{
-					Type:   v1beta1.TransformTypeString,
-					String: nil,
-				},
-			},
-			want: want{
-				err: &field.Error{
-					Type:  field.ErrorTypeRequired,
-					Field: "string",
-				},
+				cts: cts,
 			},
 		},
-		"ValidString": {
-			reason: "String transform with set string should be valid",
+		"Valid": {
+			reason: "A ProviderConfig reference with one source naming existing resource templates should be valid",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeString,
-					String: &v1beta1.StringTransform{
-						Type:   v1beta1.StringTransformTypeFormat,
-						Format: ptr.To[string]("foo"),
-					},
+				r: &v1beta1.ProviderConfigRef{
+					FromFieldPath: ptr.To("spec.providerConfigName"),
+					Resources:     []string{"bucket"},
 				},
+				cts: cts,
 			},
 		},
-		"InvalidConvertMissingConvert": {
-			reason: "Convert transform missing Convert should be invalid",
+		"MissingSource": {
+			reason: "A ProviderConfig reference without a source should be invalid",
 			args: args{
-				transform: v1beta1.Transform{
-					Type:    v1beta1.TransformTypeConvert,
-					Convert: nil,
-				},
+				r:   &v1beta1.ProviderConfigRef{},
+				cts: cts,
 			},
 			want: want{
 				err: &field.Error{
 					Type:  field.ErrorTypeRequired,
-					Field: "convert",
+					Field: "fromFieldPath",
 				},
 			},
 		},
-		"InvalidConvertUnknownFormat": {
-			reason: "Convert transform with unknown format should be invalid",
+		"TwoSources": {
+			reason: "A ProviderConfig reference with both sources should be invalid",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeConvert,
-					Convert: &v1beta1.ConvertTransform{
-						Format: &[]v1beta1.ConvertTransformFormat{"foo"}[0],
-					},
+				r: &v1beta1.ProviderConfigRef{
+					FromFieldPath:            ptr.To("spec.providerConfigName"),
+					FromEnvironmentFieldPath: ptr.To("providerConfigName"),
 				},
+				cts: cts,
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeInvalid,
-					Field: "convert.format",
+					Type:  field.ErrorTypeForbidden,
+					Field: "fromEnvironmentFieldPath",
 				},
 			},
 		},
-		"InvalidConvertUnknownToType": {
-			reason: "Convert transform with unknown toType should be invalid",
+		"UnknownResource": {
+			reason: "A ProviderConfig reference naming a resource template that doesn't exist should be invalid",
 			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeConvert,
-					Convert: &v1beta1.ConvertTransform{
-						ToType: v1beta1.TransformIOType("foo"),
-					},
+				r: &v1beta1.ProviderConfigRef{
+					FromEnvironmentFieldPath: ptr.To("providerConfigName"),
+					Resources:                []string{"bucket", "cache"},
 				},
+				cts: cts,
 			},
 			want: want{
 				err: &field.Error{
-					Type:  field.ErrorTypeInvalid,
-					Field: "convert.toType",
-				},
-			},
-		},
-		"ValidConvert": {
-			reason: "Convert transform with valid format and toType should be valid",
-			args: args{
-				transform: v1beta1.Transform{
-					Type: v1beta1.TransformTypeConvert,
-					Convert: &v1beta1.ConvertTransform{
-						Format: &[]v1beta1.ConvertTransformFormat{v1beta1.ConvertTransformFormatNone}[0],
-						ToType: v1beta1.TransformIOTypeInt,
-					},
+					Type:  field.ErrorTypeNotFound,
+					Field: "resources[1]",
 				},
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			err := ValidateTransform(tc.args.transform)
+			err := ValidateProviderConfigRef(tc.args.r, tc.args.cts)
 			if diff := cmp.Diff(tc.want.err, err, cmpopts.IgnoreFields(field.Error{}, "Detail", "BadValue")); diff != "" {
-				t.Errorf("%s\nValidateTransform(...): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("%s\nValidateProviderConfigRef(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}